@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/handlers"
+	"health-ai-portal/internal/models"
+	"health-ai-portal/pkg/archive"
+)
+
+func runSeed(db *database.DB, args []string) error {
+	fs := subcommand("seed")
+	force := fs.Bool("force", false, "seed even if user 1 already has data; rows are added again")
+	fs.Parse(args)
+
+	err := db.Seed(*force)
+	if errors.Is(err, database.ErrAlreadySeeded) {
+		return fmt.Errorf("%w; use -force to seed anyway", err)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println("Seed data inserted")
+	return nil
+}
+
+func runExport(db *database.DB, args []string) error {
+	fs := subcommand("export")
+	userID := fs.Int("user", 1, "user id")
+	out := fs.String("o", "", "output file (default health-export-DATE.zip)")
+	fs.Parse(args)
+
+	a, err := handlers.ExportArchive(db, *userID)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		*out = fmt.Sprintf("health-export-%s.zip", a.Manifest.ExportedAt.Format("2006-01-02"))
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := archive.Write(f, a); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	rows := 0
+	for _, t := range a.Tables {
+		rows += len(t)
+	}
+	fmt.Printf("Exported %d rows from %d tables to %s\n", rows, len(a.Tables), *out)
+	return nil
+}
+
+func runImport(db *database.DB, args []string) error {
+	fs := subcommand("import")
+	userID := fs.Int("user", 1, "user id")
+	onConflict := fs.String("on-conflict", models.ImportSkip, "rows that already exist: skip or overwrite")
+	dryRun := fs.Bool("dry-run", false, "report without writing")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected one archive file")
+	}
+	if *onConflict != models.ImportSkip && *onConflict != models.ImportOverwrite {
+		return fmt.Errorf("-on-conflict must be skip or overwrite")
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	a, err := archive.Read(f, info.Size())
+	if err != nil {
+		return err
+	}
+
+	report, err := handlers.ImportArchive(db, *userID, a, *onConflict, *dryRun)
+	if err != nil {
+		return err
+	}
+	if report.DryRun {
+		fmt.Println("Dry run, nothing was written")
+	}
+	for _, t := range report.Tables {
+		fmt.Printf("%-22s created %d, updated %d, skipped %d\n", t.Table, t.Created, t.Updated, t.Skipped)
+	}
+	for _, w := range report.Warnings {
+		fmt.Println("warning:", w)
+	}
+	return nil
+}
+
+func runReparseLabs(db *database.DB, args []string) error {
+	fs := subcommand("reparse-labs")
+	userID := fs.Int("user", 1, "user id")
+	dryRun := fs.Bool("dry-run", false, "report without writing")
+	fs.Parse(args)
+
+	changes, err := handlers.ReparseLabs(db, *userID, *dryRun)
+	if err != nil {
+		return err
+	}
+	updated := 0
+	for _, c := range changes {
+		line := fmt.Sprintf("#%d %s %s", c.ID, c.TestDate, c.MarkerName)
+		if c.NewName != c.MarkerName {
+			line += " → " + c.NewName
+		}
+		if c.Category == nil || *c.Category != c.NewCategory {
+			line += fmt.Sprintf(" [category %s]", c.NewCategory)
+		}
+		if c.ConflictID != 0 {
+			line += fmt.Sprintf(" — skipped, same value already stored as #%d", c.ConflictID)
+		} else {
+			updated++
+		}
+		fmt.Println(line)
+	}
+
+	verb := "Updated"
+	if *dryRun {
+		verb = "Would update"
+	}
+	fmt.Printf("%s %d of %d results\n", verb, updated, len(changes))
+	return nil
+}
@@ -0,0 +1,81 @@
+// Command healthctl runs administrative tasks against the portal database:
+// migrations, seeding, users, archives and lab maintenance. It reads the
+// same environment as the server.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"health-ai-portal/internal/config"
+	"health-ai-portal/internal/database"
+
+	"github.com/joho/godotenv"
+)
+
+const usage = `Usage: healthctl <command> [arguments]
+
+Commands:
+  migrate up                 apply pending migrations
+  migrate down [N]           roll back the last N migrations (default 1)
+  migrate status             show applied and pending migrations
+  migrate force VERSION      mark VERSION as applied after a manual repair
+  seed [-force]              load the starting protocol for user 1
+  user create -name NAME     create a user; prints its id
+  user set-pin -user ID      set a user's PIN, read from stdin
+  export [-user ID] [-o FILE]
+                             write a user's archive (zip)
+  import [-user ID] [-on-conflict skip|overwrite] [-dry-run] FILE
+                             restore an archive made by export
+  reparse-labs [-user ID] [-dry-run]
+                             re-read stored marker names and categories
+`
+
+type command func(db *database.DB, args []string) error
+
+var commands = map[string]command{
+	"migrate":      runMigrate,
+	"seed":         runSeed,
+	"user":         runUser,
+	"export":       runExport,
+	"import":       runImport,
+	"reparse-labs": runReparseLabs,
+}
+
+func main() {
+	log.SetFlags(0)
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	run, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "healthctl: unknown command %q\n\n", flag.Arg(0))
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	godotenv.Load()
+	cfg := config.Load()
+	db, err := database.New(cfg.GetDatabaseURL())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := run(db, flag.Args()[1:]); err != nil {
+		db.Close()
+		log.Fatalf("healthctl %s: %v", flag.Arg(0), err)
+	}
+}
+
+// subcommand is a flag set that prints the shared usage on errors
+func subcommand(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	return fs
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"health-ai-portal/internal/database"
+)
+
+func runMigrate(db *database.DB, args []string) error {
+	fs := subcommand("migrate")
+	path := fs.String("path", database.MigrationsPath(), "migrations directory")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return fmt.Errorf("expected up, down, status or force")
+	}
+
+	switch fs.Arg(0) {
+	case "up":
+		return db.RunMigrations(*path)
+
+	case "down":
+		steps := 1
+		if fs.NArg() > 1 {
+			n, err := strconv.Atoi(fs.Arg(1))
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of steps %q", fs.Arg(1))
+			}
+			steps = n
+		}
+		if err := db.RollbackMigrations(*path, steps); err != nil {
+			return err
+		}
+		return printMigrationStatus(db, *path)
+
+	case "status":
+		return printMigrationStatus(db, *path)
+
+	case "force":
+		if fs.NArg() < 2 {
+			return fmt.Errorf("expected a version")
+		}
+		version, err := strconv.Atoi(fs.Arg(1))
+		if err != nil {
+			return fmt.Errorf("invalid version %q", fs.Arg(1))
+		}
+		if err := db.ForceMigration(*path, version); err != nil {
+			return err
+		}
+		return printMigrationStatus(db, *path)
+	}
+	return fmt.Errorf("unknown migrate command %q", fs.Arg(0))
+}
+
+func printMigrationStatus(db *database.DB, path string) error {
+	version, dirty, err := db.MigrationVersion(path)
+	if err != nil {
+		return err
+	}
+	migrations, err := database.Migrations(path)
+	if err != nil {
+		return err
+	}
+
+	pending := 0
+	for _, m := range migrations {
+		state := "applied"
+		switch {
+		case m.Version == version && dirty:
+			state = "DIRTY"
+		case m.Version > version:
+			state = "pending"
+			pending++
+		}
+		fmt.Printf("%03d  %-8s %s\n", m.Version, state, m.Name)
+	}
+
+	fmt.Printf("\nVersion %d, %d pending\n", version, pending)
+	if dirty {
+		fmt.Printf("Migration %d failed half-way: repair the schema, then run \"healthctl migrate force VERSION\"\n", version)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"health-ai-portal/internal/auth"
+	"health-ai-portal/internal/database"
+)
+
+func runUser(db *database.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected create or set-pin")
+	}
+	switch args[0] {
+	case "create":
+		return createUser(db, args[1:])
+	case "set-pin":
+		return setPIN(db, args[1:])
+	}
+	return fmt.Errorf("unknown user command %q", args[0])
+}
+
+func createUser(db *database.DB, args []string) error {
+	fs := subcommand("user create")
+	name := fs.String("name", "", "display name")
+	timezone := fs.String("timezone", "UTC", "IANA timezone for schedules and reminders")
+	withPIN := fs.Bool("pin", false, "also set a PIN, read from stdin")
+	fs.Parse(args)
+	if strings.TrimSpace(*name) == "" {
+		return fmt.Errorf("-name is required")
+	}
+	if _, err := time.LoadLocation(*timezone); err != nil {
+		return fmt.Errorf("invalid -timezone %q: %v", *timezone, err)
+	}
+
+	var pinHash *string
+	if *withPIN {
+		hash, err := readPINHash()
+		if err != nil {
+			return err
+		}
+		pinHash = &hash
+	}
+
+	var id int
+	err := db.Get(&id, `
+		INSERT INTO users (name, timezone, pin_hash)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, strings.TrimSpace(*name), *timezone, pinHash)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created user %d\n", id)
+	return nil
+}
+
+func setPIN(db *database.DB, args []string) error {
+	fs := subcommand("user set-pin")
+	userID := fs.Int("user", 1, "user id")
+	fs.Parse(args)
+
+	hash, err := readPINHash()
+	if err != nil {
+		return err
+	}
+	res, err := db.Exec(`UPDATE users SET pin_hash = $2, updated_at = NOW() WHERE id = $1`, *userID, hash)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("user %d not found", *userID)
+	}
+	fmt.Printf("PIN set for user %d\n", *userID)
+	return nil
+}
+
+// readPINHash reads the PIN from the first line of stdin, so it stays out
+// of the shell history, and hashes it
+func readPINHash() (string, error) {
+	fmt.Fprint(os.Stderr, "PIN: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read PIN: %w", err)
+	}
+	return auth.HashPIN(strings.TrimSpace(line))
+}
@@ -0,0 +1,71 @@
+// Command importer loads the pre-portal Markdown history (cycles, labs and
+// the change log) into the database. It is safe to run again: records that
+// were imported before are skipped.
+//
+//	go run ./cmd/importer -dir ../.. -dry-run
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"health-ai-portal/internal/config"
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/handlers"
+	"health-ai-portal/internal/history"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory holding "+history.CyclesFile+", "+history.LabsFile+" and "+history.ChangeLogFile)
+	userID := flag.Int("user", 1, "user the history belongs to")
+	dryRun := flag.Bool("dry-run", false, "parse the files and report without writing")
+	flag.Parse()
+
+	godotenv.Load()
+	cfg := config.Load()
+
+	db, err := database.New(cfg.GetDatabaseURL())
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	report, err := history.NewImporter(db, handlers.NewHistoryLabStore(db)).Import(*userID, *dir, *dryRun)
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+
+	if report.DryRun {
+		fmt.Println("Dry run, nothing was written")
+	}
+	for _, c := range []struct {
+		name   string
+		counts history.ImportCounts
+	}{
+		{"cycles", report.Cycles},
+		{"lab results", report.Labs},
+		{"stack events", report.Events},
+	} {
+		fmt.Printf("%-13s parsed %d, inserted %d, merged %d, already imported %d\n",
+			c.name+":", c.counts.Parsed, c.counts.Inserted, c.counts.Merged, c.counts.Skipped)
+	}
+
+	for _, w := range report.Warnings {
+		fmt.Println("warning:", w)
+	}
+	if len(report.Conflicts) > 0 {
+		fmt.Printf("\nConflicts with the current stack (%d):\n", len(report.Conflicts))
+		for _, c := range report.Conflicts {
+			fmt.Printf("  %s:%d %s — %s\n", c.File, c.Line, c.Section, c.Reason)
+		}
+	}
+	if len(report.Unparsed) > 0 {
+		fmt.Printf("\nNot imported (%d):\n", len(report.Unparsed))
+		for _, u := range report.Unparsed {
+			fmt.Printf("  %s:%d %s — %s\n", u.File, u.Line, u.Section, u.Reason)
+		}
+	}
+}
@@ -1,16 +1,18 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
-	"path/filepath"
 
 	"health-ai-portal/internal/ai"
 	"health-ai-portal/internal/config"
 	"health-ai-portal/internal/database"
 	"health-ai-portal/internal/handlers"
 	"health-ai-portal/internal/middleware"
+	"health-ai-portal/internal/scheduler"
+	"health-ai-portal/pkg/telegram"
 
 	"github.com/go-chi/chi/v5"
 	chiMiddleware "github.com/go-chi/chi/v5/middleware"
@@ -34,22 +36,47 @@ func main() {
 	defer db.Close()
 	log.Printf("Database connected successfully")
 
-	// Run migrations only for local development (skip if using external DB like Supabase)
-	if cfg.DatabaseURL == "" && (cfg.DBHost == "localhost" || cfg.DBHost == "postgres") {
-		migrationsPath := filepath.Join("internal", "database", "migrations")
-		if _, err := os.Stat(migrationsPath); os.IsNotExist(err) {
-			migrationsPath = "/app/internal/database/migrations"
+	// Migrations run here only when asked; otherwise use healthctl migrate
+	if cfg.MigrateOnStart {
+		if err := db.RunMigrations(database.MigrationsPath()); err != nil {
+			log.Fatalf("Failed to run migrations: %v", err)
 		}
-		if err := db.RunMigrations(migrationsPath); err != nil {
-			log.Printf("Warning: Failed to run migrations: %v", err)
-		}
-	} else {
-		log.Printf("Skipping migrations (external database)")
 	}
 
 	// Initialize Claude AI client
 	claudeClient := ai.NewClaudeClient(cfg.ClaudeAPIKey)
 
+	// Notification channels; each is enabled by its configuration
+	var notifiers []scheduler.Notifier
+	var telegramHandler *handlers.TelegramHandler
+	if cfg.TelegramBotToken != "" {
+		telegramClient := telegram.NewClient(cfg.TelegramBotToken, cfg.TelegramAPIURL)
+		telegramHandler = handlers.NewTelegramHandler(db, telegramClient, cfg.TelegramAllowedChats, cfg.TelegramWebhookSecret)
+		notifiers = append(notifiers, telegramHandler)
+		if cfg.TelegramWebhookSecret == "" {
+			go telegramHandler.Poll(context.Background())
+		}
+	}
+	if cfg.VAPIDPublicKey != "" && cfg.VAPIDPrivateKey != "" {
+		notifiers = append(notifiers, scheduler.NewWebPushNotifier(db, cfg.VAPIDPublicKey, cfg.VAPIDPrivateKey, cfg.VAPIDSubject))
+	}
+	if cfg.SMTPHost != "" {
+		notifiers = append(notifiers, scheduler.NewEmailNotifier(db, cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom))
+	}
+	if len(notifiers) == 0 {
+		notifiers = append(notifiers, scheduler.LogNotifier{})
+	}
+	channels := make([]string, len(notifiers))
+	for i, n := range notifiers {
+		channels[i] = n.Name()
+	}
+
+	// Start reminder scheduler
+	if cfg.SchedulerEnabled {
+		reminderScheduler := scheduler.New(db, cfg.SchedulerInterval, cfg.SchedulerCatchUp, cfg.NotifyMaxAttempts, notifiers...)
+		go reminderScheduler.Run(context.Background())
+	}
+
 	// Initialize handlers
 	supplementHandler := handlers.NewSupplementHandler(db)
 	goalHandler := handlers.NewGoalHandler(db)
@@ -58,6 +85,16 @@ func main() {
 	cycleHandler := handlers.NewCycleHandler(db)
 	aiHandler := handlers.NewAIHandler(db, claudeClient)
 	reminderHandler := handlers.NewReminderHandler(db)
+	scheduleHandler := handlers.NewScheduleHandler(db)
+	intakeHandler := handlers.NewIntakeHandler(db)
+	notificationHandler := handlers.NewNotificationHandler(db, channels, cfg.VAPIDPublicKey)
+	calendarHandler := handlers.NewCalendarHandler(db)
+	archiveHandler := handlers.NewArchiveHandler(db)
+	fhirHandler := handlers.NewFHIRHandler(db)
+	sheetImportHandler := handlers.NewSheetImportHandler(db)
+	dashboardHandler := handlers.NewDashboardHandler(db)
+	correlationHandler := handlers.NewCorrelationHandler(db)
+	reportHandler := handlers.NewReportHandler(db, cfg.ReportFont, cfg.ReportFontBold)
 
 	// Setup router
 	r := chi.NewRouter()
@@ -81,10 +118,17 @@ func main() {
 			r.Get("/", supplementHandler.List)
 			r.Post("/", supplementHandler.Create)
 			r.Get("/schedule", supplementHandler.GetSchedule)
+			r.Get("/schedule/day", supplementHandler.GetDay)
 			r.Get("/by-category", supplementHandler.GetByCategory)
+			r.Get("/intake", supplementHandler.GetIntake)
+			r.Post("/migrate-doses", supplementHandler.MigrateDoses)
+			r.Post("/import/sheet", sheetImportHandler.ImportSupplements)
+			r.Get("/as-of", supplementHandler.AsOf)
+			r.Get("/events", supplementHandler.ListEvents)
 			r.Get("/{id}", supplementHandler.Get)
 			r.Put("/{id}", supplementHandler.Update)
 			r.Delete("/{id}", supplementHandler.Delete)
+			r.Get("/{id}/history", supplementHandler.History)
 		})
 
 		// Goals
@@ -94,6 +138,7 @@ func main() {
 			r.Get("/{id}", goalHandler.Get)
 			r.Put("/{id}", goalHandler.Update)
 			r.Delete("/{id}", goalHandler.Delete)
+			r.Get("/{id}/progress", goalHandler.Progress)
 		})
 
 		// Labs
@@ -101,17 +146,29 @@ func main() {
 			r.Get("/", labHandler.List)
 			r.Post("/", labHandler.Create)
 			r.Post("/import", labHandler.Import)
+			r.Post("/import/fhir", labHandler.ImportFHIR)
+			r.Post("/import/hl7", labHandler.ImportHL7)
+			r.Post("/import/sheet", sheetImportHandler.ImportLabs)
 			r.Get("/trends", labHandler.GetTrends)
+			r.Get("/due", labHandler.GetDue)
+			r.Get("/intervals", labHandler.ListIntervals)
+			r.Put("/intervals", labHandler.UpdateIntervals)
+			r.Delete("/intervals/{marker}", labHandler.ResetInterval)
+			r.Get("/draws", labHandler.ListDraws)
+			r.Post("/draws", labHandler.CreateDraw)
+			r.Delete("/draws/{id}", labHandler.DeleteDraw)
 			r.Get("/marker/{name}", labHandler.GetByMarker)
 			r.Get("/{id}", labHandler.Get)
 			r.Put("/{id}", labHandler.Update)
 			r.Delete("/{id}", labHandler.Delete)
+			r.Get("/{id}/stack", labHandler.GetStack)
 		})
 
 		// Interactions
 		r.Route("/interactions", func(r chi.Router) {
 			r.Get("/", interactionHandler.List)
 			r.Post("/", interactionHandler.Create)
+			r.Get("/matrix", interactionHandler.Matrix)
 			r.Get("/{id}", interactionHandler.Get)
 			r.Put("/{id}", interactionHandler.Update)
 			r.Delete("/{id}", interactionHandler.Delete)
@@ -122,9 +179,14 @@ func main() {
 			r.Get("/", cycleHandler.List)
 			r.Post("/", cycleHandler.Create)
 			r.Get("/latest", cycleHandler.GetLatest)
+			r.Post("/draft", cycleHandler.Draft)
+			r.Post("/validate", cycleHandler.ValidateInput)
+			r.Post("/migrate-input", cycleHandler.MigrateInput)
 			r.Get("/{id}", cycleHandler.Get)
 			r.Put("/{id}", cycleHandler.Update)
 			r.Delete("/{id}", cycleHandler.Delete)
+			r.Get("/{id}/compare/{other}", cycleHandler.Compare)
+			r.Get("/{id}/report", cycleHandler.Report)
 		})
 
 		// AI
@@ -140,19 +202,80 @@ func main() {
 			r.Get("/", reminderHandler.List)
 			r.Post("/", reminderHandler.Create)
 			r.Get("/today", reminderHandler.GetToday)
+			r.Get("/upcoming", reminderHandler.GetUpcoming)
 			r.Get("/{id}", reminderHandler.Get)
 			r.Put("/{id}", reminderHandler.Update)
 			r.Delete("/{id}", reminderHandler.Delete)
 			r.Post("/{id}/toggle", reminderHandler.Toggle)
+			r.Post("/{id}/snooze", reminderHandler.Snooze)
+			r.Get("/{id}/occurrences", reminderHandler.Occurrences)
 		})
 
-		// Dashboard summary
-		r.Get("/dashboard/summary", func(w http.ResponseWriter, r *http.Request) {
-			// TODO: Implement dashboard summary
-			handlers.NewSupplementHandler(db) // placeholder
-			w.Header().Set("Content-Type", "application/json")
-			w.Write([]byte(`{"status": "ok", "message": "Dashboard endpoint"}`))
+		// Schedule slots
+		r.Route("/schedule", func(r chi.Router) {
+			r.Get("/slots", scheduleHandler.GetSlots)
+			r.Put("/slots", scheduleHandler.UpdateSlots)
+		})
+
+		// Intake log and adherence
+		r.Route("/intake", func(r chi.Router) {
+			r.Get("/", intakeHandler.List)
+			r.Post("/", intakeHandler.Log)
+			r.Get("/adherence", intakeHandler.Adherence)
+			r.Delete("/{id}", intakeHandler.Delete)
+		})
+
+		// Notification channels, routing and delivery log
+		r.Route("/notifications", func(r chi.Router) {
+			r.Get("/settings", notificationHandler.GetSettings)
+			r.Put("/settings", notificationHandler.UpdateSettings)
+			r.Get("/vapid-public-key", notificationHandler.VAPIDKey)
+			r.Get("/subscriptions", notificationHandler.ListSubscriptions)
+			r.Post("/subscriptions", notificationHandler.Subscribe)
+			r.Delete("/subscriptions/{id}", notificationHandler.Unsubscribe)
+			r.Get("/deliveries", notificationHandler.Deliveries)
+		})
+
+		// iCalendar feed; authenticated by the token in its address
+		r.Get("/calendar.ics", calendarHandler.Feed)
+		r.Route("/calendar", func(r chi.Router) {
+			r.Get("/subscription", calendarHandler.GetSubscription)
+			r.Post("/subscription", calendarHandler.RotateSubscription)
+			r.Delete("/subscription", calendarHandler.RevokeSubscription)
+		})
+
+		// Backup and restore of the whole health record
+		r.Get("/export", archiveHandler.Export)
+		r.Post("/import", archiveHandler.Import)
+
+		// Saved column mappings for CSV/XLSX imports
+		r.Route("/import-profiles", func(r chi.Router) {
+			r.Get("/", sheetImportHandler.ListProfiles)
+			r.Post("/", sheetImportHandler.CreateProfile)
+			r.Put("/{id}", sheetImportHandler.UpdateProfile)
+			r.Delete("/{id}", sheetImportHandler.DeleteProfile)
+		})
+
+		// Printable reports
+		r.Get("/reports/clinician.pdf", reportHandler.Clinician)
+
+		// FHIR R4 read access to lab results
+		r.Route("/fhir", func(r chi.Router) {
+			r.Get("/Observation", fhirHandler.SearchObservations)
+			r.Get("/Observation/{id}", fhirHandler.GetObservation)
+			r.Get("/Patient/{id}", fhirHandler.GetPatient)
 		})
+
+		// Telegram webhook
+		if telegramHandler != nil && cfg.TelegramWebhookSecret != "" {
+			r.Post("/telegram/webhook", telegramHandler.Webhook)
+		}
+
+		// Dashboard summary
+		r.Get("/dashboard/summary", dashboardHandler.Summary)
+
+		// Supplement changes vs lab results
+		r.Get("/analytics/correlations", correlationHandler.List)
 	})
 
 	// Serve static files in production (frontend build)
@@ -24,6 +24,9 @@ type AnalysisRequest struct {
 	Role      string `json:"role"`
 	InputData string `json:"input_data"`
 	Context   string `json:"context"` // Previous role outputs for chain
+	// Correlations lines up supplement changes with the lab results after
+	// them; only the RSL gets it
+	Correlations string `json:"correlations,omitempty"`
 }
 
 type AnalysisResponse struct {
@@ -92,19 +95,25 @@ func (c *ClaudeClient) buildPrompt(req AnalysisRequest) string {
 		prompt += "## КОНТЕКСТ ПРЕДЫДУЩИХ АНАЛИЗОВ\n\n" + req.Context + "\n\n---\n\n"
 	}
 
+	if req.Correlations != "" {
+		prompt += "## СВЯЗИ ИЗМЕНЕНИЙ СТЕКА С АНАЛИЗАМИ\n\n" + req.Correlations + "\n\n---\n\n"
+	}
+
 	prompt += "## ВХОДНЫЕ ДАННЫЕ ТЕКУЩЕГО ЦИКЛА\n\n" + req.InputData
 
 	return prompt
 }
 
-// RunFullCycle runs all four roles in sequence: RSL → Curator → Red Team → Meta-Supervisor
-func (c *ClaudeClient) RunFullCycle(ctx context.Context, inputData string) (map[string]*AnalysisResponse, error) {
+// RunFullCycle runs all four roles in sequence: RSL → Curator → Red Team → Meta-Supervisor.
+// correlations (supplement changes against lab results) go to the RSL only.
+func (c *ClaudeClient) RunFullCycle(ctx context.Context, inputData, correlations string) (map[string]*AnalysisResponse, error) {
 	results := make(map[string]*AnalysisResponse)
 
-	// 1. Research & Strategy Lead (RSL) - first, no context
+	// 1. Research & Strategy Lead (RSL) - first, no previous analyses
 	rslResp, err := c.Analyze(ctx, AnalysisRequest{
-		Role:      "research_strategy_lead",
-		InputData: inputData,
+		Role:         "research_strategy_lead",
+		InputData:    inputData,
+		Correlations: correlations,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("research strategy lead failed: %w", err)
@@ -0,0 +1,42 @@
+// Package auth holds the PIN used to sign in to the portal
+package auth
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PIN length limits
+const (
+	MinPINLength = 4
+	MaxPINLength = 8
+)
+
+// ErrInvalidPIN is returned for a PIN that is not 4 to 8 digits
+var ErrInvalidPIN = errors.New("PIN must be 4 to 8 digits")
+
+// ValidPIN checks the PIN format
+func ValidPIN(pin string) error {
+	if len(pin) < MinPINLength || len(pin) > MaxPINLength {
+		return ErrInvalidPIN
+	}
+	for _, r := range pin {
+		if r < '0' || r > '9' {
+			return ErrInvalidPIN
+		}
+	}
+	return nil
+}
+
+// HashPIN returns the value stored in users.pin_hash
+func HashPIN(pin string) (string, error) {
+	if err := ValidPIN(pin); err != nil {
+		return "", err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
@@ -2,6 +2,9 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -14,6 +17,38 @@ type Config struct {
 	ServerPort    string
 	JWTSecret     string
 	ClaudeAPIKey  string
+
+	// Apply pending migrations when the server starts
+	MigrateOnStart bool
+
+	// Reminder scheduler
+	SchedulerEnabled  bool
+	SchedulerInterval time.Duration
+	SchedulerCatchUp  time.Duration // how far back missed reminders are still sent
+
+	// Telegram bot (disabled without a token)
+	TelegramBotToken      string
+	TelegramAPIURL        string
+	TelegramAllowedChats  []int64
+	TelegramWebhookSecret string // webhook mode when set, long polling otherwise
+
+	// Email channel (disabled without a host)
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
+	// Web Push channel (disabled without a key pair)
+	VAPIDPublicKey  string
+	VAPIDPrivateKey string
+	VAPIDSubject    string
+
+	NotifyMaxAttempts int
+
+	// TrueType fonts for PDF reports; common system fonts are tried when unset
+	ReportFont     string
+	ReportFontBold string
 }
 
 func Load() *Config {
@@ -27,6 +62,32 @@ func Load() *Config {
 		ServerPort:   getEnv("SERVER_PORT", "8080"),
 		JWTSecret:    getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
 		ClaudeAPIKey: getEnv("CLAUDE_API_KEY", ""),
+
+		MigrateOnStart: getEnv("MIGRATE_ON_START", "false") == "true",
+
+		SchedulerEnabled:  getEnv("SCHEDULER_ENABLED", "true") == "true",
+		SchedulerInterval: getDuration("SCHEDULER_INTERVAL", time.Minute),
+		SchedulerCatchUp:  getDuration("SCHEDULER_CATCHUP", 6*time.Hour),
+
+		TelegramBotToken:      getEnv("TELEGRAM_BOT_TOKEN", ""),
+		TelegramAPIURL:        getEnv("TELEGRAM_API_URL", ""),
+		TelegramAllowedChats:  getInt64List("TELEGRAM_ALLOWED_CHATS"),
+		TelegramWebhookSecret: getEnv("TELEGRAM_WEBHOOK_SECRET", ""),
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:     getEnv("SMTP_FROM", "health-ai@localhost"),
+
+		VAPIDPublicKey:  getEnv("VAPID_PUBLIC_KEY", ""),
+		VAPIDPrivateKey: getEnv("VAPID_PRIVATE_KEY", ""),
+		VAPIDSubject:    getEnv("VAPID_SUBJECT", "mailto:admin@localhost"),
+
+		NotifyMaxAttempts: getInt("NOTIFY_MAX_ATTEMPTS", 5),
+
+		ReportFont:     getEnv("REPORT_FONT", ""),
+		ReportFontBold: getEnv("REPORT_FONT_BOLD", ""),
 	}
 	return cfg
 }
@@ -50,3 +111,32 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getDuration(key string, fallback time.Duration) time.Duration {
+	if value, ok := os.LookupEnv(key); ok {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return fallback
+}
+
+// getInt64List parses a comma-separated list, skipping invalid entries
+func getInt64List(key string) []int64 {
+	var result []int64
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		if v, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64); err == nil {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func getInt(key string, fallback int) int {
+	if value, ok := os.LookupEnv(key); ok {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
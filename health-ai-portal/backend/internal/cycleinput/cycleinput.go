@@ -0,0 +1,306 @@
+// Package cycleinput validates cycles.input_data against the
+// models.CycleInputData template and upgrades older template versions.
+//
+// Version history:
+//
+//	1  the original wizard template, no schema_version; numbers may arrive
+//	   as strings and 0 in metrics means "not measured"
+//	2  schema_version is set, numbers are JSON numbers and unmeasured
+//	   metrics are omitted
+package cycleinput
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"health-ai-portal/internal/models"
+)
+
+// CurrentVersion is the template version new input is stored in
+const CurrentVersion = 2
+
+// FieldError is a problem with one field; Field is the dotted JSON path
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// migrations[v] upgrades a document from version v to v+1
+var migrations = map[int]func(doc map[string]interface{}){
+	1: migrateV1,
+}
+
+// requiredSections lists the sections each cycle type must fill in
+var requiredSections = map[string][]string{
+	"full":    {"wellbeing", "training", "nutrition", "metrics"},
+	"partial": {"wellbeing", "metrics"},
+	"control": {"wellbeing"},
+}
+
+// sentSections count as filled once present: version 1 sent metrics left
+// unmeasured as 0, so after migration an entered metrics section may be empty
+var sentSections = map[string]bool{
+	"metrics": true,
+}
+
+type numberRange struct {
+	min, max float64
+}
+
+// ranges are the plausible values of numeric fields
+var ranges = map[string]numberRange{
+	"training.steps":     {0, 100000},
+	"nutrition.calories": {800, 8000},
+	"nutrition.protein":  {0, 500},
+	"nutrition.carbs":    {0, 1000},
+	"nutrition.fats":     {0, 400},
+	"metrics.weight":     {30, 300},
+	"metrics.pulse":      {30, 220},
+	"metrics.hrv":        {5, 300},
+	"metrics.glucose":    {2, 30},
+}
+
+var bloodPressurePattern = regexp.MustCompile(`^(\d{2,3})\s*/\s*(\d{2,3})$`)
+
+// Field kinds of the template
+const (
+	kindString = "string"
+	kindNumber = "number"
+	kindInt    = "integer"
+	kindBool   = "boolean"
+	kindObject = "object"
+)
+
+type field struct {
+	kind     string
+	children map[string]field
+}
+
+// template is derived from models.CycleInputData so the two cannot drift
+var template = describe(reflect.TypeOf(models.CycleInputData{}))
+
+func describe(t reflect.Type) field {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		f := field{kind: kindObject, children: make(map[string]field)}
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			f.children[name] = describe(t.Field(i).Type)
+		}
+		return f
+	case reflect.String:
+		return field{kind: kindString}
+	case reflect.Bool:
+		return field{kind: kindBool}
+	case reflect.Float32, reflect.Float64:
+		return field{kind: kindNumber}
+	default:
+		return field{kind: kindInt}
+	}
+}
+
+// Version returns the template version of a document
+func Version(doc map[string]interface{}) int {
+	if v, ok := doc["schema_version"].(float64); ok && v >= 1 {
+		return int(v)
+	}
+	return 1
+}
+
+// Migrate upgrades doc in place to CurrentVersion and returns the version it
+// had. Documents from a newer version are an error.
+func Migrate(doc map[string]interface{}) (int, error) {
+	from := Version(doc)
+	if from > CurrentVersion {
+		return from, fmt.Errorf("schema_version %d is newer than supported version %d", from, CurrentVersion)
+	}
+	for v := from; v < CurrentVersion; v++ {
+		migrations[v](doc)
+	}
+	doc["schema_version"] = float64(CurrentVersion)
+	return from, nil
+}
+
+// migrateV1 turns numeric strings into numbers, drops empty ones and drops
+// metrics left at 0, which version 1 used for "not measured"
+func migrateV1(doc map[string]interface{}) {
+	for name, section := range template.children {
+		if section.kind != kindObject {
+			continue
+		}
+		values, ok := doc[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key, f := range section.children {
+			if f.kind != kindNumber && f.kind != kindInt {
+				continue
+			}
+			if s, ok := values[key].(string); ok {
+				s = strings.Replace(strings.TrimSpace(s), ",", ".", 1)
+				if s == "" {
+					delete(values, key)
+				} else if n, err := strconv.ParseFloat(s, 64); err == nil {
+					values[key] = n
+				}
+			}
+			if n, ok := values[key].(float64); ok && n == 0 && name == "metrics" {
+				delete(values, key)
+			}
+		}
+	}
+}
+
+// Validate checks a current-version document: known fields only, value
+// types, numeric ranges, the blood pressure format and the sections required
+// by cycleType ("" requires none). Errors are ordered by field.
+func Validate(doc map[string]interface{}, cycleType string) []FieldError {
+	errs := []FieldError{}
+	check("", doc, template, &errs)
+
+	for _, name := range requiredSections[cycleType] {
+		if section, ok := doc[name].(map[string]interface{}); !ok || !(sentSections[name] || filled(section)) {
+			errs = append(errs, FieldError{Field: name, Message: fmt.Sprintf("section is required for a %s cycle", cycleType)})
+		}
+	}
+
+	if metrics, ok := doc["metrics"].(map[string]interface{}); ok {
+		if bp, ok := metrics["blood_pressure"].(string); ok && strings.TrimSpace(bp) != "" {
+			if msg := checkBloodPressure(bp); msg != "" {
+				errs = append(errs, FieldError{Field: "metrics.blood_pressure", Message: msg})
+			}
+		}
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return errs
+}
+
+func check(path string, value interface{}, f field, errs *[]FieldError) {
+	if value == nil {
+		return
+	}
+	fail := func(msg string) {
+		field := path
+		if field == "" {
+			field = "input_data"
+		}
+		*errs = append(*errs, FieldError{Field: field, Message: msg})
+	}
+
+	switch f.kind {
+	case kindObject:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			fail("must be an object")
+			return
+		}
+		for key, child := range obj {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			spec, known := f.children[key]
+			if !known {
+				*errs = append(*errs, FieldError{Field: childPath, Message: "unknown field"})
+				continue
+			}
+			check(childPath, child, spec, errs)
+		}
+	case kindString:
+		if _, ok := value.(string); !ok {
+			fail("must be a string")
+		}
+	case kindBool:
+		if _, ok := value.(bool); !ok {
+			fail("must be true or false")
+		}
+	case kindNumber, kindInt:
+		n, ok := value.(float64)
+		if !ok {
+			fail("must be a number")
+			return
+		}
+		if f.kind == kindInt && n != math.Trunc(n) {
+			fail("must be a whole number")
+			return
+		}
+		if r, ok := ranges[path]; ok && (n < r.min || n > r.max) {
+			fail(fmt.Sprintf("must be between %g and %g", r.min, r.max))
+		}
+	}
+}
+
+// filled reports whether a section has at least one value entered
+func filled(section map[string]interface{}) bool {
+	for _, v := range section {
+		switch value := v.(type) {
+		case nil:
+		case string:
+			if strings.TrimSpace(value) != "" {
+				return true
+			}
+		case bool:
+			if value {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+func checkBloodPressure(bp string) string {
+	m := bloodPressurePattern.FindStringSubmatch(strings.TrimSpace(bp))
+	if m == nil {
+		return "must look like 120/80"
+	}
+	sys, _ := strconv.Atoi(m[1])
+	dia, _ := strconv.Atoi(m[2])
+	if sys < 60 || sys > 260 || dia < 30 || dia > 160 {
+		return "values out of range (systolic 60–260, diastolic 30–160)"
+	}
+	if sys <= dia {
+		return "systolic must be higher than diastolic"
+	}
+	return ""
+}
+
+// Normalize parses raw input_data, upgrades it to the current version and
+// validates it. It returns the document to store, the version it came in as
+// and any field errors. Empty input is an empty document.
+func Normalize(raw []byte, cycleType string) (json.RawMessage, int, []FieldError) {
+	doc := map[string]interface{}{}
+	if len(strings.TrimSpace(string(raw))) > 0 && strings.TrimSpace(string(raw)) != "null" {
+		if err := json.Unmarshal(raw, &doc); err != nil {
+			return nil, 0, []FieldError{{Field: "input_data", Message: "must be a JSON object"}}
+		}
+	}
+
+	from, err := Migrate(doc)
+	if err != nil {
+		return nil, from, []FieldError{{Field: "schema_version", Message: err.Error()}}
+	}
+	if errs := Validate(doc, cycleType); len(errs) > 0 {
+		return nil, from, errs
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, from, []FieldError{{Field: "input_data", Message: err.Error()}}
+	}
+	return data, from, nil
+}
@@ -0,0 +1,2 @@
+-- Remove separation_hours column
+ALTER TABLE interactions DROP COLUMN IF EXISTS separation_hours;
@@ -0,0 +1,2 @@
+-- Structured timing separation for interactions (hours between doses)
+ALTER TABLE interactions ADD COLUMN IF NOT EXISTS separation_hours INT;
@@ -0,0 +1,13 @@
+-- Remove structured dosing columns
+ALTER TABLE supplements DROP COLUMN IF EXISTS cycle_start_date;
+ALTER TABLE supplements DROP COLUMN IF EXISTS cycle_off_weeks;
+ALTER TABLE supplements DROP COLUMN IF EXISTS cycle_on_weeks;
+ALTER TABLE supplements DROP COLUMN IF EXISTS with_food;
+ALTER TABLE supplements DROP COLUMN IF EXISTS times_per_week;
+ALTER TABLE supplements DROP COLUMN IF EXISTS times_per_day;
+ALTER TABLE supplements DROP COLUMN IF EXISTS frequency;
+ALTER TABLE supplements DROP COLUMN IF EXISTS dose_form;
+ALTER TABLE supplements DROP COLUMN IF EXISTS dose_unit;
+ALTER TABLE supplements DROP COLUMN IF EXISTS dose_amount_min;
+ALTER TABLE supplements DROP COLUMN IF EXISTS dose_amount;
+ALTER TABLE supplements DROP COLUMN IF EXISTS ingredient;
@@ -0,0 +1,14 @@
+-- Structured dosing model for supplements (free-text dose is kept for display)
+ALTER TABLE supplements ADD COLUMN IF NOT EXISTS ingredient VARCHAR(100);
+ALTER TABLE supplements ADD COLUMN IF NOT EXISTS dose_amount DECIMAL(12,3);
+ALTER TABLE supplements ADD COLUMN IF NOT EXISTS dose_amount_min DECIMAL(12,3);
+ALTER TABLE supplements ADD COLUMN IF NOT EXISTS dose_unit VARCHAR(20);
+ALTER TABLE supplements ADD COLUMN IF NOT EXISTS dose_form VARCHAR(30);
+ALTER TABLE supplements ADD COLUMN IF NOT EXISTS frequency VARCHAR(20) DEFAULT 'daily'
+    CHECK (frequency IN ('daily', 'every_other_day', 'weekly', 'as_needed'));
+ALTER TABLE supplements ADD COLUMN IF NOT EXISTS times_per_day INT DEFAULT 1 CHECK (times_per_day > 0);
+ALTER TABLE supplements ADD COLUMN IF NOT EXISTS times_per_week INT CHECK (times_per_week BETWEEN 1 AND 7);
+ALTER TABLE supplements ADD COLUMN IF NOT EXISTS with_food VARCHAR(10) CHECK (with_food IN ('with', 'without', 'any'));
+ALTER TABLE supplements ADD COLUMN IF NOT EXISTS cycle_on_weeks INT CHECK (cycle_on_weeks > 0);
+ALTER TABLE supplements ADD COLUMN IF NOT EXISTS cycle_off_weeks INT CHECK (cycle_off_weeks >= 0);
+ALTER TABLE supplements ADD COLUMN IF NOT EXISTS cycle_start_date DATE;
@@ -0,0 +1,34 @@
+-- Append-only supplement change history
+CREATE TABLE IF NOT EXISTS supplement_events (
+    id SERIAL PRIMARY KEY,
+    supplement_id INT REFERENCES supplements(id) ON DELETE CASCADE,
+    user_id INT REFERENCES users(id) ON DELETE CASCADE,
+    event_type VARCHAR(20) NOT NULL CHECK (event_type IN ('added', 'dose_changed', 'paused', 'resumed', 'removed', 'updated')),
+    event_date TIMESTAMP NOT NULL DEFAULT NOW(),
+    dose VARCHAR(100),
+    previous_dose VARCHAR(100),
+    status VARCHAR(20),
+    snapshot JSONB,
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_supplement_events_supplement ON supplement_events(supplement_id, event_date);
+CREATE INDEX idx_supplement_events_user_date ON supplement_events(user_id, event_date);
+
+-- Backfill history from current rows (earlier dose changes are unknown).
+-- Timestamp columns are dropped from the snapshot: Postgres renders them
+-- without a zone, which the API cannot decode.
+INSERT INTO supplement_events (supplement_id, user_id, event_type, event_date, dose, status, snapshot, notes)
+SELECT id, user_id, 'added', COALESCE(created_at, NOW()), dose, 'active', (to_jsonb(s) - 'created_at' - 'updated_at' - 'removed_at' - 'cycle_start_date') || '{"status": "active"}'::jsonb, 'backfilled'
+FROM supplements s;
+
+INSERT INTO supplement_events (supplement_id, user_id, event_type, event_date, dose, status, snapshot, notes)
+SELECT id, user_id, 'removed', removed_at, dose, 'removed', to_jsonb(s) - 'created_at' - 'updated_at' - 'removed_at' - 'cycle_start_date', 'backfilled'
+FROM supplements s
+WHERE status = 'removed' AND removed_at IS NOT NULL;
+
+INSERT INTO supplement_events (supplement_id, user_id, event_type, event_date, dose, status, snapshot, notes)
+SELECT id, user_id, 'paused', COALESCE(updated_at, NOW()), dose, 'paused', to_jsonb(s) - 'created_at' - 'updated_at' - 'removed_at' - 'cycle_start_date', 'backfilled'
+FROM supplements s
+WHERE status = 'paused';
@@ -0,0 +1,4 @@
+ALTER TABLE supplements DROP COLUMN IF EXISTS days_of_week;
+DROP TABLE IF EXISTS supplement_slots;
+DROP TABLE IF EXISTS schedule_slots;
+ALTER TABLE users DROP COLUMN IF EXISTS timezone;
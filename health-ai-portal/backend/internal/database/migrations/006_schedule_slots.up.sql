@@ -0,0 +1,25 @@
+-- User timezone for schedules and reminders
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) DEFAULT 'UTC';
+
+-- Per-user clock times for named intake slots (defaults live in code)
+CREATE TABLE IF NOT EXISTS schedule_slots (
+    id SERIAL PRIMARY KEY,
+    user_id INT REFERENCES users(id) ON DELETE CASCADE,
+    slot VARCHAR(20) NOT NULL CHECK (slot IN ('wake', 'breakfast', 'pre_workout', 'lunch', 'evening', 'bedtime')),
+    clock_time TIME NOT NULL,
+    label VARCHAR(50),
+    UNIQUE(user_id, slot)
+);
+
+-- A supplement can be taken in several slots
+CREATE TABLE IF NOT EXISTS supplement_slots (
+    id SERIAL PRIMARY KEY,
+    supplement_id INT REFERENCES supplements(id) ON DELETE CASCADE,
+    slot VARCHAR(20) NOT NULL CHECK (slot IN ('wake', 'breakfast', 'pre_workout', 'lunch', 'evening', 'bedtime')),
+    UNIQUE(supplement_id, slot)
+);
+
+-- ISO weekdays (1=Mon..7=Sun) a supplement is taken on; NULL means every day
+ALTER TABLE supplements ADD COLUMN IF NOT EXISTS days_of_week JSONB;
+
+CREATE INDEX idx_supplement_slots_supplement ON supplement_slots(supplement_id);
@@ -0,0 +1,15 @@
+-- Dose intake log for adherence tracking
+CREATE TABLE IF NOT EXISTS intake_logs (
+    id SERIAL PRIMARY KEY,
+    user_id INT REFERENCES users(id) ON DELETE CASCADE,
+    supplement_id INT REFERENCES supplements(id) ON DELETE CASCADE,
+    slot VARCHAR(20) NOT NULL CHECK (slot IN ('wake', 'breakfast', 'pre_workout', 'lunch', 'evening', 'bedtime', 'as_needed')),
+    scheduled_date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('taken', 'skipped', 'late')),
+    taken_at TIMESTAMPTZ,
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE(supplement_id, scheduled_date, slot)
+);
+
+CREATE INDEX idx_intake_logs_user_date ON intake_logs(user_id, scheduled_date);
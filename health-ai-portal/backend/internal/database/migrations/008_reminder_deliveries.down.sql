@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS reminder_deliveries;
+ALTER TABLE reminders DROP COLUMN IF EXISTS snoozed_until;
@@ -0,0 +1,22 @@
+-- Snooze: one extra occurrence at snoozed_until
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS snoozed_until TIMESTAMPTZ;
+
+-- One row per fired occurrence and channel; the unique key lets the
+-- scheduler claim an occurrence exactly once, including after a restart
+CREATE TABLE IF NOT EXISTS reminder_deliveries (
+    id SERIAL PRIMARY KEY,
+    reminder_id INT REFERENCES reminders(id) ON DELETE CASCADE,
+    user_id INT REFERENCES users(id) ON DELETE CASCADE,
+    scheduled_for TIMESTAMPTZ NOT NULL,
+    channel VARCHAR(30) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    attempts INT NOT NULL DEFAULT 0,
+    error TEXT,
+    catch_up BOOLEAN NOT NULL DEFAULT false,
+    snoozed BOOLEAN NOT NULL DEFAULT false,
+    delivered_at TIMESTAMPTZ,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE(reminder_id, scheduled_for, channel)
+);
+
+CREATE INDEX idx_reminder_deliveries_user_time ON reminder_deliveries(user_id, scheduled_for);
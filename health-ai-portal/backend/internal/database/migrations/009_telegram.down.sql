@@ -0,0 +1,3 @@
+ALTER TABLE reminder_deliveries DROP COLUMN IF EXISTS responded_at;
+ALTER TABLE reminder_deliveries DROP COLUMN IF EXISTS response;
+ALTER TABLE users DROP COLUMN IF EXISTS telegram_chat_id;
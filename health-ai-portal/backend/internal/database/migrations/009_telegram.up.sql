@@ -0,0 +1,6 @@
+-- Chat that receives a user's reminders and may issue bot commands
+ALTER TABLE users ADD COLUMN IF NOT EXISTS telegram_chat_id BIGINT;
+
+-- What the user answered to a delivered reminder (taken / skip / snooze)
+ALTER TABLE reminder_deliveries ADD COLUMN IF NOT EXISTS response VARCHAR(20) CHECK (response IN ('taken', 'skipped', 'snoozed'));
+ALTER TABLE reminder_deliveries ADD COLUMN IF NOT EXISTS responded_at TIMESTAMPTZ;
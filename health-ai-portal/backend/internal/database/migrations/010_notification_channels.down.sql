@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_reminder_deliveries_pending;
+ALTER TABLE reminder_deliveries DROP COLUMN IF EXISTS next_attempt_at;
+DROP TABLE IF EXISTS notification_routes;
+DROP TABLE IF EXISTS push_subscriptions;
+ALTER TABLE users DROP COLUMN IF EXISTS quiet_hours_end;
+ALTER TABLE users DROP COLUMN IF EXISTS quiet_hours_start;
+ALTER TABLE users DROP COLUMN IF EXISTS notification_email;
@@ -0,0 +1,30 @@
+-- Email recipient and quiet hours (in the user's timezone; may wrap midnight)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS notification_email VARCHAR(255);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS quiet_hours_start TIME;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS quiet_hours_end TIME;
+
+-- Web Push subscriptions, one per browser/device
+CREATE TABLE IF NOT EXISTS push_subscriptions (
+    id SERIAL PRIMARY KEY,
+    user_id INT REFERENCES users(id) ON DELETE CASCADE,
+    endpoint TEXT NOT NULL UNIQUE,
+    p256dh VARCHAR(255) NOT NULL,
+    auth VARCHAR(255) NOT NULL,
+    device_name VARCHAR(100),
+    user_agent TEXT,
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Channels per reminder type; 'default' applies to types without own routes
+CREATE TABLE IF NOT EXISTS notification_routes (
+    id SERIAL PRIMARY KEY,
+    user_id INT REFERENCES users(id) ON DELETE CASCADE,
+    reminder_type VARCHAR(50) NOT NULL CHECK (reminder_type IN ('supplement', 'lab', 'workout', 'default')),
+    channel VARCHAR(30) NOT NULL,
+    UNIQUE(user_id, reminder_type, channel)
+);
+
+-- Failed deliveries stay pending with a later next_attempt_at until attempts run out
+ALTER TABLE reminder_deliveries ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ;
+CREATE INDEX idx_reminder_deliveries_pending ON reminder_deliveries(next_attempt_at) WHERE status = 'pending';
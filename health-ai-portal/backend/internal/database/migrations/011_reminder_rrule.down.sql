@@ -0,0 +1,3 @@
+ALTER TABLE reminders DROP COLUMN IF EXISTS exdates;
+ALTER TABLE reminders DROP COLUMN IF EXISTS dtstart;
+ALTER TABLE reminders DROP COLUMN IF EXISTS rrule;
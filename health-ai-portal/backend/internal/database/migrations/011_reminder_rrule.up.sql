@@ -0,0 +1,26 @@
+-- RFC 5545 recurrence: rrule holds the RRULE value (without "RRULE:"),
+-- dtstart anchors INTERVAL/COUNT and exdates lists skipped dates (YYYY-MM-DD)
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS rrule TEXT;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS dtstart DATE;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS exdates JSONB;
+
+UPDATE reminders SET dtstart = created_at::date WHERE dtstart IS NULL;
+
+-- days_of_week [1,3,5] becomes FREQ=WEEKLY;BYDAY=MO,WE,FR; none or all seven become daily
+UPDATE reminders r SET rrule = CASE
+    WHEN r.days_of_week IS NULL
+        OR jsonb_typeof(r.days_of_week) <> 'array'
+        OR jsonb_array_length(r.days_of_week) = 0 THEN 'FREQ=DAILY'
+    ELSE (
+        SELECT CASE
+            WHEN count(*) = 0 OR count(*) = 7 THEN 'FREQ=DAILY'
+            ELSE 'FREQ=WEEKLY;BYDAY=' || string_agg((ARRAY['MO', 'TU', 'WE', 'TH', 'FR', 'SA', 'SU'])[d], ',' ORDER BY d)
+        END
+        FROM (
+            SELECT DISTINCT e::int AS d
+            FROM jsonb_array_elements_text(r.days_of_week) AS e
+            WHERE e ~ '^[1-7]$'
+        ) days
+    )
+END
+WHERE r.rrule IS NULL;
@@ -0,0 +1,2 @@
+-- Secret token for the subscribable iCalendar feed (GET /api/calendar.ics?token=...)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS calendar_token TEXT UNIQUE;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS lab_draws;
+DROP TABLE IF EXISTS lab_retest_intervals;
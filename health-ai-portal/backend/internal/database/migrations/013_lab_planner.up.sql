@@ -0,0 +1,25 @@
+-- Per-marker retest intervals overriding the built-in defaults (0 disables)
+CREATE TABLE IF NOT EXISTS lab_retest_intervals (
+    id SERIAL PRIMARY KEY,
+    user_id INT REFERENCES users(id) ON DELETE CASCADE,
+    marker_name VARCHAR(200) NOT NULL,
+    interval_days INT NOT NULL CHECK (interval_days >= 0),
+    fasting BOOLEAN NOT NULL DEFAULT false,
+    note TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE(user_id, marker_name)
+);
+
+-- Planned blood draws and the lab reminders created for them
+CREATE TABLE IF NOT EXISTS lab_draws (
+    id SERIAL PRIMARY KEY,
+    user_id INT REFERENCES users(id) ON DELETE CASCADE,
+    draw_date DATE NOT NULL,
+    markers JSONB NOT NULL,
+    fasting BOOLEAN NOT NULL DEFAULT false,
+    reminder_id INT REFERENCES reminders(id) ON DELETE SET NULL,
+    fasting_reminder_id INT REFERENCES reminders(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_lab_draws_user_date ON lab_draws(user_id, draw_date);
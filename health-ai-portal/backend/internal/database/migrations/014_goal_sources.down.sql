@@ -0,0 +1,8 @@
+ALTER TABLE goals DROP COLUMN IF EXISTS achieved_at;
+ALTER TABLE goals DROP COLUMN IF EXISTS hold_days;
+ALTER TABLE goals DROP COLUMN IF EXISTS deadline;
+ALTER TABLE goals DROP COLUMN IF EXISTS direction;
+ALTER TABLE goals DROP COLUMN IF EXISTS baseline_value;
+ALTER TABLE goals DROP COLUMN IF EXISTS target_number;
+ALTER TABLE goals DROP COLUMN IF EXISTS source_key;
+ALTER TABLE goals DROP COLUMN IF EXISTS source_type;
@@ -0,0 +1,11 @@
+-- Goals bound to a lab marker or a daily metric with a numeric target.
+-- Progress is computed from the data; hold_days is how long the target must
+-- hold before the goal becomes achieved.
+ALTER TABLE goals ADD COLUMN IF NOT EXISTS source_type VARCHAR(20) CHECK (source_type IN ('lab', 'metric'));
+ALTER TABLE goals ADD COLUMN IF NOT EXISTS source_key VARCHAR(200);
+ALTER TABLE goals ADD COLUMN IF NOT EXISTS target_number DECIMAL(10,3);
+ALTER TABLE goals ADD COLUMN IF NOT EXISTS baseline_value DECIMAL(10,3);
+ALTER TABLE goals ADD COLUMN IF NOT EXISTS direction VARCHAR(10) CHECK (direction IN ('increase', 'decrease'));
+ALTER TABLE goals ADD COLUMN IF NOT EXISTS deadline DATE;
+ALTER TABLE goals ADD COLUMN IF NOT EXISTS hold_days INT NOT NULL DEFAULT 0 CHECK (hold_days >= 0);
+ALTER TABLE goals ADD COLUMN IF NOT EXISTS achieved_at TIMESTAMP;
@@ -0,0 +1,15 @@
+-- Saved column mappings for spreadsheet imports. mapping maps an import
+-- field to a header caption or column letter; sheet and header_row pin the
+-- worksheet and 1-based header row when detection should not be used.
+CREATE TABLE IF NOT EXISTS import_profiles (
+    id SERIAL PRIMARY KEY,
+    user_id INT REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('labs', 'supplements')),
+    mapping JSONB NOT NULL DEFAULT '{}',
+    sheet VARCHAR(200),
+    header_row INT CHECK (header_row > 0),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE(user_id, name)
+);
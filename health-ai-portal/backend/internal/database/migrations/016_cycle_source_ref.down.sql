@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_cycles_source_ref;
+ALTER TABLE cycles DROP COLUMN IF EXISTS source_ref;
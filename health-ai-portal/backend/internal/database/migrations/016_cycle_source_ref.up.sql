@@ -0,0 +1,4 @@
+-- Where an imported cycle came from, e.g. "08_cycles_history.md#CYCLE-0004".
+-- Re-running the history import skips cycles it has already stored.
+ALTER TABLE cycles ADD COLUMN IF NOT EXISTS source_ref VARCHAR(100);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cycles_source_ref ON cycles(user_id, source_ref) WHERE source_ref IS NOT NULL;
@@ -3,6 +3,11 @@ package database
 import (
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
@@ -27,10 +32,20 @@ func New(databaseURL string) (*DB, error) {
 	return &DB{db}, nil
 }
 
-func (db *DB) RunMigrations(migrationsPath string) error {
+// MigrationsPath finds the migrations next to the working directory, or
+// where the Docker image keeps them
+func MigrationsPath() string {
+	path := filepath.Join("internal", "database", "migrations")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return "/app/internal/database/migrations"
+	}
+	return path
+}
+
+func (db *DB) migrator(migrationsPath string) (*migrate.Migrate, error) {
 	driver, err := postgres.WithInstance(db.DB.DB, &postgres.Config{})
 	if err != nil {
-		return fmt.Errorf("failed to create migration driver: %w", err)
+		return nil, fmt.Errorf("failed to create migration driver: %w", err)
 	}
 
 	m, err := migrate.NewWithDatabaseInstance(
@@ -39,7 +54,15 @@ func (db *DB) RunMigrations(migrationsPath string) error {
 		driver,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to create migrate instance: %w", err)
+		return nil, fmt.Errorf("failed to create migrate instance: %w", err)
+	}
+	return m, nil
+}
+
+func (db *DB) RunMigrations(migrationsPath string) error {
+	m, err := db.migrator(migrationsPath)
+	if err != nil {
+		return err
 	}
 
 	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
@@ -50,6 +73,72 @@ func (db *DB) RunMigrations(migrationsPath string) error {
 	return nil
 }
 
+// RollbackMigrations reverts the last steps migrations
+func (db *DB) RollbackMigrations(migrationsPath string, steps int) error {
+	m, err := db.migrator(migrationsPath)
+	if err != nil {
+		return err
+	}
+	if err := m.Steps(-steps); err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("failed to roll back migrations: %w", err)
+	}
+	return nil
+}
+
+// MigrationVersion is the applied migration; 0 when none is. Dirty means
+// the last migration failed half-way and has to be fixed by hand.
+func (db *DB) MigrationVersion(migrationsPath string) (version uint, dirty bool, err error) {
+	m, err := db.migrator(migrationsPath)
+	if err != nil {
+		return 0, false, err
+	}
+	version, dirty, err = m.Version()
+	if err == migrate.ErrNilVersion {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// ForceMigration records version as applied and clean without running
+// anything, after a failed migration was repaired by hand
+func (db *DB) ForceMigration(migrationsPath string, version int) error {
+	m, err := db.migrator(migrationsPath)
+	if err != nil {
+		return err
+	}
+	return m.Force(version)
+}
+
+// Migration is a migration file pair
+type Migration struct {
+	Version uint
+	Name    string
+}
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(.+)\.up\.sql$`)
+
+// Migrations lists the available migrations, oldest first
+func Migrations(migrationsPath string) ([]Migration, error) {
+	entries, err := os.ReadDir(migrationsPath)
+	if err != nil {
+		return nil, err
+	}
+	var result []Migration
+	for _, e := range entries {
+		m := migrationFile.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		v, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		result = append(result, Migration{Version: uint(v), Name: m[2]})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
 func (db *DB) Close() error {
 	return db.DB.Close()
 }
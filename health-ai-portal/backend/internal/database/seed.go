@@ -0,0 +1,43 @@
+package database
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+)
+
+// seedSQL is the starting protocol (PROTOCOL_v12.md) for user 1
+//
+//go:embed seed.sql
+var seedSQL string
+
+// ErrAlreadySeeded means user 1 already has goals or supplements
+var ErrAlreadySeeded = errors.New("database already has data for user 1")
+
+// Seed loads the starting protocol for user 1. The seed has no natural keys
+// to skip on, so it refuses to run twice unless force is set.
+func (db *DB) Seed(force bool) error {
+	if !force {
+		var exists bool
+		err := db.Get(&exists, `
+			SELECT EXISTS (SELECT 1 FROM supplements WHERE user_id = 1)
+				OR EXISTS (SELECT 1 FROM goals WHERE user_id = 1)
+		`)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrAlreadySeeded
+		}
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(seedSQL); err != nil {
+		return fmt.Errorf("failed to seed: %w", err)
+	}
+	return tx.Commit()
+}
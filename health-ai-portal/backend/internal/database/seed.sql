@@ -145,4 +145,15 @@ VALUES
 '2026-02-19')
 ON CONFLICT DO NOTHING;
 
-SELECT 'Seed data inserted successfully' as result;
+-- History for the seeded supplements, as migration 005 backfills it for
+-- rows that existed before the history table
+INSERT INTO supplement_events (supplement_id, user_id, event_type, event_date, dose, status, snapshot, notes)
+SELECT id, user_id, 'added', COALESCE(created_at, NOW()), dose, 'active', (to_jsonb(s) - 'created_at' - 'updated_at' - 'removed_at' - 'cycle_start_date') || '{"status": "active"}'::jsonb, 'seed'
+FROM supplements s
+WHERE user_id = 1 AND NOT EXISTS (SELECT 1 FROM supplement_events e WHERE e.supplement_id = s.id);
+
+INSERT INTO supplement_events (supplement_id, user_id, event_type, event_date, dose, status, snapshot, notes)
+SELECT id, user_id, 'removed', removed_at, dose, 'removed', to_jsonb(s) - 'created_at' - 'updated_at' - 'removed_at' - 'cycle_start_date', 'seed'
+FROM supplements s
+WHERE user_id = 1 AND status = 'removed' AND removed_at IS NOT NULL
+	AND NOT EXISTS (SELECT 1 FROM supplement_events e WHERE e.supplement_id = s.id AND e.event_type = 'removed');
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
@@ -44,13 +46,15 @@ func (h *AIHandler) Analyze(w http.ResponseWriter, r *http.Request) {
 
 	// Get input data either from request or from existing cycle
 	inputData := req.InputData
-	if req.CycleID > 0 && inputData == "" {
-		cycle, err := h.getCycle(req.CycleID)
+	var cycle *models.Cycle
+	if req.CycleID > 0 {
+		var err error
+		cycle, err = h.getCycle(req.CycleID)
 		if err != nil {
 			respondError(w, http.StatusNotFound, "Cycle not found")
 			return
 		}
-		if cycle.InputData != nil {
+		if inputData == "" && cycle.InputData != nil {
 			inputData = string(*cycle.InputData)
 		}
 	}
@@ -60,12 +64,35 @@ func (h *AIHandler) Analyze(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Add adherence since the previous cycle so labs are judged against what was actually taken
+	if cycle != nil {
+		section, err := h.cycleAdherence(cycle)
+		if err != nil {
+			log.Printf("AI: adherence for cycle %d failed: %v", cycle.ID, err)
+		}
+		if section != "" {
+			inputData += "\n\n" + section
+		}
+	}
+
+	// Supplement changes lined up with the lab results after them, for the
+	// RSL; an earlier cycle sees only what was known on its date
+	userID := 1 // TODO: get from auth context
+	var asOf *time.Time
+	if cycle != nil {
+		asOf = &cycle.CycleDate
+	}
+	correlations, err := correlationContext(h.db, userID, asOf)
+	if err != nil {
+		log.Printf("AI: correlations for user %d failed: %v", userID, err)
+	}
+
 	ctx := r.Context()
 	results := make(map[string]*ai.AnalysisResponse)
 
 	if req.Role == "full" || req.Role == "" {
 		// Run full cycle (all 4 roles: RSL → Curator → Red Team → Meta-Supervisor)
-		fullResults, err := h.claude.RunFullCycle(ctx, inputData)
+		fullResults, err := h.claude.RunFullCycle(ctx, inputData, correlations)
 		if err != nil {
 			respondError(w, http.StatusInternalServerError, "AI analysis failed: "+err.Error())
 			return
@@ -73,10 +100,14 @@ func (h *AIHandler) Analyze(w http.ResponseWriter, r *http.Request) {
 		results = fullResults
 	} else {
 		// Run single role
-		result, err := h.claude.Analyze(ctx, ai.AnalysisRequest{
+		analysis := ai.AnalysisRequest{
 			Role:      req.Role,
 			InputData: inputData,
-		})
+		}
+		if req.Role == "research_strategy_lead" {
+			analysis.Correlations = correlations
+		}
+		result, err := h.claude.Analyze(ctx, analysis)
 		if err != nil {
 			respondError(w, http.StatusInternalServerError, "AI analysis failed: "+err.Error())
 			return
@@ -146,6 +177,27 @@ func (h *AIHandler) GetAnalysis(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// cycleAdherence renders adherence from the previous cycle (or 30 days before)
+// up to the given cycle's date.
+func (h *AIHandler) cycleAdherence(cycle *models.Cycle) (string, error) {
+	from := cycle.CycleDate.AddDate(0, 0, -30)
+	var previous time.Time
+	err := h.db.Get(&previous, `
+		SELECT cycle_date FROM cycles
+		WHERE user_id = $1 AND cycle_date < $2
+		ORDER BY cycle_date DESC
+		LIMIT 1
+	`, cycle.UserID, cycle.CycleDate)
+	switch {
+	case err == nil:
+		from = previous
+	case err != sql.ErrNoRows:
+		return "", err
+	}
+
+	return adherenceContext(h.db, cycle.UserID, from, cycle.CycleDate)
+}
+
 func (h *AIHandler) getCycle(id int) (*models.Cycle, error) {
 	var cycle models.Cycle
 	err := h.db.DB.Get(&cycle, "SELECT * FROM cycles WHERE id = $1", id)
@@ -0,0 +1,515 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/models"
+	"health-ai-portal/pkg/archive"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	// maxArchiveSize bounds an uploaded archive
+	maxArchiveSize = 100 << 20
+	// maxReportedConflicts bounds the conflicts listed per table; the counts
+	// stay exact
+	maxReportedConflicts = 100
+)
+
+// archiveRef is a foreign key that is remapped to the new IDs on import
+type archiveRef struct {
+	column, table string
+	nullable      bool   // an unresolved reference becomes NULL instead of skipping the row
+	snapshot      string // JSON column copying the referenced row; its id and user_id follow the remapping
+}
+
+// archiveTable describes how one table is exported and imported
+type archiveTable struct {
+	name  string
+	scope string // selects the user's rows; $1 is the user ID
+	refs  []archiveRef
+	key   []string // natural key that detects rows already present
+}
+
+const (
+	userScope        = "user_id = $1"
+	userSupplements  = "(SELECT id FROM supplements WHERE user_id = $1)"
+	supplementRef    = "supplement_id"
+	supplementsTable = "supplements"
+)
+
+// archiveTables are exported and imported in this order, parents first.
+// Device-bound data (push subscriptions, deliveries, tokens) is not exported.
+var archiveTables = []archiveTable{
+	{name: supplementsTable, scope: userScope, key: []string{"name"}},
+	{
+		name:  "supplement_events",
+		scope: userScope,
+		refs:  []archiveRef{{column: supplementRef, table: supplementsTable, snapshot: "snapshot"}},
+		key:   []string{supplementRef, "event_type", "event_date"},
+	},
+	{
+		name:  "supplement_slots",
+		scope: supplementRef + " IN " + userSupplements,
+		refs:  []archiveRef{{column: supplementRef, table: supplementsTable}},
+		key:   []string{supplementRef, "slot"},
+	},
+	{
+		name:  "interactions",
+		scope: "supplement_1_id IN " + userSupplements,
+		refs: []archiveRef{
+			{column: "supplement_1_id", table: supplementsTable},
+			{column: "supplement_2_id", table: supplementsTable},
+		},
+		key: []string{"supplement_1_id", "supplement_2_id"},
+	},
+	{name: "schedule_slots", scope: userScope, key: []string{"slot"}},
+	{
+		name:  "intake_logs",
+		scope: userScope,
+		refs:  []archiveRef{{column: supplementRef, table: supplementsTable}},
+		key:   []string{supplementRef, "scheduled_date", "slot"},
+	},
+	{name: "goals", scope: userScope, key: []string{"name"}},
+	{name: "lab_results", scope: userScope, key: []string{"marker_name", "test_date", "value"}},
+	{name: "lab_retest_intervals", scope: userScope, key: []string{"marker_name"}},
+	{name: "cycles", scope: userScope, key: []string{"cycle_date", "cycle_type"}},
+	{
+		name:  "ai_analyses",
+		scope: "cycle_id IN (SELECT id FROM cycles WHERE user_id = $1)",
+		refs:  []archiveRef{{column: "cycle_id", table: "cycles"}},
+		key:   []string{"cycle_id", "role", "created_at"},
+	},
+	{name: "reminders", scope: userScope, key: []string{"reminder_type", "title", "time"}},
+	{
+		name:  "lab_draws",
+		scope: userScope,
+		refs: []archiveRef{
+			{column: "reminder_id", table: "reminders", nullable: true},
+			{column: "fasting_reminder_id", table: "reminders", nullable: true},
+		},
+		key: []string{"draw_date"},
+	},
+	{name: "daily_metrics", scope: userScope, key: []string{"metric_date"}},
+	{name: "import_profiles", scope: userScope, key: []string{"name"}},
+}
+
+type ArchiveHandler struct {
+	db *database.DB
+}
+
+func NewArchiveHandler(db *database.DB) *ArchiveHandler {
+	return &ArchiveHandler{db: db}
+}
+
+// Export downloads the user's whole health record as a zip archive
+func (h *ArchiveHandler) Export(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	a, err := ExportArchive(h.db, userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := archive.Write(&buf, a); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("health-export-%s.zip", a.Manifest.ExportedAt.Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Write(buf.Bytes())
+}
+
+// ExportArchive collects the user's rows of every archived table
+func ExportArchive(db *database.DB, userID int) (*archive.Archive, error) {
+	a := &archive.Archive{Tables: make(map[string][]json.RawMessage, len(archiveTables))}
+	a.Manifest.ExportedAt = time.Now().UTC()
+	// golang-migrate keeps the applied migration here
+	db.Get(&a.Manifest.SchemaVersion, `SELECT version FROM schema_migrations LIMIT 1`)
+
+	for _, t := range archiveTables {
+		var rows []string
+		query := fmt.Sprintf(`SELECT row_to_json(t)::text FROM %s t WHERE %s ORDER BY id`, t.name, t.scope)
+		if err := db.Select(&rows, query, userID); err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", t.name, err)
+		}
+		a.Tables[t.name] = make([]json.RawMessage, len(rows))
+		for i, row := range rows {
+			a.Tables[t.name][i] = json.RawMessage(row)
+		}
+	}
+	return a, nil
+}
+
+// Import restores an archive from Export, uploaded as the "file" form field
+// or as the raw body. IDs are remapped and rows that already exist (by
+// natural key) are skipped, or replaced with ?on_conflict=overwrite. The
+// import is all or nothing; ?dry_run=true reports what it would do.
+func (h *ArchiveHandler) Import(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	onConflict := r.URL.Query().Get("on_conflict")
+	if onConflict == "" {
+		onConflict = models.ImportSkip
+	}
+	if onConflict != models.ImportSkip && onConflict != models.ImportOverwrite {
+		respondError(w, http.StatusBadRequest, "on_conflict must be skip or overwrite")
+		return
+	}
+
+	data, err := readUpload(w, r, maxArchiveSize)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Failed to read archive: "+err.Error())
+		return
+	}
+	a, err := archive.Read(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := ImportArchive(h.db, userID, a, onConflict, dryRun)
+	if err != nil {
+		var importErr *archiveImportError
+		if errors.As(err, &importErr) {
+			respondError(w, http.StatusUnprocessableEntity, "Import failed: "+importErr.Error())
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, report)
+}
+
+// archiveImportError is an archive that does not fit this database, as
+// opposed to a failure of the database itself
+type archiveImportError struct{ err error }
+
+func (e *archiveImportError) Error() string { return e.err.Error() }
+func (e *archiveImportError) Unwrap() error { return e.err }
+
+// archiveRowError reports a row the database refused. Invalid values and
+// broken constraints are the archive's fault; anything else is the database's.
+func archiveRowError(table string, archiveID int, err error) error {
+	err = fmt.Errorf("%s %d: %w", table, archiveID, err)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		if class := pqErr.Code.Class(); class == "22" || class == "23" {
+			return &archiveImportError{err}
+		}
+	}
+	return err
+}
+
+// ImportArchive restores an archive in one transaction; with dryRun the
+// transaction is rolled back after the report is made
+func ImportArchive(db *database.DB, userID int, a *archive.Archive, onConflict string, dryRun bool) (*models.ImportReport, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	report, err := importArchive(tx, userID, a, onConflict)
+	if err != nil {
+		return nil, err
+	}
+	report.DryRun = dryRun
+
+	if !dryRun {
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// readUpload reads a file sent as the "file" field of a multipart form or as
+// the raw body
+func readUpload(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		return io.ReadAll(r.Body)
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+func importArchive(tx *sqlx.Tx, userID int, a *archive.Archive, onConflict string) (*models.ImportReport, error) {
+	report := &models.ImportReport{
+		OnConflict:     onConflict,
+		ArchiveVersion: a.Manifest.Version,
+		SchemaVersion:  a.Manifest.SchemaVersion,
+		Tables:         []models.ImportTableReport{},
+		Warnings:       []string{},
+	}
+
+	// ids maps archived IDs to IDs in this database, per table
+	ids := make(map[string]map[int]int, len(archiveTables))
+	known := make(map[string]bool, len(archiveTables))
+	for _, t := range archiveTables {
+		known[t.name] = true
+		ids[t.name] = make(map[int]int)
+		rows, ok := a.Tables[t.name]
+		if !ok {
+			continue
+		}
+		tr, err := importTable(tx, userID, t, rows, ids, onConflict, report)
+		if err != nil {
+			return nil, err
+		}
+		report.Tables = append(report.Tables, *tr)
+	}
+
+	var unknown []string
+	for name := range a.Tables {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("table %s is not supported and was ignored", name))
+	}
+
+	return report, nil
+}
+
+func importTable(tx *sqlx.Tx, userID int, t archiveTable, rows []json.RawMessage, ids map[string]map[int]int, onConflict string, report *models.ImportReport) (*models.ImportTableReport, error) {
+	columns, err := tableColumns(tx, t.name)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := existingKeys(tx, userID, t)
+	if err != nil {
+		return nil, err
+	}
+
+	tr := &models.ImportTableReport{Table: t.name, Conflicts: []models.ImportConflict{}}
+	dropped := make(map[string]bool)
+
+	for _, raw := range rows {
+		row, err := decodeRow(raw)
+		if err != nil {
+			return nil, &archiveImportError{fmt.Errorf("%s: %v", t.name, err)}
+		}
+		archiveID, _ := intValue(row["id"])
+		delete(row, "id")
+		if columns["user_id"] {
+			row["user_id"] = userID
+		}
+
+		resolved := true
+		for _, ref := range t.refs {
+			old, ok := intValue(row[ref.column])
+			if !ok {
+				continue
+			}
+			if id, ok := ids[ref.table][old]; ok {
+				row[ref.column] = id
+				if snapshot, ok := row[ref.snapshot].(map[string]interface{}); ok {
+					snapshot["id"] = id
+					if _, ok := snapshot["user_id"]; ok {
+						snapshot["user_id"] = userID
+					}
+				}
+			} else if ref.nullable {
+				row[ref.column] = nil
+			} else {
+				resolved = false
+				report.Warnings = append(report.Warnings,
+					fmt.Sprintf("%s %d refers to missing %s %d and was skipped", t.name, archiveID, ref.table, old))
+			}
+		}
+		if !resolved {
+			tr.Skipped++
+			continue
+		}
+
+		// Columns this database does not have are dropped rather than failing
+		for column := range row {
+			if !columns[column] {
+				delete(row, column)
+				if !dropped[column] {
+					dropped[column] = true
+					report.Warnings = append(report.Warnings,
+						fmt.Sprintf("column %s.%s does not exist here and was dropped", t.name, column))
+				}
+			}
+		}
+
+		// Each row that was here before the import matches at most one
+		// archived row; rows sharing a key beyond that are created
+		key, keyValues := rowKey(t, row)
+		if matches := existing[key]; len(matches) > 0 {
+			existingID := matches[0]
+			existing[key] = matches[1:]
+			action := "skipped"
+			if onConflict == models.ImportOverwrite {
+				if err := updateRow(tx, t.name, existingID, row); err != nil {
+					return nil, archiveRowError(t.name, archiveID, err)
+				}
+				action = "updated"
+				tr.Updated++
+			} else {
+				tr.Skipped++
+			}
+			ids[t.name][archiveID] = existingID
+			if len(tr.Conflicts) < maxReportedConflicts {
+				tr.Conflicts = append(tr.Conflicts, models.ImportConflict{
+					ArchiveID:  archiveID,
+					ExistingID: existingID,
+					Key:        keyValues,
+					Action:     action,
+				})
+			}
+			continue
+		}
+
+		id, err := insertRow(tx, t.name, row)
+		if err != nil {
+			return nil, archiveRowError(t.name, archiveID, err)
+		}
+		ids[t.name][archiveID] = id
+		tr.Created++
+	}
+
+	return tr, nil
+}
+
+// tableColumns returns the columns a table has in this database
+func tableColumns(tx *sqlx.Tx, table string) (map[string]bool, error) {
+	var names []string
+	err := tx.Select(&names, `
+		SELECT column_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1
+	`, table)
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]bool, len(names))
+	for _, name := range names {
+		columns[name] = true
+	}
+	return columns, nil
+}
+
+// existingKeys indexes the user's rows of a table by natural key, oldest
+// first. Keys are not unique: reminders, supplements and cycles may share one.
+func existingKeys(tx *sqlx.Tx, userID int, t archiveTable) (map[string][]int, error) {
+	var rows []string
+	query := fmt.Sprintf(`SELECT row_to_json(t)::text FROM %s t WHERE %s ORDER BY id`, t.name, t.scope)
+	if err := tx.Select(&rows, query, userID); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string][]int, len(rows))
+	for _, raw := range rows {
+		row, err := decodeRow(json.RawMessage(raw))
+		if err != nil {
+			return nil, err
+		}
+		id, _ := intValue(row["id"])
+		key, _ := rowKey(t, row)
+		keys[key] = append(keys[key], id)
+	}
+	return keys, nil
+}
+
+// decodeRow keeps numbers as written so keys and values round-trip exactly
+func decodeRow(raw json.RawMessage) (map[string]interface{}, error) {
+	var row map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&row); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+func rowKey(t archiveTable, row map[string]interface{}) (string, map[string]interface{}) {
+	values := make([]interface{}, len(t.key))
+	named := make(map[string]interface{}, len(t.key))
+	for i, column := range t.key {
+		values[i] = row[column]
+		named[column] = row[column]
+	}
+	key, _ := json.Marshal(values)
+	return string(key), named
+}
+
+func intValue(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		i, err := n.Int64()
+		return int(i), err == nil
+	case int:
+		return n, true
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
+
+// columnList quotes the row's columns in a stable order. Column names are
+// checked against information_schema before they get here.
+func columnList(row map[string]interface{}) string {
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	for i, column := range columns {
+		columns[i] = pq.QuoteIdentifier(column)
+	}
+	return strings.Join(columns, ", ")
+}
+
+func insertRow(tx *sqlx.Tx, table string, row map[string]interface{}) (int, error) {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return 0, err
+	}
+	var id int
+	err = tx.Get(&id, fmt.Sprintf(`
+		INSERT INTO %[1]s (%[2]s)
+		SELECT %[2]s FROM json_populate_record(NULL::%[1]s, $1::json)
+		RETURNING id
+	`, table, columnList(row)), string(data))
+	return id, err
+}
+
+func updateRow(tx *sqlx.Tx, table string, id int, row map[string]interface{}) error {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(fmt.Sprintf(`
+		UPDATE %[1]s SET (%[2]s) = (
+			SELECT %[2]s FROM json_populate_record(NULL::%[1]s, $1::json)
+		)
+		WHERE id = $2
+	`, table, columnList(row)), string(data), id)
+	return err
+}
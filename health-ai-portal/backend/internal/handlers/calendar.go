@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/models"
+	"health-ai-portal/internal/scheduler"
+	"health-ai-portal/pkg/ical"
+	"health-ai-portal/pkg/schedule"
+)
+
+const (
+	calendarProdID         = "-//Health AI Portal//Protocol Calendar//RU"
+	calendarRefresh        = time.Hour
+	reminderEventDuration  = 15 * time.Minute
+	labDrawLeadDays        = 3       // labs are drawn this many days before the review
+	labDrawClock           = "08:00" // morning, fasting
+	labDrawDuration        = 30 * time.Minute
+	calendarCycleLimit     = 50
+	calendarUIDDomain      = "health-ai-portal"
+	calendarTokenByteCount = 24
+)
+
+type CalendarHandler struct {
+	db *database.DB
+}
+
+func NewCalendarHandler(db *database.DB) *CalendarHandler {
+	return &CalendarHandler{db: db}
+}
+
+// GetSubscription returns the feed address, creating a token on first use
+func (h *CalendarHandler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	token, err := newCalendarToken()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	err = h.db.Get(&token, `
+		UPDATE users SET calendar_token = COALESCE(calendar_token, $2)
+		WHERE id = $1
+		RETURNING calendar_token
+	`, userID, token)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, calendarSubscription(r, token))
+}
+
+// RotateSubscription replaces the token; subscribers of the old address stop
+// receiving updates
+func (h *CalendarHandler) RotateSubscription(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	token, err := newCalendarToken()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	result, err := h.db.Exec(`UPDATE users SET calendar_token = $2, updated_at = NOW() WHERE id = $1`, userID, token)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		respondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, calendarSubscription(r, token))
+}
+
+// RevokeSubscription disables the feed until a new address is requested
+func (h *CalendarHandler) RevokeSubscription(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	_, err := h.db.Exec(`UPDATE users SET calendar_token = NULL, updated_at = NOW() WHERE id = $1`, userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newCalendarToken() (string, error) {
+	b := make([]byte, calendarTokenByteCount)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// calendarSubscription builds the feed address from the request host, so it
+// works behind the same proxy the app is served from
+func calendarSubscription(r *http.Request, token string) models.CalendarSubscription {
+	path := "/api/calendar.ics?token=" + token
+	return models.CalendarSubscription{
+		Token:     token,
+		URL:       requestBaseURL(r) + path,
+		WebcalURL: "webcal://" + r.Host + path,
+	}
+}
+
+// Feed serves the iCalendar feed for the token in the query string. Calendar
+// apps cannot send headers, so the token is the only credential.
+func (h *CalendarHandler) Feed(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		respondError(w, http.StatusUnauthorized, "token is required")
+		return
+	}
+
+	var user models.User
+	if err := h.db.Get(&user, `SELECT * FROM users WHERE calendar_token = $1`, token); err != nil {
+		respondError(w, http.StatusUnauthorized, "Invalid calendar token")
+		return
+	}
+
+	loc := time.UTC
+	if user.Timezone != nil {
+		if l, err := time.LoadLocation(*user.Timezone); err == nil {
+			loc = l
+		}
+	}
+
+	var reminders []models.Reminder
+	err := h.db.Select(&reminders, `
+		SELECT * FROM reminders WHERE user_id = $1 AND is_active = true ORDER BY id
+	`, user.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var cycles []models.Cycle
+	err = h.db.Select(&cycles, `
+		SELECT * FROM cycles
+		WHERE user_id = $1 AND next_review_date IS NOT NULL
+		ORDER BY cycle_date DESC
+		LIMIT $2
+	`, user.ID, calendarCycleLimit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	now := time.Now()
+	cal := ical.Calendar{
+		ProdID:          calendarProdID,
+		Name:            "Health AI — протокол",
+		Location:        loc,
+		RefreshInterval: calendarRefresh,
+	}
+	for _, rem := range reminders {
+		if event, ok := reminderEvent(rem, loc, now); ok {
+			cal.Events = append(cal.Events, event)
+		}
+	}
+	for _, c := range cycles {
+		cal.Events = append(cal.Events, cycleEvents(c, loc, now)...)
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="health-ai.ics"`)
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	cal.WriteTo(w)
+}
+
+// reminderEvent turns a reminder into a recurring event. Reminders without a
+// time become all-day events. False when the rule never fires.
+func reminderEvent(r models.Reminder, loc *time.Location, now time.Time) (ical.Event, bool) {
+	rule := scheduler.Rule(r)
+	start := scheduler.DTStart(r)
+
+	// DTSTART must be an occurrence itself, so anchor at the first one
+	dates := rule.Dates(start, start, start.AddDate(1, 0, 0))
+	if len(dates) == 0 {
+		return ical.Event{}, false
+	}
+	first := dates[0]
+
+	event := ical.Event{
+		UID:     fmt.Sprintf("reminder-%d@%s", r.ID, calendarUIDDomain),
+		Summary: r.Title,
+		RRule:   rule.String(),
+		Alarms:  []ical.Alarm{{Before: 0}},
+		Stamp:   now,
+	}
+	if r.Description != nil {
+		event.Description = *r.Description
+	}
+	if r.ReminderType != nil && *r.ReminderType != "" {
+		event.Categories = []string{*r.ReminderType}
+	}
+
+	var exdates []string
+	for d := range scheduler.ExDates(r) {
+		exdates = append(exdates, d)
+	}
+	sort.Strings(exdates)
+
+	clock, timed := "", false
+	if r.Time != nil {
+		clock, timed = schedule.NormalizeClock(*r.Time)
+	}
+	slot := schedule.Slot{ClockTime: clock}
+
+	if !timed {
+		event.AllDay = true
+		event.Start = first
+		event.Duration = 24 * time.Hour
+	} else {
+		event.Start = slot.At(first, loc)
+		event.Duration = reminderEventDuration
+		// UNTIL of a timed event must be a UTC date-time
+		if rule.Until != nil {
+			until := slot.At(*rule.Until, loc).UTC().Format("20060102T150405Z")
+			event.RRule = strings.Replace(event.RRule, "UNTIL="+rule.Until.Format("20060102"), "UNTIL="+until, 1)
+		}
+	}
+
+	for _, d := range exdates {
+		date, err := time.Parse("2006-01-02", d)
+		if err != nil {
+			continue
+		}
+		if timed {
+			date = slot.At(date, loc)
+		}
+		event.ExDates = append(event.ExDates, date)
+	}
+
+	return event, true
+}
+
+// cycleEvents returns the review date of a cycle and, when it requires labs,
+// a morning lab draw a few days before so results are back for the review
+func cycleEvents(c models.Cycle, loc *time.Location, now time.Time) []ical.Event {
+	review := *c.NextReviewDate
+
+	details := "Цикл от " + c.CycleDate.Format("02.01.2006")
+	if c.CycleType != nil {
+		details += " (" + *c.CycleType + ")"
+	}
+	if c.Verdict != nil {
+		details += ", вердикт: " + *c.Verdict
+	}
+
+	var labs []string
+	if c.RequiredLabs != nil {
+		json.Unmarshal(*c.RequiredLabs, &labs)
+	}
+
+	events := []ical.Event{{
+		UID:         fmt.Sprintf("cycle-%d-review@%s", c.ID, calendarUIDDomain),
+		Summary:     "Пересмотр протокола",
+		Description: details,
+		Categories:  []string{"review"},
+		Start:       review,
+		AllDay:      true,
+		Duration:    24 * time.Hour,
+		// 09:00 the day before
+		Alarms: []ical.Alarm{{Before: 15 * time.Hour, Description: "Завтра пересмотр протокола"}},
+		Stamp:  now,
+	}}
+
+	if len(labs) > 0 {
+		draw := review.AddDate(0, 0, -labDrawLeadDays)
+		if draw.Before(c.CycleDate) {
+			draw = c.CycleDate
+		}
+		summary := fmt.Sprintf("Сдать анализы (%d)", len(labs))
+		events = append(events, ical.Event{
+			UID:         fmt.Sprintf("cycle-%d-labs@%s", c.ID, calendarUIDDomain),
+			Summary:     summary,
+			Description: "Натощак.\n\n• " + strings.Join(labs, "\n• ") + "\n\n" + details + ", пересмотр " + review.Format("02.01.2006"),
+			Categories:  []string{"lab"},
+			Start:       schedule.Slot{ClockTime: labDrawClock}.At(draw, loc),
+			Duration:    labDrawDuration,
+			Alarms: []ical.Alarm{
+				{Before: 12 * time.Hour, Description: "Завтра утром анализы натощак: " + strings.Join(labs, ", ")},
+				{Before: time.Hour, Description: summary},
+			},
+			Stamp: now,
+		})
+	}
+
+	return events
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/models"
+	"health-ai-portal/pkg/correlate"
+)
+
+// correlationPromptLimit is how many associations the analysis prompt gets
+const correlationPromptLimit = 10
+
+// correlationCaveats explain the caveat codes in the analysis prompt
+var correlationCaveats = map[string]string{
+	correlate.CaveatConfounded:       "одновременно менялись другие добавки",
+	correlate.CaveatBelowRCV:         "в пределах естественной вариабельности",
+	correlate.CaveatNoRCV:            "нет данных о вариабельности маркера",
+	correlate.CaveatApproximateDate:  "дата изменения приблизительная",
+	correlate.CaveatOldBaseline:      "исходный анализ давний",
+	correlate.CaveatSingleWindow:     "один анализ после изменения",
+	correlate.CaveatInconsistentSign: "направление в разных окнах не совпадает",
+}
+
+type CorrelationHandler struct {
+	db *database.DB
+}
+
+func NewCorrelationHandler(db *database.DB) *CorrelationHandler {
+	return &CorrelationHandler{db: db}
+}
+
+// List ranks lab changes after supplement starts, stops and dose changes.
+// Filters: ?supplement_id, ?marker, ?min_confidence (high, medium, low)
+// and ?limit.
+func (h *CorrelationHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	q := r.URL.Query()
+	supplementID := 0
+	if v := q.Get("supplement_id"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid supplement_id")
+			return
+		}
+		supplementID = n
+	}
+	minConfidence := q.Get("min_confidence")
+	switch minConfidence {
+	case "", models.CorrelationHigh, models.CorrelationMedium, models.CorrelationLow:
+	default:
+		respondError(w, http.StatusBadRequest, "min_confidence must be high, medium or low")
+		return
+	}
+	limit := 0
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			respondError(w, http.StatusBadRequest, "limit must be a positive number")
+			return
+		}
+		limit = n
+	}
+
+	report, err := loadCorrelations(h.db, userID, nil)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	marker, _ := canonicalMarker("", q.Get("marker"))
+	filtered := []models.CorrelationAssociation{}
+	for _, a := range report.Associations {
+		if supplementID != 0 && a.SupplementID != supplementID {
+			continue
+		}
+		if marker != "" && !strings.EqualFold(a.MarkerName, marker) {
+			continue
+		}
+		if minConfidence != "" && confidenceRank(a.Confidence) > confidenceRank(minConfidence) {
+			continue
+		}
+		filtered = append(filtered, a)
+		if limit > 0 && len(filtered) == limit {
+			break
+		}
+	}
+	report.Associations = filtered
+
+	respondJSON(w, http.StatusOK, report)
+}
+
+// loadCorrelations aligns the user's supplement changes with their lab
+// results; with asOf, only those up to that date
+func loadCorrelations(db *database.DB, userID int, asOf *time.Time) (*models.CorrelationReport, error) {
+	var until *string
+	if asOf != nil {
+		date := asOf.Format("2006-01-02")
+		until = &date
+	}
+
+	var events []models.SupplementEventWithName
+	err := db.Select(&events, `
+		SELECT e.*, s.name AS supplement_name
+		FROM supplement_events e
+		JOIN supplements s ON s.id = e.supplement_id
+		WHERE e.user_id = $1 AND e.event_type <> $2
+			AND ($3::date IS NULL OR e.event_date::date <= $3::date)
+		ORDER BY e.event_date, e.id
+	`, userID, models.SupplementEventUpdated, until)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.LabResult
+	err = db.Select(&results, `
+		SELECT * FROM lab_results
+		WHERE user_id = $1 AND value IS NOT NULL
+			AND ($2::date IS NULL OR test_date <= $2::date)
+		ORDER BY marker_name, test_date ASC, id ASC
+	`, userID, until)
+	if err != nil {
+		return nil, err
+	}
+
+	in := make([]correlate.Event, 0, len(events))
+	for _, e := range events {
+		in = append(in, correlate.Event{
+			SupplementID: e.SupplementID,
+			Supplement:   e.SupplementName,
+			Type:         e.EventType,
+			Date:         e.EventDate,
+			Approximate:  derefString(e.Notes) == "backfilled",
+		})
+	}
+	markers := map[string]bool{}
+	points := make([]correlate.Result, 0, len(results))
+	for _, r := range results {
+		markers[r.MarkerName] = true
+		points = append(points, correlate.Result{
+			Marker: r.MarkerName,
+			Unit:   derefString(r.Unit),
+			Date:   r.TestDate,
+			Value:  *r.Value,
+		})
+	}
+
+	report := &models.CorrelationReport{
+		Events:       len(in),
+		Markers:      len(markers),
+		Windows:      []models.CorrelationWindow{},
+		Associations: []models.CorrelationAssociation{},
+	}
+	for _, w := range correlate.DefaultWindows {
+		report.Windows = append(report.Windows, models.CorrelationWindow{Name: w.Name, MinDays: w.MinDays, MaxDays: w.MaxDays})
+	}
+	for _, a := range correlate.Associate(in, points, correlate.DefaultOptions) {
+		report.Associations = append(report.Associations, correlationAssociation(a))
+	}
+	return report, nil
+}
+
+func correlationAssociation(a correlate.Association) models.CorrelationAssociation {
+	m := models.CorrelationAssociation{
+		SupplementID:   a.SupplementID,
+		SupplementName: a.Supplement,
+		EventType:      a.EventType,
+		EventDate:      a.EventDate,
+		MarkerName:     a.Marker,
+		Unit:           a.Unit,
+		Before:         models.LabTrendPoint{Date: a.BeforeDate, Value: a.BeforeValue},
+		RCVPercent:     a.RCVPercent,
+		Best:           a.Deltas[a.Best].Window,
+		Score:          a.Score,
+		Confidence:     a.Confidence,
+		Caveats:        a.Caveats,
+	}
+	for _, d := range a.Deltas {
+		m.Deltas = append(m.Deltas, models.CorrelationDelta{
+			Window:      d.Window,
+			After:       models.LabTrendPoint{Date: d.AfterDate, Value: d.AfterValue},
+			LagDays:     d.LagDays,
+			Change:      d.Change,
+			Percent:     d.Percent,
+			ExceedsRCV:  d.ExceedsRCV,
+			Confounders: d.Confounders,
+		})
+	}
+	return m
+}
+
+func confidenceRank(confidence string) int {
+	switch confidence {
+	case models.CorrelationHigh:
+		return 0
+	case models.CorrelationMedium:
+		return 1
+	}
+	return 2
+}
+
+// correlationContext renders the strongest associations known by asOf
+// (nil for all) for the Research & Strategy Lead, with their caveats, so a
+// lab change is weighed against what changed in the stack before it
+func correlationContext(db *database.DB, userID int, asOf *time.Time) (string, error) {
+	report, err := loadCorrelations(db, userID, asOf)
+	if err != nil || len(report.Associations) == 0 {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("Наблюдательные данные одного человека: пары анализов до и после изменения. " +
+		"Это гипотезы для проверки, а не доказанные эффекты. " +
+		"RCV — минимальное изменение, которое вряд ли объясняется случайной вариабельностью.\n\n")
+
+	n := 0
+	for _, a := range report.Associations {
+		if a.Confidence == models.CorrelationLow {
+			continue
+		}
+		var best models.CorrelationDelta
+		for _, d := range a.Deltas {
+			if d.Window == a.Best {
+				best = d
+			}
+		}
+		fmt.Fprintf(&b, "- %s (%s, %s) → %s: %s → %s %s (%+.1f%%, через %d дн.",
+			a.SupplementName, a.EventType, a.EventDate.Format("2006-01-02"), a.MarkerName,
+			formatNumber(a.Before.Value), formatNumber(best.After.Value), a.Unit, best.Percent, best.LagDays)
+		if a.RCVPercent != nil {
+			fmt.Fprintf(&b, ", RCV %.1f%%", *a.RCVPercent)
+		}
+		fmt.Fprintf(&b, "); уверенность: %s", a.Confidence)
+		var caveats []string
+		for _, c := range a.Caveats {
+			if c != correlate.CaveatConfounded {
+				caveats = append(caveats, correlationCaveats[c])
+			}
+		}
+		if len(best.Confounders) > 0 {
+			caveats = append(caveats, "также менялись: "+strings.Join(best.Confounders, ", "))
+		}
+		if len(caveats) > 0 {
+			b.WriteString("; " + strings.Join(caveats, "; "))
+		}
+		b.WriteString("\n")
+
+		n++
+		if n == correlationPromptLimit {
+			break
+		}
+	}
+	if n == 0 {
+		return "", nil
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/models"
+	"health-ai-portal/pkg/diff"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// diffContextLines surround each change in the Meta-Supervisor diff
+const diffContextLines = 3
+
+// Compare returns what changed from cycle a to cycle b: input data, verdict
+// and decisions, labs measured between the two dates, adherence over that
+// period, the supplement stack and the Meta-Supervisor output
+func (h *CycleHandler) Compare(w http.ResponseWriter, r *http.Request) {
+	idA, errA := strconv.Atoi(chi.URLParam(r, "id"))
+	idB, errB := strconv.Atoi(chi.URLParam(r, "other"))
+	if errA != nil || errB != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var a, b models.Cycle
+	if err := h.db.Get(&a, "SELECT * FROM cycles WHERE id = $1", idA); err != nil {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("Cycle %d not found", idA))
+		return
+	}
+	if err := h.db.Get(&b, "SELECT * FROM cycles WHERE id = $1", idB); err != nil {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("Cycle %d not found", idB))
+		return
+	}
+
+	comparison, err := compareCycles(h.db, a, b)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, comparison)
+}
+
+func compareCycles(db *database.DB, a, b models.Cycle) (*models.CycleComparison, error) {
+	c := &models.CycleComparison{
+		From:        cycleSummary(a),
+		To:          cycleSummary(b),
+		DaysBetween: int(b.CycleDate.Sub(a.CycleDate).Hours() / 24),
+		Verdict: models.VerdictChange{
+			From:    a.Verdict,
+			To:      b.Verdict,
+			Changed: !equalString(a.Verdict, b.Verdict),
+		},
+	}
+
+	var err error
+	if c.InputChanges, err = jsonChanges(a.InputData, b.InputData); err != nil {
+		return nil, fmt.Errorf("input_data: %v", err)
+	}
+	if c.DecisionChanges, err = jsonChanges(a.Decisions, b.Decisions); err != nil {
+		return nil, fmt.Errorf("decisions: %v", err)
+	}
+
+	// Labs, adherence and supplements are compared over the period between
+	// the cycles, whichever order they were given in. Adherence tells a
+	// missed course from one that did not work.
+	from, to := a.CycleDate, b.CycleDate
+	if to.Before(from) {
+		from, to = to, from
+	}
+	adherence := newAdherenceCache(db, a.UserID)
+	if c.Labs, err = labDeltas(db, a.UserID, from, to, adherence); err != nil {
+		return nil, err
+	}
+	if c.Adherence, err = adherence.report(from, to); err != nil {
+		return nil, err
+	}
+
+	before, err := stackAsOf(db, a.UserID, a.CycleDate)
+	if err != nil {
+		return nil, err
+	}
+	after, err := stackAsOf(db, b.UserID, b.CycleDate)
+	if err != nil {
+		return nil, err
+	}
+	c.Supplements = stackDiff(before, after)
+
+	lines := diff.Lines(derefString(a.MetaSupervisorOutput), derefString(b.MetaSupervisorOutput))
+	c.MetaSupervisor.LinesAdded, c.MetaSupervisor.LinesRemoved = diff.Stats(lines)
+	c.MetaSupervisor.Diff = diff.Unified(lines,
+		fmt.Sprintf("cycle %d (%s)", a.ID, a.CycleDate.Format("2006-01-02")),
+		fmt.Sprintf("cycle %d (%s)", b.ID, b.CycleDate.Format("2006-01-02")),
+		diffContextLines)
+
+	return c, nil
+}
+
+func cycleSummary(c models.Cycle) models.CycleSummary {
+	return models.CycleSummary{ID: c.ID, CycleDate: c.CycleDate, CycleType: c.CycleType, Verdict: c.Verdict}
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func jsonChanges(a, b *json.RawMessage) ([]models.FieldChange, error) {
+	var x, y []byte
+	if a != nil {
+		x = *a
+	}
+	if b != nil {
+		y = *b
+	}
+	changes, err := diff.JSON(x, y)
+	if err != nil {
+		return nil, err
+	}
+	return fieldChanges(changes), nil
+}
+
+func fieldChanges(changes []diff.Change) []models.FieldChange {
+	result := make([]models.FieldChange, len(changes))
+	for i, c := range changes {
+		result[i] = models.FieldChange{Path: c.Path, Kind: c.Kind, From: c.From, To: c.To, Delta: c.Delta}
+	}
+	return result
+}
+
+// labDeltas compares, per marker measured in (from, to], the latest value in
+// that period with the last one on or before from
+func labDeltas(db *database.DB, userID int, from, to time.Time, adherence *adherenceCache) ([]models.LabMarkerDelta, error) {
+	var results []models.LabResult
+	err := db.Select(&results, `
+		SELECT * FROM lab_results
+		WHERE user_id = $1 AND value IS NOT NULL AND test_date <= $2
+		ORDER BY marker_name, test_date, id
+	`, userID, to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+
+	deltas := []models.LabMarkerDelta{}
+	for i := 0; i < len(results); {
+		j := i
+		for j < len(results) && results[j].MarkerName == results[i].MarkerName {
+			j++
+		}
+		if d, ok := markerDelta(results[i:j], from); ok {
+			if d.BeforeDate != nil {
+				report, err := adherence.report(*d.BeforeDate, d.AfterDate)
+				if err != nil {
+					return nil, err
+				}
+				if report.Overall.Expected > 0 {
+					d.AdherencePct = &report.Overall.Percent
+				}
+			}
+			deltas = append(deltas, d)
+		}
+		i = j
+	}
+
+	sort.SliceStable(deltas, func(i, j int) bool {
+		return math.Abs(derefFloat(deltas[i].DeltaPct)) > math.Abs(derefFloat(deltas[j].DeltaPct))
+	})
+	return deltas, nil
+}
+
+// adherenceCache computes adherence once per period; markers drawn together
+// share their before and after dates
+type adherenceCache struct {
+	db      *database.DB
+	userID  int
+	loc     *time.Location
+	reports map[[2]time.Time]*models.AdherenceReport
+}
+
+func newAdherenceCache(db *database.DB, userID int) *adherenceCache {
+	return &adherenceCache{
+		db:      db,
+		userID:  userID,
+		loc:     loadUserLocation(db, userID),
+		reports: map[[2]time.Time]*models.AdherenceReport{},
+	}
+}
+
+func (c *adherenceCache) report(from, to time.Time) (*models.AdherenceReport, error) {
+	key := [2]time.Time{civilDate(from), civilDate(to)}
+	if report, ok := c.reports[key]; ok {
+		return report, nil
+	}
+	report, err := computeAdherence(c.db, c.userID, from, to, c.loc)
+	if err != nil {
+		return nil, err
+	}
+	report.Days = int(key[1].Sub(key[0]).Hours()/24) + 1
+	c.reports[key] = report
+	return report, nil
+}
+
+// markerDelta works on one marker's results, oldest first
+func markerDelta(results []models.LabResult, from time.Time) (models.LabMarkerDelta, bool) {
+	var before, after *models.LabResult
+	measurements := 0
+	for i := range results {
+		if results[i].TestDate.After(from) {
+			after = &results[i]
+			measurements++
+		} else {
+			before = &results[i]
+		}
+	}
+	if after == nil {
+		return models.LabMarkerDelta{}, false
+	}
+
+	d := models.LabMarkerDelta{
+		MarkerName:   after.MarkerName,
+		Unit:         after.Unit,
+		After:        *after.Value,
+		AfterDate:    after.TestDate,
+		Measurements: measurements,
+		Status:       "normal",
+	}
+	if after.ReferenceMin != nil && *after.Value < *after.ReferenceMin {
+		d.Status = "low"
+	} else if after.ReferenceMax != nil && *after.Value > *after.ReferenceMax {
+		d.Status = "high"
+	}
+	if before != nil {
+		delta := math.Round((*after.Value-*before.Value)*1000) / 1000
+		d.Before, d.BeforeDate, d.Delta = before.Value, &before.TestDate, &delta
+		if *before.Value != 0 {
+			pct := math.Round(delta / *before.Value * 1000) / 10
+			d.DeltaPct = &pct
+		}
+	}
+	return d, true
+}
+
+func derefFloat(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// stackDiff compares two reconstructed stacks by supplement
+func stackDiff(before, after []models.Supplement) models.SupplementStackDiff {
+	result := models.SupplementStackDiff{
+		Added:   []models.Supplement{},
+		Removed: []models.Supplement{},
+		Changed: []models.SupplementChange{},
+	}
+
+	old := make(map[int]models.Supplement, len(before))
+	for _, s := range before {
+		old[s.ID] = s
+	}
+	current := make(map[int]bool, len(after))
+	for _, s := range after {
+		current[s.ID] = true
+		prev, ok := old[s.ID]
+		if !ok {
+			result.Added = append(result.Added, s)
+			continue
+		}
+		changes, err := diff.JSON(supplementFields(prev), supplementFields(s))
+		if err == nil && len(changes) > 0 {
+			result.Changed = append(result.Changed, models.SupplementChange{
+				SupplementID: s.ID,
+				Name:         s.Name,
+				Changes:      fieldChanges(changes),
+			})
+		}
+	}
+	for _, s := range before {
+		if !current[s.ID] {
+			result.Removed = append(result.Removed, s)
+		}
+	}
+	return result
+}
+
+// supplementFields is a supplement as JSON without identity and bookkeeping
+// fields, so only regimen changes show up in the diff
+func supplementFields(s models.Supplement) []byte {
+	data, _ := json.Marshal(s)
+	var fields map[string]interface{}
+	json.Unmarshal(data, &fields)
+	for _, key := range []string{"id", "user_id", "status", "created_at", "updated_at", "removed_at"} {
+		delete(fields, key)
+	}
+	data, _ = json.Marshal(fields)
+	return data
+}
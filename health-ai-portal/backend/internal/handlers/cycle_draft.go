@@ -0,0 +1,393 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"health-ai-portal/internal/cycleinput"
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/models"
+)
+
+const (
+	// draftPeriodDays is how far back a draft looks when there is no
+	// previous cycle
+	draftPeriodDays = 30
+	// draftBloodPressureDays is the window blood pressure is averaged over
+	draftBloodPressureDays = 7
+)
+
+// Draft builds input_data for a new cycle from what the server already
+// knows: metrics, steps, blood pressure and workouts since the previous
+// cycle, the previous cycle's nutrition, active goals and supplement
+// changes. Nothing is saved.
+func (h *CycleHandler) Draft(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	var input models.CycleDraftRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && err != io.EOF {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	date := civilDate(time.Now().In(loadUserLocation(h.db, userID)))
+	if input.CycleDate != "" {
+		parsed, err := time.Parse("2006-01-02", input.CycleDate)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "cycle_date must be YYYY-MM-DD")
+			return
+		}
+		date = parsed
+	}
+
+	draft, err := buildCycleDraft(h.db, userID, date)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	draft.CycleType = input.CycleType
+
+	respondJSON(w, http.StatusOK, draft)
+}
+
+func buildCycleDraft(db *database.DB, userID int, date time.Time) (*models.CycleDraft, error) {
+	draft := &models.CycleDraft{
+		CycleDate:  date,
+		PeriodFrom: date.AddDate(0, 0, -draftPeriodDays),
+		Sources:    map[string]string{},
+	}
+	data := &draft.InputData
+	data.SchemaVersion = cycleinput.CurrentVersion
+
+	var previous models.Cycle
+	err := db.Get(&previous, `
+		SELECT * FROM cycles
+		WHERE user_id = $1 AND cycle_date < $2
+		ORDER BY cycle_date DESC, id DESC
+		LIMIT 1
+	`, userID, date.Format("2006-01-02"))
+	switch {
+	case err == sql.ErrNoRows:
+		data.Nutrition.Calories, data.Nutrition.Protein = 2500, 200
+		data.Nutrition.Carbs, data.Nutrition.Fats = 200, 80
+		draft.Sources["nutrition"] = "значения по умолчанию"
+	case err != nil:
+		return nil, err
+	default:
+		draft.PreviousCycleID = &previous.ID
+		draft.PeriodFrom = civilDate(previous.CycleDate)
+		draftFromPrevious(draft, previous)
+	}
+
+	if err := draftMetrics(db, userID, draft); err != nil {
+		return nil, err
+	}
+	if err := draftTraining(db, userID, draft); err != nil {
+		return nil, err
+	}
+	if err := draftGoals(db, userID, draft); err != nil {
+		return nil, err
+	}
+	if draft.PreviousCycleID != nil {
+		if err := draftChanges(db, userID, previous.CycleDate, date, draft); err != nil {
+			return nil, err
+		}
+	}
+
+	return draft, nil
+}
+
+// draftFromPrevious carries over training and nutrition, which rarely change
+// between cycles; logged workouts replace the training later. Old input is
+// upgraded first so string numbers still load.
+func draftFromPrevious(draft *models.CycleDraft, previous models.Cycle) {
+	if previous.InputData == nil {
+		return
+	}
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal(*previous.InputData, &doc); err != nil {
+		return
+	}
+	if _, err := cycleinput.Migrate(doc); err != nil {
+		return
+	}
+	raw, _ := json.Marshal(doc)
+	var prev models.CycleInputData
+	if err := json.Unmarshal(raw, &prev); err != nil {
+		return
+	}
+
+	source := fmt.Sprintf("цикл от %s", previous.CycleDate.Format("02.01.2006"))
+	draft.InputData.Training = prev.Training
+	draft.InputData.Nutrition = prev.Nutrition
+	draft.Sources["training"] = source
+	draft.Sources["nutrition"] = source
+}
+
+// draftMetrics fills metrics with the latest values measured in the period,
+// blood pressure with the average of the last readings and steps with the
+// daily average over the period
+func draftMetrics(db *database.DB, userID int, draft *models.CycleDraft) error {
+	from, to := draft.PeriodFrom.Format("2006-01-02"), draft.CycleDate.Format("2006-01-02")
+	metrics := &draft.InputData.Metrics
+
+	var weight, glucose *float64
+	var pulse, hrv *int
+	for _, m := range []struct {
+		path, column string
+		dest         interface{}
+	}{
+		{"metrics.weight", "weight_kg", &weight},
+		{"metrics.pulse", "resting_hr", &pulse},
+		{"metrics.hrv", "hrv", &hrv},
+		{"metrics.glucose", "glucose", &glucose},
+	} {
+		var date time.Time
+		// Column names come from the list above, never from user input
+		row := db.QueryRowx(fmt.Sprintf(`
+			SELECT metric_date, %[1]s FROM daily_metrics
+			WHERE user_id = $1 AND %[1]s IS NOT NULL AND metric_date > $2 AND metric_date <= $3
+			ORDER BY metric_date DESC
+			LIMIT 1
+		`, m.column), userID, from, to)
+		if err := row.Scan(&date, m.dest); err == sql.ErrNoRows {
+			continue
+		} else if err != nil {
+			return err
+		}
+		draft.Sources[m.path] = fmt.Sprintf("замер от %s", date.Format("02.01.2006"))
+	}
+	metrics.Weight, metrics.Pulse, metrics.HRV, metrics.Glucose = weight, pulse, hrv, glucose
+
+	var bp struct {
+		Sys      *float64 `db:"sys"`
+		Dia      *float64 `db:"dia"`
+		Readings int      `db:"readings"`
+	}
+	err := db.Get(&bp, `
+		SELECT AVG(blood_pressure_sys)::float8 AS sys, AVG(blood_pressure_dia)::float8 AS dia, COUNT(*) AS readings
+		FROM daily_metrics
+		WHERE user_id = $1 AND blood_pressure_sys IS NOT NULL AND blood_pressure_dia IS NOT NULL
+			AND metric_date > $2::date - $3::int AND metric_date <= $2
+	`, userID, to, draftBloodPressureDays)
+	if err != nil {
+		return err
+	}
+	if bp.Readings > 0 {
+		metrics.BloodPressure = fmt.Sprintf("%.0f/%.0f", *bp.Sys, *bp.Dia)
+		draft.Sources["metrics.blood_pressure"] = fmt.Sprintf("среднее за %d дн. (%d изм.)", draftBloodPressureDays, bp.Readings)
+	}
+
+	var steps struct {
+		Avg  *float64 `db:"avg"`
+		Days int      `db:"days"`
+	}
+	err = db.Get(&steps, `
+		SELECT AVG(steps)::float8 AS avg, COUNT(*) AS days
+		FROM daily_metrics
+		WHERE user_id = $1 AND steps IS NOT NULL AND metric_date > $2 AND metric_date <= $3
+	`, userID, from, to)
+	if err != nil {
+		return err
+	}
+	if steps.Days > 0 {
+		draft.InputData.Training.Steps = int(math.Round(*steps.Avg))
+		draft.Sources["training.steps"] = fmt.Sprintf("среднее за %d дн.", steps.Days)
+	}
+	return nil
+}
+
+// draftExercises is how many exercises the training summary lists
+const draftExercises = 8
+
+// draftTraining summarises the workouts logged in the period: how often, the
+// split as the workout types in the order they came, and the exercises done
+// most often with their latest sets, reps and weight
+func draftTraining(db *database.DB, userID int, draft *models.CycleDraft) error {
+	var rows []struct {
+		WorkoutID   int      `db:"workout_id"`
+		WorkoutType string   `db:"workout_type"`
+		Name        *string  `db:"name"`
+		Sets        *int     `db:"sets"`
+		Reps        *string  `db:"reps"`
+		WeightKg    *float64 `db:"weight_kg"`
+	}
+	err := db.Select(&rows, `
+		SELECT w.id AS workout_id, COALESCE(w.workout_type, '') AS workout_type,
+			e.name, e.sets, e.reps, e.weight_kg::float8 AS weight_kg
+		FROM workouts w
+		LEFT JOIN exercises e ON e.workout_id = w.id
+		WHERE w.user_id = $1 AND w.workout_date > $2 AND w.workout_date <= $3
+		ORDER BY w.workout_date, w.id, e.order_index, e.id
+	`, userID, draft.PeriodFrom.Format("2006-01-02"), draft.CycleDate.Format("2006-01-02"))
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+
+	type exercise struct {
+		name     string
+		sessions int
+		last     string
+		lastID   int
+	}
+	workouts := map[int]bool{}
+	var split []string
+	seenTypes := map[string]bool{}
+	var exercises []*exercise
+	byName := map[string]*exercise{}
+	for _, row := range rows {
+		workouts[row.WorkoutID] = true
+		if t := strings.TrimSpace(row.WorkoutType); t != "" && !seenTypes[strings.ToLower(t)] {
+			seenTypes[strings.ToLower(t)] = true
+			split = append(split, t)
+		}
+		if row.Name == nil || strings.TrimSpace(*row.Name) == "" {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(*row.Name))
+		e, ok := byName[key]
+		if !ok {
+			e = &exercise{name: strings.TrimSpace(*row.Name)}
+			byName[key] = e
+			exercises = append(exercises, e)
+		}
+		if e.lastID != row.WorkoutID {
+			e.sessions++
+			e.lastID = row.WorkoutID
+		}
+		e.last = ""
+		if row.Sets != nil && row.Reps != nil {
+			e.last = fmt.Sprintf("%d×%s", *row.Sets, *row.Reps)
+		}
+		if row.WeightKg != nil {
+			e.last = strings.TrimSpace(e.last + fmt.Sprintf(" @ %s кг", formatNumber(*row.WeightKg)))
+		}
+	}
+
+	training := &draft.InputData.Training
+	source := fmt.Sprintf("тренировки с %s (%d)", draft.PeriodFrom.Format("02.01.2006"), len(workouts))
+	days := int(draft.CycleDate.Sub(draft.PeriodFrom).Hours() / 24)
+	if days < 1 {
+		days = 1
+	}
+	perWeek := math.Round(float64(len(workouts))*7/float64(days)*10) / 10
+	training.Frequency = fmt.Sprintf("%s×/нед. (%d за %d дн.)", formatNumber(perWeek), len(workouts), days)
+	draft.Sources["training.frequency"] = source
+	if len(split) > 0 {
+		training.Split = strings.Join(split, "/")
+		draft.Sources["training.split"] = source
+	}
+
+	if len(exercises) > 0 {
+		sort.SliceStable(exercises, func(i, j int) bool { return exercises[i].sessions > exercises[j].sessions })
+		if len(exercises) > draftExercises {
+			exercises = exercises[:draftExercises]
+		}
+		lines := make([]string, len(exercises))
+		for i, e := range exercises {
+			line := e.name
+			if e.last != "" {
+				line += " " + e.last
+			}
+			lines[i] = fmt.Sprintf("%s (%d трен.)", line, e.sessions)
+		}
+		training.Exercises = strings.Join(lines, "\n")
+		draft.Sources["training.exercises"] = source
+	}
+	return nil
+}
+
+// draftGoals lists active goals, most important first
+func draftGoals(db *database.DB, userID int, draft *models.CycleDraft) error {
+	var goals []models.Goal
+	err := db.Select(&goals, `
+		SELECT * FROM goals WHERE user_id = $1 AND status = 'active'
+		ORDER BY
+			CASE priority
+				WHEN 'critical' THEN 1
+				WHEN 'high' THEN 2
+				WHEN 'medium' THEN 3
+				WHEN 'background' THEN 4
+				ELSE 5
+			END, name
+	`, userID)
+	if err != nil {
+		return err
+	}
+	if len(goals) == 0 {
+		return nil
+	}
+
+	lines := make([]string, len(goals))
+	for i, g := range goals {
+		line := "- " + g.Name
+		switch {
+		case g.CurrentValue != nil && g.TargetValue != nil:
+			line += fmt.Sprintf(": %s → %s", *g.CurrentValue, *g.TargetValue)
+		case g.TargetValue != nil:
+			line += fmt.Sprintf(": цель %s", *g.TargetValue)
+		}
+		lines[i] = line
+	}
+	draft.InputData.Goals = strings.Join(lines, "\n")
+	draft.Sources["goals"] = fmt.Sprintf("активные цели (%d)", len(goals))
+	return nil
+}
+
+// draftChanges describes how the supplement stack changed since the previous
+// cycle
+func draftChanges(db *database.DB, userID int, since, date time.Time, draft *models.CycleDraft) error {
+	before, err := stackAsOf(db, userID, since)
+	if err != nil {
+		return err
+	}
+	after, err := stackAsOf(db, userID, date)
+	if err != nil {
+		return err
+	}
+	stack := stackDiff(before, after)
+
+	var lines []string
+	for _, s := range stack.Added {
+		line := "+ " + s.Name
+		if s.Dose != nil {
+			line += " " + *s.Dose
+		}
+		lines = append(lines, line)
+	}
+	for _, s := range stack.Removed {
+		lines = append(lines, "− "+s.Name)
+	}
+	for _, c := range stack.Changed {
+		parts := make([]string, len(c.Changes))
+		for i, f := range c.Changes {
+			parts[i] = fmt.Sprintf("%s %s → %s", f.Path, draftValue(f.From), draftValue(f.To))
+		}
+		lines = append(lines, fmt.Sprintf("~ %s: %s", c.Name, strings.Join(parts, "; ")))
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	draft.InputData.Changes = strings.Join(lines, "\n")
+	draft.Sources["changes"] = fmt.Sprintf("изменения стека с %s", since.Format("02.01.2006"))
+	return nil
+}
+
+func draftValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return "—"
+	case float64:
+		return formatNumber(value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
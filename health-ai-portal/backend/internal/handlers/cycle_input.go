@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"health-ai-portal/internal/cycleinput"
+	"health-ai-portal/internal/models"
+)
+
+// InputValidationResult is the outcome of validating cycle input_data
+type InputValidationResult struct {
+	Valid         bool                    `json:"valid"`
+	SchemaVersion int                     `json:"schema_version"`
+	MigratedFrom  int                     `json:"migrated_from"`
+	Errors        []cycleinput.FieldError `json:"errors"`
+	InputData     json.RawMessage         `json:"input_data,omitempty"`
+}
+
+// InputMigrationItem is one stored cycle visited by MigrateInput
+type InputMigrationItem struct {
+	CycleID      int                     `json:"cycle_id"`
+	MigratedFrom int                     `json:"migrated_from"`
+	Errors       []cycleinput.FieldError `json:"errors,omitempty"`
+}
+
+// InputMigrationResult summarizes an input_data migration run
+type InputMigrationResult struct {
+	DryRun   bool                 `json:"dry_run"`
+	Migrated []InputMigrationItem `json:"migrated"`
+	Invalid  []InputMigrationItem `json:"invalid"`
+}
+
+// respondInputErrors answers 422 with field-level errors
+func respondInputErrors(w http.ResponseWriter, errs []cycleinput.FieldError) {
+	respondJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+		"error":  "Invalid input_data",
+		"fields": errs,
+	})
+}
+
+// ValidateInput checks cycle input without saving it and returns the
+// upgraded document, so the wizard can show errors per field
+func (h *CycleHandler) ValidateInput(w http.ResponseWriter, r *http.Request) {
+	var input models.CycleCreate
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	data, from, errs := cycleinput.Normalize(input.InputData, derefString(input.CycleType))
+	result := InputValidationResult{
+		Valid:         len(errs) == 0,
+		SchemaVersion: cycleinput.CurrentVersion,
+		MigratedFrom:  from,
+		Errors:        []cycleinput.FieldError{},
+		InputData:     data,
+	}
+	if errs != nil {
+		result.Errors = errs
+	}
+	respondJSON(w, http.StatusOK, result)
+}
+
+// MigrateInput upgrades stored input_data to the current template version.
+// Cycles that fail validation after the upgrade are left untouched and
+// reported. Pass ?dry_run=true to preview without writing.
+func (h *CycleHandler) MigrateInput(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	var cycles []models.Cycle
+	err := h.db.Select(&cycles, `
+		SELECT * FROM cycles
+		WHERE user_id = $1 AND input_data IS NOT NULL
+			AND COALESCE((input_data->>'schema_version')::int, 1) < $2
+		ORDER BY id
+	`, userID, cycleinput.CurrentVersion)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	result := InputMigrationResult{
+		DryRun:   dryRun,
+		Migrated: []InputMigrationItem{},
+		Invalid:  []InputMigrationItem{},
+	}
+
+	for _, c := range cycles {
+		// Stored cycles are checked for types and ranges only; required
+		// sections apply to new input
+		data, from, errs := cycleinput.Normalize(*c.InputData, "")
+		item := InputMigrationItem{CycleID: c.ID, MigratedFrom: from, Errors: errs}
+		if len(errs) > 0 {
+			result.Invalid = append(result.Invalid, item)
+			continue
+		}
+
+		if !dryRun {
+			_, err := h.db.Exec(`UPDATE cycles SET input_data = $2, updated_at = NOW() WHERE id = $1`, c.ID, data)
+			if err != nil {
+				item.Errors = []cycleinput.FieldError{{Field: "input_data", Message: err.Error()}}
+				result.Invalid = append(result.Invalid, item)
+				continue
+			}
+		}
+		result.Migrated = append(result.Migrated, item)
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
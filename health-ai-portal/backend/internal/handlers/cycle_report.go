@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"health-ai-portal/internal/models"
+	"health-ai-portal/internal/report"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Report assembles the cycle's input and the four role outputs into one
+// document for archiving next to 08_cycles_history.md. Query: format (md or
+// html, default md) and lang (ru or en, default ru).
+func (h *CycleHandler) Report(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = report.FormatMarkdown
+	}
+	if format != report.FormatMarkdown && format != report.FormatHTML {
+		respondError(w, http.StatusBadRequest, "format must be md or html")
+		return
+	}
+	lang := r.URL.Query().Get("lang")
+	if lang == "" {
+		lang = report.LangRU
+	}
+	if lang != report.LangRU && lang != report.LangEN {
+		respondError(w, http.StatusBadRequest, "lang must be ru or en")
+		return
+	}
+
+	var cycle models.Cycle
+	if err := h.db.Get(&cycle, "SELECT * FROM cycles WHERE id = $1", id); err != nil {
+		respondError(w, http.StatusNotFound, "Cycle not found")
+		return
+	}
+
+	filename := fmt.Sprintf("cycle-%d-%s.%s", cycle.ID, cycle.CycleDate.Format("2006-01-02"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, filename))
+	if format == report.FormatHTML {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(report.CycleHTML(cycle, lang)))
+		return
+	}
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Write([]byte(report.CycleMarkdown(cycle, lang)))
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"time"
 
+	"health-ai-portal/internal/cycleinput"
 	"health-ai-portal/internal/database"
 	"health-ai-portal/internal/models"
 
@@ -62,10 +63,11 @@ func (h *CycleHandler) Create(w http.ResponseWriter, r *http.Request) {
 		input.CycleDate = time.Now()
 	}
 
-	// Ensure input_data is valid JSON (use empty object if nil)
-	inputData := input.InputData
-	if inputData == nil || len(inputData) == 0 {
-		inputData = json.RawMessage(`{}`)
+	// Upgrade input_data to the current template and validate it
+	inputData, _, errs := cycleinput.Normalize(input.InputData, derefString(input.CycleType))
+	if len(errs) > 0 {
+		respondInputErrors(w, errs)
+		return
 	}
 
 	var cycle models.Cycle
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/models"
+)
+
+// dashboardProjectionDays is how far ahead a projected crossing of a
+// reference bound is worth showing on the dashboard
+const dashboardProjectionDays = 180
+
+type DashboardHandler struct {
+	db *database.DB
+}
+
+func NewDashboardHandler(db *database.DB) *DashboardHandler {
+	return &DashboardHandler{db: db}
+}
+
+// Summary lists the lab markers that changed significantly, are projected
+// to cross a reference bound soon, or have outlying results
+func (h *DashboardHandler) Summary(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	trends, err := loadLabTrends(h.db, userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	summary := models.DashboardSummary{LabTrends: models.LabTrendSummary{
+		Markers:            len(trends),
+		SignificantChanges: []models.LabTrendAlert{},
+		Projections:        []models.LabTrendAlert{},
+		Outliers:           []models.LabTrendAlert{},
+	}}
+	s := &summary.LabTrends
+	for _, t := range trends {
+		a := t.Analysis
+		if a == nil {
+			continue
+		}
+		alert := models.LabTrendAlert{MarkerName: t.MarkerName, Unit: t.Unit, Analysis: a}
+		if a.LatestChange != nil && a.LatestChange.Significant {
+			s.SignificantChanges = append(s.SignificantChanges, alert)
+		}
+		if a.Projection != nil && a.Projection.DaysAway <= dashboardProjectionDays {
+			s.Projections = append(s.Projections, alert)
+		}
+		if len(a.Outliers) > 0 {
+			s.Outliers = append(s.Outliers, alert)
+		}
+	}
+	sort.SliceStable(s.Projections, func(i, j int) bool {
+		return s.Projections[i].Analysis.Projection.Date.Before(s.Projections[j].Analysis.Projection.Date)
+	})
+
+	respondJSON(w, http.StatusOK, summary)
+}
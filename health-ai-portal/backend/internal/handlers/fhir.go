@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/models"
+	"health-ai-portal/pkg/fhir"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// fhirIdentifierSystem marks identifiers that are lab_results IDs of this app
+const fhirIdentifierSystem = "urn:health-ai-portal:lab-result"
+
+type FHIRHandler struct {
+	db *database.DB
+}
+
+func NewFHIRHandler(db *database.DB) *FHIRHandler {
+	return &FHIRHandler{db: db}
+}
+
+func respondFHIR(w http.ResponseWriter, status int, resource interface{}) {
+	w.Header().Set("Content-Type", fhir.ContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resource)
+}
+
+func respondFHIRError(w http.ResponseWriter, status int, code, message string) {
+	respondFHIR(w, status, fhir.NewOperationOutcome(code, message))
+}
+
+// SearchObservations returns lab results as a searchset Bundle of
+// Observations with the Patient included. Supported parameters:
+// date (repeatable, with eq/ne/lt/le/gt/ge prefixes) and code (LOINC code or
+// marker name; commas mean "or", repeated parameters mean "and").
+func (h *FHIRHandler) SearchObservations(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+	query := r.URL.Query()
+
+	var dates []fhir.DateParam
+	for _, value := range query["date"] {
+		p, err := fhir.ParseDateParam(value)
+		if err != nil {
+			respondFHIRError(w, http.StatusBadRequest, "invalid", err.Error())
+			return
+		}
+		dates = append(dates, p)
+	}
+	var codes [][]fhir.Token
+	for _, value := range query["code"] {
+		if tokens := fhir.ParseTokens(value); len(tokens) > 0 {
+			codes = append(codes, tokens)
+		}
+	}
+
+	var user models.User
+	if err := h.db.Get(&user, `SELECT * FROM users WHERE id = $1`, userID); err != nil {
+		respondFHIRError(w, http.StatusNotFound, "not-found", "Patient not found")
+		return
+	}
+
+	var results []models.LabResult
+	err := h.db.Select(&results, `
+		SELECT * FROM lab_results
+		WHERE user_id = $1
+		ORDER BY test_date, marker_name, id
+	`, userID)
+	if err != nil {
+		respondFHIRError(w, http.StatusInternalServerError, "exception", err.Error())
+		return
+	}
+
+	base := requestBaseURL(r) + "/api/fhir/"
+	bundle := fhir.Bundle{
+		ResourceType: "Bundle",
+		Type:         "searchset",
+		Timestamp:    time.Now().UTC().Format(time.RFC3339),
+		Link:         []fhir.BundleLink{{Relation: "self", URL: requestBaseURL(r) + r.URL.RequestURI()}},
+		Entry:        []fhir.BundleEntry{},
+	}
+
+	total := 0
+	for _, lab := range results {
+		obs := labObservation(lab)
+		if !matchesDates(lab.TestDate, dates) || !matchesCodes(obs, codes) {
+			continue
+		}
+		if err := bundle.Add(base+"Observation/"+obs.ID, obs, "match"); err != nil {
+			respondFHIRError(w, http.StatusInternalServerError, "exception", err.Error())
+			return
+		}
+		total++
+	}
+	bundle.Total = &total
+
+	if err := bundle.Add(base+"Patient/"+strconv.Itoa(user.ID), userPatient(user), "include"); err != nil {
+		respondFHIRError(w, http.StatusInternalServerError, "exception", err.Error())
+		return
+	}
+
+	respondFHIR(w, http.StatusOK, bundle)
+}
+
+// GetObservation reads one lab result as an Observation
+func (h *FHIRHandler) GetObservation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondFHIRError(w, http.StatusBadRequest, "invalid", "Invalid ID")
+		return
+	}
+
+	var lab models.LabResult
+	if err := h.db.Get(&lab, `SELECT * FROM lab_results WHERE id = $1`, id); err != nil {
+		respondFHIRError(w, http.StatusNotFound, "not-found", "Observation not found")
+		return
+	}
+	respondFHIR(w, http.StatusOK, labObservation(lab))
+}
+
+// GetPatient reads a user as a Patient
+func (h *FHIRHandler) GetPatient(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondFHIRError(w, http.StatusBadRequest, "invalid", "Invalid ID")
+		return
+	}
+
+	var user models.User
+	if err := h.db.Get(&user, `SELECT * FROM users WHERE id = $1`, id); err != nil {
+		respondFHIRError(w, http.StatusNotFound, "not-found", "Patient not found")
+		return
+	}
+	respondFHIR(w, http.StatusOK, userPatient(user))
+}
+
+func userPatient(u models.User) fhir.Patient {
+	p := fhir.Patient{
+		ResourceType: "Patient",
+		ID:           strconv.Itoa(u.ID),
+		Name:         []fhir.HumanName{{Text: u.Name}},
+	}
+	if u.BirthDate != nil {
+		p.BirthDate = u.BirthDate.Format("2006-01-02")
+	}
+	return p
+}
+
+// labObservation converts a lab result. The unit is translated to UCUM and
+// the marker to LOINC where known; otherwise they are kept as text.
+func labObservation(lab models.LabResult) fhir.Observation {
+	unit := derefString(lab.Unit)
+	ucum, _ := fhir.UCUM(unit)
+
+	obs := fhir.Observation{
+		ResourceType:      "Observation",
+		ID:                strconv.Itoa(lab.ID),
+		Identifier:        []fhir.Identifier{{System: fhirIdentifierSystem, Value: strconv.Itoa(lab.ID)}},
+		Status:            "final",
+		Category:          []fhir.CodeableConcept{fhir.LaboratoryCategory},
+		Code:              fhir.CodeableConcept{Text: lab.MarkerName},
+		Subject:           &fhir.Reference{Reference: "Patient/" + strconv.Itoa(lab.UserID)},
+		EffectiveDateTime: lab.TestDate.Format("2006-01-02"),
+		Issued:            lab.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if coding, ok := fhir.LOINC(lab.MarkerName, ucum); ok {
+		obs.Code.Coding = []fhir.Coding{coding}
+	}
+	if lab.LabName != nil && *lab.LabName != "" {
+		obs.Performer = []fhir.Reference{{Display: *lab.LabName}}
+	}
+	if lab.Notes != nil && *lab.Notes != "" {
+		obs.Note = []fhir.Annotation{{Text: *lab.Notes}}
+	}
+
+	quantity := func(v *float64) *fhir.Quantity {
+		q := &fhir.Quantity{Value: v, Unit: unit}
+		if ucum != "" {
+			q.System, q.Code = fhir.SystemUCUM, ucum
+		}
+		return q
+	}
+
+	if lab.Value != nil {
+		obs.ValueQuantity = quantity(lab.Value)
+		if interpretation := fhir.Interpretation(*lab.Value, lab.ReferenceMin, lab.ReferenceMax); interpretation != nil {
+			obs.Interpretation = []fhir.CodeableConcept{*interpretation}
+		}
+	} else {
+		obs.DataAbsentReason = &fhir.CodeableConcept{
+			Coding: []fhir.Coding{{System: "http://terminology.hl7.org/CodeSystem/data-absent-reason", Code: "unknown", Display: "Unknown"}},
+		}
+	}
+
+	if lab.ReferenceMin != nil || lab.ReferenceMax != nil {
+		rr := fhir.ReferenceRange{}
+		if lab.ReferenceMin != nil {
+			rr.Low = quantity(lab.ReferenceMin)
+		}
+		if lab.ReferenceMax != nil {
+			rr.High = quantity(lab.ReferenceMax)
+		}
+		obs.ReferenceRange = []fhir.ReferenceRange{rr}
+	}
+
+	return obs
+}
+
+func matchesDates(date time.Time, params []fhir.DateParam) bool {
+	for _, p := range params {
+		if !p.Matches(date) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesCodes needs every parameter to match one of its tokens. A token
+// without a system also matches the marker name.
+func matchesCodes(obs fhir.Observation, params [][]fhir.Token) bool {
+	for _, tokens := range params {
+		matched := false
+		for _, t := range tokens {
+			for _, c := range obs.Code.Coding {
+				if (t.System == "" || t.System == c.System) && t.Code == c.Code {
+					matched = true
+				}
+			}
+			if t.System == "" && strings.EqualFold(t.Code, obs.Code.Text) {
+				matched = true
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/models"
+	"health-ai-portal/pkg/pdf"
+	"health-ai-portal/pkg/progress"
+
+	"github.com/lib/pq"
+)
+
+// goalMetricColumn maps a metric goal's source key to its daily_metrics
+// column: the /log metrics plus both blood pressure readings
+func goalMetricColumn(key string) (string, bool) {
+	switch key {
+	case "bp_sys":
+		return "blood_pressure_sys", true
+	case "bp_dia":
+		return "blood_pressure_dia", true
+	}
+	metric, ok := metricColumns[key]
+	return metric.column, ok
+}
+
+// normalizeGoalSource validates the binding of a goal to its data and returns
+// the canonical source key. A goal without a source type stays free-form.
+func normalizeGoalSource(sourceType, sourceKey *string, target *float64, direction *string) (*string, error) {
+	if sourceType == nil || *sourceType == "" {
+		return sourceKey, nil
+	}
+	if sourceKey == nil || strings.TrimSpace(*sourceKey) == "" {
+		return nil, fmt.Errorf("source_key is required")
+	}
+	if target == nil {
+		return nil, fmt.Errorf("target_number is required")
+	}
+	if direction == nil || (*direction != progress.Increase && *direction != progress.Decrease) {
+		return nil, fmt.Errorf("direction must be increase or decrease")
+	}
+
+	var key string
+	switch *sourceType {
+	case "lab":
+		key = pdf.NormalizeMarkerName(*sourceKey)
+	case "metric":
+		key = strings.ToLower(strings.TrimSpace(*sourceKey))
+		if alias, ok := metricAliases[key]; ok {
+			key = alias
+		}
+		if _, ok := goalMetricColumn(key); !ok {
+			return nil, fmt.Errorf("unknown metric %s", *sourceKey)
+		}
+	default:
+		return nil, fmt.Errorf("source_type must be lab or metric")
+	}
+	return &key, nil
+}
+
+// goalSource identifies the data a bound goal follows
+func goalSource(goal models.Goal) string {
+	return *goal.SourceType + ":" + strings.ToLower(*goal.SourceKey)
+}
+
+// loadGoalPoints returns the observations of the user's bound goals, one per
+// date, keyed by goalSource. Lab markers and metrics take one query each.
+func loadGoalPoints(db *database.DB, userID int, goals []models.Goal) (map[string][]progress.Point, error) {
+	var markers, columns []string
+	metricKeys := map[string][]string{}
+	for _, goal := range goals {
+		if goal.SourceType == nil || goal.SourceKey == nil {
+			continue
+		}
+		switch *goal.SourceType {
+		case "lab":
+			markers = append(markers, strings.ToLower(*goal.SourceKey))
+		case "metric":
+			column, ok := goalMetricColumn(*goal.SourceKey)
+			if !ok {
+				return nil, fmt.Errorf("unknown metric %s", *goal.SourceKey)
+			}
+			if _, ok := metricKeys[column]; !ok {
+				columns = append(columns, column)
+			}
+			metricKeys[column] = append(metricKeys[column], goalSource(goal))
+		default:
+			return nil, fmt.Errorf("unknown source type %s", *goal.SourceType)
+		}
+	}
+
+	points := map[string][]progress.Point{}
+	if len(markers) > 0 {
+		var rows []struct {
+			Marker string `db:"marker"`
+			progress.Point
+		}
+		err := db.Select(&rows, `
+			SELECT lower(marker_name) AS marker, test_date AS date, AVG(value)::float8 AS value
+			FROM lab_results
+			WHERE user_id = $1 AND lower(marker_name) = ANY($2) AND value IS NOT NULL
+			GROUP BY lower(marker_name), test_date
+			ORDER BY test_date
+		`, userID, pq.Array(markers))
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			points["lab:"+row.Marker] = append(points["lab:"+row.Marker], row.Point)
+		}
+	}
+
+	if len(columns) > 0 {
+		// Column names come from goalMetricColumn, never from user input
+		selected := make([]string, len(columns))
+		for i, column := range columns {
+			selected[i] = column + "::float8"
+		}
+		rows, err := db.Query(fmt.Sprintf(`
+			SELECT metric_date, %s
+			FROM daily_metrics
+			WHERE user_id = $1
+			ORDER BY metric_date`, strings.Join(selected, ", ")), userID)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		values := make([]sql.NullFloat64, len(columns))
+		dest := make([]interface{}, len(columns)+1)
+		for i := range values {
+			dest[i+1] = &values[i]
+		}
+		for rows.Next() {
+			var date time.Time
+			dest[0] = &date
+			if err := rows.Scan(dest...); err != nil {
+				return nil, err
+			}
+			for i, column := range columns {
+				if !values[i].Valid {
+					continue
+				}
+				for _, key := range metricKeys[column] {
+					points[key] = append(points[key], progress.Point{Date: date, Value: values[i].Float64})
+				}
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return points, nil
+}
+
+// goalProgress computes progress from the goal's observations without
+// changing the goal. Unbound goals have no progress.
+func goalProgress(goal models.Goal, points []progress.Point, today time.Time, withSeries bool) (*models.GoalProgress, progress.Result) {
+	if goal.SourceType == nil || goal.TargetNumber == nil || goal.Direction == nil {
+		return nil, progress.Result{}
+	}
+
+	asOf := civilDate(today)
+	result := progress.Evaluate(progress.Goal{
+		Target:    *goal.TargetNumber,
+		Direction: *goal.Direction,
+		Baseline:  goal.BaselineValue,
+		Since:     goal.CreatedAt,
+		HoldDays:  goal.HoldDays,
+	}, points, asOf)
+
+	p := &models.GoalProgress{
+		CurrentValue:  result.Current,
+		CurrentDate:   result.CurrentDate,
+		BaselineValue: result.Baseline,
+		ProgressPct:   result.Percent,
+		TargetMet:     result.TargetMet,
+		MetSince:      result.MetSince,
+	}
+	if goal.Deadline != nil {
+		days := int(civilDate(*goal.Deadline).Sub(asOf).Hours() / 24)
+		p.DaysLeft = &days
+	}
+	if withSeries {
+		p.Series = make([]models.GoalProgressPoint, len(result.Series))
+		for i, s := range result.Series {
+			p.Series[i] = models.GoalProgressPoint{Date: s.Date, Value: s.Value, ProgressPct: s.Percent, TargetMet: s.TargetMet}
+		}
+	}
+	return p, result
+}
+
+// evaluateGoals computes the progress of the user's goals for reading
+func evaluateGoals(db *database.DB, userID int, goals []models.Goal, withSeries bool) ([]*models.GoalProgress, error) {
+	points, err := loadGoalPoints(db, userID, goals)
+	if err != nil {
+		return nil, err
+	}
+	today := time.Now().In(loadUserLocation(db, userID))
+	result := make([]*models.GoalProgress, len(goals))
+	for i, goal := range goals {
+		if goal.SourceType != nil && goal.SourceKey != nil {
+			result[i], _ = goalProgress(goal, points[goalSource(goal)], today, withSeries)
+		}
+	}
+	return result, nil
+}
+
+// storeGoalProgress writes what the data says back to the goal: the
+// free-text current_value follows it, and an active goal whose target has
+// held for hold_days becomes achieved
+func storeGoalProgress(db *database.DB, goal *models.Goal, result progress.Result) error {
+	if result.Current == nil {
+		return nil
+	}
+	current := formatNumber(*result.Current)
+	status := goal.Status
+	if status == "active" && result.Achieved {
+		status = "achieved"
+	}
+	if goal.CurrentValue != nil && *goal.CurrentValue == current && status == goal.Status {
+		return nil
+	}
+	return db.Get(goal, `
+		UPDATE goals SET
+			current_value = $2,
+			status = $3,
+			achieved_at = CASE WHEN $3 = 'achieved' AND status <> 'achieved' THEN NOW() ELSE achieved_at END,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING *
+	`, goal.ID, current, status)
+}
+
+// refreshGoal re-evaluates one goal after it was written and stores the result
+func refreshGoal(db *database.DB, goal *models.Goal) (*models.GoalProgress, error) {
+	if goal.SourceType == nil || goal.SourceKey == nil {
+		return nil, nil
+	}
+	points, err := loadGoalPoints(db, goal.UserID, []models.Goal{*goal})
+	if err != nil {
+		return nil, err
+	}
+	p, result := goalProgress(*goal, points[goalSource(*goal)], time.Now().In(loadUserLocation(db, goal.UserID)), false)
+	if err := storeGoalProgress(db, goal, result); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// refreshGoals re-evaluates the user's bound goals after their lab results or
+// daily metrics changed and stores the results
+func refreshGoals(db *database.DB, userID int) error {
+	var goals []models.Goal
+	err := db.Select(&goals, `
+		SELECT * FROM goals
+		WHERE user_id = $1 AND source_type IS NOT NULL AND source_key IS NOT NULL
+	`, userID)
+	if err != nil || len(goals) == 0 {
+		return err
+	}
+	points, err := loadGoalPoints(db, userID, goals)
+	if err != nil {
+		return err
+	}
+	today := time.Now().In(loadUserLocation(db, userID))
+	for i := range goals {
+		_, result := goalProgress(goals[i], points[goalSource(goals[i])], today, false)
+		if err := storeGoalProgress(db, &goals[i], result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"health-ai-portal/internal/database"
 	"health-ai-portal/internal/models"
@@ -40,7 +42,17 @@ func (h *GoalHandler) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondJSON(w, http.StatusOK, goals)
+	evaluated, err := evaluateGoals(h.db, userID, goals, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result := make([]models.GoalWithProgress, len(goals))
+	for i := range goals {
+		result[i] = models.GoalWithProgress{Goal: goals[i], Progress: evaluated[i]}
+	}
+
+	respondJSON(w, http.StatusOK, result)
 }
 
 func (h *GoalHandler) Get(w http.ResponseWriter, r *http.Request) {
@@ -57,7 +69,44 @@ func (h *GoalHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondJSON(w, http.StatusOK, goal)
+	evaluated, err := evaluateGoals(h.db, goal.UserID, []models.Goal{goal}, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	p := evaluated[0]
+
+	respondJSON(w, http.StatusOK, models.GoalWithProgress{Goal: goal, Progress: p})
+}
+
+// Progress returns the goal's progress with the time series of observations
+// since it was created
+func (h *GoalHandler) Progress(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var goal models.Goal
+	err = h.db.Get(&goal, `SELECT * FROM goals WHERE id = $1`, id)
+	if err != nil {
+		http.Error(w, "Goal not found", http.StatusNotFound)
+		return
+	}
+
+	evaluated, err := evaluateGoals(h.db, goal.UserID, []models.Goal{goal}, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	p := evaluated[0]
+	if p == nil {
+		http.Error(w, "Goal is not bound to a lab marker or metric", http.StatusBadRequest)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.GoalWithProgress{Goal: goal, Progress: p})
 }
 
 func (h *GoalHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -74,19 +123,75 @@ func (h *GoalHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	sourceKey, err := normalizeGoalSource(input.SourceType, input.SourceKey, input.TargetNumber, input.Direction)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateGoalSchedule(input.Deadline, input.HoldDays); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	holdDays := 0
+	if input.HoldDays != nil {
+		holdDays = *input.HoldDays
+	}
+	// A goal without target text shows the numeric target
+	targetValue := input.TargetValue
+	if targetValue == nil && input.TargetNumber != nil {
+		text := formatNumber(*input.TargetNumber)
+		targetValue = &text
+	}
+
 	var goal models.Goal
-	err := h.db.Get(&goal, `
-		INSERT INTO goals (user_id, name, current_value, target_value, strategy, priority, status)
-		VALUES ($1, $2, $3, $4, $5, $6, 'active')
+	err = h.db.Get(&goal, `
+		INSERT INTO goals (user_id, name, current_value, target_value, strategy, priority, status,
+			source_type, source_key, target_number, baseline_value, direction, deadline, hold_days)
+		VALUES ($1, $2, $3, $4, $5, $6, 'active', $7, $8, $9, $10, $11, $12, $13)
 		RETURNING *
-	`, userID, input.Name, input.CurrentValue, input.TargetValue, input.Strategy, input.Priority)
+	`, userID, input.Name, input.CurrentValue, targetValue, input.Strategy, input.Priority,
+		nullIfEmpty(input.SourceType), sourceKey, input.TargetNumber, input.BaselineValue, input.Direction,
+		input.Deadline, holdDays)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
+	// Freeze the baseline at the value the goal started from
+	p, err := refreshGoal(h.db, &goal)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if p != nil && goal.BaselineValue == nil && p.BaselineValue != nil {
+		if err := h.db.Get(&goal, `UPDATE goals SET baseline_value = $2 WHERE id = $1 RETURNING *`, goal.ID, *p.BaselineValue); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	respondJSON(w, http.StatusCreated, models.GoalWithProgress{Goal: goal, Progress: p})
+}
+
+// validateGoalSchedule checks the deadline date and the hold duration
+func validateGoalSchedule(deadline *string, holdDays *int) error {
+	if deadline != nil {
+		if _, err := time.Parse("2006-01-02", *deadline); err != nil {
+			return fmt.Errorf("deadline must be YYYY-MM-DD")
+		}
+	}
+	if holdDays != nil && (*holdDays < 0 || *holdDays > 365) {
+		return fmt.Errorf("hold_days must be 0..365")
+	}
+	return nil
+}
 
-	respondJSON(w, http.StatusCreated, goal)
+func nullIfEmpty(s *string) *string {
+	if s == nil || *s == "" {
+		return nil
+	}
+	return s
 }
 
 func (h *GoalHandler) Update(w http.ResponseWriter, r *http.Request) {
@@ -102,6 +207,39 @@ func (h *GoalHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var existing models.Goal
+	if err := h.db.Get(&existing, `SELECT * FROM goals WHERE id = $1`, id); err != nil {
+		http.Error(w, "Goal not found", http.StatusNotFound)
+		return
+	}
+
+	// The binding is validated as a whole, with unchanged fields taken from the goal
+	sourceType, sourceKey := existing.SourceType, existing.SourceKey
+	target, direction := existing.TargetNumber, existing.Direction
+	if input.SourceType != nil {
+		sourceType = input.SourceType
+	}
+	if input.SourceKey != nil {
+		sourceKey = input.SourceKey
+	}
+	if input.TargetNumber != nil {
+		target = input.TargetNumber
+	}
+	if input.Direction != nil {
+		direction = input.Direction
+	}
+	sourceKey, err = normalizeGoalSource(sourceType, sourceKey, target, direction)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateGoalSchedule(input.Deadline, input.HoldDays); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// source_type "" unbinds the goal; a new source resets the baseline
+	resetBaseline := input.SourceKey != nil || input.SourceType != nil
 	var goal models.Goal
 	err = h.db.Get(&goal, `
 		UPDATE goals SET
@@ -111,17 +249,33 @@ func (h *GoalHandler) Update(w http.ResponseWriter, r *http.Request) {
 			strategy = COALESCE($5, strategy),
 			priority = COALESCE($6, priority),
 			status = COALESCE($7, status),
+			source_type = CASE WHEN $8::text IS NULL THEN source_type ELSE NULLIF($8, '') END,
+			source_key = $9,
+			target_number = COALESCE($10, target_number),
+			baseline_value = CASE WHEN $11::numeric IS NOT NULL THEN $11 WHEN $15 THEN NULL ELSE baseline_value END,
+			direction = COALESCE($12, direction),
+			deadline = COALESCE($13::date, deadline),
+			hold_days = COALESCE($14, hold_days),
+			achieved_at = CASE WHEN COALESCE($7, status) = 'achieved' THEN COALESCE(achieved_at, NOW()) ELSE NULL END,
 			updated_at = NOW()
 		WHERE id = $1
 		RETURNING *
-	`, id, input.Name, input.CurrentValue, input.TargetValue, input.Strategy, input.Priority, input.Status)
+	`, id, input.Name, input.CurrentValue, input.TargetValue, input.Strategy, input.Priority, input.Status,
+		input.SourceType, sourceKey, input.TargetNumber, input.BaselineValue, input.Direction,
+		input.Deadline, input.HoldDays, resetBaseline && input.BaselineValue == nil)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
+	p, err := refreshGoal(h.db, &goal)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, goal)
+	respondJSON(w, http.StatusOK, models.GoalWithProgress{Goal: goal, Progress: p})
 }
 
 func (h *GoalHandler) Delete(w http.ResponseWriter, r *http.Request) {
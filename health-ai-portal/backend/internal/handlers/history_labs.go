@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/history"
+)
+
+// HistoryLabStore stores the lab history for history.Importer through the
+// regular lab import, which normalises names and units and skips results
+// already stored
+type HistoryLabStore struct {
+	db *database.DB
+}
+
+func NewHistoryLabStore(db *database.DB) *HistoryLabStore {
+	return &HistoryLabStore{db: db}
+}
+
+// StoreLabs implements history.LabStore
+func (s *HistoryLabStore) StoreLabs(userID int, labs []history.LabReport) (history.ImportCounts, []string) {
+	var reports labReports
+	for _, lab := range labs {
+		for _, m := range lab.Markers {
+			name, _ := canonicalMarker("", m.Name)
+			refMin, refMax := parseReferenceRange(m.Reference)
+			notes := []string{history.LabsFile + ": " + lab.Title}
+			if m.Comparator != "" {
+				notes = append(notes, fmt.Sprintf("%s%s %s", m.Comparator, formatNumber(*m.Value), m.Unit))
+			}
+			if m.Notes != "" {
+				notes = append(notes, m.Notes)
+			}
+			reports.add(lab.Date, lab.LabName, ImportMarkerRequest{
+				MarkerName:   name,
+				Value:        m.Value,
+				Unit:         m.Unit,
+				ReferenceMin: refMin,
+				ReferenceMax: refMax,
+				Notes:        strings.TrimSpace(strings.Join(notes, "; ")),
+			})
+		}
+	}
+
+	result := newLabImportResult()
+	(&LabHandler{db: s.db}).importReports(userID, reports, result)
+	return history.ImportCounts{Inserted: result.Imported, Skipped: len(result.Duplicates)}, result.Warnings
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"health-ai-portal/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ListProfiles returns the saved column mappings, optionally of one ?kind=
+func (h *SheetImportHandler) ListProfiles(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	query := `SELECT * FROM import_profiles WHERE user_id = $1`
+	args := []interface{}{userID}
+	if kind := r.URL.Query().Get("kind"); kind != "" {
+		query += ` AND kind = $2`
+		args = append(args, kind)
+	}
+	query += ` ORDER BY name`
+
+	profiles := []models.ImportProfile{}
+	if err := h.db.Select(&profiles, query, args...); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, profiles)
+}
+
+func (h *SheetImportHandler) CreateProfile(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	input, mapping, ok := decodeProfileInput(w, r)
+	if !ok {
+		return
+	}
+
+	var profile models.ImportProfile
+	err := h.db.Get(&profile, `
+		INSERT INTO import_profiles (user_id, name, kind, mapping, sheet, header_row)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, name) DO NOTHING
+		RETURNING *
+	`, userID, input.Name, input.Kind, mapping, input.Sheet, input.HeaderRow)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusConflict, "Import profile "+input.Name+" already exists")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, profile)
+}
+
+func (h *SheetImportHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	input, mapping, ok := decodeProfileInput(w, r)
+	if !ok {
+		return
+	}
+
+	var profile models.ImportProfile
+	err = h.db.Get(&profile, `
+		UPDATE import_profiles SET
+			name = $2, kind = $3, mapping = $4, sheet = $5, header_row = $6, updated_at = NOW()
+		WHERE id = $1
+		RETURNING *
+	`, id, input.Name, input.Kind, mapping, input.Sheet, input.HeaderRow)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "Import profile not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, profile)
+}
+
+func (h *SheetImportHandler) DeleteProfile(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if _, err := h.db.Exec(`DELETE FROM import_profiles WHERE id = $1`, id); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeProfileInput validates a profile and returns its mapping as JSON
+func decodeProfileInput(w http.ResponseWriter, r *http.Request) (models.ImportProfileInput, []byte, bool) {
+	var input models.ImportProfileInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return input, nil, false
+	}
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		respondError(w, http.StatusBadRequest, "name is required")
+		return input, nil, false
+	}
+	fields, ok := sheetFields(input.Kind)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "kind must be labs or supplements")
+		return input, nil, false
+	}
+	if input.HeaderRow != nil && *input.HeaderRow <= 0 {
+		respondError(w, http.StatusBadRequest, "header_row must be a positive row number")
+		return input, nil, false
+	}
+	if input.Mapping == nil {
+		input.Mapping = map[string]string{}
+	}
+	if err := validateSheetMapping(fields, input.Mapping); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return input, nil, false
+	}
+
+	mapping, err := json.Marshal(input.Mapping)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return input, nil, false
+	}
+	return input, mapping, true
+}
@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/models"
+	"health-ai-portal/pkg/schedule"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// lateAfter is how long after the slot time a dose still counts as on time
+const lateAfter = 2 * time.Hour
+
+// adherenceWindows are reported when no ?days= is given
+var adherenceWindows = []int{7, 30, 90}
+
+type IntakeHandler struct {
+	db *database.DB
+}
+
+func NewIntakeHandler(db *database.DB) *IntakeHandler {
+	return &IntakeHandler{db: db}
+}
+
+// List returns logged doses for ?date=YYYY-MM-DD (default today)
+func (h *IntakeHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	loc := loadUserLocation(h.db, userID)
+	date := time.Now().In(loc).Format("2006-01-02")
+	if d := r.URL.Query().Get("date"); d != "" {
+		date = d
+	}
+
+	logs := []models.IntakeLog{}
+	err := h.db.Select(&logs, `
+		SELECT * FROM intake_logs
+		WHERE user_id = $1 AND scheduled_date = $2
+		ORDER BY slot, supplement_id
+	`, userID, date)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, logs)
+}
+
+// Log records a dose as taken, skipped or late. Re-logging the same
+// supplement/slot/date replaces the previous entry.
+func (h *IntakeHandler) Log(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	var input models.IntakeLogCreate
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	log, err := logIntake(h.db, userID, input)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, log)
+}
+
+// logIntake validates and upserts one intake entry. Shared with notifiers
+// that let the user confirm a dose outside the web UI.
+func logIntake(db *database.DB, userID int, input models.IntakeLogCreate) (*models.IntakeLog, error) {
+	if input.SupplementID == 0 {
+		return nil, fmt.Errorf("supplement_id is required")
+	}
+	if input.Slot != "as_needed" && !schedule.IsValidSlot(input.Slot) {
+		return nil, fmt.Errorf("unknown slot: %s", input.Slot)
+	}
+
+	loc := loadUserLocation(db, userID)
+	now := time.Now().In(loc)
+
+	date := now
+	if input.ScheduledDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", input.ScheduledDate, loc)
+		if err != nil {
+			return nil, fmt.Errorf("scheduled_date must be YYYY-MM-DD")
+		}
+		date = parsed
+	}
+
+	status := "taken"
+	if input.Status != nil {
+		status = *input.Status
+	}
+	if status != "taken" && status != "skipped" && status != "late" {
+		return nil, fmt.Errorf("status must be taken, skipped or late")
+	}
+
+	takenAt := input.TakenAt
+	if takenAt == nil && status != "skipped" {
+		takenAt = &now
+	}
+
+	// A dose confirmed well after its slot counts as late
+	if status == "taken" && takenAt != nil && input.Slot != "as_needed" {
+		slots, err := loadSlots(db, userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range slots {
+			if s.Name == input.Slot && takenAt.After(s.At(date, loc).Add(lateAfter)) {
+				status = "late"
+			}
+		}
+	}
+
+	var log models.IntakeLog
+	err := db.Get(&log, `
+		INSERT INTO intake_logs (user_id, supplement_id, slot, scheduled_date, status, taken_at, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (supplement_id, scheduled_date, slot) DO UPDATE SET
+			status = EXCLUDED.status,
+			taken_at = EXCLUDED.taken_at,
+			notes = COALESCE(EXCLUDED.notes, intake_logs.notes)
+		RETURNING *
+	`, userID, input.SupplementID, input.Slot, date.Format("2006-01-02"), status, takenAt, input.Notes)
+	if err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+func (h *IntakeHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	_, err = h.db.Exec(`DELETE FROM intake_logs WHERE id = $1`, id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Adherence returns per-supplement and overall compliance with streaks for
+// ?days=N, or for 7, 30 and 90 days when omitted.
+func (h *IntakeHandler) Adherence(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	windows := adherenceWindows
+	if d := r.URL.Query().Get("days"); d != "" {
+		days, err := strconv.Atoi(d)
+		if err != nil || days < 1 || days > 365 {
+			respondError(w, http.StatusBadRequest, "days must be between 1 and 365")
+			return
+		}
+		windows = []int{days}
+	}
+
+	loc := loadUserLocation(h.db, userID)
+	to := time.Now().In(loc)
+
+	reports := make([]models.AdherenceReport, 0, len(windows))
+	for _, days := range windows {
+		from := to.AddDate(0, 0, -(days - 1))
+		report, err := computeAdherence(h.db, userID, from, to, loc)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		report.Days = days
+		reports = append(reports, *report)
+	}
+
+	respondJSON(w, http.StatusOK, reports)
+}
+
+// doseKey identifies one expected dose
+type doseKey struct {
+	date         string
+	supplementID int
+	slot         string
+}
+
+// dayTally counts expected and satisfied doses for one day
+type dayTally struct {
+	expected  int
+	satisfied int
+}
+
+// computeAdherence compares the reconstructed schedule of every day in
+// [from, to] with the intake log.
+func computeAdherence(db *database.DB, userID int, from, to time.Time, loc *time.Location) (*models.AdherenceReport, error) {
+	fromDate := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	toDate := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, loc)
+
+	timeline, err := loadStackTimeline(db, userID, toDate)
+	if err != nil {
+		return nil, err
+	}
+	slots, err := loadSlots(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var logs []models.IntakeLog
+	err = db.Select(&logs, `
+		SELECT * FROM intake_logs
+		WHERE user_id = $1 AND scheduled_date BETWEEN $2 AND $3
+	`, userID, fromDate.Format("2006-01-02"), toDate.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	logged := make(map[doseKey]string, len(logs))
+	for _, l := range logs {
+		logged[doseKey{l.ScheduledDate.Format("2006-01-02"), l.SupplementID, l.Slot}] = l.Status
+	}
+
+	var assigned map[int][]string
+	overall := models.AdherenceStats{}
+	perSupplement := make(map[int]*models.SupplementAdherence)
+	var order []int
+	var overallDays []dayTally
+	supplementDays := make(map[int][]dayTally)
+
+	for date := fromDate; !date.After(toDate); date = date.AddDate(0, 0, 1) {
+		stack := timeline.activeOn(date)
+		if assigned == nil {
+			ids := make([]int, 0, len(timeline.events))
+			for id := range timeline.events {
+				ids = append(ids, id)
+			}
+			if assigned, err = loadSupplementSlots(db, ids); err != nil {
+				return nil, err
+			}
+		}
+
+		day := buildDaySchedule(date, loc, slots, stack, assigned)
+		key := date.Format("2006-01-02")
+		tally := dayTally{}
+		supplementTally := make(map[int]*dayTally)
+
+		for _, item := range day.Items {
+			for _, s := range item.Supplements {
+				sa, ok := perSupplement[s.ID]
+				if !ok {
+					sa = &models.SupplementAdherence{SupplementID: s.ID, Name: s.Name}
+					perSupplement[s.ID] = sa
+					order = append(order, s.ID)
+				}
+				st := supplementTally[s.ID]
+				if st == nil {
+					st = &dayTally{}
+					supplementTally[s.ID] = st
+				}
+
+				sa.Expected++
+				overall.Expected++
+				tally.expected++
+				st.expected++
+
+				switch logged[doseKey{key, s.ID, item.Slot}] {
+				case "taken":
+					sa.Taken++
+					overall.Taken++
+					tally.satisfied++
+					st.satisfied++
+				case "late":
+					sa.Late++
+					overall.Late++
+					tally.satisfied++
+					st.satisfied++
+				case "skipped":
+					sa.Skipped++
+					overall.Skipped++
+				default:
+					sa.Missed++
+					overall.Missed++
+				}
+			}
+		}
+
+		overallDays = append(overallDays, tally)
+		for _, id := range order {
+			if st, ok := supplementTally[id]; ok {
+				supplementDays[id] = append(supplementDays[id], *st)
+			} else {
+				supplementDays[id] = append(supplementDays[id], dayTally{})
+			}
+		}
+	}
+
+	finishStats(&overall, overallDays)
+	report := &models.AdherenceReport{
+		From:        fromDate,
+		To:          toDate,
+		Overall:     overall,
+		Supplements: make([]models.SupplementAdherence, 0, len(order)),
+	}
+	for _, id := range order {
+		sa := perSupplement[id]
+		finishStats(&sa.AdherenceStats, supplementDays[id])
+		report.Supplements = append(report.Supplements, *sa)
+	}
+	return report, nil
+}
+
+// finishStats fills percent and streaks. A streak counts consecutive days on
+// which every expected dose was taken; days with nothing due do not break it.
+// The current streak may start yesterday while today is still in progress.
+func finishStats(stats *models.AdherenceStats, days []dayTally) {
+	if stats.Expected > 0 {
+		pct := float64(stats.Taken+stats.Late) / float64(stats.Expected) * 100
+		stats.Percent = math.Round(pct*10) / 10
+	}
+
+	run := 0
+	for _, d := range days {
+		switch {
+		case d.expected == 0:
+		case d.satisfied == d.expected:
+			run++
+			if run > stats.LongestStreak {
+				stats.LongestStreak = run
+			}
+		default:
+			run = 0
+		}
+	}
+
+	current := 0
+	for i := len(days) - 1; i >= 0; i-- {
+		d := days[i]
+		if d.expected == 0 {
+			continue
+		}
+		if d.satisfied < d.expected {
+			if i == len(days)-1 {
+				continue
+			}
+			break
+		}
+		current++
+	}
+	stats.CurrentStreak = current
+}
+
+// adherenceContext renders compliance between two dates as a prompt section,
+// so a missed course is not mistaken for a failed one. It is empty when
+// nothing was due.
+func adherenceContext(db *database.DB, userID int, from, to time.Time) (string, error) {
+	loc := loadUserLocation(db, userID)
+	report, err := computeAdherence(db, userID, from, to, loc)
+	if err != nil || report.Overall.Expected == 0 {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "## ПРИВЕРЖЕННОСТЬ ПРИЁМУ (%s — %s)\n\n",
+		report.From.Format("2006-01-02"), report.To.Format("2006-01-02"))
+	fmt.Fprintf(&b, "Общая: %.1f%% (принято %d, с опозданием %d, пропущено %d, не отмечено %d из %d)\n\n",
+		report.Overall.Percent, report.Overall.Taken, report.Overall.Late, report.Overall.Skipped,
+		report.Overall.Missed, report.Overall.Expected)
+
+	for _, s := range report.Supplements {
+		if s.Percent >= 80 {
+			continue
+		}
+		fmt.Fprintf(&b, "- %s: %.1f%% (%d/%d)\n", s.Name, s.Percent, s.Taken+s.Late, s.Expected)
+	}
+	return b.String(), nil
+}
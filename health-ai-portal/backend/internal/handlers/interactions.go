@@ -2,8 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"regexp"
+	"sort"
 	"strconv"
+	"unicode"
+	"unicode/utf8"
 
 	"health-ai-portal/internal/database"
 	"health-ai-portal/internal/models"
@@ -107,10 +112,10 @@ func (h *InteractionHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	var interaction models.Interaction
 	err := h.db.Get(&interaction, `
-		INSERT INTO interactions (supplement_1_id, supplement_2_id, interaction_type, description, solution)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO interactions (supplement_1_id, supplement_2_id, interaction_type, description, solution, separation_hours)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING *
-	`, input.Supplement1ID, input.Supplement2ID, input.InteractionType, input.Description, input.Solution)
+	`, input.Supplement1ID, input.Supplement2ID, input.InteractionType, input.Description, input.Solution, input.SeparationHours)
 
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -150,10 +155,11 @@ func (h *InteractionHandler) Update(w http.ResponseWriter, r *http.Request) {
 		UPDATE interactions SET
 			interaction_type = COALESCE($2, interaction_type),
 			description = COALESCE($3, description),
-			solution = COALESCE($4, solution)
+			solution = COALESCE($4, solution),
+			separation_hours = COALESCE($5, separation_hours)
 		WHERE id = $1
 		RETURNING *
-	`, id, input.InteractionType, input.Description, input.Solution)
+	`, id, input.InteractionType, input.Description, input.Solution, input.SeparationHours)
 
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -190,3 +196,168 @@ func (h *InteractionHandler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// severityRank orders interaction types from harmless to dangerous so the
+// matrix can keep the worst one per pair.
+var severityRank = map[string]int{
+	"synergy":  1,
+	"warning":  2,
+	"critical": 3,
+}
+
+// separationPattern finds "4h", "4 hours", "2-3 ч", "4 часа" in solution text.
+// The number and the unit must stand alone, so "B12 helps" and "2 чашки"
+// do not match; separationBounded checks that around each match, since \b
+// ignores Cyrillic and a matched boundary character would hide the next
+// match in "2h/4h".
+var separationPattern = regexp.MustCompile(`(?i)(\d+)(?:\s*[-–]\s*(\d+))?\s*(?:hours?|hrs?|h|часа|часов|час|ч)`)
+
+// separationBounded reports whether text[start:end] is not glued to a word:
+// no letter or digit before it and no letter after it
+func separationBounded(text string, start, end int) bool {
+	if before, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && (unicode.IsLetter(before) || unicode.IsDigit(before)) {
+		return false
+	}
+	if after, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && unicode.IsLetter(after) {
+		return false
+	}
+	return true
+}
+
+// Matrix returns a symmetric interaction grid over the active stack with the
+// worst severity per unordered pair.
+func (h *InteractionHandler) Matrix(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	supplements := []models.MatrixSupplement{}
+	err := h.db.Select(&supplements, `
+		SELECT id, name, time_of_day, category FROM supplements
+		WHERE user_id = $1 AND status = 'active'
+		ORDER BY time_of_day, name
+	`, userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var interactions []models.InteractionWithNames
+	err = h.db.Select(&interactions, `
+		SELECT i.*,
+			s1.name as supplement_1_name,
+			s2.name as supplement_2_name
+		FROM interactions i
+		JOIN supplements s1 ON i.supplement_1_id = s1.id
+		JOIN supplements s2 ON i.supplement_2_id = s2.id
+		WHERE s1.user_id = $1 AND s2.user_id = $1
+			AND s1.status = 'active' AND s2.status = 'active'
+		ORDER BY i.created_at DESC
+	`, userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, buildInteractionMatrix(supplements, interactions))
+}
+
+func buildInteractionMatrix(supplements []models.MatrixSupplement, interactions []models.InteractionWithNames) models.InteractionMatrix {
+	index := make(map[int]int, len(supplements))
+	for i, s := range supplements {
+		index[s.ID] = i
+	}
+
+	n := len(supplements)
+	cells := make([][]*models.InteractionMatrixCell, n)
+	for i := range cells {
+		cells[i] = make([]*models.InteractionMatrixCell, n)
+	}
+
+	for _, in := range interactions {
+		i, ok1 := index[in.Supplement1ID]
+		j, ok2 := index[in.Supplement2ID]
+		if !ok1 || !ok2 || i == j {
+			continue
+		}
+
+		severity := "unknown"
+		if in.InteractionType != nil {
+			severity = *in.InteractionType
+		}
+
+		cell := cells[i][j]
+		if cell == nil {
+			cell = &models.InteractionMatrixCell{}
+			cells[i][j] = cell
+			cells[j][i] = cell
+		}
+		cell.InteractionIDs = append(cell.InteractionIDs, in.ID)
+
+		if cell.Severity != "" && severityRank[severity] <= severityRank[cell.Severity] {
+			continue
+		}
+		cell.Severity = severity
+		cell.Description = in.Description
+		cell.Solution = in.Solution
+		cell.SeparationHours = in.SeparationHours
+		if cell.SeparationHours == nil && in.Solution != nil {
+			cell.SeparationHours = parseSeparationHours(*in.Solution)
+		}
+		cell.Suggestion = ""
+		if cell.SeparationHours != nil {
+			cell.Suggestion = fmt.Sprintf("take %dh apart", *cell.SeparationHours)
+		}
+	}
+
+	pairs := []models.InteractionMatrixPair{}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if cells[i][j] == nil {
+				continue
+			}
+			pairs = append(pairs, models.InteractionMatrixPair{
+				Supplement1ID:         supplements[i].ID,
+				Supplement1Name:       supplements[i].Name,
+				Supplement2ID:         supplements[j].ID,
+				Supplement2Name:       supplements[j].Name,
+				InteractionMatrixCell: *cells[i][j],
+			})
+		}
+	}
+
+	// Worst pairs first, same as the flat list
+	sort.SliceStable(pairs, func(a, b int) bool {
+		return severityRank[pairs[a].Severity] > severityRank[pairs[b].Severity]
+	})
+
+	return models.InteractionMatrix{
+		Supplements: supplements,
+		Cells:       cells,
+		Pairs:       pairs,
+	}
+}
+
+// parseSeparationHours extracts the largest hour gap mentioned in free text.
+func parseSeparationHours(text string) *int {
+	var hours *int
+	for _, m := range separationPattern.FindAllStringSubmatchIndex(text, -1) {
+		if !separationBounded(text, m[0], m[1]) {
+			continue
+		}
+		v, err := strconv.Atoi(text[m[2]:m[3]])
+		if err != nil {
+			continue
+		}
+		if m[4] >= 0 {
+			if upper, err := strconv.Atoi(text[m[4]:m[5]]); err == nil && upper > v {
+				v = upper
+			}
+		}
+		if v <= 0 || v > 24 {
+			continue
+		}
+		if hours == nil || v > *hours {
+			hours = &v
+		}
+	}
+	return hours
+}
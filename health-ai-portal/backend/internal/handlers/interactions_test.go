@@ -0,0 +1,31 @@
+package handlers
+
+import "testing"
+
+func TestParseSeparationHours(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		want int // 0 for none
+	}{
+		{"Separate by 4h", 4},
+		{"take 4 hours apart", 4},
+		{"разнести на 2-3 ч", 3},
+		{"интервал 4 часа", 4},
+		{"не менее 6 часов", 6},
+		{"2h/4h", 4},
+		{"4h,6h", 6},
+		{"(2ч)", 2},
+		{"B12 helps", 0},
+		{"2 чашки кофе", 0},
+		{"48h fast", 0},
+		{"no numbers here", 0},
+	} {
+		got := parseSeparationHours(tc.text)
+		switch {
+		case tc.want == 0 && got != nil:
+			t.Errorf("%q = %d, want none", tc.text, *got)
+		case tc.want != 0 && (got == nil || *got != tc.want):
+			t.Errorf("%q = %v, want %d", tc.text, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"health-ai-portal/pkg/fhir"
+	"health-ai-portal/pkg/hl7"
+	"health-ai-portal/pkg/pdf"
+)
+
+// maxLabImportSize bounds an uploaded FHIR bundle or HL7 file
+const maxLabImportSize = 10 << 20
+
+// labReports groups parsed results into one import request per date and lab,
+// in the order they were first seen
+type labReports struct {
+	order    []string
+	requests map[string]*ImportLabsRequest
+}
+
+func (g *labReports) add(date time.Time, labName string, marker ImportMarkerRequest) {
+	if g.requests == nil {
+		g.requests = make(map[string]*ImportLabsRequest)
+	}
+	day := date.Format("2006-01-02")
+	key := day + "|" + labName
+	req, ok := g.requests[key]
+	if !ok {
+		req = &ImportLabsRequest{LabName: labName, TestDate: day}
+		g.requests[key] = req
+		g.order = append(g.order, key)
+	}
+	req.Markers = append(req.Markers, marker)
+}
+
+func (h *LabHandler) importReports(userID int, reports labReports, result *LabImportResult) {
+	for _, key := range reports.order {
+		h.importLabs(userID, *reports.requests[key], result)
+	}
+}
+
+// canonicalMarker maps a LOINC code or a lab's own code and name to the name
+// markers are stored under. Unknown markers keep the first name given.
+func canonicalMarker(loinc string, names ...string) (string, bool) {
+	if loinc != "" {
+		if m, ok := fhir.MarkerForLOINC(loinc); ok {
+			return m.Name, true
+		}
+	}
+	fallback := ""
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if marker, ok := pdf.MatchMarkerName(name); ok {
+			return marker, true
+		}
+		if fallback == "" {
+			fallback = name
+		}
+	}
+	return fallback, false
+}
+
+// ImportFHIR imports Observations from a FHIR R4 Bundle (any bundle type) or
+// a single Observation
+func (h *LabHandler) ImportFHIR(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxLabImportSize))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Failed to read body: "+err.Error())
+		return
+	}
+
+	resources, err := fhirResources(data)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result := newLabImportResult()
+	var reports labReports
+	for _, raw := range resources {
+		var obs fhir.Observation
+		if err := json.Unmarshal(raw, &obs); err != nil || obs.ResourceType != "Observation" {
+			continue
+		}
+		date, labName, marker, warning := fhirMarker(obs)
+		if warning != "" {
+			result.Warnings = append(result.Warnings, warning)
+		}
+		if marker != nil {
+			reports.add(date, labName, *marker)
+		}
+	}
+
+	if len(reports.order) == 0 {
+		result.Warnings = append(result.Warnings, "no importable Observations found")
+		respondJSON(w, http.StatusOK, result)
+		return
+	}
+
+	h.importReports(userID, reports, result)
+	respondJSON(w, http.StatusCreated, result)
+}
+
+// fhirResources returns the resources of a Bundle, or the resource itself
+func fhirResources(data []byte) ([]json.RawMessage, error) {
+	var head struct {
+		ResourceType string             `json:"resourceType"`
+		Entry        []fhir.BundleEntry `json:"entry"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, fmt.Errorf("invalid FHIR JSON: %v", err)
+	}
+	switch head.ResourceType {
+	case "Bundle":
+		resources := make([]json.RawMessage, 0, len(head.Entry))
+		for _, e := range head.Entry {
+			resources = append(resources, e.Resource)
+		}
+		return resources, nil
+	case "Observation":
+		return []json.RawMessage{data}, nil
+	}
+	return nil, fmt.Errorf("expected a Bundle or an Observation, got %q", head.ResourceType)
+}
+
+// fhirMarker converts one Observation. A nil marker means it was skipped;
+// the warning says why.
+func fhirMarker(obs fhir.Observation) (time.Time, string, *ImportMarkerRequest, string) {
+	var loinc string
+	names := []string{}
+	for _, c := range obs.Code.Coding {
+		if c.System == fhir.SystemLOINC && loinc == "" {
+			loinc = c.Code
+		}
+		names = append(names, c.Display, c.Code)
+	}
+	names = append([]string{obs.Code.Text}, names...)
+	name, known := canonicalMarker(loinc, names...)
+	if name == "" {
+		return time.Time{}, "", nil, "Observation without a code skipped"
+	}
+
+	switch obs.Status {
+	case "entered-in-error", "cancelled", "registered":
+		return time.Time{}, "", nil, fmt.Sprintf("%s: status %s skipped", name, obs.Status)
+	}
+
+	date, err := fhirDate(obs)
+	if err != nil {
+		return time.Time{}, "", nil, fmt.Sprintf("%s: %v", name, err)
+	}
+	if obs.ValueQuantity == nil || obs.ValueQuantity.Value == nil {
+		return time.Time{}, "", nil, fmt.Sprintf("%s: no numeric value, skipped", name)
+	}
+
+	marker := &ImportMarkerRequest{
+		MarkerName: name,
+		Value:      obs.ValueQuantity.Value,
+		Unit:       quantityUnit(*obs.ValueQuantity),
+	}
+	if c := obs.ValueQuantity.Comparator; c != "" {
+		marker.Notes = c + formatNumber(*obs.ValueQuantity.Value)
+	}
+	if len(obs.ReferenceRange) > 0 {
+		rr := obs.ReferenceRange[0]
+		if rr.Low != nil {
+			marker.ReferenceMin = rr.Low.Value
+		}
+		if rr.High != nil {
+			marker.ReferenceMax = rr.High.Value
+		}
+	}
+
+	labName := ""
+	if len(obs.Performer) > 0 {
+		labName = obs.Performer[0].Display
+	}
+
+	warning := ""
+	if !known {
+		warning = fmt.Sprintf("%s: not a known marker, imported under this name", name)
+	}
+	return date, labName, marker, warning
+}
+
+// quantityUnit prefers the human unit; UCUM codes are understood as well
+func quantityUnit(q fhir.Quantity) string {
+	if q.Unit != "" {
+		return q.Unit
+	}
+	return q.Code
+}
+
+func fhirDate(obs fhir.Observation) (time.Time, error) {
+	value := obs.EffectiveDateTime
+	if value == "" && obs.EffectivePeriod != nil {
+		value = obs.EffectivePeriod.Start
+	}
+	if value == "" {
+		value = obs.Issued
+	}
+	if value == "" {
+		return time.Time{}, fmt.Errorf("no effective date")
+	}
+	// The calendar date as the lab wrote it, whatever the offset
+	if len(value) >= 10 {
+		if t, err := time.Parse("2006-01-02", value[:10]); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+// ImportHL7 imports OBX results of HL7 v2 ORU^R01 messages sent as the raw
+// body; several messages may follow each other
+func (h *LabHandler) ImportHL7(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxLabImportSize))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Failed to read body: "+err.Error())
+		return
+	}
+
+	messages, err := hl7.ParseBatch(string(data))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result := newLabImportResult()
+	var reports labReports
+	for i, m := range messages {
+		observations, err := m.Observations()
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("message %d: %v", i+1, err))
+			continue
+		}
+		for _, obs := range observations {
+			marker, warning := hl7Marker(obs)
+			if warning != "" {
+				result.Warnings = append(result.Warnings, warning)
+			}
+			if marker != nil {
+				reports.add(obs.Observed, obs.Facility, *marker)
+			}
+		}
+	}
+
+	if len(reports.order) == 0 {
+		result.Warnings = append(result.Warnings, "no importable OBX results found")
+		respondJSON(w, http.StatusOK, result)
+		return
+	}
+
+	h.importReports(userID, reports, result)
+	respondJSON(w, http.StatusCreated, result)
+}
+
+// hl7Marker converts one OBX result; a nil marker means it was skipped
+func hl7Marker(obs hl7.Observation) (*ImportMarkerRequest, string) {
+	id := obs.Identifier
+	var loinc string
+	if id.System == "LN" {
+		loinc = id.Code
+	} else if id.AltSystem == "LN" {
+		loinc = id.AltCode
+	}
+	name, known := canonicalMarker(loinc, id.Text, id.Code, id.AltText, id.AltCode)
+	if name == "" {
+		return nil, "OBX without an identifier skipped"
+	}
+
+	switch obs.Status {
+	case "X", "D", "W":
+		return nil, fmt.Sprintf("%s: result status %s skipped", name, obs.Status)
+	}
+	if obs.Observed.IsZero() {
+		return nil, fmt.Sprintf("%s: no observation date, skipped", name)
+	}
+
+	value, comparator, ok := parseResultValue(obs.Value)
+	if !ok {
+		return nil, fmt.Sprintf("%s: value %q is not numeric, skipped", name, obs.Value)
+	}
+
+	unit := obs.Units.Text
+	if unit == "" {
+		unit = obs.Units.Code
+	}
+	marker := &ImportMarkerRequest{MarkerName: name, Value: &value, Unit: unit}
+	if comparator != "" {
+		marker.Notes = obs.Value
+	}
+	marker.ReferenceMin, marker.ReferenceMax = parseReferenceRange(obs.ReferenceRange)
+
+	if !known {
+		return marker, fmt.Sprintf("%s: not a known marker, imported under this name", name)
+	}
+	return marker, ""
+}
+
+var resultValuePattern = regexp.MustCompile(`^\s*(<=|>=|<|>)?\s*(-?\d+(?:[.,]\d+)?)\s*$`)
+
+// parseResultValue reads "5.2", "5,2" or a bound such as "<0.5"
+func parseResultValue(s string) (float64, string, bool) {
+	m := resultValuePattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0, "", false
+	}
+	v, err := strconv.ParseFloat(strings.Replace(m[2], ",", ".", 1), 64)
+	return v, m[1], err == nil
+}
+
+var rangePattern = regexp.MustCompile(`^\s*(-?\d+(?:[.,]\d+)?)\s*[-–]\s*(-?\d+(?:[.,]\d+)?)\s*$`)
+
+// parseReferenceRange reads "3.5-5.0", "<5" or ">1.2"
+func parseReferenceRange(s string) (*float64, *float64) {
+	parse := func(v string) *float64 {
+		f, err := strconv.ParseFloat(strings.Replace(v, ",", ".", 1), 64)
+		if err != nil {
+			return nil
+		}
+		return &f
+	}
+	if m := rangePattern.FindStringSubmatch(s); m != nil {
+		return parse(m[1]), parse(m[2])
+	}
+	if v, comparator, ok := parseResultValue(s); ok {
+		switch comparator {
+		case "<", "<=":
+			return nil, &v
+		case ">", ">=":
+			return &v, nil
+		}
+	}
+	return nil, nil
+}
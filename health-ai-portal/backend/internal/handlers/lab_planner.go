@@ -0,0 +1,442 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/models"
+	"health-ai-portal/pkg/labplan"
+	"health-ai-portal/pkg/pdf"
+	"health-ai-portal/pkg/schedule"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	defaultLabHorizonDays = 14
+	maxLabHorizonDays     = 180
+	fastingReminderClock  = "20:00" // the evening before a fasting draw
+	maxReminderTitle      = 200
+)
+
+// oneOffRule fires a reminder once, on its dtstart
+const oneOffRule = "FREQ=DAILY;COUNT=1"
+
+// GetDue returns the prioritised list of markers due within ?horizon days
+// (default 14) and the single draw that covers them
+func (h *LabHandler) GetDue(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	horizon := defaultLabHorizonDays
+	if v := r.URL.Query().Get("horizon"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > maxLabHorizonDays {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("horizon must be 0..%d days", maxLabHorizonDays))
+			return
+		}
+		horizon = n
+	}
+
+	loc := loadUserLocation(h.db, userID)
+	plan, err := loadLabPlan(h.db, userID, time.Now().In(loc), horizon)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, plan)
+}
+
+// loadLabPlan combines the latest cycle's required_labs, the retest intervals
+// and the last test date of every marker
+func loadLabPlan(db *database.DB, userID int, today time.Time, horizon int) (labplan.Plan, error) {
+	intervals, _, err := loadLabIntervals(db, userID)
+	if err != nil {
+		return labplan.Plan{}, err
+	}
+
+	var measured []struct {
+		MarkerName string    `db:"marker_name"`
+		LastDate   time.Time `db:"last_date"`
+	}
+	err = db.Select(&measured, `
+		SELECT marker_name, MAX(test_date) AS last_date
+		FROM lab_results
+		WHERE user_id = $1
+		GROUP BY marker_name
+	`, userID)
+	if err != nil {
+		return labplan.Plan{}, err
+	}
+	last := make(map[string]time.Time, len(measured))
+	for _, m := range measured {
+		key := labplan.Key(m.MarkerName)
+		if prev, ok := last[key]; !ok || m.LastDate.After(prev) {
+			last[key] = m.LastDate
+		}
+	}
+
+	// Only the latest cycle's request counts; older ones were superseded
+	var cycles []models.Cycle
+	err = db.Select(&cycles, `
+		SELECT * FROM cycles
+		WHERE user_id = $1 AND required_labs IS NOT NULL
+		ORDER BY cycle_date DESC, id DESC
+		LIMIT 1
+	`, userID)
+	if err != nil {
+		return labplan.Plan{}, err
+	}
+	var required []labplan.Required
+	if len(cycles) > 0 {
+		required = requiredLabs(cycles[0])
+	}
+
+	return labplan.Build(today, required, intervals, last, horizon), nil
+}
+
+// requiredLabs splits the cycle's free-text list ("fT3, fT4, ТТГ") into
+// markers. Labs are due a few days before the review so results are back.
+func requiredLabs(c models.Cycle) []labplan.Required {
+	var labels []string
+	if c.RequiredLabs == nil || json.Unmarshal(*c.RequiredLabs, &labels) != nil {
+		return nil
+	}
+
+	var dueBy *time.Time
+	if c.NextReviewDate != nil {
+		due := c.NextReviewDate.AddDate(0, 0, -labDrawLeadDays)
+		if due.Before(c.CycleDate) {
+			due = c.CycleDate
+		}
+		dueBy = &due
+	}
+
+	var required []labplan.Required
+	for _, label := range labels {
+		for _, part := range strings.FieldsFunc(label, func(r rune) bool { return r == ',' || r == ';' }) {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			marker, ok := pdf.MatchMarkerName(part)
+			if !ok {
+				marker = part
+			}
+			required = append(required, labplan.Required{
+				Marker:    marker,
+				Label:     part,
+				CycleID:   c.ID,
+				CycleDate: c.CycleDate,
+				DueBy:     dueBy,
+			})
+		}
+	}
+	return required
+}
+
+// loadLabIntervals returns the defaults overlaid with the user's overrides,
+// and the set of overridden markers
+func loadLabIntervals(db *database.DB, userID int) ([]labplan.Interval, map[string]bool, error) {
+	var rows []models.LabRetestInterval
+	if err := db.Select(&rows, `SELECT * FROM lab_retest_intervals WHERE user_id = $1`, userID); err != nil {
+		return nil, nil, err
+	}
+
+	custom := make(map[string]bool, len(rows))
+	var intervals []labplan.Interval
+	for _, row := range rows {
+		custom[labplan.Key(row.MarkerName)] = true
+		iv := labplan.Interval{Marker: row.MarkerName, Days: row.IntervalDays, Fasting: row.Fasting}
+		if row.Note != nil {
+			iv.Note = *row.Note
+		}
+		intervals = append(intervals, iv)
+	}
+	for _, iv := range labplan.DefaultIntervals {
+		if !custom[labplan.Key(iv.Marker)] {
+			intervals = append(intervals, iv)
+		}
+	}
+
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].Marker < intervals[j].Marker })
+	return intervals, custom, nil
+}
+
+// ListIntervals returns the effective retest interval of every marker
+func (h *LabHandler) ListIntervals(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	intervals, custom, err := loadLabIntervals(h.db, userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	settings := make([]models.LabIntervalSetting, len(intervals))
+	for i, iv := range intervals {
+		settings[i] = models.LabIntervalSetting{
+			MarkerName:   iv.Marker,
+			IntervalDays: iv.Days,
+			Fasting:      iv.Fasting,
+			Note:         iv.Note,
+			Custom:       custom[labplan.Key(iv.Marker)],
+		}
+	}
+
+	respondJSON(w, http.StatusOK, settings)
+}
+
+// UpdateIntervals stores overrides for the listed markers
+func (h *LabHandler) UpdateIntervals(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	var input []models.LabRetestIntervalUpdate
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	for _, iv := range input {
+		if strings.TrimSpace(iv.MarkerName) == "" {
+			respondError(w, http.StatusBadRequest, "marker_name is required")
+			return
+		}
+		if iv.IntervalDays < 0 || iv.IntervalDays > 3650 {
+			respondError(w, http.StatusBadRequest, "interval_days must be 0..3650")
+			return
+		}
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	for _, iv := range input {
+		_, err := tx.Exec(`
+			INSERT INTO lab_retest_intervals (user_id, marker_name, interval_days, fasting, note)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (user_id, marker_name) DO UPDATE SET
+				interval_days = EXCLUDED.interval_days,
+				fasting = EXCLUDED.fasting,
+				note = EXCLUDED.note
+		`, userID, pdf.NormalizeMarkerName(iv.MarkerName), iv.IntervalDays, iv.Fasting, iv.Note)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.ListIntervals(w, r)
+}
+
+// ResetInterval drops the override so the default applies again
+func (h *LabHandler) ResetInterval(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	marker := chi.URLParam(r, "marker")
+	_, err := h.db.Exec(`
+		DELETE FROM lab_retest_intervals WHERE user_id = $1 AND lower(marker_name) = lower($2)
+	`, userID, pdf.NormalizeMarkerName(marker))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *LabHandler) ListDraws(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	draws := []models.LabDraw{}
+	err := h.db.Select(&draws, `
+		SELECT * FROM lab_draws WHERE user_id = $1 ORDER BY draw_date DESC, id DESC LIMIT 50
+	`, userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, draws)
+}
+
+// CreateDraw turns the current plan into a lab draw with a lab reminder on
+// the draw morning and, for fasting draws, one the evening before. Upcoming
+// draws planned earlier are replaced.
+func (h *LabHandler) CreateDraw(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	var input models.LabDrawCreate
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	clock := labDrawClock
+	if input.Time != nil {
+		normalized, ok := schedule.NormalizeClock(*input.Time)
+		if !ok {
+			respondError(w, http.StatusBadRequest, "time must be HH:MM")
+			return
+		}
+		clock = normalized
+	}
+
+	loc := loadUserLocation(h.db, userID)
+	today := time.Now().In(loc)
+	plan, err := loadLabPlan(h.db, userID, today, defaultLabHorizonDays)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if plan.Draw == nil {
+		respondError(w, http.StatusConflict, "No labs are due")
+		return
+	}
+
+	draw := *plan.Draw
+	if input.Date != nil {
+		date, err := time.Parse("2006-01-02", *input.Date)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "date must be YYYY-MM-DD")
+			return
+		}
+		if date.Before(civilDate(today)) {
+			respondError(w, http.StatusBadRequest, "date must not be in the past")
+			return
+		}
+		draw.Date = date
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	// Replace upcoming draws together with their reminders
+	_, err = tx.Exec(`
+		DELETE FROM reminders WHERE id IN (
+			SELECT reminder_id FROM lab_draws WHERE user_id = $1 AND draw_date >= $2
+			UNION
+			SELECT fasting_reminder_id FROM lab_draws WHERE user_id = $1 AND draw_date >= $2
+		)
+	`, userID, civilDate(today))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if _, err := tx.Exec(`DELETE FROM lab_draws WHERE user_id = $1 AND draw_date >= $2`, userID, civilDate(today)); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	labType := "lab"
+	markerList := strings.Join(draw.Markers, ", ")
+	description := "Анализы: " + markerList
+	if draw.Fasting {
+		description += "\nНатощак: последний приём пищи накануне до 20:00, утром только вода."
+	}
+	rule := oneOffRule
+	drawDate := draw.Date.Format("2006-01-02")
+
+	var reminderID int
+	err = tx.Get(&reminderID, `
+		INSERT INTO reminders (user_id, reminder_type, title, description, time, rrule, dtstart, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, true)
+		RETURNING id
+	`, userID, labType, truncateTitle("Сдать анализы: "+markerList), description, clock, rule, drawDate)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var fastingReminderID *int
+	if draw.Fasting {
+		var id int
+		err = tx.Get(&id, `
+			INSERT INTO reminders (user_id, reminder_type, title, description, time, rrule, dtstart, is_active)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, true)
+			RETURNING id
+		`, userID, labType, "Завтра анализы натощак — не есть после 20:00", description,
+			fastingReminderClock, rule, draw.Date.AddDate(0, 0, -1).Format("2006-01-02"))
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		fastingReminderID = &id
+	}
+
+	markers, _ := json.Marshal(draw.Markers)
+	var created models.LabDraw
+	err = tx.Get(&created, `
+		INSERT INTO lab_draws (user_id, draw_date, markers, fasting, reminder_id, fasting_reminder_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING *
+	`, userID, drawDate, markers, draw.Fasting, reminderID, fastingReminderID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, created)
+}
+
+// DeleteDraw cancels a draw and removes its reminders
+func (h *LabHandler) DeleteDraw(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var draw models.LabDraw
+	if err := h.db.Get(&draw, `DELETE FROM lab_draws WHERE id = $1 RETURNING *`, id); err != nil {
+		respondError(w, http.StatusNotFound, "Lab draw not found")
+		return
+	}
+	_, err = h.db.Exec(`DELETE FROM reminders WHERE id = ANY(ARRAY[$1, $2]::int[])`, draw.ReminderID, draw.FastingReminderID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// truncateTitle keeps a title within the reminders.title column
+func truncateTitle(title string) string {
+	runes := []rune(title)
+	if len(runes) <= maxReminderTitle {
+		return title
+	}
+	return string(runes[:maxReminderTitle-1]) + "…"
+}
+
+// civilDate is the calendar date of t as midnight UTC
+func civilDate(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/models"
+	"health-ai-portal/pkg/pdf"
+)
+
+// LabReparseChange is a stored result whose name or category the current
+// marker dictionary reads differently
+type LabReparseChange struct {
+	ID          int     `json:"id"`
+	TestDate    string  `json:"test_date"`
+	MarkerName  string  `json:"marker_name"`
+	NewName     string  `json:"new_name"`
+	Category    *string `json:"category"`
+	NewCategory string  `json:"new_category"`
+	// ConflictID is a result already stored under the new name for the same
+	// date and value; such rows are left alone
+	ConflictID int `json:"conflict_id,omitempty"`
+}
+
+// ReparseLabs runs stored marker names through the marker dictionary again,
+// so results imported before a name or category was known join their
+// trends. With dryRun nothing is written.
+func ReparseLabs(db *database.DB, userID int, dryRun bool) ([]LabReparseChange, error) {
+	var labs []models.LabResult
+	if err := db.Select(&labs, `SELECT * FROM lab_results WHERE user_id = $1 ORDER BY test_date, id`, userID); err != nil {
+		return nil, err
+	}
+
+	changes := []LabReparseChange{}
+	for _, lab := range labs {
+		name, _ := canonicalMarker("", lab.MarkerName)
+		name = pdf.NormalizeMarkerName(name)
+		category := pdf.MarkerCategory(name)
+		if category == "" {
+			category = derefString(lab.Category)
+		}
+		if name == lab.MarkerName && category == derefString(lab.Category) {
+			continue
+		}
+
+		change := LabReparseChange{
+			ID:          lab.ID,
+			TestDate:    lab.TestDate.Format("2006-01-02"),
+			MarkerName:  lab.MarkerName,
+			NewName:     name,
+			Category:    lab.Category,
+			NewCategory: category,
+		}
+		if name != lab.MarkerName {
+			db.Get(&change.ConflictID, `
+				SELECT id FROM lab_results
+				WHERE user_id = $1 AND test_date = $2 AND marker_name = $3 AND id <> $4
+					AND value IS NOT DISTINCT FROM $5
+				LIMIT 1
+			`, userID, lab.TestDate, name, lab.ID, lab.Value)
+		}
+		changes = append(changes, change)
+		if dryRun || change.ConflictID != 0 {
+			continue
+		}
+
+		_, err := db.Exec(`
+			UPDATE lab_results SET marker_name = $2, category = NULLIF($3, '')
+			WHERE id = $1
+		`, lab.ID, name, category)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// Renamed results may now belong to a bound goal
+	if !dryRun && len(changes) > 0 {
+		if err := refreshGoals(db, userID); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"strings"
+
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/models"
+	"health-ai-portal/pkg/trend"
+)
+
+// loadLabTrends returns every marker's results, oldest first, with the
+// trend analysis of those in the latest unit
+func loadLabTrends(db *database.DB, userID int) ([]models.LabTrend, error) {
+	var results []models.LabResult
+	err := db.Select(&results, `
+		SELECT * FROM lab_results
+		WHERE user_id = $1 AND value IS NOT NULL
+		ORDER BY marker_name, test_date ASC, id ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	trends := []models.LabTrend{}
+	for start := 0; start < len(results); {
+		end := start
+		for end < len(results) && results[end].MarkerName == results[start].MarkerName {
+			end++
+		}
+		trends = append(trends, labTrend(results[start:end]))
+		start = end
+	}
+	return trends, nil
+}
+
+// labTrend builds one marker's trend. The unit and reference range are the
+// latest result's; results in another unit are shown but not analysed.
+func labTrend(results []models.LabResult) models.LabTrend {
+	latest := results[len(results)-1]
+	t := models.LabTrend{
+		MarkerName:   latest.MarkerName,
+		Unit:         derefString(latest.Unit),
+		ReferenceMin: latest.ReferenceMin,
+		ReferenceMax: latest.ReferenceMax,
+	}
+
+	var points []trend.Point
+	for _, r := range results {
+		t.DataPoints = append(t.DataPoints, models.LabTrendPoint{Date: r.TestDate, Value: *r.Value})
+		if strings.EqualFold(derefString(r.Unit), t.Unit) {
+			points = append(points, trend.Point{Date: r.TestDate, Value: *r.Value})
+		}
+	}
+
+	var cv *trend.Variation
+	if v, ok := trend.VariationFor(t.MarkerName); ok {
+		cv = &v
+	}
+	a := trend.Analyze(points, trend.Range{Min: t.ReferenceMin, Max: t.ReferenceMax}, cv)
+
+	analysis := &models.LabTrendAnalysis{Outliers: []models.LabTrendPoint{}}
+	if a.Slope != nil {
+		analysis.SlopePerMonth = &a.Slope.Linear
+		analysis.RobustSlopePerMonth = &a.Slope.TheilSen
+		analysis.R2 = &a.Slope.R2
+	}
+	if p := a.Projection; p != nil {
+		analysis.Projection = &models.LabProjection{
+			Bound:     p.Bound,
+			Value:     p.Value,
+			Date:      p.Date,
+			DaysAway:  p.Days,
+			Direction: p.Direction,
+		}
+	}
+	if c := a.Change; c != nil {
+		analysis.LatestChange = &models.LabChange{
+			From:        c.From,
+			To:          c.To,
+			Percent:     c.Percent,
+			RCVPercent:  c.RCV,
+			Significant: c.Significant,
+		}
+	}
+	for _, o := range a.Outliers {
+		analysis.Outliers = append(analysis.Outliers, models.LabTrendPoint{Date: o.Date, Value: o.Value, Outlier: true})
+		for i := range t.DataPoints {
+			if t.DataPoints[i].Date.Equal(o.Date) && t.DataPoints[i].Value == o.Value {
+				t.DataPoints[i].Outlier = true
+			}
+		}
+	}
+	t.Analysis = analysis
+	return t
+}
@@ -1,12 +1,18 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"health-ai-portal/internal/database"
 	"health-ai-portal/internal/models"
+	"health-ai-portal/pkg/fhir"
+	"health-ai-portal/pkg/pdf"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -86,6 +92,7 @@ func (h *LabHandler) Create(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	h.refreshGoals(userID)
 
 	respondJSON(w, http.StatusCreated, result)
 }
@@ -123,6 +130,7 @@ func (h *LabHandler) Update(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	h.refreshGoals(result.UserID)
 
 	respondJSON(w, http.StatusOK, result)
 }
@@ -134,11 +142,15 @@ func (h *LabHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = h.db.Exec(`DELETE FROM lab_results WHERE id = $1`, id)
-	if err != nil {
+	var userID int
+	err = h.db.Get(&userID, `DELETE FROM lab_results WHERE id = $1 RETURNING user_id`, id)
+	if err != nil && err != sql.ErrNoRows {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if err == nil {
+		h.refreshGoals(userID)
+	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -175,6 +187,32 @@ type ImportMarkerRequest struct {
 	ReferenceMin *float64 `json:"reference_min"`
 	ReferenceMax *float64 `json:"reference_max"`
 	Category     string   `json:"category"`
+	Notes        string   `json:"notes,omitempty"`
+}
+
+// LabImportDuplicate is a marker that was already stored with the same value
+type LabImportDuplicate struct {
+	MarkerName string   `json:"marker_name"`
+	TestDate   string   `json:"test_date"`
+	Value      *float64 `json:"value"`
+	ExistingID int      `json:"existing_id"`
+}
+
+// LabImportResult is the outcome of every lab import format
+type LabImportResult struct {
+	Imported   int                  `json:"imported"`
+	Total      int                  `json:"total"`
+	Results    []models.LabResult   `json:"results"`
+	Duplicates []LabImportDuplicate `json:"duplicates"`
+	Warnings   []string             `json:"warnings"`
+}
+
+func newLabImportResult() *LabImportResult {
+	return &LabImportResult{
+		Results:    []models.LabResult{},
+		Duplicates: []LabImportDuplicate{},
+		Warnings:   []string{},
+	}
 }
 
 func (h *LabHandler) Import(w http.ResponseWriter, r *http.Request) {
@@ -191,79 +229,154 @@ func (h *LabHandler) Import(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var imported []models.LabResult
+	result := newLabImportResult()
+	h.importLabs(userID, input, result)
+
+	respondJSON(w, http.StatusCreated, result)
+}
+
+// importLabs stores one lab report. Values are converted to the unit the
+// marker is already stored in where possible, and a marker already stored
+// for the same date with the same value counts as a duplicate.
+func (h *LabHandler) importLabs(userID int, input ImportLabsRequest, result *LabImportResult) {
 	for _, marker := range input.Markers {
-		var result models.LabResult
-		err := h.db.Get(&result, `
-			INSERT INTO lab_results (user_id, test_date, lab_name, marker_name, value, unit, reference_min, reference_max, category)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		result.Total++
+		marker.MarkerName = pdf.NormalizeMarkerName(marker.MarkerName)
+		if marker.Category == "" {
+			marker.Category = pdf.MarkerCategory(marker.MarkerName)
+		}
+		h.convertToStoredUnit(userID, &marker, result)
+
+		var existingID int
+		err := h.db.Get(&existingID, `
+			SELECT id FROM lab_results
+			WHERE user_id = $1 AND test_date = $2 AND lower(marker_name) = lower($3)
+				AND value IS NOT DISTINCT FROM ROUND($4::numeric, 3)
+			LIMIT 1
+		`, userID, input.TestDate, marker.MarkerName, marker.Value)
+		if err == nil {
+			result.Duplicates = append(result.Duplicates, LabImportDuplicate{
+				MarkerName: marker.MarkerName,
+				TestDate:   input.TestDate,
+				Value:      marker.Value,
+				ExistingID: existingID,
+			})
+			continue
+		}
+		if err != sql.ErrNoRows {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %v", marker.MarkerName, err))
+			continue
+		}
+
+		var lab models.LabResult
+		err = h.db.Get(&lab, `
+			INSERT INTO lab_results (user_id, test_date, lab_name, marker_name, value, unit, reference_min, reference_max, category, notes)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''))
 			RETURNING *
-		`, userID, input.TestDate, input.LabName, marker.MarkerName, marker.Value, marker.Unit, marker.ReferenceMin, marker.ReferenceMax, marker.Category)
+		`, userID, input.TestDate, input.LabName, marker.MarkerName, marker.Value, marker.Unit, marker.ReferenceMin, marker.ReferenceMax, marker.Category, marker.Notes)
 
 		if err != nil {
-			// Log error but continue with other markers
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %v", marker.MarkerName, err))
 			continue
 		}
-		imported = append(imported, result)
+		result.Results = append(result.Results, lab)
+		result.Imported++
 	}
 
-	respondJSON(w, http.StatusCreated, map[string]interface{}{
-		"imported": len(imported),
-		"total":    len(input.Markers),
-		"results":  imported,
-	})
+	if result.Imported > 0 {
+		if err := refreshGoals(h.db, userID); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("goals: %v", err))
+		}
+	}
 }
 
-func (h *LabHandler) GetTrends(w http.ResponseWriter, r *http.Request) {
-	userID := 1
+// refreshGoals brings goals bound to lab markers up to date after a single
+// result changed; a failure there does not undo the change
+func (h *LabHandler) refreshGoals(userID int) {
+	if err := refreshGoals(h.db, userID); err != nil {
+		log.Printf("Goals: refresh for user %d failed: %v", userID, err)
+	}
+}
 
-	// Get unique markers with their history
-	rows, err := h.db.Query(`
-		SELECT DISTINCT marker_name FROM lab_results WHERE user_id = $1
-	`, userID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+// convertToStoredUnit rewrites a marker into the unit its earlier results
+// use, so trends stay comparable. Units that cannot be converted are kept.
+func (h *LabHandler) convertToStoredUnit(userID int, marker *ImportMarkerRequest, result *LabImportResult) {
+	var stored string
+	err := h.db.Get(&stored, `
+		SELECT unit FROM lab_results
+		WHERE user_id = $1 AND lower(marker_name) = lower($2) AND unit IS NOT NULL AND unit <> ''
+		ORDER BY test_date DESC, id DESC
+		LIMIT 1
+	`, userID, marker.MarkerName)
+	if err != nil || marker.Unit == "" || strings.EqualFold(stored, marker.Unit) {
 		return
 	}
-	defer rows.Close()
 
-	var trends []models.LabTrend
-	for rows.Next() {
-		var markerName string
-		if err := rows.Scan(&markerName); err != nil {
-			continue
+	from, ok1 := fhir.UCUM(marker.Unit)
+	to, ok2 := fhir.UCUM(stored)
+	if !ok1 || !ok2 {
+		return
+	}
+	if _, ok := fhir.Convert(marker.MarkerName, 1, from, to); !ok {
+		result.Warnings = append(result.Warnings,
+			fmt.Sprintf("%s: %s cannot be converted to %s, kept as is", marker.MarkerName, marker.Unit, stored))
+		return
+	}
+
+	for _, v := range []**float64{&marker.Value, &marker.ReferenceMin, &marker.ReferenceMax} {
+		if *v != nil {
+			converted, _ := fhir.Convert(marker.MarkerName, **v, from, to)
+			*v = &converted
+		}
+	}
+	if from != to {
+		note := fmt.Sprintf("converted from %s", marker.Unit)
+		if marker.Notes != "" {
+			note = marker.Notes + "; " + note
 		}
+		marker.Notes = note
+	}
+	marker.Unit = stored
+}
 
-		var results []models.LabResult
-		h.db.Select(&results, `
-			SELECT * FROM lab_results
-			WHERE user_id = $1 AND marker_name = $2 AND value IS NOT NULL
-			ORDER BY test_date ASC
-		`, userID, markerName)
+// GetTrends returns every marker's results with slopes, the projected
+// crossing of a reference bound, the latest change against the reference
+// change value and outliers
+func (h *LabHandler) GetTrends(w http.ResponseWriter, r *http.Request) {
+	userID := 1
 
-		if len(results) == 0 {
-			continue
-		}
+	trends, err := loadLabTrends(h.db, userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 
-		trend := models.LabTrend{
-			MarkerName: markerName,
-		}
+	respondJSON(w, http.StatusOK, trends)
+}
 
-		if results[0].Unit != nil {
-			trend.Unit = *results[0].Unit
-		}
+// GetStack returns the supplement stack that was active on the result's test date
+func (h *LabHandler) GetStack(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
 
-		for _, r := range results {
-			if r.Value != nil {
-				trend.DataPoints = append(trend.DataPoints, models.LabTrendPoint{
-					Date:  r.TestDate,
-					Value: *r.Value,
-				})
-			}
-		}
+	var result models.LabResult
+	err = h.db.Get(&result, `SELECT * FROM lab_results WHERE id = $1`, id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Lab result not found")
+		return
+	}
 
-		trends = append(trends, trend)
+	supplements, err := stackAsOf(h.db, result.UserID, result.TestDate)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
-	respondJSON(w, http.StatusOK, trends)
+	respondJSON(w, http.StatusOK, models.StackSnapshot{
+		Date:        result.TestDate,
+		Supplements: supplements,
+	})
 }
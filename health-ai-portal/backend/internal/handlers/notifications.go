@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/models"
+	"health-ai-portal/pkg/schedule"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// routableTypes are the reminder types a channel route can be set for
+var routableTypes = map[string]bool{"supplement": true, "lab": true, "workout": true, "default": true}
+
+type NotificationHandler struct {
+	db             *database.DB
+	channels       []string
+	vapidPublicKey string
+}
+
+// NewNotificationHandler takes the names of the configured channels and the
+// VAPID public key browsers need to subscribe
+func NewNotificationHandler(db *database.DB, channels []string, vapidPublicKey string) *NotificationHandler {
+	return &NotificationHandler{db: db, channels: channels, vapidPublicKey: vapidPublicKey}
+}
+
+// GetSettings returns email, quiet hours and per-type channel routes
+func (h *NotificationHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	var user models.User
+	if err := h.db.Get(&user, `SELECT * FROM users WHERE id = $1`, userID); err != nil {
+		respondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	var routes []models.NotificationRoute
+	if err := h.db.Select(&routes, `SELECT * FROM notification_routes WHERE user_id = $1 ORDER BY id`, userID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	settings := models.NotificationSettings{
+		Email:             user.NotificationEmail,
+		QuietHoursStart:   user.QuietHoursStart,
+		QuietHoursEnd:     user.QuietHoursEnd,
+		Routes:            make(map[string][]string),
+		AvailableChannels: h.channels,
+	}
+	for _, route := range routes {
+		settings.Routes[route.ReminderType] = append(settings.Routes[route.ReminderType], route.Channel)
+	}
+
+	respondJSON(w, http.StatusOK, settings)
+}
+
+// UpdateSettings changes email and quiet hours and replaces the routes of
+// every reminder type present in the request (an empty list removes them)
+func (h *NotificationHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	var input models.NotificationSettingsUpdate
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	quietStart, ok := normalizeOptionalClock(input.QuietHoursStart)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "quiet_hours_start must be HH:MM")
+		return
+	}
+	quietEnd, ok := normalizeOptionalClock(input.QuietHoursEnd)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "quiet_hours_end must be HH:MM")
+		return
+	}
+
+	for typ, channels := range input.Routes {
+		if !routableTypes[typ] {
+			respondError(w, http.StatusBadRequest, "Unknown reminder type: "+typ)
+			return
+		}
+		for _, c := range channels {
+			if !containsString(h.channels, c) {
+				respondError(w, http.StatusBadRequest, "Channel not configured: "+c)
+				return
+			}
+		}
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	// "" clears a field, so NULLIF turns it into NULL while a missing field keeps the column
+	_, err = tx.Exec(`
+		UPDATE users SET
+			notification_email = CASE WHEN $2::text IS NULL THEN notification_email ELSE NULLIF($2, '') END,
+			quiet_hours_start = CASE WHEN $3::text IS NULL THEN quiet_hours_start ELSE NULLIF($3, '')::time END,
+			quiet_hours_end = CASE WHEN $4::text IS NULL THEN quiet_hours_end ELSE NULLIF($4, '')::time END,
+			updated_at = NOW()
+		WHERE id = $1
+	`, userID, input.Email, quietStart, quietEnd)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	for typ, channels := range input.Routes {
+		if _, err := tx.Exec(`DELETE FROM notification_routes WHERE user_id = $1 AND reminder_type = $2`, userID, typ); err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		for _, c := range channels {
+			_, err := tx.Exec(`
+				INSERT INTO notification_routes (user_id, reminder_type, channel) VALUES ($1, $2, $3)
+				ON CONFLICT DO NOTHING
+			`, userID, typ, c)
+			if err != nil {
+				respondError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.GetSettings(w, r)
+}
+
+// normalizeOptionalClock keeps nil and "" as they are and normalizes HH:MM
+func normalizeOptionalClock(v *string) (*string, bool) {
+	if v == nil || *v == "" {
+		return v, true
+	}
+	clock, ok := schedule.NormalizeClock(*v)
+	if !ok {
+		return nil, false
+	}
+	return &clock, true
+}
+
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// VAPIDKey returns the public key for PushManager.subscribe
+func (h *NotificationHandler) VAPIDKey(w http.ResponseWriter, r *http.Request) {
+	if h.vapidPublicKey == "" {
+		respondError(w, http.StatusNotFound, "Web Push is not configured")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"public_key": h.vapidPublicKey})
+}
+
+func (h *NotificationHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	subs := []models.PushSubscription{}
+	if err := h.db.Select(&subs, `SELECT * FROM push_subscriptions WHERE user_id = $1 ORDER BY created_at`, userID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, subs)
+}
+
+// Subscribe stores a browser push subscription; re-subscribing the same
+// endpoint refreshes its keys
+func (h *NotificationHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	var input models.PushSubscriptionCreate
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if input.Endpoint == "" || input.Keys.P256dh == "" || input.Keys.Auth == "" {
+		respondError(w, http.StatusBadRequest, "endpoint, keys.p256dh and keys.auth are required")
+		return
+	}
+
+	userAgent := r.UserAgent()
+	var sub models.PushSubscription
+	err := h.db.Get(&sub, `
+		INSERT INTO push_subscriptions (user_id, endpoint, p256dh, auth, device_name, user_agent)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (endpoint) DO UPDATE SET
+			user_id = EXCLUDED.user_id,
+			p256dh = EXCLUDED.p256dh,
+			auth = EXCLUDED.auth,
+			device_name = COALESCE(EXCLUDED.device_name, push_subscriptions.device_name),
+			user_agent = EXCLUDED.user_agent
+		RETURNING *
+	`, userID, input.Endpoint, input.Keys.P256dh, input.Keys.Auth, input.DeviceName, userAgent)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, sub)
+}
+
+func (h *NotificationHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	_, err = h.db.Exec(`DELETE FROM push_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Deliveries returns the delivery log, newest first
+// (filters: reminder_id, channel, status, from, to, limit)
+func (h *NotificationHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	query := `
+		SELECT d.*, r.title, r.reminder_type
+		FROM reminder_deliveries d
+		JOIN reminders r ON d.reminder_id = r.id
+		WHERE d.user_id = $1`
+	args := []interface{}{userID}
+
+	q := r.URL.Query()
+	if v := q.Get("reminder_id"); v != "" {
+		args = append(args, v)
+		query += ` AND d.reminder_id = $` + strconv.Itoa(len(args))
+	}
+	if v := q.Get("channel"); v != "" {
+		args = append(args, v)
+		query += ` AND d.channel = $` + strconv.Itoa(len(args))
+	}
+	if v := q.Get("status"); v != "" {
+		args = append(args, v)
+		query += ` AND d.status = $` + strconv.Itoa(len(args))
+	}
+	if v := q.Get("from"); v != "" {
+		args = append(args, v)
+		query += ` AND d.scheduled_for >= $` + strconv.Itoa(len(args)) + `::date`
+	}
+	if v := q.Get("to"); v != "" {
+		args = append(args, v)
+		query += ` AND d.scheduled_for < $` + strconv.Itoa(len(args)) + `::date + 1`
+	}
+
+	limit := 100
+	if v := q.Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 500 {
+			limit = n
+		}
+	}
+	query += ` ORDER BY d.scheduled_for DESC, d.id DESC LIMIT ` + strconv.Itoa(limit)
+
+	deliveries := []models.ReminderDeliveryWithTitle{}
+	if err := h.db.Select(&deliveries, query, args...); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, deliveries)
+}
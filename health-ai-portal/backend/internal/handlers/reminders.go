@@ -2,15 +2,24 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
+	"time"
 
 	"health-ai-portal/internal/database"
 	"health-ai-portal/internal/models"
+	"health-ai-portal/internal/scheduler"
+	"health-ai-portal/pkg/rrule"
+	"health-ai-portal/pkg/schedule"
 
 	"github.com/go-chi/chi/v5"
 )
 
+// defaultSnoozeMinutes is used when a snooze request gives no duration
+const defaultSnoozeMinutes = 10
+
 type ReminderHandler struct {
 	db *database.DB
 }
@@ -73,9 +82,25 @@ func (h *ReminderHandler) Create(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "Title is required")
 		return
 	}
+	if err := validateReminderSchedule(input.Time, input.DaysOfWeek); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	// Convert days_of_week to JSON
-	daysJSON, _ := json.Marshal(input.DaysOfWeek)
+	// Without an rrule the days_of_week list becomes one (daily when empty)
+	days := input.DaysOfWeek
+	if input.RRule == nil && days == nil {
+		days = []int{}
+	}
+	rec, err := parseRecurrence(input.RRule, days, input.DTStart, input.ExDates)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if rec.dtstart == nil {
+		today := time.Now().In(loadUserLocation(h.db, userID)).Format("2006-01-02")
+		rec.dtstart = &today
+	}
 
 	isActive := true
 	if input.IsActive != nil {
@@ -83,11 +108,12 @@ func (h *ReminderHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var reminder models.Reminder
-	err := h.db.Get(&reminder, `
-		INSERT INTO reminders (user_id, reminder_type, title, description, time, days_of_week, is_active)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	err = h.db.Get(&reminder, `
+		INSERT INTO reminders (user_id, reminder_type, title, description, time, days_of_week, rrule, dtstart, exdates, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING *
-	`, userID, input.ReminderType, input.Title, input.Description, input.Time, daysJSON, isActive)
+	`, userID, input.ReminderType, input.Title, input.Description, input.Time,
+		rec.daysJSON, rec.rrule, rec.dtstart, rec.exdatesJSON, isActive)
 
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
@@ -109,11 +135,15 @@ func (h *ReminderHandler) Update(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+	if err := validateReminderSchedule(input.Time, input.DaysOfWeek); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	// Build update query dynamically
-	var daysJSON []byte
-	if input.DaysOfWeek != nil {
-		daysJSON, _ = json.Marshal(input.DaysOfWeek)
+	rec, err := parseRecurrence(input.RRule, input.DaysOfWeek, input.DTStart, input.ExDates)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
 	var reminder models.Reminder
@@ -124,10 +154,14 @@ func (h *ReminderHandler) Update(w http.ResponseWriter, r *http.Request) {
 			description = COALESCE($4, description),
 			time = COALESCE($5, time),
 			days_of_week = COALESCE($6, days_of_week),
-			is_active = COALESCE($7, is_active)
+			is_active = COALESCE($7, is_active),
+			rrule = COALESCE($8, rrule),
+			dtstart = COALESCE($9, dtstart),
+			exdates = COALESCE($10, exdates)
 		WHERE id = $1
 		RETURNING *
-	`, id, input.ReminderType, input.Title, input.Description, input.Time, daysJSON, input.IsActive)
+	`, id, input.ReminderType, input.Title, input.Description, input.Time, rec.daysJSON, input.IsActive,
+		rec.rrule, rec.dtstart, rec.exdatesJSON)
 
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
@@ -175,12 +209,11 @@ func (h *ReminderHandler) Toggle(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, reminder)
 }
 
-// GetTodayReminders returns reminders for today
+// GetToday returns today's reminders in the user's timezone, filtered by
+// days_of_week, with their fire time and delivery status
 func (h *ReminderHandler) GetToday(w http.ResponseWriter, r *http.Request) {
 	userID := 1
 
-	// Get current day of week (1=Monday, 7=Sunday)
-	// PostgreSQL uses 0=Sunday, but we'll use 1-7 in our JSON
 	reminders := []models.Reminder{}
 	err := h.db.Select(&reminders, `
 		SELECT * FROM reminders
@@ -194,5 +227,298 @@ func (h *ReminderHandler) GetToday(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondJSON(w, http.StatusOK, reminders)
+	loc := loadUserLocation(h.db, userID)
+	now := time.Now().In(loc)
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	delivered, err := h.deliveryStatuses(userID, dayStart, dayEnd)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	result := []models.ReminderOccurrence{}
+	for _, rem := range reminders {
+		if !scheduler.OnDay(rem, now) {
+			continue
+		}
+		occ := models.ReminderOccurrence{Reminder: rem}
+		if at, ok := scheduler.OccurrenceOn(rem, now, loc); ok {
+			occ.OccursAt = &at
+			occ.Status = occurrenceStatus(delivered, rem.ID, at, now)
+		}
+		result = append(result, occ)
+
+		if rem.SnoozedUntil != nil && !rem.SnoozedUntil.Before(dayStart) && rem.SnoozedUntil.Before(dayEnd) {
+			at := *rem.SnoozedUntil
+			result = append(result, models.ReminderOccurrence{
+				Reminder: rem,
+				OccursAt: &at,
+				Snoozed:  true,
+				Status:   occurrenceStatus(delivered, rem.ID, at, now),
+			})
+		}
+	}
+	sortOccurrences(result)
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// GetUpcoming returns occurrences in the next ?hours=N (default 24, max 336)
+func (h *ReminderHandler) GetUpcoming(w http.ResponseWriter, r *http.Request) {
+	userID := 1
+
+	hours := 24
+	if v := r.URL.Query().Get("hours"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 || parsed > 336 {
+			respondError(w, http.StatusBadRequest, "hours must be between 1 and 336")
+			return
+		}
+		hours = parsed
+	}
+
+	var reminders []models.Reminder
+	err := h.db.Select(&reminders, `
+		SELECT * FROM reminders
+		WHERE user_id = $1 AND is_active = true
+	`, userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	loc := loadUserLocation(h.db, userID)
+	now := time.Now().In(loc)
+	until := now.Add(time.Duration(hours) * time.Hour)
+
+	result := []models.ReminderOccurrence{}
+	for _, rem := range reminders {
+		for _, at := range scheduler.Occurrences(rem, now, until, loc) {
+			at := at
+			result = append(result, models.ReminderOccurrence{Reminder: rem, OccursAt: &at, Status: "upcoming"})
+		}
+		if rem.SnoozedUntil != nil && rem.SnoozedUntil.After(now) && !rem.SnoozedUntil.After(until) {
+			at := *rem.SnoozedUntil
+			result = append(result, models.ReminderOccurrence{Reminder: rem, OccursAt: &at, Snoozed: true, Status: "upcoming"})
+		}
+	}
+	sortOccurrences(result)
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// Occurrences expands one reminder's recurrence for ?from=&to= (YYYY-MM-DD,
+// default: the next 60 days; at most a year)
+func (h *ReminderHandler) Occurrences(w http.ResponseWriter, r *http.Request) {
+	userID := 1
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var reminder models.Reminder
+	if err := h.db.Get(&reminder, `SELECT * FROM reminders WHERE id = $1`, id); err != nil {
+		respondError(w, http.StatusNotFound, "Reminder not found")
+		return
+	}
+
+	loc := loadUserLocation(h.db, userID)
+	now := time.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 0, 60)
+	if v := r.URL.Query().Get("from"); v != "" {
+		if from, err = time.ParseInLocation("2006-01-02", v, loc); err != nil {
+			respondError(w, http.StatusBadRequest, "from must be YYYY-MM-DD")
+			return
+		}
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = time.ParseInLocation("2006-01-02", v, loc); err != nil {
+			respondError(w, http.StatusBadRequest, "to must be YYYY-MM-DD")
+			return
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+	if !to.After(from) || to.Sub(from) > 366*24*time.Hour {
+		respondError(w, http.StatusBadRequest, "range must be positive and at most a year")
+		return
+	}
+
+	occurrences := scheduler.Occurrences(reminder, from.Add(-time.Nanosecond), to.Add(-time.Nanosecond), loc)
+	if occurrences == nil {
+		occurrences = []time.Time{}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"reminder_id": reminder.ID,
+		"rrule":       scheduler.Rule(reminder).String(),
+		"timezone":    loc.String(),
+		"occurrences": occurrences,
+	})
+}
+
+// Snooze fires the reminder again after {"minutes": N} (default 10)
+func (h *ReminderHandler) Snooze(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	input := models.ReminderSnooze{Minutes: defaultSnoozeMinutes}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	reminder, err := snoozeReminder(h.db, id, input.Minutes)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, reminder)
+}
+
+// snoozeReminder schedules a one-off repeat. Shared with notifiers that offer
+// a snooze button.
+func snoozeReminder(db *database.DB, id int, minutes int) (*models.Reminder, error) {
+	if minutes == 0 {
+		minutes = defaultSnoozeMinutes
+	}
+	if minutes < 1 || minutes > 24*60 {
+		return nil, fmt.Errorf("minutes must be between 1 and 1440")
+	}
+
+	var reminder models.Reminder
+	err := db.Get(&reminder, `
+		UPDATE reminders SET snoozed_until = $2
+		WHERE id = $1
+		RETURNING *
+	`, id, time.Now().Add(time.Duration(minutes)*time.Minute).Truncate(time.Second))
+	if err != nil {
+		return nil, fmt.Errorf("reminder not found")
+	}
+	return &reminder, nil
+}
+
+type occurrenceKey struct {
+	reminderID int
+	at         int64
+}
+
+// deliveryStatuses maps delivered occurrences in [from, to) to their status
+func (h *ReminderHandler) deliveryStatuses(userID int, from, to time.Time) (map[occurrenceKey]string, error) {
+	var deliveries []models.ReminderDelivery
+	err := h.db.Select(&deliveries, `
+		SELECT * FROM reminder_deliveries
+		WHERE user_id = $1 AND scheduled_for >= $2 AND scheduled_for < $3
+		ORDER BY id
+	`, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make(map[occurrenceKey]string, len(deliveries))
+	for _, d := range deliveries {
+		key := occurrenceKey{d.ReminderID, d.ScheduledFor.Unix()}
+		// Any successful channel counts as delivered
+		if statuses[key] != "sent" {
+			statuses[key] = d.Status
+		}
+	}
+	return statuses, nil
+}
+
+func occurrenceStatus(delivered map[occurrenceKey]string, reminderID int, at, now time.Time) string {
+	if status, ok := delivered[occurrenceKey{reminderID, at.Unix()}]; ok {
+		return status
+	}
+	if at.After(now) {
+		return "upcoming"
+	}
+	return "due"
+}
+
+// sortOccurrences orders by fire time; reminders without a time go last
+func sortOccurrences(list []models.ReminderOccurrence) {
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i].OccursAt, list[j].OccursAt
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.Before(*b)
+	})
+}
+
+// recurrence holds validated recurrence columns; nil fields are left unchanged
+type recurrence struct {
+	rrule       *string
+	daysJSON    []byte
+	dtstart     *string
+	exdatesJSON []byte
+}
+
+// parseRecurrence validates the recurrence input. An rrule wins over
+// days_of_week; days_of_week alone is converted to an rrule. The legacy
+// days_of_week column mirrors simple weekly rules and is JSON null otherwise.
+func parseRecurrence(rruleText *string, days []int, dtstart *string, exdates []string) (recurrence, error) {
+	var rec recurrence
+
+	switch {
+	case rruleText != nil && *rruleText != "":
+		rule, err := rrule.Parse(*rruleText)
+		if err != nil {
+			return rec, fmt.Errorf("rrule: %v", err)
+		}
+		text := rule.String()
+		rec.rrule = &text
+		rec.daysJSON = []byte("null")
+		if weekdays, ok := rule.DaysOfWeek(); ok {
+			rec.daysJSON, _ = json.Marshal(weekdays)
+		}
+	case days != nil:
+		text := rrule.FromDaysOfWeek(days).String()
+		rec.rrule = &text
+		rec.daysJSON, _ = json.Marshal(days)
+	}
+
+	if dtstart != nil {
+		if _, err := time.Parse("2006-01-02", *dtstart); err != nil {
+			return rec, fmt.Errorf("dtstart must be YYYY-MM-DD")
+		}
+		rec.dtstart = dtstart
+	}
+
+	if exdates != nil {
+		for _, d := range exdates {
+			if _, err := time.Parse("2006-01-02", d); err != nil {
+				return rec, fmt.Errorf("exdates must contain YYYY-MM-DD dates")
+			}
+		}
+		rec.exdatesJSON, _ = json.Marshal(exdates)
+	}
+
+	return rec, nil
+}
+
+// validateReminderSchedule checks the clock time and ISO weekdays
+func validateReminderSchedule(clock *string, days []int) error {
+	if clock != nil {
+		if _, ok := schedule.NormalizeClock(*clock); !ok {
+			return fmt.Errorf("time must be HH:MM")
+		}
+	}
+	for _, d := range days {
+		if d < 1 || d > 7 {
+			return fmt.Errorf("days_of_week must contain ISO weekdays 1 (Mon) to 7 (Sun)")
+		}
+	}
+	return nil
 }
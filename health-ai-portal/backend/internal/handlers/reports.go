@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/models"
+	"health-ai-portal/internal/report"
+	"health-ai-portal/pkg/pdfdoc"
+)
+
+// Fonts tried when none is configured; they must cover Cyrillic
+var (
+	reportFontCandidates = []string{
+		"/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf",
+		"/usr/share/fonts/dejavu/DejaVuSans.ttf",
+		"/usr/share/fonts/TTF/DejaVuSans.ttf",
+		"/Library/Fonts/Arial Unicode.ttf",
+		"/System/Library/Fonts/Supplemental/Arial.ttf",
+		`C:\Windows\Fonts\arial.ttf`,
+	}
+	reportBoldFontCandidates = []string{
+		"/usr/share/fonts/truetype/dejavu/DejaVuSans-Bold.ttf",
+		"/usr/share/fonts/dejavu/DejaVuSans-Bold.ttf",
+		"/usr/share/fonts/TTF/DejaVuSans-Bold.ttf",
+		"/System/Library/Fonts/Supplemental/Arial Bold.ttf",
+		`C:\Windows\Fonts\arialbd.ttf`,
+	}
+)
+
+type ReportHandler struct {
+	db       *database.DB
+	font     string
+	boldFont string
+
+	mu    sync.Mutex
+	fonts *report.Fonts
+}
+
+func NewReportHandler(db *database.DB, font, boldFont string) *ReportHandler {
+	return &ReportHandler{db: db, font: font, boldFont: boldFont}
+}
+
+// Clinician renders a printable summary for a doctor's visit. Query:
+// from/to (YYYY-MM-DD, default the year up to today), sections (comma list,
+// default all) and lang (ru or en, default ru).
+func (h *ReportHandler) Clinician(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	opts, err := clinicianOptions(r, loadUserLocation(h.db, userID))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	fonts, err := h.loadFonts()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	data, err := h.clinicianData(userID, opts)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var buf bytes.Buffer
+	if _, err := report.Clinician(*data, opts, *fonts).WriteTo(&buf); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("clinician-report-%s.pdf", opts.To.Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, filename))
+	w.Write(buf.Bytes())
+}
+
+func clinicianOptions(r *http.Request, loc *time.Location) (report.ClinicianOptions, error) {
+	q := r.URL.Query()
+	opts := report.ClinicianOptions{
+		To:       civilDate(time.Now().In(loc)),
+		Sections: map[string]bool{},
+		Lang:     report.LangRU,
+	}
+
+	var err error
+	if v := q.Get("to"); v != "" {
+		if opts.To, err = time.Parse("2006-01-02", v); err != nil {
+			return opts, fmt.Errorf("to must be YYYY-MM-DD")
+		}
+	}
+	opts.From = opts.To.AddDate(-1, 0, 0)
+	if v := q.Get("from"); v != "" {
+		if opts.From, err = time.Parse("2006-01-02", v); err != nil {
+			return opts, fmt.Errorf("from must be YYYY-MM-DD")
+		}
+	}
+	if opts.From.After(opts.To) {
+		return opts, fmt.Errorf("from must not be after to")
+	}
+
+	if v := q.Get("lang"); v != "" {
+		if v != report.LangRU && v != report.LangEN {
+			return opts, fmt.Errorf("lang must be ru or en")
+		}
+		opts.Lang = v
+	}
+
+	known := map[string]bool{}
+	for _, s := range report.Sections {
+		known[s] = true
+	}
+	if v := q.Get("sections"); v != "" {
+		for _, s := range strings.Split(v, ",") {
+			s = strings.TrimSpace(s)
+			if !known[s] {
+				return opts, fmt.Errorf("unknown section %q; expected %s", s, strings.Join(report.Sections, ", "))
+			}
+			opts.Sections[s] = true
+		}
+	} else {
+		opts.Sections = known
+	}
+	return opts, nil
+}
+
+func (h *ReportHandler) clinicianData(userID int, opts report.ClinicianOptions) (*report.ClinicianData, error) {
+	data := &report.ClinicianData{Generated: time.Now()}
+	if err := h.db.Get(&data.Patient, `SELECT * FROM users WHERE id = $1`, userID); err != nil {
+		return nil, fmt.Errorf("user: %v", err)
+	}
+
+	stack, err := stackAsOf(h.db, userID, opts.To)
+	if err != nil {
+		return nil, err
+	}
+	data.Stack = stack
+
+	var results []models.LabResult
+	err = h.db.Select(&results, `
+		SELECT * FROM lab_results
+		WHERE user_id = $1 AND test_date BETWEEN $2 AND $3
+		ORDER BY test_date, id
+	`, userID, opts.From, opts.To)
+	if err != nil {
+		return nil, err
+	}
+	data.Markers = report.SelectMarkers(results)
+
+	// Only combinations of supplements that are both in the stack matter
+	active := make(map[int]bool, len(stack))
+	for _, s := range stack {
+		active[s.ID] = true
+	}
+	var interactions []models.InteractionWithNames
+	err = h.db.Select(&interactions, `
+		SELECT i.*, s1.name AS supplement_1_name, s2.name AS supplement_2_name
+		FROM interactions i
+		JOIN supplements s1 ON i.supplement_1_id = s1.id
+		JOIN supplements s2 ON i.supplement_2_id = s2.id
+		WHERE i.interaction_type = 'critical' AND s1.user_id = $1
+		ORDER BY s1.name, s2.name
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, in := range interactions {
+		if active[in.Supplement1ID] && active[in.Supplement2ID] {
+			data.Interactions = append(data.Interactions, in)
+		}
+	}
+
+	var cycles []models.Cycle
+	err = h.db.Select(&cycles, `
+		SELECT * FROM cycles
+		WHERE user_id = $1 AND cycle_date BETWEEN $2 AND $3
+		ORDER BY cycle_date DESC, id DESC
+		LIMIT 1
+	`, userID, opts.From, opts.To)
+	if err != nil {
+		return nil, err
+	}
+	if len(cycles) > 0 {
+		data.Cycle = &cycles[0]
+	}
+	return data, nil
+}
+
+// loadFonts loads the report fonts on first use. A missing bold face falls
+// back to the regular one.
+func (h *ReportHandler) loadFonts() (*report.Fonts, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.fonts != nil {
+		return h.fonts, nil
+	}
+
+	regular, err := loadReportFont(h.font, reportFontCandidates)
+	if err != nil {
+		return nil, err
+	}
+	if regular == nil {
+		return nil, fmt.Errorf("report font not found; set REPORT_FONT to a TrueType font with Cyrillic")
+	}
+	bold, err := loadReportFont(h.boldFont, reportBoldFontCandidates)
+	if err != nil {
+		return nil, err
+	}
+	h.fonts = &report.Fonts{Regular: regular, Bold: bold}
+	return h.fonts, nil
+}
+
+// loadReportFont loads the configured font, or the first candidate that
+// exists; nil when there is none
+func loadReportFont(configured string, candidates []string) (*pdfdoc.Font, error) {
+	if configured != "" {
+		return pdfdoc.LoadFont(configured)
+	}
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			return pdfdoc.LoadFont(path)
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/models"
+	"health-ai-portal/pkg/schedule"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type ScheduleHandler struct {
+	db *database.DB
+}
+
+func NewScheduleHandler(db *database.DB) *ScheduleHandler {
+	return &ScheduleHandler{db: db}
+}
+
+// GetSlots returns the user's slot clock times (defaults merged in) and timezone
+func (h *ScheduleHandler) GetSlots(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	loc := loadUserLocation(h.db, userID)
+	slots, err := loadSlots(h.db, userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"timezone": loc.String(),
+		"slots":    slots,
+	})
+}
+
+// UpdateSlots sets the user's timezone and/or slot clock times
+func (h *ScheduleHandler) UpdateSlots(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	var input models.ScheduleSlotUpdate
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if input.Timezone != nil {
+		if _, err := time.LoadLocation(*input.Timezone); err != nil {
+			respondError(w, http.StatusBadRequest, "Unknown timezone: "+*input.Timezone)
+			return
+		}
+	}
+	for i, s := range input.Slots {
+		if !schedule.IsValidSlot(s.Slot) {
+			respondError(w, http.StatusBadRequest, "Unknown slot: "+s.Slot)
+			return
+		}
+		clock, ok := schedule.NormalizeClock(s.ClockTime)
+		if !ok {
+			respondError(w, http.StatusBadRequest, "clock_time must be HH:MM for slot "+s.Slot)
+			return
+		}
+		input.Slots[i].ClockTime = clock
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	if input.Timezone != nil {
+		if _, err := tx.Exec(`UPDATE users SET timezone = $2, updated_at = NOW() WHERE id = $1`, userID, *input.Timezone); err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	for _, s := range input.Slots {
+		_, err := tx.Exec(`
+			INSERT INTO schedule_slots (user_id, slot, clock_time, label)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id, slot) DO UPDATE SET
+				clock_time = EXCLUDED.clock_time,
+				label = COALESCE(EXCLUDED.label, schedule_slots.label)
+		`, userID, s.Slot, s.ClockTime, s.Label)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.GetSlots(w, r)
+}
+
+// GetDay returns the ordered intake plan for ?date=YYYY-MM-DD (default: today
+// in the user's timezone), honouring days_of_week and on/off cycling.
+func (h *SupplementHandler) GetDay(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	loc := loadUserLocation(h.db, userID)
+	date := time.Now().In(loc)
+	if d := r.URL.Query().Get("date"); d != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", d, loc)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "date must be YYYY-MM-DD")
+			return
+		}
+		date = parsed
+	}
+
+	day, err := loadDaySchedule(h.db, userID, date, loc)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, day)
+}
+
+// loadUserLocation returns the user's timezone, falling back to UTC
+func loadUserLocation(db *database.DB, userID int) *time.Location {
+	var tz *string
+	if err := db.Get(&tz, `SELECT timezone FROM users WHERE id = $1`, userID); err != nil || tz == nil {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// loadSlots returns the user's slots with defaults filled in, ordered by time
+func loadSlots(db *database.DB, userID int) ([]schedule.Slot, error) {
+	var rows []models.ScheduleSlot
+	if err := db.Select(&rows, `SELECT * FROM schedule_slots WHERE user_id = $1`, userID); err != nil {
+		return nil, err
+	}
+
+	custom := make([]schedule.Slot, 0, len(rows))
+	for _, row := range rows {
+		s := schedule.Slot{Name: row.Slot}
+		s.ClockTime, _ = schedule.NormalizeClock(row.ClockTime)
+		if row.Label != nil {
+			s.Label = *row.Label
+		}
+		custom = append(custom, s)
+	}
+	return schedule.Merge(custom), nil
+}
+
+// loadSupplementSlots returns explicit slot assignments keyed by supplement ID
+func loadSupplementSlots(db *database.DB, ids []int) (map[int][]string, error) {
+	assigned := make(map[int][]string)
+	if len(ids) == 0 {
+		return assigned, nil
+	}
+
+	var rows []models.SupplementSlot
+	if err := db.Select(&rows, `SELECT * FROM supplement_slots WHERE supplement_id = ANY($1)`, pq.Array(ids)); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		assigned[row.SupplementID] = append(assigned[row.SupplementID], row.Slot)
+	}
+	return assigned, nil
+}
+
+// replaceSupplementSlots stores the slot list for a supplement
+func replaceSupplementSlots(tx *sqlx.Tx, supplementID int, slots []string) error {
+	if _, err := tx.Exec(`DELETE FROM supplement_slots WHERE supplement_id = $1`, supplementID); err != nil {
+		return err
+	}
+	for _, slot := range slots {
+		if _, err := tx.Exec(`
+			INSERT INTO supplement_slots (supplement_id, slot) VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, supplementID, slot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validSlots reports the first unknown slot name, if any
+func validSlots(slots []string) (string, bool) {
+	for _, s := range slots {
+		if !schedule.IsValidSlot(s) {
+			return s, false
+		}
+	}
+	return "", true
+}
+
+// loadDaySchedule builds the ordered plan for one day from the active stack
+func loadDaySchedule(db *database.DB, userID int, date time.Time, loc *time.Location) (models.DaySchedule, error) {
+	var supplements []models.Supplement
+	err := db.Select(&supplements, `
+		SELECT * FROM supplements
+		WHERE user_id = $1 AND status = 'active'
+		ORDER BY name
+	`, userID)
+	if err != nil {
+		return models.DaySchedule{}, err
+	}
+
+	slots, err := loadSlots(db, userID)
+	if err != nil {
+		return models.DaySchedule{}, err
+	}
+
+	ids := make([]int, len(supplements))
+	for i, s := range supplements {
+		ids[i] = s.ID
+	}
+	assigned, err := loadSupplementSlots(db, ids)
+	if err != nil {
+		return models.DaySchedule{}, err
+	}
+
+	return buildDaySchedule(date, loc, slots, supplements, assigned), nil
+}
+
+func buildDaySchedule(date time.Time, loc *time.Location, slots []schedule.Slot, supplements []models.Supplement, assigned map[int][]string) models.DaySchedule {
+	day := models.DaySchedule{
+		Date:        time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc),
+		Timezone:    loc.String(),
+		Items:       []models.ScheduleItem{},
+		AsNeeded:    []models.Supplement{},
+		OffCycle:    []models.Supplement{},
+		Unscheduled: []models.Supplement{},
+	}
+
+	bySlot := make(map[string][]models.Supplement)
+	for _, s := range supplements {
+		names, rule := supplementSchedule(s, slots, assigned[s.ID])
+
+		if rule.Frequency == "as_needed" {
+			day.AsNeeded = append(day.AsNeeded, s)
+			continue
+		}
+		if rule.InOffWeeks(date) {
+			day.OffCycle = append(day.OffCycle, s)
+			continue
+		}
+		if !rule.IsDoseDay(date) {
+			continue
+		}
+		if len(names) == 0 {
+			day.Unscheduled = append(day.Unscheduled, s)
+			continue
+		}
+		for _, name := range names {
+			bySlot[name] = append(bySlot[name], s)
+		}
+	}
+
+	for _, slot := range slots {
+		supps, ok := bySlot[slot.Name]
+		if !ok {
+			continue
+		}
+		day.Items = append(day.Items, models.ScheduleItem{
+			TimeOfDay:   slot.ClockTime,
+			Slot:        slot.Name,
+			Label:       slot.Label,
+			Time:        slot.At(date, loc),
+			Supplements: supps,
+		})
+	}
+
+	return day
+}
+
+// supplementSchedule resolves a supplement's slots and day rule, falling back
+// to the legacy time_of_day text when nothing structured is stored.
+func supplementSchedule(s models.Supplement, slots []schedule.Slot, assigned []string) ([]string, schedule.Rule) {
+	timeOfDay, category := "", ""
+	if s.TimeOfDay != nil {
+		timeOfDay = *s.TimeOfDay
+	}
+	if s.Category != nil {
+		category = *s.Category
+	}
+	legacySlots, legacyDays := schedule.NormalizeTimeOfDay(timeOfDay, category, slots)
+
+	names := assigned
+	if len(names) == 0 {
+		names = legacySlots
+	}
+
+	rule := schedule.Rule{
+		Frequency:     "daily",
+		TimesPerWeek:  s.TimesPerWeek,
+		CycleOnWeeks:  s.CycleOnWeeks,
+		CycleOffWeeks: s.CycleOffWeeks,
+		Anchor:        s.CreatedAt,
+	}
+	if s.Frequency != nil {
+		rule.Frequency = *s.Frequency
+	}
+	if s.CycleStartDate != nil {
+		rule.Anchor = *s.CycleStartDate
+	}
+	if s.DaysOfWeek != nil {
+		json.Unmarshal(*s.DaysOfWeek, &rule.DaysOfWeek)
+	}
+	if len(rule.DaysOfWeek) == 0 {
+		rule.DaysOfWeek = legacyDays
+	}
+
+	return names, rule
+}
@@ -0,0 +1,731 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/models"
+	"health-ai-portal/pkg/sheet"
+)
+
+const (
+	// maxSheetSize bounds an uploaded CSV or XLSX file
+	maxSheetSize = 20 << 20
+	// headerScanRows is how far down a sheet the header row is looked for
+	headerScanRows = 30
+)
+
+// Header captions recognised for each import field, lower case
+var (
+	labSheetFields = sheet.Fields{
+		"date":          {"дата", "дата анализа", "дата сдачи", "дата взятия", "date", "test date", "test_date"},
+		"marker":        {"показатель", "маркер", "анализ", "тест", "исследование", "наименование", "параметр", "marker", "marker_name", "test", "analyte", "parameter"},
+		"value":         {"результат", "значение", "value", "result"},
+		"unit":          {"ед", "ед. изм", "ед.изм", "единицы", "единица", "единицы измерения", "unit", "units"},
+		"reference":     {"референс", "референсные значения", "референсный интервал", "норма", "нормы", "reference", "reference range", "ref range", "range"},
+		"reference_min": {"норма от", "мин", "min", "ref min", "reference min", "reference_min"},
+		"reference_max": {"норма до", "макс", "max", "ref max", "reference max", "reference_max"},
+		"lab":           {"лаборатория", "lab", "lab name", "laboratory"},
+		"category":      {"категория", "группа", "раздел", "category", "group"},
+		"notes":         {"комментарий", "примечание", "заметки", "notes", "note", "comment"},
+	}
+	supplementSheetFields = sheet.Fields{
+		"name":           {"препарат", "добавка", "название", "наименование", "компонент", "item", "name", "supplement"},
+		"dose":           {"доза", "дозировка", "dose", "dosage"},
+		"time_of_day":    {"время", "время приема", "прием", "time", "timing", "time of day"},
+		"category":       {"категория", "группа", "category"},
+		"mechanism":      {"механизм", "эффект", "mechanism", "effect"},
+		"target":         {"цель", "цели", "target", "goal"},
+		"evidence_level": {"доказательность", "уровень доказательности", "evidence", "evidence level"},
+		"notes":          {"примечание", "обоснование", "комментарий", "notes", "note", "comment", "rationale"},
+	}
+)
+
+func sheetFields(kind string) (sheet.Fields, bool) {
+	switch kind {
+	case models.ImportKindLabs:
+		return labSheetFields, true
+	case models.ImportKindSupplements:
+		return supplementSheetFields, true
+	}
+	return nil, false
+}
+
+type SheetImportHandler struct {
+	db *database.DB
+}
+
+func NewSheetImportHandler(db *database.DB) *SheetImportHandler {
+	return &SheetImportHandler{db: db}
+}
+
+// SheetInfo describes one worksheet of an upload
+type SheetInfo struct {
+	Name      string `json:"name"`
+	Rows      int    `json:"rows"`
+	HeaderRow *int   `json:"header_row"` // detected, 1-based
+}
+
+// SheetLabRow is one lab result read from a spreadsheet row
+type SheetLabRow struct {
+	Row      int    `json:"row"` // 1-based, as the spreadsheet shows it
+	TestDate string `json:"test_date"`
+	LabName  string `json:"lab_name,omitempty"`
+	ImportMarkerRequest
+	Errors []string `json:"errors,omitempty"`
+
+	date time.Time
+}
+
+// SheetSupplementRow is one supplement read from a spreadsheet row
+type SheetSupplementRow struct {
+	Row int `json:"row"`
+	models.SupplementCreate
+	ExistingID *int     `json:"existing_id,omitempty"` // an active supplement with this name
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// SheetPreview is what an import would store. Rows with errors are skipped.
+type SheetPreview struct {
+	Kind        string               `json:"kind"`
+	Sheets      []SheetInfo          `json:"sheets"`
+	Sheet       string               `json:"sheet"`
+	HeaderRow   int                  `json:"header_row"` // 1-based
+	Header      []string             `json:"header"`
+	Mapping     map[string]string    `json:"mapping"` // field to header caption or column letter
+	Layout      string               `json:"layout,omitempty"`
+	Labs        []SheetLabRow        `json:"labs,omitempty"`
+	Supplements []SheetSupplementRow `json:"supplements,omitempty"`
+	Valid       int                  `json:"valid"`
+	Invalid     int                  `json:"invalid"`
+	Warnings    []string             `json:"warnings"`
+}
+
+// Lab layouts: one result per row, or a row per marker with a column per date
+const (
+	sheetLayoutRows  = "rows"
+	sheetLayoutDates = "dates"
+)
+
+// SupplementImportResult is the outcome of a supplement import
+type SupplementImportResult struct {
+	Imported   int                 `json:"imported"`
+	Total      int                 `json:"total"`
+	Results    []models.Supplement `json:"results"`
+	Duplicates []string            `json:"duplicates"`
+}
+
+type SheetImportResponse struct {
+	DryRun      bool                    `json:"dry_run"`
+	Preview     SheetPreview            `json:"preview"`
+	Labs        *LabImportResult        `json:"labs,omitempty"`
+	Supplements *SupplementImportResult `json:"supplements,omitempty"`
+	Profile     *models.ImportProfile   `json:"profile,omitempty"`
+}
+
+// sheetImportOptions are read from form fields or the query string
+type sheetImportOptions struct {
+	sheet       string
+	headerRow   int
+	mapping     map[string]string
+	testDate    string
+	labName     string
+	saveProfile string
+}
+
+// ImportLabs reads lab results from a CSV or XLSX upload. With ?dry_run=true
+// only the preview is returned; otherwise valid rows are imported like
+// POST /labs/import.
+func (h *SheetImportHandler) ImportLabs(w http.ResponseWriter, r *http.Request) {
+	h.importSheet(w, r, models.ImportKindLabs)
+}
+
+// ImportSupplements reads supplements from a CSV or XLSX upload. Names
+// already in the active stack are skipped.
+func (h *SheetImportHandler) ImportSupplements(w http.ResponseWriter, r *http.Request) {
+	h.importSheet(w, r, models.ImportKindSupplements)
+}
+
+func (h *SheetImportHandler) importSheet(w http.ResponseWriter, r *http.Request, kind string) {
+	userID := 1 // TODO: get from auth context
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	data, err := readUpload(w, r, maxSheetSize)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Failed to read file: "+err.Error())
+		return
+	}
+	sheets, err := sheet.Read(data)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	opts, err := h.sheetOptions(r, userID, kind)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	preview, err := buildSheetPreview(sheets, kind, opts)
+	if err != nil {
+		respondError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	response := SheetImportResponse{DryRun: dryRun}
+	if kind == models.ImportKindSupplements {
+		if err := h.markExistingSupplements(userID, preview); err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	if !dryRun {
+		if opts.saveProfile != "" {
+			profile, err := h.saveProfile(userID, kind, opts.saveProfile, preview)
+			if err != nil {
+				respondError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			response.Profile = profile
+		}
+
+		switch kind {
+		case models.ImportKindLabs:
+			response.Labs = h.commitLabs(userID, preview)
+		case models.ImportKindSupplements:
+			result, err := h.commitSupplements(userID, preview)
+			if err != nil {
+				respondError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			response.Supplements = result
+		}
+	}
+
+	response.Preview = *preview
+	status := http.StatusOK
+	if !dryRun {
+		status = http.StatusCreated
+	}
+	respondJSON(w, status, response)
+}
+
+// sheetOptions applies a saved profile first, then explicit fields
+func (h *SheetImportHandler) sheetOptions(r *http.Request, userID int, kind string) (sheetImportOptions, error) {
+	opts := sheetImportOptions{
+		mapping:     map[string]string{},
+		testDate:    strings.TrimSpace(r.FormValue("test_date")),
+		labName:     strings.TrimSpace(r.FormValue("lab_name")),
+		saveProfile: strings.TrimSpace(r.FormValue("save_profile")),
+	}
+
+	if v := r.FormValue("profile_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid profile_id")
+		}
+		var profile models.ImportProfile
+		if err := h.db.Get(&profile, `SELECT * FROM import_profiles WHERE id = $1 AND user_id = $2`, id, userID); err != nil {
+			return opts, fmt.Errorf("import profile %d not found", id)
+		}
+		if profile.Kind != kind {
+			return opts, fmt.Errorf("import profile %q is for %s", profile.Name, profile.Kind)
+		}
+		if err := json.Unmarshal(profile.Mapping, &opts.mapping); err != nil {
+			return opts, fmt.Errorf("import profile %q: invalid mapping", profile.Name)
+		}
+		if profile.Sheet != nil {
+			opts.sheet = *profile.Sheet
+		}
+		if profile.HeaderRow != nil {
+			opts.headerRow = *profile.HeaderRow
+		}
+	}
+
+	if v := r.FormValue("mapping"); v != "" {
+		var mapping map[string]string
+		if err := json.Unmarshal([]byte(v), &mapping); err != nil {
+			return opts, fmt.Errorf("mapping must be a JSON object of field to column")
+		}
+		for field, column := range mapping {
+			opts.mapping[field] = column
+		}
+	}
+	if v := r.FormValue("sheet"); v != "" {
+		opts.sheet = v
+	}
+	if v := r.FormValue("header_row"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return opts, fmt.Errorf("header_row must be a positive row number")
+		}
+		opts.headerRow = n
+	}
+	if opts.testDate != "" {
+		d, ok := sheet.ParseDate(opts.testDate)
+		if !ok {
+			return opts, fmt.Errorf("invalid test_date %q", opts.testDate)
+		}
+		opts.testDate = d.Format("2006-01-02")
+	}
+
+	fields, _ := sheetFields(kind)
+	if err := validateSheetMapping(fields, opts.mapping); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+func validateSheetMapping(fields sheet.Fields, mapping map[string]string) error {
+	for field := range mapping {
+		if _, ok := fields[field]; !ok {
+			known := make([]string, 0, len(fields))
+			for f := range fields {
+				known = append(known, f)
+			}
+			sort.Strings(known)
+			return fmt.Errorf("unknown mapping field %q; expected one of %s", field, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+// buildSheetPreview picks the sheet and header row, resolves the column
+// mapping and reads every row below the header
+func buildSheetPreview(sheets []sheet.Sheet, kind string, opts sheetImportOptions) (*SheetPreview, error) {
+	fields, _ := sheetFields(kind)
+	preview := &SheetPreview{Kind: kind, Sheets: make([]SheetInfo, len(sheets)), Warnings: []string{}}
+
+	// Without a choice, take the sheet whose header names the most fields
+	chosen, best := -1, 0
+	for i, s := range sheets {
+		preview.Sheets[i] = SheetInfo{Name: s.Name, Rows: len(s.Rows)}
+		if h, ok := sheet.DetectHeader(s.Rows, fields, headerScanRows); ok {
+			row := h.Row + 1
+			preview.Sheets[i].HeaderRow = &row
+			if len(h.Columns) > best {
+				chosen, best = i, len(h.Columns)
+			}
+		}
+	}
+	if opts.sheet != "" {
+		chosen = -1
+		for i, s := range sheets {
+			if strings.EqualFold(s.Name, opts.sheet) {
+				chosen = i
+			}
+		}
+		if n, err := strconv.Atoi(opts.sheet); err == nil && chosen < 0 && n >= 1 && n <= len(sheets) {
+			chosen = n - 1
+		}
+		if chosen < 0 {
+			return nil, fmt.Errorf("sheet %q not found", opts.sheet)
+		}
+	}
+	if chosen < 0 {
+		if len(sheets) == 1 && opts.headerRow > 0 {
+			chosen = 0
+		} else {
+			return nil, fmt.Errorf("no header row found; choose the sheet and header_row or send a mapping")
+		}
+	}
+	s := sheets[chosen]
+	preview.Sheet = s.Name
+
+	headerRow := -1
+	if opts.headerRow > 0 {
+		headerRow = opts.headerRow - 1
+	} else if h, ok := sheet.DetectHeader(s.Rows, fields, headerScanRows); ok {
+		headerRow = h.Row
+	}
+	if headerRow < 0 || headerRow >= len(s.Rows) {
+		return nil, fmt.Errorf("no header row found in sheet %q; pass header_row", s.Name)
+	}
+	header := s.Rows[headerRow]
+	preview.HeaderRow = headerRow + 1
+	preview.Header = header
+
+	columns := sheet.HeaderColumns(header, fields)
+	for field, ref := range opts.mapping {
+		if strings.TrimSpace(ref) == "" {
+			delete(columns, field)
+			continue
+		}
+		c, ok := sheet.ResolveColumn(header, ref)
+		if !ok {
+			return nil, fmt.Errorf("column %q for %s not found in row %d", ref, field, preview.HeaderRow)
+		}
+		columns[field] = c
+	}
+
+	// The name column of hand-made sheets is often captioned with the
+	// section title; take the first column when nothing else claims it
+	key := "marker"
+	if kind == models.ImportKindSupplements {
+		key = "name"
+	}
+	if _, ok := columns[key]; !ok && !columnUsed(columns, 0) {
+		columns[key] = 0
+	}
+
+	preview.Mapping = make(map[string]string, len(columns))
+	for field, c := range columns {
+		preview.Mapping[field] = columnRef(header, c)
+	}
+
+	switch kind {
+	case models.ImportKindLabs:
+		readLabRows(preview, s, headerRow, columns, opts)
+	case models.ImportKindSupplements:
+		readSupplementRows(preview, s, headerRow, columns)
+	}
+	return preview, nil
+}
+
+func columnUsed(columns map[string]int, c int) bool {
+	for _, used := range columns {
+		if used == c {
+			return true
+		}
+	}
+	return false
+}
+
+// columnRef names a column by its caption, or by its letter when the caption
+// is blank or repeated
+func columnRef(header []string, c int) string {
+	caption := ""
+	if c < len(header) {
+		caption = strings.TrimSpace(header[c])
+	}
+	if caption == "" {
+		return sheet.ColumnName(c)
+	}
+	for i, cell := range header {
+		if i != c && sheet.NormalizeHeader(cell) == sheet.NormalizeHeader(caption) {
+			return sheet.ColumnName(c)
+		}
+	}
+	return caption
+}
+
+func readLabRows(preview *SheetPreview, s sheet.Sheet, headerRow int, columns map[string]int, opts sheetImportOptions) {
+	cell := func(r int, field string) string {
+		if c, ok := columns[field]; ok {
+			return s.Cell(r, c)
+		}
+		return ""
+	}
+
+	// Without a value column, the unmapped columns captioned with a date
+	// hold the values of that date
+	type dateColumn struct {
+		column int
+		date   time.Time
+	}
+	var dateColumns []dateColumn
+	_, hasValue := columns["value"]
+	if !hasValue {
+		for c, caption := range s.Rows[headerRow] {
+			if columnUsed(columns, c) {
+				continue
+			}
+			if d, ok := sheet.ParseDate(caption); ok {
+				dateColumns = append(dateColumns, dateColumn{column: c, date: d})
+			}
+		}
+		if len(dateColumns) == 0 {
+			preview.Warnings = append(preview.Warnings, "no value column and no date columns found; map the value column")
+			return
+		}
+		preview.Layout = sheetLayoutDates
+	} else {
+		preview.Layout = sheetLayoutRows
+	}
+
+	section := ""
+	seen := map[string]int{} // marker and date to the row they first appeared in
+	for r := headerRow + 1; r < len(s.Rows); r++ {
+		row := s.Rows[r]
+		if sheet.Empty(row) {
+			continue
+		}
+		if text, ok := sheet.Section(row); ok {
+			section = text
+			continue
+		}
+
+		base := SheetLabRow{Row: r + 1, LabName: cell(r, "lab")}
+		if base.LabName == "" {
+			base.LabName = opts.labName
+		}
+		base.Unit = cell(r, "unit")
+		base.Category = cell(r, "category")
+		if base.Category == "" {
+			base.Category = section
+		}
+		base.Notes = cell(r, "notes")
+
+		marker := cell(r, "marker")
+		if marker == "" {
+			base.Errors = append(base.Errors, "marker name is empty")
+		} else {
+			name, known := canonicalMarker("", marker)
+			base.MarkerName = name
+			if !known {
+				preview.Warnings = append(preview.Warnings, fmt.Sprintf("row %d: %s is not a known marker, imported under this name", r+1, name))
+			}
+		}
+
+		base.ReferenceMin, base.ReferenceMax = parseReferenceRange(cell(r, "reference"))
+		for _, bound := range []struct {
+			field  string
+			target **float64
+		}{{"reference_min", &base.ReferenceMin}, {"reference_max", &base.ReferenceMax}} {
+			if text := cell(r, bound.field); text != "" {
+				if v, ok := sheet.ParseNumber(text); ok {
+					*bound.target = &v
+				} else {
+					base.Errors = append(base.Errors, fmt.Sprintf("%s %q is not numeric", bound.field, text))
+				}
+			}
+		}
+
+		if hasValue {
+			lab := base
+			lab.Errors = append([]string(nil), base.Errors...)
+			text := cell(r, "date")
+			switch d, ok := sheet.ParseDate(text); {
+			case ok:
+				lab.date = d
+			case text == "" && opts.testDate != "":
+				lab.date, _ = time.Parse("2006-01-02", opts.testDate)
+			case text == "":
+				lab.Errors = append(lab.Errors, "no date; map the date column or pass test_date")
+			default:
+				lab.Errors = append(lab.Errors, fmt.Sprintf("invalid date %q", text))
+			}
+			setSheetValue(&lab, cell(r, "value"))
+			addLabRow(preview, lab, seen)
+			continue
+		}
+
+		for _, dc := range dateColumns {
+			text := s.Cell(r, dc.column)
+			if text == "" {
+				continue
+			}
+			lab := base
+			lab.Errors = append([]string(nil), base.Errors...)
+			lab.date = dc.date
+			setSheetValue(&lab, text)
+			addLabRow(preview, lab, seen)
+		}
+	}
+}
+
+// addLabRow adds a result to the preview; a marker repeated for the same
+// date is an error on every row after the first
+func addLabRow(preview *SheetPreview, lab SheetLabRow, seen map[string]int) {
+	if !lab.date.IsZero() {
+		lab.TestDate = lab.date.Format("2006-01-02")
+		if lab.MarkerName != "" {
+			key := strings.ToLower(lab.MarkerName) + "|" + lab.TestDate
+			if first, ok := seen[key]; ok {
+				lab.Errors = append(lab.Errors, fmt.Sprintf("repeats row %d", first))
+			} else {
+				seen[key] = lab.Row
+			}
+		}
+	}
+	if len(lab.Errors) == 0 {
+		preview.Valid++
+	} else {
+		preview.Invalid++
+	}
+	preview.Labs = append(preview.Labs, lab)
+}
+
+// setSheetValue reads "5,2", "1 234" or a bound such as "<0,5"; the bound is
+// kept in the notes as the lab wrote it
+func setSheetValue(lab *SheetLabRow, text string) {
+	if text == "" {
+		lab.Errors = append(lab.Errors, "value is empty")
+		return
+	}
+	number := strings.TrimSpace(text)
+	comparator := ""
+	for _, prefix := range []string{"<=", ">=", "≤", "≥", "<", ">"} {
+		if strings.HasPrefix(number, prefix) {
+			comparator, number = prefix, strings.TrimSpace(number[len(prefix):])
+			break
+		}
+	}
+	v, ok := sheet.ParseNumber(number)
+	if !ok {
+		lab.Errors = append(lab.Errors, fmt.Sprintf("value %q is not numeric", text))
+		return
+	}
+	lab.Value = &v
+	if comparator != "" {
+		if lab.Notes == "" {
+			lab.Notes = text
+		} else {
+			lab.Notes = text + "; " + lab.Notes
+		}
+	}
+}
+
+func readSupplementRows(preview *SheetPreview, s sheet.Sheet, headerRow int, columns map[string]int) {
+	cell := func(r int, field string) *string {
+		c, ok := columns[field]
+		if !ok {
+			return nil
+		}
+		if v := s.Cell(r, c); v != "" && v != "—" && v != "-" {
+			return &v
+		}
+		return nil
+	}
+
+	section := ""
+	seen := map[string]int{} // name to the row it first appeared in
+	for r := headerRow + 1; r < len(s.Rows); r++ {
+		row := s.Rows[r]
+		if sheet.Empty(row) {
+			continue
+		}
+		if text, ok := sheet.Section(row); ok {
+			section = text
+			continue
+		}
+
+		item := SheetSupplementRow{Row: r + 1}
+		if name := cell(r, "name"); name != nil {
+			item.Name = *name
+			key := strings.ToLower(strings.TrimSpace(*name))
+			if first, ok := seen[key]; ok {
+				item.Errors = append(item.Errors, fmt.Sprintf("repeats row %d", first))
+			} else {
+				seen[key] = item.Row
+			}
+		} else {
+			item.Errors = append(item.Errors, "name is empty")
+		}
+		item.Dose = cell(r, "dose")
+		item.TimeOfDay = cell(r, "time_of_day")
+		item.Category = cell(r, "category")
+		if item.Category == nil && section != "" {
+			category := section
+			item.Category = &category
+		}
+		item.Mechanism = cell(r, "mechanism")
+		item.Target = cell(r, "target")
+		item.EvidenceLevel = cell(r, "evidence_level")
+		item.Notes = cell(r, "notes")
+		applyParsedDose(&item.SupplementDose, item.Dose, item.TimeOfDay)
+
+		if len(item.Errors) == 0 {
+			preview.Valid++
+		} else {
+			preview.Invalid++
+		}
+		preview.Supplements = append(preview.Supplements, item)
+	}
+}
+
+// markExistingSupplements flags rows naming a supplement already active
+func (h *SheetImportHandler) markExistingSupplements(userID int, preview *SheetPreview) error {
+	var active []models.Supplement
+	if err := h.db.Select(&active, `SELECT * FROM supplements WHERE user_id = $1 AND status = 'active'`, userID); err != nil {
+		return err
+	}
+	ids := make(map[string]int, len(active))
+	for _, s := range active {
+		ids[strings.ToLower(strings.TrimSpace(s.Name))] = s.ID
+	}
+	for i := range preview.Supplements {
+		if id, ok := ids[strings.ToLower(preview.Supplements[i].Name)]; ok {
+			preview.Supplements[i].ExistingID = &id
+		}
+	}
+	return nil
+}
+
+func (h *SheetImportHandler) commitLabs(userID int, preview *SheetPreview) *LabImportResult {
+	result := newLabImportResult()
+	var reports labReports
+	for _, lab := range preview.Labs {
+		if len(lab.Errors) == 0 {
+			reports.add(lab.date, lab.LabName, lab.ImportMarkerRequest)
+		}
+	}
+	(&LabHandler{db: h.db}).importReports(userID, reports, result)
+	return result
+}
+
+// commitSupplements stores every valid row in one transaction
+func (h *SheetImportHandler) commitSupplements(userID int, preview *SheetPreview) (*SupplementImportResult, error) {
+	result := &SupplementImportResult{Results: []models.Supplement{}, Duplicates: []string{}}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, item := range preview.Supplements {
+		if len(item.Errors) > 0 {
+			continue
+		}
+		result.Total++
+		if item.ExistingID != nil {
+			result.Duplicates = append(result.Duplicates, item.Name)
+			continue
+		}
+		supplement, err := createSupplement(tx, userID, item.SupplementCreate)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %v", item.Row, err)
+		}
+		result.Results = append(result.Results, supplement)
+		result.Imported++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// saveProfile stores the mapping the preview used under a name, replacing
+// a profile of the same name
+func (h *SheetImportHandler) saveProfile(userID int, kind, name string, preview *SheetPreview) (*models.ImportProfile, error) {
+	mapping, err := json.Marshal(preview.Mapping)
+	if err != nil {
+		return nil, err
+	}
+	var profile models.ImportProfile
+	err = h.db.Get(&profile, `
+		INSERT INTO import_profiles (user_id, name, kind, mapping, sheet, header_row)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, name) DO UPDATE SET
+			kind = EXCLUDED.kind,
+			mapping = EXCLUDED.mapping,
+			sheet = EXCLUDED.sheet,
+			header_row = EXCLUDED.header_row,
+			updated_at = NOW()
+		RETURNING *
+	`, userID, name, kind, mapping, preview.Sheet, preview.HeaderRow)
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
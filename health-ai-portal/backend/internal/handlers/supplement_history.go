@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// recordSupplementEvent appends a history entry with a snapshot of the
+// supplement after the change.
+func recordSupplementEvent(q sqlx.Execer, eventType string, before *models.Supplement, after models.Supplement) error {
+	snapshot, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+
+	var previousDose *string
+	if before != nil {
+		previousDose = before.Dose
+	}
+
+	_, err = q.Exec(`
+		INSERT INTO supplement_events (supplement_id, user_id, event_type, dose, previous_dose, status, snapshot)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, after.ID, after.UserID, eventType, after.Dose, previousDose, after.Status, snapshot)
+	return err
+}
+
+// classifySupplementChange picks the event type for an update. Empty means
+// nothing worth recording changed.
+func classifySupplementChange(before, after models.Supplement) string {
+	if before.Status != after.Status {
+		switch after.Status {
+		case "removed":
+			return models.SupplementEventRemoved
+		case "paused":
+			return models.SupplementEventPaused
+		case "active":
+			return models.SupplementEventResumed
+		}
+	}
+
+	if !equalString(before.Dose, after.Dose) || !equalFloat(before.DoseAmount, after.DoseAmount) ||
+		!equalString(before.DoseUnit, after.DoseUnit) || !equalInt(before.TimesPerDay, after.TimesPerDay) ||
+		!equalString(before.Frequency, after.Frequency) {
+		return models.SupplementEventDoseChanged
+	}
+
+	if before.Name != after.Name || !equalString(before.TimeOfDay, after.TimeOfDay) ||
+		!equalString(before.Category, after.Category) || !equalInt(before.TimesPerWeek, after.TimesPerWeek) ||
+		!equalInt(before.CycleOnWeeks, after.CycleOnWeeks) || !equalInt(before.CycleOffWeeks, after.CycleOffWeeks) {
+		return models.SupplementEventUpdated
+	}
+
+	return ""
+}
+
+// stackAsOf reconstructs the supplements that were active at the end of date
+// from the latest event of each supplement up to that point.
+func stackAsOf(db *database.DB, userID int, date time.Time) ([]models.Supplement, error) {
+	var events []models.SupplementEvent
+	err := db.Select(&events, `
+		SELECT DISTINCT ON (supplement_id) *
+		FROM supplement_events
+		WHERE user_id = $1 AND event_date < $2::date + 1
+		ORDER BY supplement_id, event_date DESC, id DESC
+	`, userID, date.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+
+	supplements := []models.Supplement{}
+	for _, e := range events {
+		if s, ok := activeAfter(e); ok {
+			supplements = append(supplements, s)
+		}
+	}
+
+	return supplements, nil
+}
+
+// activeAfter returns the supplement as it was after an event, if it was
+// active then. The status recorded with the event decides, not its type:
+// a dose change on a paused supplement leaves it paused.
+func activeAfter(e models.SupplementEvent) (models.Supplement, bool) {
+	if e.Snapshot == nil {
+		return models.Supplement{}, false
+	}
+	var s models.Supplement
+	if err := json.Unmarshal(*e.Snapshot, &s); err != nil {
+		return models.Supplement{}, false
+	}
+
+	status := s.Status
+	if e.Status != nil && *e.Status != "" {
+		status = *e.Status
+	}
+	if status == "" && e.EventType != models.SupplementEventRemoved && e.EventType != models.SupplementEventPaused {
+		status = "active"
+	}
+	if status != "active" {
+		return models.Supplement{}, false
+	}
+
+	s.Status = "active"
+	s.RemovedAt = nil
+	return s, true
+}
+
+// stackTimeline holds every supplement's history so the active stack can be
+// reconstructed for many days without a query per day.
+type stackTimeline struct {
+	events map[int][]models.SupplementEvent // per supplement, oldest first
+}
+
+func loadStackTimeline(db *database.DB, userID int, to time.Time) (*stackTimeline, error) {
+	var events []models.SupplementEvent
+	err := db.Select(&events, `
+		SELECT * FROM supplement_events
+		WHERE user_id = $1 AND event_date < $2::date + 1
+		ORDER BY supplement_id, event_date ASC, id ASC
+	`, userID, to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+
+	t := &stackTimeline{events: make(map[int][]models.SupplementEvent)}
+	for _, e := range events {
+		t.events[e.SupplementID] = append(t.events[e.SupplementID], e)
+	}
+	return t, nil
+}
+
+// activeOn returns the supplements active at the end of date, ordered by ID
+func (t *stackTimeline) activeOn(date time.Time) []models.Supplement {
+	end := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+
+	ids := make([]int, 0, len(t.events))
+	for id := range t.events {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	supplements := []models.Supplement{}
+	for _, id := range ids {
+		var last *models.SupplementEvent
+		for i := range t.events[id] {
+			e := &t.events[id][i]
+			if !e.EventDate.Before(end) {
+				break
+			}
+			last = e
+		}
+		if last == nil {
+			continue
+		}
+		if s, ok := activeAfter(*last); ok {
+			supplements = append(supplements, s)
+		}
+	}
+	return supplements
+}
+
+// History returns the change log of one supplement, oldest first
+func (h *SupplementHandler) History(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	events := []models.SupplementEvent{}
+	err = h.db.Select(&events, `
+		SELECT * FROM supplement_events
+		WHERE supplement_id = $1
+		ORDER BY event_date ASC, id ASC
+	`, id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, events)
+}
+
+// ListEvents returns stack changes across all supplements (filters: from, to)
+func (h *SupplementHandler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	query := `
+		SELECT e.*, s.name as supplement_name
+		FROM supplement_events e
+		JOIN supplements s ON e.supplement_id = s.id
+		WHERE e.user_id = $1`
+	args := []interface{}{userID}
+
+	if from := r.URL.Query().Get("from"); from != "" {
+		args = append(args, from)
+		query += ` AND e.event_date >= $` + strconv.Itoa(len(args)) + `::date`
+	}
+	if to := r.URL.Query().Get("to"); to != "" {
+		args = append(args, to)
+		query += ` AND e.event_date < $` + strconv.Itoa(len(args)) + `::date + 1`
+	}
+
+	query += ` ORDER BY e.event_date DESC, e.id DESC`
+
+	events := []models.SupplementEventWithName{}
+	if err := h.db.Select(&events, query, args...); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, events)
+}
+
+// AsOf returns the stack that was active on ?date=YYYY-MM-DD
+func (h *SupplementHandler) AsOf(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	date, err := time.Parse("2006-01-02", r.URL.Query().Get("date"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "date must be YYYY-MM-DD")
+		return
+	}
+
+	supplements, err := stackAsOf(h.db, userID, date)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.StackSnapshot{
+		Date:        date,
+		Supplements: supplements,
+	})
+}
+
+func equalString(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func equalInt(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func equalFloat(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
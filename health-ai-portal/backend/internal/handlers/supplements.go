@@ -2,14 +2,19 @@ package handlers
 
 import (
 	"encoding/json"
+	"math"
 	"net/http"
+	"sort"
 	"strconv"
 	"time"
 
 	"health-ai-portal/internal/database"
 	"health-ai-portal/internal/models"
+	"health-ai-portal/pkg/dose"
+	"health-ai-portal/pkg/schedule"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
 )
 
 type SupplementHandler struct {
@@ -84,21 +89,61 @@ func (h *SupplementHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var supplement models.Supplement
-	err := h.db.Get(&supplement, `
-		INSERT INTO supplements (user_id, name, dose, time_of_day, category, mechanism, target, evidence_level, notes, status)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 'active')
-		RETURNING *
-	`, userID, input.Name, input.Dose, input.TimeOfDay, input.Category, input.Mechanism, input.Target, input.EvidenceLevel, input.Notes)
+	if slot, ok := validSlots(input.Slots); !ok {
+		http.Error(w, "Unknown slot: "+slot, http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
 
+	supplement, err := createSupplement(tx, userID, input)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	respondJSON(w, http.StatusCreated, supplement)
 }
 
+// createSupplement stores a validated supplement with its slots and records
+// that it was added
+func createSupplement(tx *sqlx.Tx, userID int, input models.SupplementCreate) (models.Supplement, error) {
+	applyParsedDose(&input.SupplementDose, input.Dose, input.TimeOfDay)
+	d := input.SupplementDose
+	daysJSON := daysOfWeekJSON(input.DaysOfWeek)
+
+	var supplement models.Supplement
+	err := tx.Get(&supplement, `
+		INSERT INTO supplements (user_id, name, dose, time_of_day, category, mechanism, target, evidence_level, notes, status,
+			ingredient, dose_amount, dose_amount_min, dose_unit, dose_form, frequency, times_per_day, times_per_week,
+			with_food, cycle_on_weeks, cycle_off_weeks, cycle_start_date, days_of_week)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 'active',
+			$10, $11, $12, $13, $14, COALESCE($15, 'daily'), COALESCE($16, 1), $17, $18, $19, $20, $21, $22)
+		RETURNING *
+	`, userID, input.Name, input.Dose, input.TimeOfDay, input.Category, input.Mechanism, input.Target, input.EvidenceLevel, input.Notes,
+		d.Ingredient, d.DoseAmount, d.DoseAmountMin, d.DoseUnit, d.DoseForm, d.Frequency, d.TimesPerDay, d.TimesPerWeek,
+		d.WithFood, d.CycleOnWeeks, d.CycleOffWeeks, d.CycleStartDate, daysJSON)
+	if err != nil {
+		return supplement, err
+	}
+
+	if len(input.Slots) > 0 {
+		if err := replaceSupplementSlots(tx, supplement.ID, input.Slots); err != nil {
+			return supplement, err
+		}
+	}
+	return supplement, recordSupplementEvent(tx, models.SupplementEventAdded, nil, supplement)
+}
+
 func (h *SupplementHandler) Update(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
@@ -112,8 +157,37 @@ func (h *SupplementHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if slot, ok := validSlots(input.Slots); !ok {
+		http.Error(w, "Unknown slot: "+slot, http.StatusBadRequest)
+		return
+	}
+
+	// A new free-text dose re-derives the structured fields unless they were sent too.
+	// When it has no recognisable amount none of the old parsed fields apply:
+	// those not sent go back to their defaults.
+	clearParsed := false
+	if input.Dose != nil {
+		applyParsedDose(&input.SupplementDose, input.Dose, input.TimeOfDay)
+		clearParsed = input.SupplementDose.DoseAmount == nil
+	}
+	d := input.SupplementDose
+	daysJSON := daysOfWeekJSON(input.DaysOfWeek)
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var before models.Supplement
+	if err := tx.Get(&before, `SELECT * FROM supplements WHERE id = $1 FOR UPDATE`, id); err != nil {
+		http.Error(w, "Supplement not found", http.StatusNotFound)
+		return
+	}
+
 	var supplement models.Supplement
-	err = h.db.Get(&supplement, `
+	err = tx.Get(&supplement, `
 		UPDATE supplements SET
 			name = COALESCE($2, name),
 			dose = COALESCE($3, dose),
@@ -124,16 +198,50 @@ func (h *SupplementHandler) Update(w http.ResponseWriter, r *http.Request) {
 			status = COALESCE($8, status),
 			evidence_level = COALESCE($9, evidence_level),
 			notes = COALESCE($10, notes),
+			ingredient = COALESCE($11, ingredient),
+			dose_amount = CASE WHEN $24 THEN NULL ELSE COALESCE($12, dose_amount) END,
+			dose_amount_min = CASE WHEN $24 THEN NULL ELSE COALESCE($13, dose_amount_min) END,
+			dose_unit = CASE WHEN $24 THEN $14 ELSE COALESCE($14, dose_unit) END,
+			dose_form = CASE WHEN $24 THEN $15 ELSE COALESCE($15, dose_form) END,
+			frequency = CASE WHEN $24 THEN COALESCE($16, 'daily') ELSE COALESCE($16, frequency) END,
+			times_per_day = CASE WHEN $24 THEN COALESCE($17, 1) ELSE COALESCE($17, times_per_day) END,
+			times_per_week = CASE WHEN $24 THEN $18 ELSE COALESCE($18, times_per_week) END,
+			with_food = CASE WHEN $24 THEN $19 ELSE COALESCE($19, with_food) END,
+			cycle_on_weeks = COALESCE($20, cycle_on_weeks),
+			cycle_off_weeks = COALESCE($21, cycle_off_weeks),
+			cycle_start_date = COALESCE($22, cycle_start_date),
+			days_of_week = COALESCE($23, days_of_week),
 			updated_at = NOW()
 		WHERE id = $1
 		RETURNING *
-	`, id, input.Name, input.Dose, input.TimeOfDay, input.Category, input.Mechanism, input.Target, input.Status, input.EvidenceLevel, input.Notes)
+	`, id, input.Name, input.Dose, input.TimeOfDay, input.Category, input.Mechanism, input.Target, input.Status, input.EvidenceLevel, input.Notes,
+		d.Ingredient, d.DoseAmount, d.DoseAmountMin, d.DoseUnit, d.DoseForm, d.Frequency, d.TimesPerDay, d.TimesPerWeek,
+		d.WithFood, d.CycleOnWeeks, d.CycleOffWeeks, d.CycleStartDate, daysJSON, clearParsed)
 
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	// An explicit (possibly empty) slot list replaces the stored one
+	if input.Slots != nil {
+		if err := replaceSupplementSlots(tx, supplement.ID, input.Slots); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if eventType := classifySupplementChange(before, supplement); eventType != "" {
+		if err := recordSupplementEvent(tx, eventType, &before, supplement); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	respondJSON(w, http.StatusOK, supplement)
 }
 
@@ -144,11 +252,26 @@ func (h *SupplementHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var before models.Supplement
+	if err := tx.Get(&before, `SELECT * FROM supplements WHERE id = $1 FOR UPDATE`, id); err != nil {
+		http.Error(w, "Supplement not found", http.StatusNotFound)
+		return
+	}
+
 	// Soft delete
 	now := time.Now()
-	_, err = h.db.Exec(`
+	var supplement models.Supplement
+	err = tx.Get(&supplement, `
 		UPDATE supplements SET status = 'removed', removed_at = $2, updated_at = NOW()
 		WHERE id = $1
+		RETURNING *
 	`, id, now)
 
 	if err != nil {
@@ -156,41 +279,32 @@ func (h *SupplementHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if before.Status != "removed" {
+		if err := recordSupplementEvent(tx, models.SupplementEventRemoved, &before, supplement); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// GetSchedule returns today's intake slots in the user's timezone, ordered by time
 func (h *SupplementHandler) GetSchedule(w http.ResponseWriter, r *http.Request) {
 	userID := 1 // TODO: get from auth context
 
-	var supplements []models.Supplement
-	err := h.db.Select(&supplements, `
-		SELECT * FROM supplements
-		WHERE user_id = $1 AND status = 'active' AND time_of_day IS NOT NULL
-		ORDER BY time_of_day, name
-	`, userID)
-
+	loc := loadUserLocation(h.db, userID)
+	day, err := loadDaySchedule(h.db, userID, time.Now().In(loc), loc)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Group by time_of_day
-	scheduleMap := make(map[string][]models.Supplement)
-	for _, s := range supplements {
-		if s.TimeOfDay != nil {
-			scheduleMap[*s.TimeOfDay] = append(scheduleMap[*s.TimeOfDay], s)
-		}
-	}
-
-	var schedule []models.ScheduleItem
-	for time, supps := range scheduleMap {
-		schedule = append(schedule, models.ScheduleItem{
-			TimeOfDay:   time,
-			Supplements: supps,
-		})
-	}
-
-	respondJSON(w, http.StatusOK, schedule)
+	respondJSON(w, http.StatusOK, day.Items)
 }
 
 func (h *SupplementHandler) GetByCategory(w http.ResponseWriter, r *http.Request) {
@@ -220,3 +334,238 @@ func (h *SupplementHandler) GetByCategory(w http.ResponseWriter, r *http.Request
 
 	respondJSON(w, http.StatusOK, categoryMap)
 }
+
+// daysOfWeekJSON encodes ISO weekdays for the JSONB column; nil keeps NULL
+func daysOfWeekJSON(days []int) []byte {
+	if days == nil {
+		return nil
+	}
+	b, _ := json.Marshal(days)
+	return b
+}
+
+// applyParsedDose fills structured dose fields that were not sent explicitly
+// from the free-text dose and legacy time_of_day.
+func applyParsedDose(d *models.SupplementDose, text, schedule *string) {
+	if text == nil || d.DoseAmount != nil {
+		return
+	}
+	sched := ""
+	if schedule != nil {
+		sched = *schedule
+	}
+	parsed, ok := dose.Parse(*text, sched)
+	if !ok {
+		return
+	}
+
+	d.DoseAmount = parsed.Amount
+	if d.DoseAmountMin == nil {
+		d.DoseAmountMin = parsed.AmountMin
+	}
+	if d.DoseUnit == nil && parsed.Unit != "" {
+		d.DoseUnit = &parsed.Unit
+	}
+	if d.DoseForm == nil && parsed.Form != "" {
+		d.DoseForm = &parsed.Form
+	}
+	if d.Frequency == nil {
+		d.Frequency = &parsed.Frequency
+	}
+	if d.TimesPerDay == nil {
+		d.TimesPerDay = &parsed.TimesPerDay
+	}
+	if d.TimesPerWeek == nil {
+		d.TimesPerWeek = parsed.TimesPerWeek
+	}
+	if d.WithFood == nil && parsed.WithFood != "" {
+		d.WithFood = &parsed.WithFood
+	}
+}
+
+type DoseMigrationResult struct {
+	DryRun   bool                `json:"dry_run"`
+	Parsed   []DoseMigrationItem `json:"parsed"`
+	Unparsed []DoseMigrationItem `json:"unparsed"`
+}
+
+type DoseMigrationItem struct {
+	SupplementID int        `json:"supplement_id"`
+	Name         string     `json:"name"`
+	Dose         string     `json:"dose"`
+	Parsed       *dose.Dose `json:"parsed,omitempty"`
+}
+
+// MigrateDoses parses free-text doses of supplements that have no structured
+// dose yet. Pass ?dry_run=true to preview without writing.
+func (h *SupplementHandler) MigrateDoses(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	var supplements []models.Supplement
+	err := h.db.Select(&supplements, `
+		SELECT * FROM supplements
+		WHERE user_id = $1 AND dose IS NOT NULL AND dose_amount IS NULL
+		ORDER BY id
+	`, userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	result := DoseMigrationResult{
+		DryRun:   dryRun,
+		Parsed:   []DoseMigrationItem{},
+		Unparsed: []DoseMigrationItem{},
+	}
+
+	for _, s := range supplements {
+		schedule := ""
+		if s.TimeOfDay != nil {
+			schedule = *s.TimeOfDay
+		}
+		parsed, ok := dose.Parse(*s.Dose, schedule)
+		item := DoseMigrationItem{SupplementID: s.ID, Name: s.Name, Dose: *s.Dose}
+		if !ok {
+			result.Unparsed = append(result.Unparsed, item)
+			continue
+		}
+		item.Parsed = &parsed
+
+		if !dryRun {
+			_, err := h.db.Exec(`
+				UPDATE supplements SET
+					dose_amount = $2,
+					dose_amount_min = $3,
+					dose_unit = NULLIF($4, ''),
+					dose_form = COALESCE(NULLIF($5, ''), dose_form),
+					frequency = $6,
+					times_per_day = $7,
+					times_per_week = $8,
+					with_food = COALESCE(NULLIF($9, ''), with_food),
+					updated_at = NOW()
+				WHERE id = $1
+			`, s.ID, parsed.Amount, parsed.AmountMin, parsed.Unit, parsed.Form, parsed.Frequency,
+				parsed.TimesPerDay, parsed.TimesPerWeek, parsed.WithFood)
+			if err != nil {
+				result.Unparsed = append(result.Unparsed, item)
+				continue
+			}
+		}
+		result.Parsed = append(result.Parsed, item)
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// GetIntake returns daily intake per ingredient across the active stack,
+// compared with tolerable upper intake levels.
+func (h *SupplementHandler) GetIntake(w http.ResponseWriter, r *http.Request) {
+	userID := 1 // TODO: get from auth context
+
+	var supplements []models.Supplement
+	err := h.db.Select(&supplements, `
+		SELECT * FROM supplements
+		WHERE user_id = $1 AND status = 'active' AND dose_amount IS NOT NULL
+		ORDER BY name
+	`, userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, computeIntakeTotals(supplements, time.Now().In(loadUserLocation(h.db, userID))))
+}
+
+// computeIntakeTotals sums the daily amounts taken on today; supplements in
+// the off weeks of their on/off cycle are not taken and do not count
+func computeIntakeTotals(supplements []models.Supplement, today time.Time) []models.IntakeTotal {
+	totals := make(map[string]*models.IntakeTotal)
+	var order []string
+
+	for _, s := range supplements {
+		cycle := schedule.Rule{CycleOnWeeks: s.CycleOnWeeks, CycleOffWeeks: s.CycleOffWeeks, Anchor: s.CreatedAt}
+		if s.CycleStartDate != nil {
+			cycle.Anchor = *s.CycleStartDate
+		}
+		if cycle.InOffWeeks(today) {
+			continue
+		}
+
+		name := s.Name
+		if s.Ingredient != nil && *s.Ingredient != "" {
+			name = *s.Ingredient
+		}
+		ingredient := dose.NormalizeIngredient(name)
+
+		times := 1
+		if s.TimesPerDay != nil && *s.TimesPerDay > 0 {
+			times = *s.TimesPerDay
+		}
+		frequency := dose.FrequencyDaily
+		if s.Frequency != nil {
+			frequency = *s.Frequency
+		}
+		// Averaged over the week, so a weekly dose is not read as a daily one
+		daily := *s.DoseAmount * float64(times) * dose.DaysPerWeek(frequency, s.TimesPerWeek) / 7
+		unit := ""
+		if s.DoseUnit != nil {
+			unit = *s.DoseUnit
+		}
+
+		total, ok := totals[ingredient]
+		if !ok {
+			total = &models.IntakeTotal{Ingredient: ingredient, Unit: unit}
+			if limit, ok := dose.LookupLimit(ingredient); ok {
+				ul := limit.Amount
+				total.Unit = limit.Unit
+				total.UpperLimit = &ul
+				total.LimitNote = limit.Note
+			}
+			totals[ingredient] = total
+			order = append(order, ingredient)
+		}
+
+		entry := models.IntakeEntry{
+			SupplementID: s.ID,
+			Name:         s.Name,
+			DailyAmount:  daily,
+			Unit:         unit,
+			Frequency:    frequency,
+		}
+		total.Sources = append(total.Sources, entry)
+
+		converted, ok := dose.Convert(daily, unit, total.Unit, ingredient)
+		if !ok {
+			total.Unconvertible = append(total.Unconvertible, s.Name)
+			continue
+		}
+		total.DailyAmount += converted
+	}
+
+	result := make([]models.IntakeTotal, 0, len(order))
+	for _, key := range order {
+		t := totals[key]
+		t.DailyAmount = math.Round(t.DailyAmount*1000) / 1000
+		if t.UpperLimit != nil {
+			pct := math.Round(t.DailyAmount / *t.UpperLimit * 1000) / 10
+			t.PercentOfUL = &pct
+			t.ExceedsUL = t.DailyAmount > *t.UpperLimit
+		}
+		result = append(result, *t)
+	}
+
+	// Ingredients closest to their limit first
+	sort.SliceStable(result, func(i, j int) bool {
+		pi, pj := -1.0, -1.0
+		if result[i].PercentOfUL != nil {
+			pi = *result[i].PercentOfUL
+		}
+		if result[j].PercentOfUL != nil {
+			pj = *result[j].PercentOfUL
+		}
+		return pi > pj
+	})
+
+	return result
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"health-ai-portal/internal/models"
+)
+
+func TestComputeIntakeTotalsSkipsOffWeeks(t *testing.T) {
+	amount := func(v float64) *float64 { return &v }
+	weeks := func(n int) *int { return &n }
+	unit, weekly, d3 := "IU", "weekly", "vitamin d3"
+	start := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC) // a Monday
+
+	supplements := []models.Supplement{
+		{ID: 1, Name: "D3 daily", Ingredient: &d3, DoseAmount: amount(5000), DoseUnit: &unit},
+		{ID: 2, Name: "D3 weekly", Ingredient: &d3, DoseAmount: amount(35000), DoseUnit: &unit, Frequency: &weekly},
+		{ID: 3, Name: "D3 cycled", Ingredient: &d3, DoseAmount: amount(10000), DoseUnit: &unit,
+			CycleOnWeeks: weeks(2), CycleOffWeeks: weeks(1), CycleStartDate: &start},
+	}
+
+	for _, tc := range []struct {
+		name  string
+		today time.Time
+		want  float64
+	}{
+		{"on week", start.AddDate(0, 0, 10), 5000 + 5000 + 10000},
+		{"off week", start.AddDate(0, 0, 15), 5000 + 5000},
+		{"next cycle", start.AddDate(0, 0, 21), 5000 + 5000 + 10000},
+	} {
+		totals := computeIntakeTotals(supplements, tc.today)
+		if len(totals) != 1 || totals[0].DailyAmount != tc.want {
+			t.Errorf("%s: totals = %+v, want %g", tc.name, totals, tc.want)
+		}
+	}
+}
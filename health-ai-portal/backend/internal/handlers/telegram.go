@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/models"
+	"health-ai-portal/internal/scheduler"
+	"health-ai-portal/pkg/schedule"
+	"health-ai-portal/pkg/telegram"
+)
+
+// telegramSnoozeMinutes is the delay behind the snooze button
+const telegramSnoozeMinutes = 15
+
+// TelegramHandler delivers reminders to Telegram and serves bot commands.
+// It implements scheduler.Notifier.
+type TelegramHandler struct {
+	db      *database.DB
+	client  *telegram.Client
+	allowed map[int64]bool
+	secret  string
+}
+
+// NewTelegramHandler creates the bot. Only chats in allowedChats may link
+// themselves or issue commands; webhookSecret is checked on webhook calls.
+func NewTelegramHandler(db *database.DB, client *telegram.Client, allowedChats []int64, webhookSecret string) *TelegramHandler {
+	allowed := make(map[int64]bool, len(allowedChats))
+	for _, id := range allowedChats {
+		allowed[id] = true
+	}
+	return &TelegramHandler{db: db, client: client, allowed: allowed, secret: webhookSecret}
+}
+
+func (h *TelegramHandler) Name() string { return "telegram" }
+
+// Notify sends a due reminder with taken / skip / snooze buttons to the
+// user's linked chat
+func (h *TelegramHandler) Notify(ctx context.Context, n scheduler.Notification) error {
+	var chatID *int64
+	if err := h.db.GetContext(ctx, &chatID, `SELECT telegram_chat_id FROM users WHERE id = $1`, n.Reminder.UserID); err != nil {
+		return err
+	}
+	if chatID == nil {
+		return fmt.Errorf("telegram chat not linked for user %d (send /start to the bot)", n.Reminder.UserID)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "⏰ %s — %s", n.ScheduledFor.In(n.Location).Format("15:04"), n.Reminder.Title)
+	if n.CatchUp {
+		b.WriteString(" (пропущено, сервер был недоступен)")
+	}
+	if n.Snoozed {
+		b.WriteString(" (повтор)")
+	}
+	if n.Reminder.Description != nil && *n.Reminder.Description != "" {
+		b.WriteString("\n" + *n.Reminder.Description)
+	}
+
+	takenLabel := "✅ Выполнено"
+	if isSupplementReminder(n.Reminder) {
+		takenLabel = "✅ Принял"
+		_, supplements, err := h.reminderSlot(n.Reminder, n.ScheduledFor, n.Location)
+		if err == nil {
+			for _, s := range supplements {
+				b.WriteString("\n• " + supplementLine(s))
+			}
+		}
+	}
+
+	markup := &telegram.InlineKeyboardMarkup{InlineKeyboard: [][]telegram.InlineKeyboardButton{{
+		{Text: takenLabel, CallbackData: fmt.Sprintf("taken:%d", n.DeliveryID)},
+		{Text: "⏭ Пропустить", CallbackData: fmt.Sprintf("skip:%d", n.DeliveryID)},
+		{Text: fmt.Sprintf("💤 %d мин", telegramSnoozeMinutes), CallbackData: fmt.Sprintf("snooze:%d", n.DeliveryID)},
+	}}}
+
+	_, err := h.client.SendMessage(ctx, *chatID, b.String(), markup)
+	return err
+}
+
+// Webhook receives updates pushed by Telegram
+func (h *TelegramHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Telegram-Bot-Api-Secret-Token") != h.secret {
+		respondError(w, http.StatusUnauthorized, "Invalid secret token")
+		return
+	}
+
+	var update telegram.Update
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid update")
+		return
+	}
+
+	h.handleUpdate(r.Context(), update)
+	w.WriteHeader(http.StatusOK)
+}
+
+// Poll long-polls for updates until ctx is cancelled. Used when no webhook
+// is configured, e.g. on a laptop without a public URL.
+func (h *TelegramHandler) Poll(ctx context.Context) {
+	log.Printf("Telegram bot polling for updates")
+
+	offset := 0
+	for ctx.Err() == nil {
+		updates, err := h.client.GetUpdates(ctx, offset, 50*time.Second)
+		if err != nil {
+			log.Printf("Telegram: %v", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+		for _, u := range updates {
+			h.handleUpdate(ctx, u)
+			offset = u.UpdateID + 1
+		}
+	}
+}
+
+func (h *TelegramHandler) handleUpdate(ctx context.Context, u telegram.Update) {
+	switch {
+	case u.CallbackQuery != nil:
+		h.handleCallback(ctx, u.CallbackQuery)
+	case u.Message != nil && u.Message.Text != "":
+		h.handleMessage(ctx, u.Message)
+	}
+}
+
+func (h *TelegramHandler) reply(ctx context.Context, chatID int64, text string) {
+	if _, err := h.client.SendMessage(ctx, chatID, text, nil); err != nil {
+		log.Printf("Telegram: reply to %d failed: %v", chatID, err)
+	}
+}
+
+// chatUser returns the user linked to a chat
+func (h *TelegramHandler) chatUser(chatID int64) (int, bool) {
+	var userID int
+	if err := h.db.Get(&userID, `SELECT id FROM users WHERE telegram_chat_id = $1`, chatID); err != nil {
+		return 0, false
+	}
+	return userID, true
+}
+
+func (h *TelegramHandler) handleMessage(ctx context.Context, msg *telegram.Message) {
+	chatID := msg.Chat.ID
+	if !h.allowed[chatID] {
+		h.reply(ctx, chatID, fmt.Sprintf("Этот чат не авторизован. Добавьте %d в TELEGRAM_ALLOWED_CHATS.", chatID))
+		return
+	}
+
+	// Whitespace-only text has no command; fields[0] would panic the poller
+	fields := strings.Fields(msg.Text)
+	if len(fields) == 0 {
+		return
+	}
+	command := strings.ToLower(fields[0])
+	if i := strings.Index(command, "@"); i > 0 {
+		command = command[:i] // /today@my_bot in group chats
+	}
+	args := fields[1:]
+
+	if command == "/start" {
+		userID := 1 // TODO: get from auth context
+		if _, err := h.db.Exec(`UPDATE users SET telegram_chat_id = $2, updated_at = NOW() WHERE id = $1`, userID, chatID); err != nil {
+			h.reply(ctx, chatID, "Не удалось привязать чат: "+err.Error())
+			return
+		}
+		h.reply(ctx, chatID, "Чат привязан, напоминания будут приходить сюда.\n\n"+telegramHelp)
+		return
+	}
+
+	userID, ok := h.chatUser(chatID)
+	if !ok {
+		h.reply(ctx, chatID, "Чат не привязан. Отправьте /start.")
+		return
+	}
+
+	var text string
+	var err error
+	switch command {
+	case "/today":
+		text, err = h.todayText(userID)
+	case "/labs":
+		text, err = h.labsText(userID, strings.Join(args, " "))
+	case "/log":
+		text, err = h.logMetric(userID, args)
+	default:
+		text = telegramHelp
+	}
+	if err != nil {
+		text = "Ошибка: " + err.Error()
+	}
+	h.reply(ctx, chatID, text)
+}
+
+const telegramHelp = `Команды:
+/today — расписание приёма на сегодня
+/labs <маркер> — последнее значение и тренд
+/log <метрика> <значение> — записать показатель (weight 94.2, sleep 7.5, bp 120/80, steps, hrv, hr, glucose, energy, mood)`
+
+// handleCallback applies a reminder button press and writes the answer back
+func (h *TelegramHandler) handleCallback(ctx context.Context, q *telegram.CallbackQuery) {
+	if q.Message == nil || !h.allowed[q.Message.Chat.ID] {
+		h.client.AnswerCallbackQuery(ctx, q.ID, "Чат не авторизован")
+		return
+	}
+
+	action, idText, _ := strings.Cut(q.Data, ":")
+	deliveryID, err := strconv.Atoi(idText)
+	if err != nil {
+		h.client.AnswerCallbackQuery(ctx, q.ID, "Неизвестная команда")
+		return
+	}
+	if action == "done" {
+		h.client.AnswerCallbackQuery(ctx, q.ID, "Ответ уже записан")
+		return
+	}
+
+	userID, ok := h.chatUser(q.Message.Chat.ID)
+	if !ok {
+		h.client.AnswerCallbackQuery(ctx, q.ID, "Чат не привязан")
+		return
+	}
+
+	result, err := h.respondToDelivery(userID, deliveryID, action)
+	if err != nil {
+		h.client.AnswerCallbackQuery(ctx, q.ID, "Ошибка: "+err.Error())
+		return
+	}
+
+	// The buttons give way to the outcome, so the reminder is not answered twice
+	h.client.AnswerCallbackQuery(ctx, q.ID, result)
+	h.client.EditMessageReplyMarkup(ctx, q.Message.Chat.ID, q.Message.MessageID, &telegram.InlineKeyboardMarkup{
+		InlineKeyboard: [][]telegram.InlineKeyboardButton{{{Text: result, CallbackData: "done:" + idText}}},
+	})
+}
+
+// respondToDelivery records taken / skip / snooze for a delivered reminder.
+// For supplement reminders taken and skip are also written to the intake log
+// for every supplement in the matching slot.
+func (h *TelegramHandler) respondToDelivery(userID, deliveryID int, action string) (string, error) {
+	var delivery models.ReminderDelivery
+	err := h.db.Get(&delivery, `SELECT * FROM reminder_deliveries WHERE id = $1 AND user_id = $2`, deliveryID, userID)
+	if err != nil {
+		return "", fmt.Errorf("напоминание не найдено")
+	}
+	var reminder models.Reminder
+	if err := h.db.Get(&reminder, `SELECT * FROM reminders WHERE id = $1`, delivery.ReminderID); err != nil {
+		return "", fmt.Errorf("напоминание не найдено")
+	}
+	loc := loadUserLocation(h.db, delivery.UserID)
+
+	var response, result string
+	switch action {
+	case "taken", "skip":
+		response, result = "taken", "✅ Отмечено"
+		status := "taken"
+		if action == "skip" {
+			response, result, status = "skipped", "⏭ Пропущено", "skipped"
+		}
+		if isSupplementReminder(reminder) {
+			slot, supplements, err := h.reminderSlot(reminder, delivery.ScheduledFor, loc)
+			if err != nil {
+				return "", err
+			}
+			date := delivery.ScheduledFor.In(loc).Format("2006-01-02")
+			for _, s := range supplements {
+				_, err := logIntake(h.db, delivery.UserID, models.IntakeLogCreate{
+					SupplementID:  s.ID,
+					Slot:          slot,
+					ScheduledDate: date,
+					Status:        &status,
+				})
+				if err != nil {
+					return "", err
+				}
+			}
+			if len(supplements) > 0 {
+				result += fmt.Sprintf(" (%d шт.)", len(supplements))
+			}
+		}
+	case "snooze":
+		snoozed, err := snoozeReminder(h.db, reminder.ID, telegramSnoozeMinutes)
+		if err != nil {
+			return "", err
+		}
+		response = "snoozed"
+		result = "💤 Напомню в " + snoozed.SnoozedUntil.In(loc).Format("15:04")
+	default:
+		return "", fmt.Errorf("неизвестное действие")
+	}
+
+	_, err = h.db.Exec(`
+		UPDATE reminder_deliveries SET response = $2, responded_at = NOW()
+		WHERE id = $1
+	`, deliveryID, response)
+	if err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+func isSupplementReminder(r models.Reminder) bool {
+	return r.ReminderType != nil && *r.ReminderType == "supplement"
+}
+
+// reminderSlot maps a supplement reminder to the schedule slot closest to its
+// time and returns the supplements due in that slot on the occurrence date
+func (h *TelegramHandler) reminderSlot(r models.Reminder, at time.Time, loc *time.Location) (string, []models.Supplement, error) {
+	slots, err := loadSlots(h.db, r.UserID)
+	if err != nil {
+		return "", nil, err
+	}
+	clock := at.In(loc).Format("15:04")
+	if r.Time != nil {
+		clock = *r.Time
+	}
+	slot := schedule.NearestSlot(clock, slots)
+
+	day, err := loadDaySchedule(h.db, r.UserID, at.In(loc), loc)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, item := range day.Items {
+		if item.Slot == slot {
+			return slot, item.Supplements, nil
+		}
+	}
+	return slot, nil, nil
+}
+
+func supplementLine(s models.Supplement) string {
+	if s.Dose != nil && *s.Dose != "" {
+		return s.Name + " — " + *s.Dose
+	}
+	return s.Name
+}
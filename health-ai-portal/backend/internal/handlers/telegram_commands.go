@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"health-ai-portal/internal/models"
+	"health-ai-portal/pkg/pdf"
+)
+
+// todayText renders today's intake plan with what has already been logged
+func (h *TelegramHandler) todayText(userID int) (string, error) {
+	loc := loadUserLocation(h.db, userID)
+	now := time.Now().In(loc)
+
+	day, err := loadDaySchedule(h.db, userID, now, loc)
+	if err != nil {
+		return "", err
+	}
+
+	var logs []models.IntakeLog
+	err = h.db.Select(&logs, `
+		SELECT * FROM intake_logs WHERE user_id = $1 AND scheduled_date = $2
+	`, userID, now.Format("2006-01-02"))
+	if err != nil {
+		return "", err
+	}
+	logged := make(map[string]string, len(logs))
+	for _, l := range logs {
+		logged[fmt.Sprintf("%d:%s", l.SupplementID, l.Slot)] = l.Status
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "📋 Приём на %s", now.Format("02.01.2006"))
+	if len(day.Items) == 0 {
+		b.WriteString("\n\nНа сегодня приёмов нет.")
+	}
+	for _, item := range day.Items {
+		fmt.Fprintf(&b, "\n\n%s %s", item.TimeOfDay, item.Label)
+		for _, s := range item.Supplements {
+			icon := "▫️"
+			switch logged[fmt.Sprintf("%d:%s", s.ID, item.Slot)] {
+			case "taken":
+				icon = "✅"
+			case "late":
+				icon = "🕓"
+			case "skipped":
+				icon = "⏭"
+			}
+			fmt.Fprintf(&b, "\n%s %s", icon, supplementLine(s))
+		}
+	}
+	if len(day.AsNeeded) > 0 {
+		b.WriteString("\n\nПо необходимости: " + supplementNames(day.AsNeeded))
+	}
+	if len(day.OffCycle) > 0 {
+		b.WriteString("\nПерерыв в цикле: " + supplementNames(day.OffCycle))
+	}
+	if len(day.Unscheduled) > 0 {
+		b.WriteString("\nБез времени приёма: " + supplementNames(day.Unscheduled))
+	}
+	return b.String(), nil
+}
+
+func supplementNames(list []models.Supplement) string {
+	names := make([]string, len(list))
+	for i, s := range list {
+		names[i] = s.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// labsText shows the latest value of a marker against its reference range and
+// the change since the previous measurement
+func (h *TelegramHandler) labsText(userID int, marker string) (string, error) {
+	if strings.TrimSpace(marker) == "" {
+		return "", fmt.Errorf("укажите маркер, например /labs ферритин")
+	}
+	name := pdf.NormalizeMarkerName(marker)
+
+	var results []models.LabResult
+	err := h.db.Select(&results, `
+		SELECT * FROM lab_results
+		WHERE user_id = $1 AND value IS NOT NULL AND marker_name ILIKE $2
+		ORDER BY test_date DESC
+		LIMIT 5
+	`, userID, name)
+	if err != nil {
+		return "", err
+	}
+	if len(results) == 0 {
+		// Fall back to a partial match and keep the most recent marker
+		err = h.db.Select(&results, `
+			SELECT * FROM lab_results
+			WHERE user_id = $1 AND value IS NOT NULL AND marker_name = (
+				SELECT marker_name FROM lab_results
+				WHERE user_id = $1 AND value IS NOT NULL AND marker_name ILIKE '%' || $2 || '%'
+				ORDER BY test_date DESC LIMIT 1
+			)
+			ORDER BY test_date DESC
+			LIMIT 5
+		`, userID, name)
+		if err != nil {
+			return "", err
+		}
+	}
+	if len(results) == 0 {
+		return "", fmt.Errorf("нет результатов для «%s»", marker)
+	}
+
+	latest := results[0]
+	unit := ""
+	if latest.Unit != nil {
+		unit = " " + *latest.Unit
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "🧪 %s: %s%s (%s)", latest.MarkerName, formatNumber(*latest.Value), unit,
+		latest.TestDate.Format("02.01.2006"))
+
+	if latest.ReferenceMin != nil || latest.ReferenceMax != nil {
+		status := "✓ норма"
+		if latest.ReferenceMin != nil && *latest.Value < *latest.ReferenceMin {
+			status = "↓ ниже нормы"
+		} else if latest.ReferenceMax != nil && *latest.Value > *latest.ReferenceMax {
+			status = "↑ выше нормы"
+		}
+		fmt.Fprintf(&b, "\n%s [%s–%s]", status, formatOptional(latest.ReferenceMin), formatOptional(latest.ReferenceMax))
+	}
+
+	if len(results) > 1 {
+		prev := results[1]
+		delta := *latest.Value - *prev.Value
+		arrow := "→"
+		if delta > 0 {
+			arrow = "↑"
+		} else if delta < 0 {
+			arrow = "↓"
+		}
+		fmt.Fprintf(&b, "\nТренд: %s %+g", arrow, math.Round(delta*100)/100)
+		if *prev.Value != 0 {
+			fmt.Fprintf(&b, " (%+.0f%%)", delta / *prev.Value * 100)
+		}
+		fmt.Fprintf(&b, " с %s", prev.TestDate.Format("02.01.2006"))
+
+		values := make([]string, 0, len(results))
+		for i := len(results) - 1; i >= 0; i-- {
+			values = append(values, formatNumber(*results[i].Value))
+		}
+		b.WriteString("\nИстория: " + strings.Join(values, " → "))
+	}
+
+	return b.String(), nil
+}
+
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatOptional(v *float64) string {
+	if v == nil {
+		return "…"
+	}
+	return formatNumber(*v)
+}
+
+// metricColumn describes a daily_metrics column that /log can write
+type metricColumn struct {
+	column   string
+	integer  bool
+	min, max float64
+	label    string
+}
+
+var metricColumns = map[string]metricColumn{
+	"weight":     {"weight_kg", false, 20, 400, "вес"},
+	"steps":      {"steps", true, 0, 200000, "шаги"},
+	"sleep":      {"sleep_hours", false, 0, 24, "сон"},
+	"deep_sleep": {"deep_sleep_pct", true, 0, 100, "глубокий сон"},
+	"hrv":        {"hrv", true, 1, 300, "HRV"},
+	"hr":         {"resting_hr", true, 20, 250, "пульс покоя"},
+	"glucose":    {"glucose", false, 1, 40, "глюкоза"},
+	"energy":     {"energy_level", true, 1, 10, "энергия"},
+	"mood":       {"mood_level", true, 1, 10, "настроение"},
+}
+
+var metricAliases = map[string]string{
+	"вес":        "weight",
+	"шаги":       "steps",
+	"сон":        "sleep",
+	"пульс":      "hr",
+	"pulse":      "hr",
+	"глюкоза":    "glucose",
+	"энергия":    "energy",
+	"настроение": "mood",
+	"давление":   "bp",
+}
+
+// refreshGoals brings goals bound to daily metrics up to date; the value is
+// logged either way
+func (h *TelegramHandler) refreshGoals(userID int) {
+	if err := refreshGoals(h.db, userID); err != nil {
+		log.Printf("Telegram: goals refresh for user %d failed: %v", userID, err)
+	}
+}
+
+// logMetric writes one value into today's daily_metrics row. Blood pressure
+// is given as "bp 120/80".
+func (h *TelegramHandler) logMetric(userID int, args []string) (string, error) {
+	if len(args) != 2 {
+		return "", fmt.Errorf("формат: /log <метрика> <значение>, например /log weight 94.2")
+	}
+
+	name := strings.ToLower(args[0])
+	if alias, ok := metricAliases[name]; ok {
+		name = alias
+	}
+
+	loc := loadUserLocation(h.db, userID)
+	date := time.Now().In(loc).Format("2006-01-02")
+
+	if name == "bp" {
+		sysText, diaText, ok := strings.Cut(args[1], "/")
+		sys, err1 := strconv.Atoi(sysText)
+		dia, err2 := strconv.Atoi(diaText)
+		if !ok || err1 != nil || err2 != nil || sys < 50 || sys > 300 || dia < 30 || dia > 200 {
+			return "", fmt.Errorf("давление в формате 120/80")
+		}
+		_, err := h.db.Exec(`
+			INSERT INTO daily_metrics (user_id, metric_date, blood_pressure_sys, blood_pressure_dia)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id, metric_date) DO UPDATE SET
+				blood_pressure_sys = EXCLUDED.blood_pressure_sys,
+				blood_pressure_dia = EXCLUDED.blood_pressure_dia
+		`, userID, date, sys, dia)
+		if err != nil {
+			return "", err
+		}
+		h.refreshGoals(userID)
+		return fmt.Sprintf("📝 Давление %d/%d записано", sys, dia), nil
+	}
+
+	metric, ok := metricColumns[name]
+	if !ok {
+		return "", fmt.Errorf("неизвестная метрика %s", args[0])
+	}
+	value, err := strconv.ParseFloat(strings.Replace(args[1], ",", ".", 1), 64)
+	if err != nil || value < metric.min || value > metric.max {
+		return "", fmt.Errorf("%s: ожидается число от %g до %g", metric.label, metric.min, metric.max)
+	}
+	var param interface{} = value
+	if metric.integer {
+		param = int(math.Round(value))
+	}
+
+	// Column names come from metricColumns, never from user input
+	_, err = h.db.Exec(fmt.Sprintf(`
+		INSERT INTO daily_metrics (user_id, metric_date, %[1]s)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, metric_date) DO UPDATE SET %[1]s = EXCLUDED.%[1]s
+	`, metric.column), userID, date, param)
+	if err != nil {
+		return "", err
+	}
+
+	if name == "weight" {
+		h.db.Exec(`UPDATE users SET weight_kg = $2, updated_at = NOW() WHERE id = $1`, userID, value)
+	}
+	h.refreshGoals(userID)
+
+	return fmt.Sprintf("📝 %s: %s записано", metric.label, args[1]), nil
+}
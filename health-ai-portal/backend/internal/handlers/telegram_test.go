@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"health-ai-portal/internal/database"
+	"health-ai-portal/pkg/telegram"
+)
+
+const testChat = 42
+
+// botCall is one request the bot made to the fake Bot API
+type botCall struct {
+	Method string
+	Params map[string]interface{}
+}
+
+// fakeBotAPI is a local Bot API server that accepts every call and keeps it
+type fakeBotAPI struct {
+	*httptest.Server
+	mu    sync.Mutex
+	calls []botCall
+}
+
+func newFakeBotAPI(t *testing.T) *fakeBotAPI {
+	t.Helper()
+	f := &fakeBotAPI{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := botCall{Method: r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]}
+		json.NewDecoder(r.Body).Decode(&call.Params)
+		f.mu.Lock()
+		f.calls = append(f.calls, call)
+		f.mu.Unlock()
+
+		var result interface{} = true
+		if call.Method == "sendMessage" {
+			result = telegram.Message{MessageID: 1, Chat: telegram.Chat{ID: testChat}}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// take returns and forgets the calls made so far
+func (f *fakeBotAPI) take() []botCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	calls := f.calls
+	f.calls = nil
+	return calls
+}
+
+// replies returns the texts of the messages sent so far
+func (f *fakeBotAPI) replies() []string {
+	var texts []string
+	for _, c := range f.take() {
+		if c.Method == "sendMessage" {
+			texts = append(texts, c.Params["text"].(string))
+		}
+	}
+	return texts
+}
+
+func newTestBot(t *testing.T, db *database.DB) (*TelegramHandler, *fakeBotAPI) {
+	api := newFakeBotAPI(t)
+	client := telegram.NewClient("token", api.URL)
+	return NewTelegramHandler(db, client, []int64{testChat}, "secret"), api
+}
+
+func message(chat int64, text string) telegram.Update {
+	return telegram.Update{Message: &telegram.Message{MessageID: 5, Chat: telegram.Chat{ID: chat}, Text: text}}
+}
+
+// testDB connects to TEST_DATABASE_URL and migrates it; tests that need
+// Postgres are skipped without one
+func testDB(t *testing.T) *database.DB {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := database.New(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.RunMigrations("../database/migrations"); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestTelegramMalformedUpdates(t *testing.T) {
+	bot, api := newTestBot(t, nil)
+	ctx := context.Background()
+
+	for name, u := range map[string]telegram.Update{
+		"empty":                {},
+		"empty text":           message(testChat, ""),
+		"whitespace only":      message(testChat, "  \n\t "),
+		"callback without msg": {CallbackQuery: &telegram.CallbackQuery{ID: "q1", Data: "taken:1"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			bot.handleUpdate(ctx, u)
+			if texts := api.replies(); len(texts) != 0 {
+				t.Errorf("replied %q", texts)
+			}
+		})
+	}
+
+	t.Run("callback with bad data", func(t *testing.T) {
+		bot.handleUpdate(ctx, telegram.Update{CallbackQuery: &telegram.CallbackQuery{
+			ID: "q2", Data: "taken:x", Message: &telegram.Message{Chat: telegram.Chat{ID: testChat}},
+		}})
+		calls := api.take()
+		if len(calls) != 1 || calls[0].Method != "answerCallbackQuery" || calls[0].Params["text"] != "Неизвестная команда" {
+			t.Errorf("calls = %+v", calls)
+		}
+	})
+}
+
+func TestTelegramUnauthorizedChat(t *testing.T) {
+	bot, api := newTestBot(t, nil)
+
+	bot.handleUpdate(context.Background(), message(7, "/start"))
+	texts := api.replies()
+	if len(texts) != 1 || !strings.Contains(texts[0], "не авторизован") {
+		t.Errorf("replies = %q", texts)
+	}
+}
+
+func TestTelegramWebhook(t *testing.T) {
+	bot, _ := newTestBot(t, nil)
+
+	for name, tc := range map[string]struct {
+		secret, body string
+		status       int
+	}{
+		"wrong secret": {"nope", `{"update_id": 1}`, http.StatusUnauthorized},
+		"invalid json": {"secret", `{"update_id":`, http.StatusBadRequest},
+		"empty update": {"secret", `{"update_id": 1}`, http.StatusOK},
+		"blank text":   {"secret", `{"update_id": 2, "message": {"chat": {"id": 42}, "text": " "}}`, http.StatusOK},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/telegram/webhook", strings.NewReader(tc.body))
+			req.Header.Set("X-Telegram-Bot-Api-Secret-Token", tc.secret)
+			rec := httptest.NewRecorder()
+			bot.Webhook(rec, req)
+			if rec.Code != tc.status {
+				t.Errorf("status = %d, want %d", rec.Code, tc.status)
+			}
+		})
+	}
+}
+
+func TestTelegramCommands(t *testing.T) {
+	db := testDB(t)
+	bot, api := newTestBot(t, db)
+	ctx := context.Background()
+
+	var previous *int64
+	if err := db.Get(&previous, `SELECT telegram_chat_id FROM users WHERE id = 1`); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Exec(`UPDATE users SET telegram_chat_id = $1 WHERE id = 1`, previous) })
+	db.Exec(`UPDATE users SET telegram_chat_id = NULL WHERE id = 1`)
+
+	bot.handleUpdate(ctx, message(testChat, "/today"))
+	if texts := api.replies(); len(texts) != 1 || !strings.Contains(texts[0], "/start") {
+		t.Fatalf("unlinked /today replies = %q", texts)
+	}
+
+	bot.handleUpdate(ctx, message(testChat, "/start"))
+	if texts := api.replies(); len(texts) != 1 || !strings.Contains(texts[0], "Чат привязан") {
+		t.Fatalf("/start replies = %q", texts)
+	}
+	var linked int64
+	if err := db.Get(&linked, `SELECT telegram_chat_id FROM users WHERE id = 1`); err != nil || linked != testChat {
+		t.Fatalf("linked chat = %d, %v", linked, err)
+	}
+
+	for _, tc := range []struct{ text, want string }{
+		{"/today", "Приём на"},
+		{"/today@health_bot", "Приём на"},
+		{"/labs", "укажите маркер"},
+		{"/log weight", "формат: /log"},
+		{"/log weight 1000", "ожидается число"},
+		{"/log bp 120-80", "120/80"},
+		{"/log unknown 5", "неизвестная метрика"},
+		{"hello", "Команды:"},
+	} {
+		bot.handleUpdate(ctx, message(testChat, tc.text))
+		if texts := api.replies(); len(texts) != 1 || !strings.Contains(texts[0], tc.want) {
+			t.Errorf("%s replies = %q, want %q", tc.text, texts, tc.want)
+		}
+	}
+}
+
+func callback(id, data string) telegram.Update {
+	return telegram.Update{CallbackQuery: &telegram.CallbackQuery{
+		ID: id, Data: data,
+		Message: &telegram.Message{MessageID: 9, Chat: telegram.Chat{ID: testChat}, Text: "Завтрак"},
+	}}
+}
+
+func TestTelegramReminderButtons(t *testing.T) {
+	db := testDB(t)
+	bot, api := newTestBot(t, db)
+	ctx := context.Background()
+
+	var previous *int64
+	if err := db.Get(&previous, `SELECT telegram_chat_id FROM users WHERE id = 1`); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Exec(`UPDATE users SET telegram_chat_id = $1 WHERE id = 1`, previous) })
+	db.Exec(`UPDATE users SET telegram_chat_id = $1 WHERE id = 1`, testChat)
+
+	// A daily supplement in the breakfast slot and an inactive reminder at
+	// breakfast time, answered for a date in the past
+	var supplementID, reminderID int
+	if err := db.Get(&supplementID, `
+		INSERT INTO supplements (user_id, name, status, frequency) VALUES (1, 'callback test', 'active', 'daily') RETURNING id
+	`); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Exec(`DELETE FROM supplements WHERE id = $1`, supplementID) })
+	if _, err := db.Exec(`INSERT INTO supplement_slots (supplement_id, slot) VALUES ($1, 'breakfast')`, supplementID); err != nil {
+		t.Fatal(err)
+	}
+
+	slots, err := loadSlots(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := ""
+	for _, s := range slots {
+		if s.Name == "breakfast" {
+			clock = s.ClockTime
+		}
+	}
+	if err := db.Get(&reminderID, `
+		INSERT INTO reminders (user_id, reminder_type, title, time, is_active)
+		VALUES (1, 'supplement', 'callback test', $1, false) RETURNING id
+	`, clock); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Exec(`DELETE FROM reminders WHERE id = $1`, reminderID) })
+
+	loc := loadUserLocation(db, 1)
+	at := func(day int) time.Time {
+		at, _ := time.ParseInLocation("2006-01-02 15:04", fmt.Sprintf("2000-01-%02d %s", day, clock), loc)
+		return at
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM intake_logs WHERE user_id = 1 AND scheduled_date BETWEEN '2000-01-01' AND '2000-01-31'`)
+	})
+	delivery := func(day int) int {
+		var id int
+		if err := db.Get(&id, `
+			INSERT INTO reminder_deliveries (reminder_id, user_id, scheduled_for, channel, status)
+			VALUES ($1, 1, $2, 'telegram', 'sent') RETURNING id
+		`, reminderID, at(day)); err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	for _, tc := range []struct {
+		action   string
+		day      int
+		status   string // intake_logs status, empty for none
+		response string
+		answer   string
+	}{
+		// Confirmed long after the slot, so the dose counts as late
+		{"taken", 3, "late", "taken", "✅ Отмечено"},
+		{"skip", 4, "skipped", "skipped", "⏭ Пропущено"},
+		{"snooze", 5, "", "snoozed", "💤 Напомню в "},
+	} {
+		t.Run(tc.action, func(t *testing.T) {
+			id := delivery(tc.day)
+			data := fmt.Sprintf("%s:%d", tc.action, id)
+			start := time.Now()
+			bot.handleUpdate(ctx, callback("q-"+tc.action, data))
+
+			calls := api.take()
+			if len(calls) != 2 {
+				t.Fatalf("calls = %+v", calls)
+			}
+			answer, edit := calls[0], calls[1]
+			if answer.Method != "answerCallbackQuery" || answer.Params["callback_query_id"] != "q-"+tc.action ||
+				!strings.HasPrefix(answer.Params["text"].(string), tc.answer) {
+				t.Errorf("answer = %+v", answer)
+			}
+			if edit.Method != "editMessageReplyMarkup" || edit.Params["chat_id"] != float64(testChat) || edit.Params["message_id"] != float64(9) {
+				t.Errorf("edit = %+v", edit)
+			}
+			keyboard, _ := json.Marshal(edit.Params["reply_markup"])
+			want := fmt.Sprintf(`{"inline_keyboard":[[{"text":%q,"callback_data":"done:%d"}]]}`, answer.Params["text"], id)
+			if string(keyboard) != want {
+				t.Errorf("reply_markup = %s, want %s", keyboard, want)
+			}
+
+			var response *string
+			db.Get(&response, `SELECT response FROM reminder_deliveries WHERE id = $1`, id)
+			if response == nil || *response != tc.response {
+				t.Errorf("delivery response = %v, want %s", response, tc.response)
+			}
+
+			var status string
+			err := db.Get(&status, `
+				SELECT status FROM intake_logs
+				WHERE supplement_id = $1 AND slot = 'breakfast' AND scheduled_date = $2
+			`, supplementID, at(tc.day).Format("2006-01-02"))
+			switch {
+			case tc.status == "" && err == nil:
+				t.Errorf("intake logged as %s", status)
+			case tc.status != "" && status != tc.status:
+				t.Errorf("intake status = %q (%v), want %s", status, err, tc.status)
+			}
+
+			if tc.action == "snooze" {
+				var until *time.Time
+				db.Get(&until, `SELECT snoozed_until FROM reminders WHERE id = $1`, reminderID)
+				want := start.Add(telegramSnoozeMinutes * time.Minute)
+				if until == nil || until.Before(want.Add(-time.Second)) || until.After(want.Add(time.Minute)) {
+					t.Errorf("snoozed_until = %v, want about %s", until, want)
+				}
+			}
+
+			// The outcome button only acknowledges
+			bot.handleUpdate(ctx, callback("q-done", fmt.Sprintf("done:%d", id)))
+			if calls := api.take(); len(calls) != 1 || calls[0].Params["text"] != "Ответ уже записан" {
+				t.Errorf("done calls = %+v", calls)
+			}
+		})
+	}
+}
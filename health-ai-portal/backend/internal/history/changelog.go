@@ -0,0 +1,179 @@
+package history
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// StackChange is a row of "Изменения в протоколе" that adds or removes
+// something from the stack
+type StackChange struct {
+	Date     time.Time
+	Cycle    string   // CYCLE-NNNN the entry belongs to, if named
+	Subjects []string // what changed, as written: "MAO-стек", "Суглат"
+	Event    string   // added, removed or resumed
+	Dose     string   // as written, empty when the entry names none
+	Text     string
+	Reason   string
+	Line     int
+}
+
+var changeHeading = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})\s*[—–-]*\s*(CYCLE-\d+)?`)
+
+// changeDose finds a dose written into the change text: "Суглат 500 мг ДОБАВЛЕН"
+var changeDose = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?(?:\s*[-–]\s*\d+(?:[.,]\d+)?)?\s*(?:мкг|мг|ме|мл|mcg|mg|iu|ml|г|g))(?:[^\p{L}]|$)`)
+
+// changeEvents classify a change by the verb that follows its subject,
+// first match wins
+var changeEvents = []struct {
+	event   string
+	pattern *regexp.Regexp
+}{
+	{"removed", regexp.MustCompile(`убран|отмен|исключ|❌`)},
+	{"resumed", regexp.MustCompile(`возобнов|вернут`)},
+	{"added", regexp.MustCompile(`добавл|начат|введ`)},
+}
+
+// ParseChangeLog reads the dated entries of the change log. Only protocol
+// changes are imported; verdicts, approvals and the created files are
+// skipped, other sections are reported.
+func ParseChangeLog(file, src string) ([]StackChange, []Unparsed) {
+	var changes []StackChange
+	var unparsed []Unparsed
+	_, entries := sections(splitFile(src), 1, 2)
+	for _, entry := range entries {
+		title := plain(entry.title)
+		if skippedBlock(title) {
+			continue
+		}
+		m := changeHeading.FindStringSubmatch(title)
+		date, ok := firstDate(title)
+		if m == nil || !ok {
+			if !empty(entry.lines) {
+				unparsed = append(unparsed, Unparsed{file, entry.line, title, "heading does not start with a date"})
+			}
+			continue
+		}
+
+		_, parts := sections(entry.lines, entry.line+1, 3)
+		for _, part := range parts {
+			where := title + " / " + plain(part.title)
+			lower := strings.ToLower(plain(part.title))
+			switch {
+			case strings.HasPrefix(lower, "вердикт"), strings.HasPrefix(lower, "создано"), strings.HasPrefix(lower, "одобрено"):
+				// Recorded with the cycle itself
+
+			case strings.HasPrefix(lower, "изменения в протоколе"):
+				for _, t := range tables(part.lines) {
+					found, problems := stackChanges(t, part, date, m[2])
+					changes = append(changes, found...)
+					for _, p := range problems {
+						unparsed = append(unparsed, Unparsed{file, p.line, where, p.reason})
+					}
+				}
+
+			case strings.HasPrefix(lower, "ключевые находки"):
+				unparsed = append(unparsed, Unparsed{file, part.line, where, "lab values are imported from the lab history instead"})
+
+			case strings.HasPrefix(lower, "решения по"):
+				unparsed = append(unparsed, Unparsed{file, part.line, where, "decisions without a change to the stack; not imported"})
+
+			default:
+				if !empty(part.lines) {
+					unparsed = append(unparsed, Unparsed{file, part.line, where, "not a stack change; not imported"})
+				}
+			}
+		}
+	}
+	return changes, unparsed
+}
+
+func stackChanges(t table, part section, date time.Time, cycle string) ([]StackChange, []problem) {
+	changeCol := t.column("изменение")
+	reasonCol := t.column("причина")
+	statusCol := t.column("статус")
+	doseCol := t.column("доза")
+	if changeCol < 0 {
+		return nil, []problem{{part.line, "table has no Изменение column"}}
+	}
+
+	var changes []StackChange
+	var problems []problem
+	for _, row := range t.rows {
+		text := plain(t.cell(row, changeCol))
+		if text == "" {
+			continue
+		}
+		line := part.line + rowLine(part.lines, row)
+		event := changeEvent(strings.ToLower(text + " " + t.cell(row, statusCol)))
+		subject := changeSubject(text)
+		if event == "" || subject == "" {
+			problems = append(problems, problem{line, fmt.Sprintf("%q: not an addition or removal", text)})
+			continue
+		}
+		dose := plain(t.cell(row, doseCol))
+		if m := changeDose.FindStringSubmatchIndex(subject); m != nil {
+			if dose == "" {
+				dose = subject[m[2]:m[3]]
+			}
+			subject = strings.TrimSpace(subject[:m[2]] + subject[m[3]:])
+		}
+		var subjects []string
+		for _, s := range strings.Split(subject, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				subjects = append(subjects, s)
+			}
+		}
+		changes = append(changes, StackChange{
+			Date:     date,
+			Cycle:    cycle,
+			Subjects: subjects,
+			Event:    event,
+			Dose:     dose,
+			Text:     text,
+			Reason:   plain(t.cell(row, reasonCol)),
+			Line:     line,
+		})
+	}
+	return changes, problems
+}
+
+func changeEvent(s string) string {
+	// A permission to return or a confirmation is not a change
+	if strings.Contains(s, "разреш") || strings.Contains(s, "подтвержд") {
+		return ""
+	}
+	for _, e := range changeEvents {
+		if e.pattern.MatchString(s) {
+			return e.event
+		}
+	}
+	return ""
+}
+
+// changeSubject is the text before the first upper-case verb: "MAO-стек" of
+// "MAO-стек УБРАН"
+func changeSubject(text string) string {
+	words := strings.Fields(text)
+	for i, w := range words {
+		if i > 0 && shouting(w) {
+			return strings.Join(words[:i], " ")
+		}
+	}
+	return ""
+}
+
+// shouting reports a word of at least three letters, all Cyrillic capitals
+func shouting(w string) bool {
+	n := 0
+	for _, r := range w {
+		if !unicode.Is(unicode.Cyrillic, r) || !unicode.IsUpper(r) {
+			return false
+		}
+		n++
+	}
+	return n >= 3
+}
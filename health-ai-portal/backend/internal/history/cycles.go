@@ -0,0 +1,256 @@
+package history
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cycle is one "### CYCLE-NNNN" entry of the cycle history
+type Cycle struct {
+	Ref                  string
+	Date                 time.Time
+	Type                 string // full, partial or control; "" when not recognised
+	Verdict              string // go, wait or stop; "" when not recognised
+	Goals                string
+	RSLOutput            string
+	MasterCuratorOutput  string
+	RedTeamOutput        string
+	MetaSupervisorOutput string
+	Decisions            map[string][]string
+	RequiredLabs         []string
+	NextReviewDate       *time.Time
+}
+
+var cycleHeading = regexp.MustCompile(`^CYCLE-\d+`)
+
+// roleCaptions map the bold captions of "Ключевые выводы" to role outputs
+var roleCaptions = map[string]string{
+	"RESEARCH & STRATEGY LEAD": "rsl",
+	"RSL":                      "rsl",
+	"MASTER CURATOR":           "curator",
+	"RED TEAM":                 "red_team",
+	"META-SUPERVISOR":          "meta",
+}
+
+var boldCaption = regexp.MustCompile(`^\*\*(.+?):?\*\*:?\s*$`)
+
+// ParseCycles reads the cycle history. Entries without a real date, such as
+// the template, are reported and skipped.
+func ParseCycles(file, src string) ([]Cycle, []Unparsed) {
+	var cycles []Cycle
+	var unparsed []Unparsed
+	_, entries := sections(splitFile(src), 1, 3)
+	for _, entry := range entries {
+		ref := cycleHeading.FindString(entry.title)
+		if ref == "" {
+			unparsed = append(unparsed, Unparsed{file, entry.line, entry.title, "not a CYCLE-NNNN entry"})
+			continue
+		}
+		c, problems := parseCycle(file, ref, entry)
+		unparsed = append(unparsed, problems...)
+		if c != nil {
+			cycles = append(cycles, *c)
+		}
+	}
+	return cycles, unparsed
+}
+
+func parseCycle(file, ref string, entry section) (*Cycle, []Unparsed) {
+	var unparsed []Unparsed
+	report := func(line int, title, reason string) {
+		unparsed = append(unparsed, Unparsed{file, line, ref + " / " + title, reason})
+	}
+
+	header, parts := sections(entry.lines, entry.line+1, 4)
+	dateText, _ := field(header, "Дата цикла")
+	date, ok := firstDate(dateText)
+	if !ok {
+		report(entry.line, entry.title, fmt.Sprintf("no cycle date (%q); template entries are skipped", dateText))
+		return nil, unparsed
+	}
+
+	c := &Cycle{Ref: ref, Date: date, Decisions: map[string][]string{}}
+	if typeText, ok := field(header, "Тип цикла"); ok {
+		c.Type = cycleType(typeText)
+		if c.Type == "" {
+			report(entry.line, "Тип цикла", fmt.Sprintf("unknown cycle type %q", typeText))
+		}
+	}
+
+	var meta []string
+	for _, part := range parts {
+		title := strings.ToLower(plain(part.title))
+		switch {
+		case strings.HasPrefix(title, "фокус"):
+			c.Goals = strings.Join(bullets(part.lines), "\n")
+
+		case strings.HasPrefix(title, "ключевые выводы"):
+			for _, p := range c.setRoleOutputs(part) {
+				report(part.line, part.title, p)
+			}
+
+		case strings.HasPrefix(title, "вердикт"):
+			c.Verdict = verdict(part.text())
+			if c.Verdict == "" {
+				report(part.line, part.title, "no 🟢/🟡/🔴 verdict mark")
+			}
+			meta = append(meta, part.text())
+
+		case strings.HasPrefix(title, "принятые решения"):
+			for _, item := range bullets(part.lines) {
+				kind := decisionKind(item)
+				c.Decisions[kind] = append(c.Decisions[kind], plain(item))
+			}
+
+		case strings.HasPrefix(title, "обязательные условия"):
+			for _, t := range tables(part.lines) {
+				col := t.column("анализ")
+				for _, row := range t.rows {
+					if lab := plain(t.cell(row, col)); lab != "" {
+						c.RequiredLabs = append(c.RequiredLabs, lab)
+					}
+				}
+			}
+			meta = append(meta, "## "+part.title+"\n\n"+part.text())
+
+		case strings.HasPrefix(title, "протокол"):
+			meta = append(meta, "## "+part.title+"\n\n"+part.text())
+
+		case strings.HasPrefix(title, "ожидаемый горизонт"):
+			if d, ok := reviewDate(date, part.text()); ok {
+				c.NextReviewDate = &d
+			} else {
+				report(part.line, part.title, "no period in weeks, days or months")
+			}
+
+		case strings.HasPrefix(title, "фактический результат"):
+			if !empty(part.lines) {
+				meta = append(meta, "## "+part.title+"\n\n"+part.text())
+			}
+
+		case strings.HasPrefix(title, "входные данные"):
+			report(part.line, part.title, "lists the source files only; not imported")
+
+		case strings.HasPrefix(title, "ключевые находки"):
+			report(part.line, part.title, "lab values are imported from the lab history instead")
+
+		default:
+			if !empty(part.lines) {
+				report(part.line, part.title, "unknown section")
+			}
+		}
+	}
+	if c.MetaSupervisorOutput != "" {
+		meta = append([]string{c.MetaSupervisorOutput}, meta...)
+	}
+	c.MetaSupervisorOutput = strings.Join(meta, "\n\n")
+	return c, unparsed
+}
+
+// setRoleOutputs splits "Ключевые выводы" at the bold role captions. Other
+// captions stay with the role before them. Returns problems to report.
+func (c *Cycle) setRoleOutputs(part section) []string {
+	outputs := map[string][]string{}
+	role := ""
+	var orphan []string
+	for _, line := range part.lines {
+		if m := boldCaption.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			if r, ok := roleCaptions[strings.ToUpper(strings.TrimSpace(m[1]))]; ok {
+				role = r
+				continue
+			}
+		}
+		if role == "" {
+			orphan = append(orphan, line)
+			continue
+		}
+		outputs[role] = append(outputs[role], line)
+	}
+
+	text := func(role string) string { return strings.TrimSpace(strings.Join(outputs[role], "\n")) }
+	c.RSLOutput = text("rsl")
+	c.MasterCuratorOutput = text("curator")
+	c.RedTeamOutput = text("red_team")
+	if meta := text("meta"); meta != "" {
+		c.MetaSupervisorOutput = meta
+	}
+
+	var problems []string
+	if !empty(orphan) {
+		problems = append(problems, "text before the first role caption was not imported")
+	}
+	if len(outputs) == 0 {
+		problems = append(problems, "no role captions (**MASTER CURATOR:** and so on)")
+	}
+	return problems
+}
+
+func cycleType(s string) string {
+	s = strings.ToLower(s)
+	switch {
+	case strings.HasPrefix(s, "контрольн"):
+		return "control"
+	case strings.HasPrefix(s, "полн"):
+		return "full"
+	case strings.HasPrefix(s, "частичн"):
+		return "partial"
+	}
+	return ""
+}
+
+// verdict reads the first traffic-light mark
+func verdict(s string) string {
+	marks := map[string]string{"🟢": "go", "🟡": "wait", "🔴": "stop"}
+	first, at := "", len(s)
+	for mark, v := range marks {
+		if i := strings.Index(s, mark); i >= 0 && i < at {
+			first, at = v, i
+		}
+	}
+	return first
+}
+
+// decisionKinds classify decision lines, first match wins
+var decisionKinds = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{"continued", regexp.MustCompile(`не добавл|подтвержд|продолж|без изменений|:\s*нет(?:[\s(]|$)`)},
+	{"removed", regexp.MustCompile(`убран|отмен|исключ`)},
+	{"added", regexp.MustCompile(`добав|начат|разреш|вернут|введ`)},
+}
+
+func decisionKind(item string) string {
+	lower := strings.ToLower(item)
+	for _, k := range decisionKinds {
+		if k.pattern.MatchString(lower) {
+			return k.kind
+		}
+	}
+	return "changed"
+}
+
+var periodPattern = regexp.MustCompile(`(\d+)(?:\s*[-–]\s*(\d+))?\s*(нед|дн|мес|week|day|month)`)
+
+// reviewDate adds the longest period of the horizon ("6-8 недель") to the
+// cycle date
+func reviewDate(from time.Time, s string) (time.Time, bool) {
+	m := periodPattern.FindStringSubmatch(strings.ToLower(s))
+	if m == nil {
+		return time.Time{}, false
+	}
+	n, _ := strconv.Atoi(m[1])
+	if m[2] != "" {
+		n, _ = strconv.Atoi(m[2])
+	}
+	switch m[3] {
+	case "нед", "week":
+		return from.AddDate(0, 0, 7*n), true
+	case "дн", "day":
+		return from.AddDate(0, 0, n), true
+	}
+	return from.AddDate(0, n, 0), true
+}
@@ -0,0 +1,322 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"health-ai-portal/internal/cycleinput"
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/models"
+)
+
+// The pre-portal Markdown records read by the history import
+const (
+	CyclesFile    = "08_cycles_history.md"
+	LabsFile      = "07_labs_history.md"
+	ChangeLogFile = "06_change_log.md"
+)
+
+// ImportCounts is the outcome for one kind of record
+type ImportCounts struct {
+	Parsed   int `json:"parsed"`
+	Inserted int `json:"inserted"`
+	Merged   int `json:"merged"`
+	Skipped  int `json:"skipped"` // already imported
+}
+
+// ImportReport is the outcome of a history import
+type ImportReport struct {
+	DryRun    bool         `json:"dry_run"`
+	Cycles    ImportCounts `json:"cycles"`
+	Labs      ImportCounts `json:"labs"`
+	Events    ImportCounts `json:"events"`
+	Warnings  []string     `json:"warnings"`
+	Unparsed  []Unparsed   `json:"unparsed"`
+	Conflicts []Unparsed   `json:"conflicts"` // changes the current stack contradicts, not recorded
+}
+
+// LabStore stores lab reports the way the portal's own lab import does:
+// names and units normalised, results already stored skipped. It returns
+// the inserted and skipped counts and any warnings.
+type LabStore interface {
+	StoreLabs(userID int, labs []LabReport) (ImportCounts, []string)
+}
+
+// Importer loads the Markdown history into cycles, lab_results and
+// supplement_events. Re-running it is safe: cycles are matched by their
+// source reference, lab results and events by date and value. Lab results
+// go through labs.
+type Importer struct {
+	db   *database.DB
+	labs LabStore
+}
+
+func NewImporter(db *database.DB, labs LabStore) *Importer {
+	return &Importer{db: db, labs: labs}
+}
+
+// Import reads the history files from dir. A missing file is reported and
+// skipped; with dryRun the files are only parsed.
+func (im *Importer) Import(userID int, dir string, dryRun bool) (*ImportReport, error) {
+	report := &ImportReport{DryRun: dryRun, Warnings: []string{}, Unparsed: []Unparsed{}, Conflicts: []Unparsed{}}
+	read := func(name string) (string, bool, error) {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			report.Unparsed = append(report.Unparsed, Unparsed{File: name, Reason: "file not found"})
+			return "", false, nil
+		}
+		return string(data), err == nil, err
+	}
+
+	src, ok, err := read(CyclesFile)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		cycles, unparsed := ParseCycles(CyclesFile, src)
+		report.Unparsed = append(report.Unparsed, unparsed...)
+		report.Cycles.Parsed = len(cycles)
+		if !dryRun {
+			for _, c := range cycles {
+				if err := im.importCycle(userID, c, report); err != nil {
+					return nil, fmt.Errorf("%s: %w", c.Ref, err)
+				}
+			}
+		}
+	}
+
+	src, ok, err = read(LabsFile)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		labs, unparsed := ParseLabs(LabsFile, src)
+		report.Unparsed = append(report.Unparsed, unparsed...)
+		for _, lab := range labs {
+			report.Labs.Parsed += len(lab.Markers)
+		}
+		if !dryRun {
+			counts, warnings := im.labs.StoreLabs(userID, labs)
+			report.Labs.Inserted, report.Labs.Skipped = counts.Inserted, counts.Skipped
+			report.Warnings = append(report.Warnings, warnings...)
+		}
+	}
+
+	src, ok, err = read(ChangeLogFile)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		changes, unparsed := ParseChangeLog(ChangeLogFile, src)
+		report.Unparsed = append(report.Unparsed, unparsed...)
+		if err := im.importChanges(userID, changes, dryRun, report); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// importCycle stores a cycle unless it was imported before. A cycle entered
+// by hand or seeded for the same date and type is completed instead, so the
+// history does not show it twice.
+func (im *Importer) importCycle(userID int, c Cycle, report *ImportReport) error {
+	ref := CyclesFile + "#" + c.Ref
+
+	var existing int
+	err := im.db.Get(&existing, `SELECT id FROM cycles WHERE user_id = $1 AND source_ref = $2`, userID, ref)
+	if err == nil {
+		report.Cycles.Skipped++
+		return nil
+	}
+
+	// Only the focus of a cycle was written down; the rest of the input
+	// template stays empty
+	inputData, err := json.Marshal(map[string]interface{}{"schema_version": cycleinput.CurrentVersion, "goals": c.Goals})
+	if err != nil {
+		return err
+	}
+	var decisions, requiredLabs []byte
+	if len(c.Decisions) > 0 {
+		if decisions, err = json.Marshal(c.Decisions); err != nil {
+			return err
+		}
+	}
+	if len(c.RequiredLabs) > 0 {
+		if requiredLabs, err = json.Marshal(c.RequiredLabs); err != nil {
+			return err
+		}
+	}
+	cycleType, verdict := optional(c.Type), optional(c.Verdict)
+	outputs := []*string{optional(c.RSLOutput), optional(c.MasterCuratorOutput), optional(c.RedTeamOutput), optional(c.MetaSupervisorOutput)}
+
+	res, err := im.db.Exec(`
+		UPDATE cycles SET
+			cycle_type = COALESCE(cycle_type, $4),
+			verdict = COALESCE(verdict, $5),
+			input_data = COALESCE(input_data, $6),
+			rsl_output = COALESCE(rsl_output, $7),
+			master_curator_output = COALESCE(master_curator_output, $8),
+			red_team_output = COALESCE(red_team_output, $9),
+			meta_supervisor_output = COALESCE(meta_supervisor_output, $10),
+			decisions = COALESCE(decisions, $11),
+			required_labs = COALESCE(required_labs, $12),
+			next_review_date = COALESCE(next_review_date, $13),
+			source_ref = $3,
+			updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM cycles
+			WHERE user_id = $1 AND cycle_date = $2 AND source_ref IS NULL
+				AND (cycle_type IS NULL OR $4::varchar IS NULL OR cycle_type = $4)
+			ORDER BY id
+			LIMIT 1
+		)
+	`, userID, c.Date, ref, cycleType, verdict, inputData,
+		outputs[0], outputs[1], outputs[2], outputs[3], nullJSON(decisions), nullJSON(requiredLabs), c.NextReviewDate)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		report.Cycles.Merged++
+		return nil
+	}
+
+	_, err = im.db.Exec(`
+		INSERT INTO cycles (user_id, cycle_date, cycle_type, verdict, input_data, rsl_output, master_curator_output,
+			red_team_output, meta_supervisor_output, decisions, required_labs, next_review_date, source_ref)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`, userID, c.Date, cycleType, verdict, inputData,
+		outputs[0], outputs[1], outputs[2], outputs[3], nullJSON(decisions), nullJSON(requiredLabs), c.NextReviewDate, ref)
+	if err != nil {
+		return err
+	}
+	report.Cycles.Inserted++
+	return nil
+}
+
+// importChanges records stack changes against the supplements they name.
+// Names are matched without the parenthesised part: "Суглат" finds
+// "Суглат (ипраглифлозин)". Group names such as "MAO-стек" are reported, as
+// are removals of supplements that are active today.
+func (im *Importer) importChanges(userID int, changes []StackChange, dryRun bool, report *ImportReport) error {
+	var supplements []models.Supplement
+	if err := im.db.Select(&supplements, `SELECT * FROM supplements WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		for _, subject := range change.Subjects {
+			report.Events.Parsed++
+			where := fmt.Sprintf("%s / %s", change.Date.Format("2006-01-02"), change.Text)
+			s, ok := matchSupplement(supplements, subject)
+			if !ok {
+				report.Unparsed = append(report.Unparsed, Unparsed{
+					File: ChangeLogFile, Line: change.Line, Section: where,
+					Reason: fmt.Sprintf("%q does not match one supplement", subject),
+				})
+				continue
+			}
+			// Without a later event the removal would end a supplement that
+			// is still taken
+			if change.Event == models.SupplementEventRemoved && s.Status == "active" {
+				report.Conflicts = append(report.Conflicts, Unparsed{
+					File: ChangeLogFile, Line: change.Line, Section: where,
+					Reason: fmt.Sprintf("%s is active now; the removal was not recorded", s.Name),
+				})
+				continue
+			}
+			if dryRun {
+				continue
+			}
+
+			var existing int
+			err := im.db.Get(&existing, `
+				SELECT id FROM supplement_events
+				WHERE supplement_id = $1 AND event_type = $2 AND event_date::date = $3
+				LIMIT 1
+			`, s.ID, change.Event, change.Date)
+			if err == nil {
+				report.Events.Skipped++
+				continue
+			}
+
+			// The snapshot holds only what the entry says; today's row may
+			// differ in anything from dose to schedule
+			status := "active"
+			if change.Event == models.SupplementEventRemoved {
+				status = "removed"
+			}
+			var dose *string
+			if change.Dose != "" {
+				dose = &change.Dose
+			}
+			data, err := json.Marshal(map[string]interface{}{
+				"id":      s.ID,
+				"user_id": userID,
+				"name":    s.Name,
+				"status":  status,
+				"dose":    dose,
+			})
+			if err != nil {
+				return err
+			}
+			notes := fmt.Sprintf("imported from %s", ChangeLogFile)
+			if change.Cycle != "" {
+				notes += " (" + change.Cycle + ")"
+			}
+			if change.Reason != "" {
+				notes += ": " + change.Reason
+			}
+			if dose == nil {
+				notes += "; backfilled, dose unknown"
+			}
+			_, err = im.db.Exec(`
+				INSERT INTO supplement_events (supplement_id, user_id, event_type, event_date, dose, status, snapshot, notes)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			`, s.ID, userID, change.Event, change.Date, dose, status, data, notes)
+			if err != nil {
+				return err
+			}
+			report.Events.Inserted++
+		}
+	}
+	return nil
+}
+
+// matchSupplement finds the one supplement named subject, ignoring case and
+// anything in parentheses
+func matchSupplement(supplements []models.Supplement, subject string) (models.Supplement, bool) {
+	key := strings.ToLower(strings.TrimSpace(subject))
+	var found []models.Supplement
+	for _, s := range supplements {
+		name := strings.ToLower(strings.TrimSpace(s.Name))
+		if i := strings.Index(name, "("); i > 0 {
+			name = strings.TrimSpace(name[:i])
+		}
+		if name == key || strings.HasPrefix(name, key+" ") {
+			found = append(found, s)
+		}
+	}
+	if len(found) != 1 {
+		return models.Supplement{}, false
+	}
+	return found[0], true
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func nullJSON(data []byte) interface{} {
+	if data == nil {
+		return nil
+	}
+	return data
+}
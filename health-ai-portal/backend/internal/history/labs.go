@@ -0,0 +1,166 @@
+package history
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LabReport is one dated "## ..." block of the lab history
+type LabReport struct {
+	Title   string
+	Date    time.Time
+	LabName string
+	Markers []LabMarker
+}
+
+// LabMarker is a table row of a lab report, as written
+type LabMarker struct {
+	Name       string
+	Value      *float64
+	Comparator string // "<", ">" and so on when the value is a bound
+	Unit       string
+	Reference  string
+	Notes      string
+	Line       int
+}
+
+// markerValue is a number, optionally a bound, then the unit and an aside
+// in parentheses: "7.54 нг/мл (56.6 нмоль/л)", "<0.5 нг/мл"
+var markerValue = regexp.MustCompile(`^(<=|>=|<|>|≤|≥)?\s*(-?\d+(?:[.,]\d+)?)\s*([^()]*?)\s*(?:\((.*)\))?\s*$`)
+
+var rangeValue = regexp.MustCompile(`^\d+(?:[.,]\d+)?\s*[-–]\s*\d+(?:[.,]\d+)?`)
+
+// ParseLabs reads the lab history. Level-2 blocks with a "Дата:" line are
+// reports; the purpose, rules and markers of the template are skipped.
+func ParseLabs(file, src string) ([]LabReport, []Unparsed) {
+	var reports []LabReport
+	var unparsed []Unparsed
+	_, blocks := sections(splitFile(src), 1, 2)
+	for _, block := range blocks {
+		title := plain(block.title)
+		if skippedBlock(title) {
+			continue
+		}
+		header, parts := sections(block.lines, block.line+1, 3)
+		dateText, ok := lineField(header, "Дата")
+		date, dated := firstDate(dateText)
+		if !ok || !dated {
+			if !empty(block.lines) {
+				unparsed = append(unparsed, Unparsed{file, block.line, title, "no \"Дата:\" line with an ISO date"})
+			}
+			continue
+		}
+		labName, _ := lineField(header, "Лаборатория")
+		report := LabReport{Title: title, Date: date, LabName: labName}
+
+		for _, part := range parts {
+			where := title + " / " + plain(part.title)
+			found := tables(part.lines)
+			if len(found) == 0 {
+				if !empty(part.lines) {
+					unparsed = append(unparsed, Unparsed{file, part.line, where, "no table of results"})
+				}
+				continue
+			}
+			for _, t := range found {
+				markers, problems := labMarkers(t, part)
+				report.Markers = append(report.Markers, markers...)
+				for _, p := range problems {
+					unparsed = append(unparsed, Unparsed{file, p.line, where, p.reason})
+				}
+			}
+		}
+		reports = append(reports, report)
+	}
+	return reports, unparsed
+}
+
+func skippedBlock(title string) bool {
+	lower := strings.ToLower(title)
+	return strings.HasPrefix(title, "🔽") || strings.HasPrefix(lower, "назначение") ||
+		strings.HasPrefix(lower, "правила") || strings.HasPrefix(lower, "легенда")
+}
+
+type problem struct {
+	line   int
+	reason string
+}
+
+func labMarkers(t table, part section) ([]LabMarker, []problem) {
+	nameCol := t.column("показатель", "маркер", "анализ")
+	valueCol := t.column("значение", "результат")
+	refCol := t.column("референс", "норма")
+	noteCol := t.column("оценка", "комментарий")
+	if nameCol < 0 || valueCol < 0 {
+		return nil, []problem{{part.line, "table has no Показатель/Значение columns"}}
+	}
+
+	var markers []LabMarker
+	var problems []problem
+	for _, row := range t.rows {
+		name := plain(t.cell(row, nameCol))
+		raw := plain(t.cell(row, valueCol))
+		if name == "" || raw == "" {
+			continue
+		}
+		line := part.line + rowLine(part.lines, row)
+		if rangeValue.MatchString(raw) {
+			problems = append(problems, problem{line, fmt.Sprintf("%s: %q is a range, not a single value", name, raw)})
+			continue
+		}
+		m := markerValue.FindStringSubmatch(raw)
+		if m == nil {
+			problems = append(problems, problem{line, fmt.Sprintf("%s: cannot read value %q", name, raw)})
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.Replace(m[2], ",", ".", 1), 64)
+		if err != nil {
+			problems = append(problems, problem{line, fmt.Sprintf("%s: cannot read value %q", name, raw)})
+			continue
+		}
+
+		var notes []string
+		if m[4] != "" {
+			notes = append(notes, m[4])
+		}
+		if n := plain(t.cell(row, noteCol)); n != "" {
+			notes = append(notes, n)
+		}
+		unit := m[3]
+		markers = append(markers, LabMarker{
+			Name:       name,
+			Value:      &v,
+			Comparator: strings.NewReplacer("≤", "<=", "≥", ">=").Replace(m[1]),
+			Unit:       unit,
+			Reference:  strings.TrimSpace(strings.TrimSuffix(plain(t.cell(row, refCol)), unit)),
+			Notes:      strings.Join(notes, "; "),
+			Line:       line,
+		})
+	}
+	return markers, problems
+}
+
+// rowLine is the offset of a table row below its section heading
+func rowLine(lines []string, row []string) int {
+	for i, line := range lines {
+		if strings.Contains(line, "|") && strings.Join(cells(line), "|") == strings.Join(row, "|") {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// lineField reads plain "Label: value" lines, as under a report heading
+func lineField(lines []string, label string) (string, bool) {
+	prefix := strings.ToLower(label) + ":"
+	for _, line := range lines {
+		t := plain(strings.TrimLeft(strings.TrimSpace(line), "-* "))
+		if strings.HasPrefix(strings.ToLower(t), prefix) {
+			return strings.TrimSpace(t[len(prefix):]), true
+		}
+	}
+	return "", false
+}
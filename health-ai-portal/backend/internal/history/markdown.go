@@ -0,0 +1,176 @@
+// Package history parses the pre-portal Markdown records: the cycle history
+// (08_cycles_history.md), the lab history (07_labs_history.md) and the
+// change log (06_change_log.md). Parsing is lenient; whatever does not fit
+// is returned as Unparsed so it can be reviewed by hand. Importer loads the
+// parsed records into the database.
+package history
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Unparsed is a part of a file that was not imported, and why
+type Unparsed struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Section string `json:"section"`
+	Reason  string `json:"reason"`
+}
+
+// section is a heading and everything under it up to the next heading of
+// the same or a higher level
+type section struct {
+	title string
+	line  int // 1-based line of the heading
+	lines []string
+}
+
+var headingPattern = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+
+// sections splits lines at headings of the given level. Lines before the
+// first such heading are returned as the preamble.
+func sections(lines []string, firstLine, level int) (preamble []string, result []section) {
+	inFence := false
+	current := -1
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		}
+		if m := headingPattern.FindStringSubmatch(line); m != nil && !inFence && len(m[1]) == level {
+			result = append(result, section{title: m[2], line: firstLine + i})
+			current = len(result) - 1
+			continue
+		}
+		if m := headingPattern.FindStringSubmatch(line); m != nil && !inFence && len(m[1]) < level {
+			// A higher heading closes the current section
+			current = -1
+		}
+		if current < 0 {
+			preamble = append(preamble, line)
+		} else {
+			result[current].lines = append(result[current].lines, line)
+		}
+	}
+	return preamble, result
+}
+
+func (s section) text() string {
+	return strings.TrimSpace(strings.Join(s.lines, "\n"))
+}
+
+// splitFile normalises line endings
+func splitFile(src string) []string {
+	return strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+}
+
+// empty reports whether lines hold nothing but blanks, rules and the "…"
+// placeholders of the templates
+func empty(lines []string) bool {
+	for _, line := range lines {
+		t := strings.Trim(strings.TrimSpace(line), "-*_ ")
+		if t != "" && t != "…" && t != "..." && !placeholder.MatchString(t) {
+			return false
+		}
+	}
+	return true
+}
+
+var placeholder = regexp.MustCompile(`^\(заполняется.*\)$`)
+
+// table is a Markdown table: a header row and data rows of trimmed cells
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+var delimiterRow = regexp.MustCompile(`^\|?\s*:?-{2,}:?\s*(\|\s*:?-{2,}:?\s*)*\|?$`)
+
+// tables returns every table in lines
+func tables(lines []string) []table {
+	var result []table
+	for i := 0; i+1 < len(lines); i++ {
+		if !strings.Contains(lines[i], "|") || !delimiterRow.MatchString(strings.TrimSpace(lines[i+1])) {
+			continue
+		}
+		t := table{header: cells(lines[i])}
+		i += 2
+		for ; i < len(lines) && strings.Contains(lines[i], "|"); i++ {
+			t.rows = append(t.rows, cells(lines[i]))
+		}
+		result = append(result, t)
+	}
+	return result
+}
+
+func cells(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	parts := strings.Split(line, "|")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
+
+// column finds the column whose caption starts with one of the prefixes
+func (t table) column(prefixes ...string) int {
+	for i, h := range t.header {
+		h = strings.ToLower(h)
+		for _, p := range prefixes {
+			if strings.HasPrefix(h, p) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (t table) cell(row []string, col int) string {
+	if col < 0 || col >= len(row) {
+		return ""
+	}
+	return row[col]
+}
+
+var bulletPattern = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+(.*)$`)
+
+// bullets returns the text of the list items in lines, nested ones included
+func bullets(lines []string) []string {
+	var items []string
+	for _, line := range lines {
+		if m := bulletPattern.FindStringSubmatch(line); m != nil {
+			items = append(items, strings.TrimSpace(m[1]))
+		}
+	}
+	return items
+}
+
+// field reads "- Label: value" lines
+func field(lines []string, label string) (string, bool) {
+	for _, item := range bullets(lines) {
+		if strings.HasPrefix(strings.ToLower(item), strings.ToLower(label)+":") {
+			return strings.TrimSpace(item[len(label)+1:]), true
+		}
+	}
+	return "", false
+}
+
+var datePattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}`)
+
+// firstDate is the first ISO date in s
+func firstDate(s string) (time.Time, bool) {
+	m := datePattern.FindString(s)
+	if m == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse("2006-01-02", m)
+	return t, err == nil
+}
+
+// plain drops emphasis and code markers
+func plain(s string) string {
+	return strings.TrimSpace(strings.NewReplacer("**", "", "__", "", "`", "").Replace(s))
+}
@@ -0,0 +1,36 @@
+package models
+
+// Import conflict modes
+const (
+	ImportSkip      = "skip"      // keep the existing row
+	ImportOverwrite = "overwrite" // replace the existing row with the archived one
+)
+
+// ImportReport is the outcome of restoring an archive. In a dry run nothing
+// is written but the counts are exactly what a real import would do.
+type ImportReport struct {
+	DryRun         bool                `json:"dry_run"`
+	OnConflict     string              `json:"on_conflict"`
+	ArchiveVersion int                 `json:"archive_version"`
+	SchemaVersion  int                 `json:"schema_version"` // of the archived rows
+	Tables         []ImportTableReport `json:"tables"`
+	Warnings       []string            `json:"warnings"`
+}
+
+// ImportTableReport counts what happened to one table's rows
+type ImportTableReport struct {
+	Table     string           `json:"table"`
+	Created   int              `json:"created"`
+	Updated   int              `json:"updated"`
+	Skipped   int              `json:"skipped"`
+	Conflicts []ImportConflict `json:"conflicts"`
+}
+
+// ImportConflict is an archived row that matches an existing one by its
+// natural key
+type ImportConflict struct {
+	ArchiveID  int                    `json:"archive_id"`
+	ExistingID int                    `json:"existing_id"`
+	Key        map[string]interface{} `json:"key"`
+	Action     string                 `json:"action"` // skipped or updated
+}
@@ -0,0 +1,8 @@
+package models
+
+// CalendarSubscription is the secret feed address for calendar apps
+type CalendarSubscription struct {
+	Token     string `json:"token"`
+	URL       string `json:"url"`
+	WebcalURL string `json:"webcal_url"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Correlation confidence levels
+const (
+	CorrelationHigh   = "high"
+	CorrelationMedium = "medium"
+	CorrelationLow    = "low"
+)
+
+// CorrelationReport ranks what lab markers did after supplement changes.
+// The associations are observational (one person, single results) and
+// suggest what to test, not what caused what.
+type CorrelationReport struct {
+	Events       int                      `json:"events"` // supplement changes considered
+	Markers      int                      `json:"markers"`
+	Windows      []CorrelationWindow      `json:"windows"`
+	Associations []CorrelationAssociation `json:"associations"` // strongest first
+}
+
+// CorrelationWindow is a lag after a change in which a result is its outcome
+type CorrelationWindow struct {
+	Name    string `json:"name"`
+	MinDays int    `json:"min_days"`
+	MaxDays int    `json:"max_days"`
+}
+
+// CorrelationAssociation is one supplement change and what one marker did
+// after it
+type CorrelationAssociation struct {
+	SupplementID   int                `json:"supplement_id"`
+	SupplementName string             `json:"supplement_name"`
+	EventType      string             `json:"event_type"`
+	EventDate      time.Time          `json:"event_date"`
+	MarkerName     string             `json:"marker_name"`
+	Unit           string             `json:"unit"`
+	Before         LabTrendPoint      `json:"before"` // latest result on or before the change
+	RCVPercent     *float64           `json:"rcv_percent"`
+	Deltas         []CorrelationDelta `json:"deltas"` // one per lag window with a result
+	Best           string             `json:"best_window"`
+	Score          float64            `json:"score"` // best change in multiples of the RCV, discounted for confounders
+	Confidence     string             `json:"confidence"`
+	Caveats        []string           `json:"caveats"`
+}
+
+// CorrelationDelta is the first result in a lag window against the one
+// before the change
+type CorrelationDelta struct {
+	Window      string        `json:"window"`
+	After       LabTrendPoint `json:"after"`
+	LagDays     int           `json:"lag_days"`
+	Change      float64       `json:"change"`
+	Percent     float64       `json:"percent"`
+	ExceedsRCV  bool          `json:"exceeds_rcv"`
+	Confounders []string      `json:"confounders"` // other supplements changed since shortly before this one
+}
@@ -19,6 +19,7 @@ type Cycle struct {
 	Decisions            *json.RawMessage `db:"decisions" json:"decisions"`
 	RequiredLabs         *json.RawMessage `db:"required_labs" json:"required_labs"`
 	NextReviewDate       *time.Time       `db:"next_review_date" json:"next_review_date"`
+	SourceRef            *string          `db:"source_ref" json:"source_ref"`
 	CreatedAt            time.Time        `db:"created_at" json:"created_at"`
 	UpdatedAt            time.Time        `db:"updated_at" json:"updated_at"`
 }
@@ -41,7 +42,10 @@ type CycleUpdate struct {
 	NextReviewDate       *time.Time      `json:"next_review_date"`
 }
 
+// CycleInputData is the template of cycles.input_data. SchemaVersion tracks
+// template changes (see internal/cycleinput); version 1 rows have none.
 type CycleInputData struct {
+	SchemaVersion int    `json:"schema_version"`
 	Goals       string `json:"goals"`
 	Wellbeing   struct {
 		Sleep            string `json:"sleep"`
@@ -70,11 +74,11 @@ type CycleInputData struct {
 		Alcohol  bool   `json:"alcohol"`
 	} `json:"nutrition"`
 	Metrics struct {
-		Weight        float64 `json:"weight"`
-		BloodPressure string  `json:"blood_pressure"`
-		Pulse         int     `json:"pulse"`
-		HRV           int     `json:"hrv"`
-		Glucose       float64 `json:"glucose"`
+		Weight        *float64 `json:"weight"`
+		BloodPressure string   `json:"blood_pressure"` // "120/80"
+		Pulse         *int     `json:"pulse"`
+		HRV           *int     `json:"hrv"`
+		Glucose       *float64 `json:"glucose"`
 	} `json:"metrics"`
 	Changes   string `json:"changes"`
 	AIRequest string `json:"ai_request"`
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+)
+
+// CycleComparison is what changed from one cycle to another
+type CycleComparison struct {
+	From            CycleSummary         `json:"from"`
+	To              CycleSummary         `json:"to"`
+	DaysBetween     int                  `json:"days_between"`
+	InputChanges    []FieldChange        `json:"input_changes"`
+	Verdict         VerdictChange        `json:"verdict"`
+	DecisionChanges []FieldChange        `json:"decision_changes"`
+	Labs            []LabMarkerDelta     `json:"labs"`
+	Adherence       *AdherenceReport     `json:"adherence"` // between the cycles
+	Supplements     SupplementStackDiff  `json:"supplements"`
+	MetaSupervisor  MetaSupervisorChange `json:"meta_supervisor"`
+}
+
+type CycleSummary struct {
+	ID        int       `json:"id"`
+	CycleDate time.Time `json:"cycle_date"`
+	CycleType *string   `json:"cycle_type"`
+	Verdict   *string   `json:"verdict"`
+}
+
+// FieldChange is one differing field; Path joins nested keys with dots
+type FieldChange struct {
+	Path  string      `json:"path"`
+	Kind  string      `json:"kind"` // added, removed, changed
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+	Delta *float64    `json:"delta,omitempty"`
+}
+
+type VerdictChange struct {
+	From    *string `json:"from"`
+	To      *string `json:"to"`
+	Changed bool    `json:"changed"`
+}
+
+// LabMarkerDelta compares the last value before the first cycle with the
+// latest one measured between the cycles
+type LabMarkerDelta struct {
+	MarkerName   string     `json:"marker_name"`
+	Unit         *string    `json:"unit"`
+	Before       *float64   `json:"before"`
+	BeforeDate   *time.Time `json:"before_date"`
+	After        float64    `json:"after"`
+	AfterDate    time.Time  `json:"after_date"`
+	Delta        *float64   `json:"delta"`
+	DeltaPct     *float64   `json:"delta_pct"`
+	Measurements int        `json:"measurements"`  // between the cycles
+	Status       string     `json:"status"`        // low, normal, high against the reference range
+	AdherencePct *float64   `json:"adherence_pct"` // from the before to the after result, nil without doses due
+}
+
+type SupplementStackDiff struct {
+	Added   []Supplement       `json:"added"`
+	Removed []Supplement       `json:"removed"`
+	Changed []SupplementChange `json:"changed"`
+}
+
+type SupplementChange struct {
+	SupplementID int           `json:"supplement_id"`
+	Name         string        `json:"name"`
+	Changes      []FieldChange `json:"changes"`
+}
+
+// MetaSupervisorChange is a unified line diff of the Meta-Supervisor outputs
+type MetaSupervisorChange struct {
+	Diff         string `json:"diff"`
+	LinesAdded   int    `json:"lines_added"`
+	LinesRemoved int    `json:"lines_removed"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// CycleDraftRequest selects what POST /cycles/draft pre-fills; both fields
+// are optional
+type CycleDraftRequest struct {
+	CycleDate string  `json:"cycle_date"` // YYYY-MM-DD, defaults to today
+	CycleType *string `json:"cycle_type"`
+}
+
+// CycleDraft is input_data pre-filled from data the server already has. Only
+// the subjective wellbeing fields are left for the user.
+type CycleDraft struct {
+	CycleDate       time.Time      `json:"cycle_date"`
+	CycleType       *string        `json:"cycle_type"`
+	PreviousCycleID *int           `json:"previous_cycle_id"`
+	PeriodFrom      time.Time      `json:"period_from"`
+	InputData       CycleInputData `json:"input_data"`
+	// Sources explains where each pre-filled field came from, keyed by the
+	// dotted input_data path
+	Sources map[string]string `json:"sources"`
+}
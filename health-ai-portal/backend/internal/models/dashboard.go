@@ -0,0 +1,21 @@
+package models
+
+// DashboardSummary is what the dashboard shows at a glance
+type DashboardSummary struct {
+	LabTrends LabTrendSummary `json:"lab_trends"`
+}
+
+// LabTrendSummary picks out the markers whose trend needs attention
+type LabTrendSummary struct {
+	Markers            int             `json:"markers"`
+	SignificantChanges []LabTrendAlert `json:"significant_changes"` // latest change above the RCV
+	Projections        []LabTrendAlert `json:"projections"`         // soonest crossing first
+	Outliers           []LabTrendAlert `json:"outliers"`
+}
+
+// LabTrendAlert is a marker's analysis without its results
+type LabTrendAlert struct {
+	MarkerName string            `json:"marker_name"`
+	Unit       string            `json:"unit"`
+	Analysis   *LabTrendAnalysis `json:"analysis"`
+}
@@ -5,31 +5,77 @@ import (
 )
 
 type Goal struct {
-	ID           int       `db:"id" json:"id"`
-	UserID       int       `db:"user_id" json:"user_id"`
-	Name         string    `db:"name" json:"name"`
-	CurrentValue *string   `db:"current_value" json:"current_value"`
-	TargetValue  *string   `db:"target_value" json:"target_value"`
-	Strategy     *string   `db:"strategy" json:"strategy"`
-	Priority     *string   `db:"priority" json:"priority"`
-	Status       string    `db:"status" json:"status"`
-	CreatedAt    time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+	ID            int        `db:"id" json:"id"`
+	UserID        int        `db:"user_id" json:"user_id"`
+	Name          string     `db:"name" json:"name"`
+	CurrentValue  *string    `db:"current_value" json:"current_value"`
+	TargetValue   *string    `db:"target_value" json:"target_value"`
+	Strategy      *string    `db:"strategy" json:"strategy"`
+	Priority      *string    `db:"priority" json:"priority"`
+	Status        string     `db:"status" json:"status"`
+	SourceType    *string    `db:"source_type" json:"source_type"` // lab or metric
+	SourceKey     *string    `db:"source_key" json:"source_key"`   // marker name or metric
+	TargetNumber  *float64   `db:"target_number" json:"target_number"`
+	BaselineValue *float64   `db:"baseline_value" json:"baseline_value"`
+	Direction     *string    `db:"direction" json:"direction"` // increase or decrease
+	Deadline      *time.Time `db:"deadline" json:"deadline"`
+	HoldDays      int        `db:"hold_days" json:"hold_days"`
+	AchievedAt    *time.Time `db:"achieved_at" json:"achieved_at"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time  `db:"updated_at" json:"updated_at"`
 }
 
 type GoalCreate struct {
-	Name         string  `json:"name" validate:"required"`
-	CurrentValue *string `json:"current_value"`
-	TargetValue  *string `json:"target_value"`
-	Strategy     *string `json:"strategy"`
-	Priority     *string `json:"priority"`
+	Name          string   `json:"name" validate:"required"`
+	CurrentValue  *string  `json:"current_value"`
+	TargetValue   *string  `json:"target_value"`
+	Strategy      *string  `json:"strategy"`
+	Priority      *string  `json:"priority"`
+	SourceType    *string  `json:"source_type"`
+	SourceKey     *string  `json:"source_key"`
+	TargetNumber  *float64 `json:"target_number"`
+	BaselineValue *float64 `json:"baseline_value"` // default: the value when the goal is created
+	Direction     *string  `json:"direction"`
+	Deadline      *string  `json:"deadline"` // YYYY-MM-DD
+	HoldDays      *int     `json:"hold_days"`
 }
 
 type GoalUpdate struct {
-	Name         *string `json:"name"`
-	CurrentValue *string `json:"current_value"`
-	TargetValue  *string `json:"target_value"`
-	Strategy     *string `json:"strategy"`
-	Priority     *string `json:"priority"`
-	Status       *string `json:"status"`
+	Name          *string  `json:"name"`
+	CurrentValue  *string  `json:"current_value"`
+	TargetValue   *string  `json:"target_value"`
+	Strategy      *string  `json:"strategy"`
+	Priority      *string  `json:"priority"`
+	Status        *string  `json:"status"`
+	SourceType    *string  `json:"source_type"`
+	SourceKey     *string  `json:"source_key"`
+	TargetNumber  *float64 `json:"target_number"`
+	BaselineValue *float64 `json:"baseline_value"`
+	Direction     *string  `json:"direction"`
+	Deadline      *string  `json:"deadline"` // YYYY-MM-DD
+	HoldDays      *int     `json:"hold_days"`
+}
+
+// GoalProgress is computed from the goal's source data
+type GoalProgress struct {
+	CurrentValue  *float64            `json:"current_value"`
+	CurrentDate   *time.Time          `json:"current_date"`
+	BaselineValue *float64            `json:"baseline_value"`
+	ProgressPct   *float64            `json:"progress_pct"`
+	TargetMet     bool                `json:"target_met"`
+	MetSince      *time.Time          `json:"met_since"`
+	DaysLeft      *int                `json:"days_left"` // until the deadline
+	Series        []GoalProgressPoint `json:"series,omitempty"`
+}
+
+type GoalProgressPoint struct {
+	Date        time.Time `json:"date"`
+	Value       float64   `json:"value"`
+	ProgressPct float64   `json:"progress_pct"`
+	TargetMet   bool      `json:"target_met"`
+}
+
+type GoalWithProgress struct {
+	Goal
+	Progress *GoalProgress `json:"progress"`
 }
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	ImportKindLabs        = "labs"
+	ImportKindSupplements = "supplements"
+)
+
+// ImportProfile is a saved column mapping for spreadsheet imports
+type ImportProfile struct {
+	ID        int             `db:"id" json:"id"`
+	UserID    int             `db:"user_id" json:"user_id"`
+	Name      string          `db:"name" json:"name"`
+	Kind      string          `db:"kind" json:"kind"`
+	Mapping   json.RawMessage `db:"mapping" json:"mapping"`
+	Sheet     *string         `db:"sheet" json:"sheet"`
+	HeaderRow *int            `db:"header_row" json:"header_row"` // 1-based
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+type ImportProfileInput struct {
+	Name      string            `json:"name" validate:"required"`
+	Kind      string            `json:"kind" validate:"required"`
+	Mapping   map[string]string `json:"mapping"`
+	Sheet     *string           `json:"sheet"`
+	HeaderRow *int              `json:"header_row"`
+}
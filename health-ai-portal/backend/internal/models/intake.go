@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+)
+
+type IntakeLog struct {
+	ID            int        `db:"id" json:"id"`
+	UserID        int        `db:"user_id" json:"user_id"`
+	SupplementID  int        `db:"supplement_id" json:"supplement_id"`
+	Slot          string     `db:"slot" json:"slot"`
+	ScheduledDate time.Time  `db:"scheduled_date" json:"scheduled_date"`
+	Status        string     `db:"status" json:"status"`
+	TakenAt       *time.Time `db:"taken_at" json:"taken_at"`
+	Notes         *string    `db:"notes" json:"notes"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+}
+
+type IntakeLogCreate struct {
+	SupplementID  int        `json:"supplement_id" validate:"required"`
+	Slot          string     `json:"slot" validate:"required"`
+	ScheduledDate string     `json:"scheduled_date"` // YYYY-MM-DD, default today
+	Status        *string    `json:"status"`         // taken (default), skipped, late
+	TakenAt       *time.Time `json:"taken_at"`
+	Notes         *string    `json:"notes"`
+}
+
+// AdherenceStats summarises logged doses against the schedule for a window
+type AdherenceStats struct {
+	Expected      int     `json:"expected"`
+	Taken         int     `json:"taken"`
+	Late          int     `json:"late"`
+	Skipped       int     `json:"skipped"`
+	Missed        int     `json:"missed"`
+	Percent       float64 `json:"percent"`
+	CurrentStreak int     `json:"current_streak"`
+	LongestStreak int     `json:"longest_streak"`
+}
+
+type SupplementAdherence struct {
+	SupplementID int    `json:"supplement_id"`
+	Name         string `json:"name"`
+	AdherenceStats
+}
+
+type AdherenceReport struct {
+	Days        int                   `json:"days"`
+	From        time.Time             `json:"from"`
+	To          time.Time             `json:"to"`
+	Overall     AdherenceStats        `json:"overall"`
+	Supplements []SupplementAdherence `json:"supplements"`
+}
@@ -11,6 +11,7 @@ type Interaction struct {
 	InteractionType *string   `db:"interaction_type" json:"interaction_type"`
 	Description     *string   `db:"description" json:"description"`
 	Solution        *string   `db:"solution" json:"solution"`
+	SeparationHours *int      `db:"separation_hours" json:"separation_hours"`
 	CreatedAt       time.Time `db:"created_at" json:"created_at"`
 }
 
@@ -26,10 +27,47 @@ type InteractionCreate struct {
 	InteractionType *string `json:"interaction_type"`
 	Description     *string `json:"description"`
 	Solution        *string `json:"solution"`
+	SeparationHours *int    `json:"separation_hours"`
 }
 
 type InteractionUpdate struct {
 	InteractionType *string `json:"interaction_type"`
 	Description     *string `json:"description"`
 	Solution        *string `json:"solution"`
+	SeparationHours *int    `json:"separation_hours"`
+}
+
+// InteractionMatrix is a symmetric N×N grid over the active stack.
+// Cells[i][j] and Cells[j][i] describe the same unordered pair; nil means
+// no known interaction.
+type InteractionMatrix struct {
+	Supplements []MatrixSupplement         `json:"supplements"`
+	Cells       [][]*InteractionMatrixCell `json:"cells"`
+	Pairs       []InteractionMatrixPair    `json:"pairs"`
+}
+
+type MatrixSupplement struct {
+	ID        int     `db:"id" json:"id"`
+	Name      string  `db:"name" json:"name"`
+	TimeOfDay *string `db:"time_of_day" json:"time_of_day"`
+	Category  *string `db:"category" json:"category"`
+}
+
+// InteractionMatrixCell holds the worst severity recorded for a pair and
+// the details of the interaction that produced it.
+type InteractionMatrixCell struct {
+	Severity        string  `json:"severity"`
+	InteractionIDs  []int   `json:"interaction_ids"`
+	Description     *string `json:"description"`
+	Solution        *string `json:"solution"`
+	SeparationHours *int    `json:"separation_hours"`
+	Suggestion      string  `json:"suggestion,omitempty"`
+}
+
+type InteractionMatrixPair struct {
+	Supplement1ID   int    `json:"supplement_1_id"`
+	Supplement1Name string `json:"supplement_1_name"`
+	Supplement2ID   int    `json:"supplement_2_id"`
+	Supplement2Name string `json:"supplement_2_name"`
+	InteractionMatrixCell
 }
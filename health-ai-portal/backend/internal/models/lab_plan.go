@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// LabRetestInterval is a user's override of a marker's retest interval
+type LabRetestInterval struct {
+	ID           int       `db:"id" json:"id"`
+	UserID       int       `db:"user_id" json:"user_id"`
+	MarkerName   string    `db:"marker_name" json:"marker_name"`
+	IntervalDays int       `db:"interval_days" json:"interval_days"`
+	Fasting      bool      `db:"fasting" json:"fasting"`
+	Note         *string   `db:"note" json:"note"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+}
+
+type LabRetestIntervalUpdate struct {
+	MarkerName   string  `json:"marker_name" validate:"required"`
+	IntervalDays int     `json:"interval_days"` // 0 stops retest reminders
+	Fasting      bool    `json:"fasting"`
+	Note         *string `json:"note"`
+}
+
+type LabDraw struct {
+	ID                int             `db:"id" json:"id"`
+	UserID            int             `db:"user_id" json:"user_id"`
+	DrawDate          time.Time       `db:"draw_date" json:"draw_date"`
+	Markers           json.RawMessage `db:"markers" json:"markers"`
+	Fasting           bool            `db:"fasting" json:"fasting"`
+	ReminderID        *int            `db:"reminder_id" json:"reminder_id"`
+	FastingReminderID *int            `db:"fasting_reminder_id" json:"fasting_reminder_id"`
+	CreatedAt         time.Time       `db:"created_at" json:"created_at"`
+}
+
+// LabDrawCreate schedules the planned draw; the planner's date is used
+// unless one is given
+type LabDrawCreate struct {
+	Date *string `json:"date"` // YYYY-MM-DD
+	Time *string `json:"time"` // HH:MM, default 08:00
+}
+
+// LabIntervalSetting is the effective retest interval of a marker
+type LabIntervalSetting struct {
+	MarkerName   string `json:"marker_name"`
+	IntervalDays int    `json:"interval_days"`
+	Fasting      bool   `json:"fasting"`
+	Note         string `json:"note,omitempty"`
+	Custom       bool   `json:"custom"` // overridden by the user
+}
@@ -44,14 +44,48 @@ type LabResultUpdate struct {
 }
 
 type LabTrend struct {
-	MarkerName string           `json:"marker_name"`
-	Unit       string           `json:"unit"`
-	DataPoints []LabTrendPoint  `json:"data_points"`
+	MarkerName   string            `json:"marker_name"`
+	Unit         string            `json:"unit"`
+	ReferenceMin *float64          `json:"reference_min"`
+	ReferenceMax *float64          `json:"reference_max"`
+	DataPoints   []LabTrendPoint   `json:"data_points"`
+	Analysis     *LabTrendAnalysis `json:"analysis,omitempty"`
 }
 
 type LabTrendPoint struct {
-	Date  time.Time `json:"date"`
-	Value float64   `json:"value"`
+	Date    time.Time `json:"date"`
+	Value   float64   `json:"value"`
+	Outlier bool      `json:"outlier,omitempty"`
+}
+
+// LabTrendAnalysis describes how a marker moves; fields are nil when there
+// are too few results
+type LabTrendAnalysis struct {
+	SlopePerMonth       *float64        `json:"slope_per_month"`        // least squares
+	RobustSlopePerMonth *float64        `json:"robust_slope_per_month"` // Theil–Sen
+	R2                  *float64        `json:"r2"`
+	Projection          *LabProjection  `json:"projection"`
+	LatestChange        *LabChange      `json:"latest_change"`
+	Outliers            []LabTrendPoint `json:"outliers"`
+}
+
+// LabProjection is when the robust trend line crosses a reference bound
+type LabProjection struct {
+	Bound     string    `json:"bound"` // min or max
+	Value     float64   `json:"value"`
+	Date      time.Time `json:"date"`
+	DaysAway  int       `json:"days_away"` // from the latest result
+	Direction string    `json:"direction"` // leaving or entering the range
+}
+
+// LabChange compares the latest result with the previous one against the
+// reference change value (RCV)
+type LabChange struct {
+	From        float64  `json:"from"`
+	To          float64  `json:"to"`
+	Percent     float64  `json:"percent"`
+	RCVPercent  *float64 `json:"rcv_percent"`
+	Significant bool     `json:"significant"`
 }
 
 type LabMarkerSummary struct {
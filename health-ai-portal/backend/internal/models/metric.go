@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+type DailyMetric struct {
+	ID               int       `db:"id" json:"id"`
+	UserID           int       `db:"user_id" json:"user_id"`
+	MetricDate       time.Time `db:"metric_date" json:"metric_date"`
+	WeightKg         *float64  `db:"weight_kg" json:"weight_kg"`
+	Steps            *int      `db:"steps" json:"steps"`
+	SleepHours       *float64  `db:"sleep_hours" json:"sleep_hours"`
+	DeepSleepPct     *int      `db:"deep_sleep_pct" json:"deep_sleep_pct"`
+	HRV              *int      `db:"hrv" json:"hrv"`
+	RestingHR        *int      `db:"resting_hr" json:"resting_hr"`
+	BloodPressureSys *int      `db:"blood_pressure_sys" json:"blood_pressure_sys"`
+	BloodPressureDia *int      `db:"blood_pressure_dia" json:"blood_pressure_dia"`
+	Glucose          *float64  `db:"glucose" json:"glucose"`
+	EnergyLevel      *int      `db:"energy_level" json:"energy_level"`
+	MoodLevel        *int      `db:"mood_level" json:"mood_level"`
+	Notes            *string   `db:"notes" json:"notes"`
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+}
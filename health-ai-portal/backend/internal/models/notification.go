@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+type PushSubscription struct {
+	ID         int        `db:"id" json:"id"`
+	UserID     int        `db:"user_id" json:"user_id"`
+	Endpoint   string     `db:"endpoint" json:"endpoint"`
+	P256dh     string     `db:"p256dh" json:"-"`
+	Auth       string     `db:"auth" json:"-"`
+	DeviceName *string    `db:"device_name" json:"device_name"`
+	UserAgent  *string    `db:"user_agent" json:"user_agent"`
+	LastUsedAt *time.Time `db:"last_used_at" json:"last_used_at"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+}
+
+// PushSubscriptionCreate mirrors the browser's PushSubscription.toJSON()
+type PushSubscriptionCreate struct {
+	Endpoint string `json:"endpoint" validate:"required"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+	DeviceName *string `json:"device_name"`
+}
+
+type NotificationRoute struct {
+	ID           int    `db:"id" json:"id"`
+	UserID       int    `db:"user_id" json:"user_id"`
+	ReminderType string `db:"reminder_type" json:"reminder_type"`
+	Channel      string `db:"channel" json:"channel"`
+}
+
+// NotificationSettings is the user's channel routing and quiet hours.
+// Routes maps a reminder type (or "default") to channel names.
+type NotificationSettings struct {
+	Email             *string             `json:"email"`
+	QuietHoursStart   *string             `json:"quiet_hours_start"`
+	QuietHoursEnd     *string             `json:"quiet_hours_end"`
+	Routes            map[string][]string `json:"routes"`
+	AvailableChannels []string            `json:"available_channels"`
+}
+
+type NotificationSettingsUpdate struct {
+	Email           *string             `json:"email"`
+	QuietHoursStart *string             `json:"quiet_hours_start"` // "" clears quiet hours
+	QuietHoursEnd   *string             `json:"quiet_hours_end"`
+	Routes          map[string][]string `json:"routes"` // replaces routes of the given types
+}
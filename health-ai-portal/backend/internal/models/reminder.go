@@ -13,24 +13,70 @@ type Reminder struct {
 	Description  *string         `db:"description" json:"description"`
 	Time         *string         `db:"time" json:"time"`
 	DaysOfWeek   json.RawMessage `db:"days_of_week" json:"days_of_week"`
+	RRule        *string         `db:"rrule" json:"rrule"`
+	DTStart      *time.Time      `db:"dtstart" json:"dtstart"`
+	ExDates      json.RawMessage `db:"exdates" json:"exdates"`
 	IsActive     bool            `db:"is_active" json:"is_active"`
+	SnoozedUntil *time.Time      `db:"snoozed_until" json:"snoozed_until"`
 	CreatedAt    time.Time       `db:"created_at" json:"created_at"`
 }
 
 type ReminderCreate struct {
-	ReminderType *string `json:"reminder_type"`
-	Title        string  `json:"title" validate:"required"`
-	Description  *string `json:"description"`
-	Time         *string `json:"time"`
-	DaysOfWeek   []int   `json:"days_of_week"`
-	IsActive     *bool   `json:"is_active"`
+	ReminderType *string  `json:"reminder_type"`
+	Title        string   `json:"title" validate:"required"`
+	Description  *string  `json:"description"`
+	Time         *string  `json:"time"`
+	DaysOfWeek   []int    `json:"days_of_week"`
+	RRule        *string  `json:"rrule"`   // RFC 5545 RRULE; overrides days_of_week
+	DTStart      *string  `json:"dtstart"` // YYYY-MM-DD, default today
+	ExDates      []string `json:"exdates"` // YYYY-MM-DD dates to skip
+	IsActive     *bool    `json:"is_active"`
 }
 
 type ReminderUpdate struct {
-	ReminderType *string `json:"reminder_type"`
-	Title        *string `json:"title"`
-	Description  *string `json:"description"`
-	Time         *string `json:"time"`
-	DaysOfWeek   []int   `json:"days_of_week"`
-	IsActive     *bool   `json:"is_active"`
+	ReminderType *string  `json:"reminder_type"`
+	Title        *string  `json:"title"`
+	Description  *string  `json:"description"`
+	Time         *string  `json:"time"`
+	DaysOfWeek   []int    `json:"days_of_week"`
+	RRule        *string  `json:"rrule"`   // RFC 5545 RRULE; overrides days_of_week
+	DTStart      *string  `json:"dtstart"` // YYYY-MM-DD, default today
+	ExDates      []string `json:"exdates"` // YYYY-MM-DD dates to skip
+	IsActive     *bool    `json:"is_active"`
+}
+
+type ReminderSnooze struct {
+	Minutes int `json:"minutes"` // default 10
+}
+
+// ReminderOccurrence is one scheduled firing of a reminder
+type ReminderOccurrence struct {
+	Reminder
+	OccursAt *time.Time `json:"occurs_at"`
+	Snoozed  bool       `json:"snoozed"`
+	Status   string     `json:"delivery_status"` // upcoming, due, sent, failed
+}
+
+type ReminderDelivery struct {
+	ID            int        `db:"id" json:"id"`
+	ReminderID    int        `db:"reminder_id" json:"reminder_id"`
+	UserID        int        `db:"user_id" json:"user_id"`
+	ScheduledFor  time.Time  `db:"scheduled_for" json:"scheduled_for"`
+	Channel       string     `db:"channel" json:"channel"`
+	Status        string     `db:"status" json:"status"`
+	Attempts      int        `db:"attempts" json:"attempts"`
+	Error         *string    `db:"error" json:"error"`
+	CatchUp       bool       `db:"catch_up" json:"catch_up"`
+	Snoozed       bool       `db:"snoozed" json:"snoozed"`
+	DeliveredAt   *time.Time `db:"delivered_at" json:"delivered_at"`
+	Response      *string    `db:"response" json:"response"` // taken, skipped, snoozed
+	RespondedAt   *time.Time `db:"responded_at" json:"responded_at"`
+	NextAttemptAt *time.Time `db:"next_attempt_at" json:"next_attempt_at"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+}
+
+type ReminderDeliveryWithTitle struct {
+	ReminderDelivery
+	Title        string  `db:"title" json:"title"`
+	ReminderType *string `db:"reminder_type" json:"reminder_type"`
 }
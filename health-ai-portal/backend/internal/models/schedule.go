@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+)
+
+type ScheduleSlot struct {
+	ID        int     `db:"id" json:"id"`
+	UserID    int     `db:"user_id" json:"user_id"`
+	Slot      string  `db:"slot" json:"slot"`
+	ClockTime string  `db:"clock_time" json:"clock_time"`
+	Label     *string `db:"label" json:"label"`
+}
+
+type SupplementSlot struct {
+	ID           int    `db:"id" json:"id"`
+	SupplementID int    `db:"supplement_id" json:"supplement_id"`
+	Slot         string `db:"slot" json:"slot"`
+}
+
+type ScheduleSlotUpdate struct {
+	Timezone *string `json:"timezone"`
+	Slots    []struct {
+		Slot      string  `json:"slot"`
+		ClockTime string  `json:"clock_time"`
+		Label     *string `json:"label"`
+	} `json:"slots"`
+}
+
+// DaySchedule is the intake plan for one calendar day in the user's timezone.
+// Items are ordered by slot clock time.
+type DaySchedule struct {
+	Date     time.Time      `json:"date"`
+	Timezone string         `json:"timezone"`
+	Items    []ScheduleItem `json:"items"`
+	AsNeeded []Supplement   `json:"as_needed"`
+	OffCycle []Supplement   `json:"off_cycle"`
+
+	// Active supplements whose time of day could not be mapped to a slot
+	Unscheduled []Supplement `json:"unscheduled"`
+}
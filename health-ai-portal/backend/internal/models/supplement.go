@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -19,29 +20,67 @@ type Supplement struct {
 	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
 	UpdatedAt     time.Time  `db:"updated_at" json:"updated_at"`
 	RemovedAt     *time.Time `db:"removed_at" json:"removed_at,omitempty"`
+
+	// Structured dose (Dose above is kept as the display string)
+	Ingredient     *string    `db:"ingredient" json:"ingredient"`
+	DoseAmount     *float64   `db:"dose_amount" json:"dose_amount"`
+	DoseAmountMin  *float64   `db:"dose_amount_min" json:"dose_amount_min"`
+	DoseUnit       *string    `db:"dose_unit" json:"dose_unit"`
+	DoseForm       *string    `db:"dose_form" json:"dose_form"`
+	Frequency      *string    `db:"frequency" json:"frequency"`
+	TimesPerDay    *int       `db:"times_per_day" json:"times_per_day"`
+	TimesPerWeek   *int       `db:"times_per_week" json:"times_per_week"`
+	WithFood       *string    `db:"with_food" json:"with_food"`
+	CycleOnWeeks   *int       `db:"cycle_on_weeks" json:"cycle_on_weeks"`
+	CycleOffWeeks  *int       `db:"cycle_off_weeks" json:"cycle_off_weeks"`
+	CycleStartDate *time.Time `db:"cycle_start_date" json:"cycle_start_date"`
+
+	DaysOfWeek *json.RawMessage `db:"days_of_week" json:"days_of_week"`
+}
+
+// SupplementDose groups the structured dose fields accepted on create/update
+type SupplementDose struct {
+	Ingredient     *string    `json:"ingredient"`
+	DoseAmount     *float64   `json:"dose_amount"`
+	DoseAmountMin  *float64   `json:"dose_amount_min"`
+	DoseUnit       *string    `json:"dose_unit"`
+	DoseForm       *string    `json:"dose_form"`
+	Frequency      *string    `json:"frequency"`
+	TimesPerDay    *int       `json:"times_per_day"`
+	TimesPerWeek   *int       `json:"times_per_week"`
+	WithFood       *string    `json:"with_food"`
+	CycleOnWeeks   *int       `json:"cycle_on_weeks"`
+	CycleOffWeeks  *int       `json:"cycle_off_weeks"`
+	CycleStartDate *time.Time `json:"cycle_start_date"`
 }
 
 type SupplementCreate struct {
-	Name          string  `json:"name" validate:"required"`
-	Dose          *string `json:"dose"`
-	TimeOfDay     *string `json:"time_of_day"`
-	Category      *string `json:"category"`
-	Mechanism     *string `json:"mechanism"`
-	Target        *string `json:"target"`
-	EvidenceLevel *string `json:"evidence_level"`
-	Notes         *string `json:"notes"`
+	Name          string   `json:"name" validate:"required"`
+	Dose          *string  `json:"dose"`
+	TimeOfDay     *string  `json:"time_of_day"`
+	Category      *string  `json:"category"`
+	Mechanism     *string  `json:"mechanism"`
+	Target        *string  `json:"target"`
+	EvidenceLevel *string  `json:"evidence_level"`
+	Notes         *string  `json:"notes"`
+	Slots         []string `json:"slots"`
+	DaysOfWeek    []int    `json:"days_of_week"`
+	SupplementDose
 }
 
 type SupplementUpdate struct {
-	Name          *string `json:"name"`
-	Dose          *string `json:"dose"`
-	TimeOfDay     *string `json:"time_of_day"`
-	Category      *string `json:"category"`
-	Mechanism     *string `json:"mechanism"`
-	Target        *string `json:"target"`
-	Status        *string `json:"status"`
-	EvidenceLevel *string `json:"evidence_level"`
-	Notes         *string `json:"notes"`
+	Name          *string  `json:"name"`
+	Dose          *string  `json:"dose"`
+	TimeOfDay     *string  `json:"time_of_day"`
+	Category      *string  `json:"category"`
+	Mechanism     *string  `json:"mechanism"`
+	Target        *string  `json:"target"`
+	Status        *string  `json:"status"`
+	EvidenceLevel *string  `json:"evidence_level"`
+	Notes         *string  `json:"notes"`
+	Slots         []string `json:"slots"`
+	DaysOfWeek    []int    `json:"days_of_week"`
+	SupplementDose
 }
 
 type SupplementFilter struct {
@@ -51,5 +90,30 @@ type SupplementFilter struct {
 
 type ScheduleItem struct {
 	TimeOfDay   string       `json:"time_of_day"`
+	Slot        string       `json:"slot"`
+	Label       string       `json:"label"`
+	Time        time.Time    `json:"time"`
 	Supplements []Supplement `json:"supplements"`
 }
+
+// IntakeTotal is the summed daily intake of one ingredient across the active
+// stack, checked against its tolerable upper intake level.
+type IntakeTotal struct {
+	Ingredient    string        `json:"ingredient"`
+	DailyAmount   float64       `json:"daily_amount"`
+	Unit          string        `json:"unit"`
+	UpperLimit    *float64      `json:"upper_limit"`
+	PercentOfUL   *float64      `json:"percent_of_ul"`
+	ExceedsUL     bool          `json:"exceeds_ul"`
+	LimitNote     string        `json:"limit_note,omitempty"`
+	Sources       []IntakeEntry `json:"sources"`
+	Unconvertible []string      `json:"unconvertible,omitempty"`
+}
+
+type IntakeEntry struct {
+	SupplementID int     `json:"supplement_id"`
+	Name         string  `json:"name"`
+	DailyAmount  float64 `json:"daily_amount"`
+	Unit         string  `json:"unit"`
+	Frequency    string  `json:"frequency"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Supplement event types
+const (
+	SupplementEventAdded       = "added"
+	SupplementEventDoseChanged = "dose_changed"
+	SupplementEventPaused      = "paused"
+	SupplementEventResumed     = "resumed"
+	SupplementEventRemoved     = "removed"
+	SupplementEventUpdated     = "updated"
+)
+
+// SupplementEvent is one append-only entry in a supplement's history.
+// Snapshot holds the full supplement row right after the change.
+type SupplementEvent struct {
+	ID           int              `db:"id" json:"id"`
+	SupplementID int              `db:"supplement_id" json:"supplement_id"`
+	UserID       int              `db:"user_id" json:"user_id"`
+	EventType    string           `db:"event_type" json:"event_type"`
+	EventDate    time.Time        `db:"event_date" json:"event_date"`
+	Dose         *string          `db:"dose" json:"dose"`
+	PreviousDose *string          `db:"previous_dose" json:"previous_dose"`
+	Status       *string          `db:"status" json:"status"`
+	Snapshot     *json.RawMessage `db:"snapshot" json:"snapshot"`
+	Notes        *string          `db:"notes" json:"notes"`
+	CreatedAt    time.Time        `db:"created_at" json:"created_at"`
+}
+
+// SupplementEventWithName is a SupplementEvent joined with the supplement name
+type SupplementEventWithName struct {
+	SupplementEvent
+	SupplementName string `db:"supplement_name" json:"supplement_name"`
+}
+
+// StackSnapshot is the supplement stack as it was on a given date
+type StackSnapshot struct {
+	Date        time.Time    `json:"date"`
+	Supplements []Supplement `json:"supplements"`
+}
@@ -5,15 +5,21 @@ import (
 )
 
 type User struct {
-	ID         int        `db:"id" json:"id"`
-	Name       string     `db:"name" json:"name"`
-	PinHash    *string    `db:"pin_hash" json:"-"`
-	BirthDate  *time.Time `db:"birth_date" json:"birth_date"`
-	HeightCm   *int       `db:"height_cm" json:"height_cm"`
-	WeightKg   *float64   `db:"weight_kg" json:"weight_kg"`
-	BodyFatPct *float64   `db:"body_fat_pct" json:"body_fat_pct"`
-	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
-	UpdatedAt  time.Time  `db:"updated_at" json:"updated_at"`
+	ID                int        `db:"id" json:"id"`
+	Name              string     `db:"name" json:"name"`
+	PinHash           *string    `db:"pin_hash" json:"-"`
+	BirthDate         *time.Time `db:"birth_date" json:"birth_date"`
+	HeightCm          *int       `db:"height_cm" json:"height_cm"`
+	WeightKg          *float64   `db:"weight_kg" json:"weight_kg"`
+	BodyFatPct        *float64   `db:"body_fat_pct" json:"body_fat_pct"`
+	Timezone          *string    `db:"timezone" json:"timezone"`
+	TelegramChatID    *int64     `db:"telegram_chat_id" json:"telegram_chat_id"`
+	NotificationEmail *string    `db:"notification_email" json:"notification_email"`
+	QuietHoursStart   *string    `db:"quiet_hours_start" json:"quiet_hours_start"`
+	QuietHoursEnd     *string    `db:"quiet_hours_end" json:"quiet_hours_end"`
+	CalendarToken     *string    `db:"calendar_token" json:"-"`
+	CreatedAt         time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time  `db:"updated_at" json:"updated_at"`
 }
 
 type UserUpdate struct {
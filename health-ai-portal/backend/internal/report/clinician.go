@@ -0,0 +1,375 @@
+// Package report lays out printable reports from portal data
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"health-ai-portal/internal/models"
+	"health-ai-portal/pkg/pdfdoc"
+)
+
+// Clinician report sections
+const (
+	SectionPatient      = "patient"
+	SectionStack        = "stack"
+	SectionLabs         = "labs"
+	SectionInteractions = "interactions"
+	SectionCycle        = "cycle"
+)
+
+// Sections lists the clinician report sections in print order
+var Sections = []string{SectionPatient, SectionStack, SectionLabs, SectionInteractions, SectionCycle}
+
+var verdictColors = map[string]pdfdoc.Color{
+	"verdict_go":   colorOK,
+	"verdict_wait": colorWarn,
+	"verdict_stop": colorAlert,
+	"verdict_none": colorMuted,
+}
+
+// trendThreshold is the relative change from the first to the latest value
+// that makes an in-range marker worth showing
+const trendThreshold = 0.2
+
+// ClinicianOptions selects what the clinician report covers
+type ClinicianOptions struct {
+	From     time.Time
+	To       time.Time
+	Sections map[string]bool
+	Lang     string
+}
+
+// LabPoint is one measurement of a marker
+type LabPoint struct {
+	Date  time.Time
+	Value float64
+}
+
+// MarkerSeries is a marker's measurements in the report period
+type MarkerSeries struct {
+	Name       string
+	Unit       string
+	Category   string
+	RefMin     *float64
+	RefMax     *float64
+	Points     []LabPoint
+	OutOfRange bool
+	// Change is relative, from the first to the latest value
+	Change *float64
+}
+
+// ClinicianData is everything the clinician report prints
+type ClinicianData struct {
+	Patient      models.User
+	Stack        []models.Supplement
+	Markers      []MarkerSeries
+	Interactions []models.InteractionWithNames
+	Cycle        *models.Cycle
+	Generated    time.Time
+}
+
+// SelectMarkers groups results into series and keeps the markers whose
+// latest value is out of range or that moved by trendThreshold or more over
+// at least three measurements. Out-of-range markers come first.
+func SelectMarkers(results []models.LabResult) []MarkerSeries {
+	sorted := make([]models.LabResult, 0, len(results))
+	for _, r := range results {
+		if r.Value != nil {
+			sorted = append(sorted, r)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].TestDate.Before(sorted[j].TestDate) })
+
+	byName := map[string]*MarkerSeries{}
+	var order []string
+	for _, r := range sorted {
+		s, ok := byName[r.MarkerName]
+		if !ok {
+			s = &MarkerSeries{Name: r.MarkerName}
+			byName[r.MarkerName] = s
+			order = append(order, r.MarkerName)
+		}
+		s.Points = append(s.Points, LabPoint{Date: r.TestDate, Value: *r.Value})
+		// The latest result's unit and reference describe the series
+		if r.Unit != nil {
+			s.Unit = *r.Unit
+		}
+		if r.Category != nil {
+			s.Category = *r.Category
+		}
+		if r.ReferenceMin != nil || r.ReferenceMax != nil {
+			s.RefMin, s.RefMax = r.ReferenceMin, r.ReferenceMax
+		}
+	}
+
+	var markers []MarkerSeries
+	for _, name := range order {
+		s := byName[name]
+		first, last := s.Points[0].Value, s.Points[len(s.Points)-1].Value
+		s.OutOfRange = outside(last, s.RefMin, s.RefMax)
+		if len(s.Points) > 1 && first != 0 {
+			change := (last - first) / math.Abs(first)
+			s.Change = &change
+		}
+		trending := len(s.Points) >= 3 && s.Change != nil && math.Abs(*s.Change) >= trendThreshold
+		if s.OutOfRange || trending {
+			markers = append(markers, *s)
+		}
+	}
+	sort.SliceStable(markers, func(i, j int) bool {
+		if markers[i].OutOfRange != markers[j].OutOfRange {
+			return markers[i].OutOfRange
+		}
+		return markers[i].Name < markers[j].Name
+	})
+	return markers
+}
+
+func outside(v float64, refMin, refMax *float64) bool {
+	return refMin != nil && v < *refMin || refMax != nil && v > *refMax
+}
+
+// Clinician lays out the clinician summary
+func Clinician(data ClinicianData, opts ClinicianOptions, fonts Fonts) *pdfdoc.Document {
+	tr := translator{lang: opts.Lang}
+	doc := pdfdoc.New()
+	doc.Title = tr.t("title") + " — " + data.Patient.Name
+	doc.Author = data.Patient.Name
+	doc.Created = data.Generated
+
+	l := newLayout(doc, fonts)
+	l.y += 18
+	l.page.Text(l.fonts.Bold, 18, marginLeft, l.y, tr.t("title"), colorText)
+	l.paragraph(fmt.Sprintf("%s — %s: %s – %s", data.Patient.Name, tr.t("period"), tr.date(opts.From), tr.date(opts.To)),
+		l.fonts.Regular, bodySize+1, colorMuted)
+
+	for _, section := range Sections {
+		if !opts.Sections[section] {
+			continue
+		}
+		switch section {
+		case SectionPatient:
+			patientSection(l, tr, data)
+		case SectionStack:
+			stackSection(l, tr, data.Stack, opts.To)
+		case SectionLabs:
+			labsSection(l, tr, data.Markers)
+		case SectionInteractions:
+			interactionsSection(l, tr, data.Interactions)
+		case SectionCycle:
+			cycleSection(l, tr, data.Cycle)
+		}
+	}
+
+	l.footers(fmt.Sprintf("%s: %s · %s", tr.t("generated"), tr.date(data.Generated), data.Patient.Name), tr.t("page"))
+	return doc
+}
+
+func patientSection(l *layout, tr translator, data ClinicianData) {
+	p := data.Patient
+	l.heading(tr.t("patient"))
+	pairs := [][2]string{{tr.t("name"), p.Name}}
+	if p.BirthDate != nil {
+		age := data.Generated.Year() - p.BirthDate.Year()
+		if data.Generated.YearDay() < p.BirthDate.YearDay() {
+			age--
+		}
+		pairs = append(pairs,
+			[2]string{tr.t("birth_date"), tr.date(*p.BirthDate)},
+			[2]string{tr.t("age"), fmt.Sprintf("%d %s", age, tr.t("years"))})
+	}
+	if p.HeightCm != nil {
+		pairs = append(pairs, [2]string{tr.t("height"), fmt.Sprintf("%d cm", *p.HeightCm)})
+	}
+	if p.WeightKg != nil {
+		pairs = append(pairs, [2]string{tr.t("weight"), tr.number(*p.WeightKg) + " kg"})
+	}
+	if p.BodyFatPct != nil {
+		pairs = append(pairs, [2]string{tr.t("body_fat"), tr.number(*p.BodyFatPct) + " %"})
+	}
+	if p.HeightCm != nil && p.WeightKg != nil && *p.HeightCm > 0 {
+		m := float64(*p.HeightCm) / 100
+		pairs = append(pairs, [2]string{tr.t("bmi"), tr.number(math.Round(*p.WeightKg/(m*m)*10) / 10)})
+	}
+	l.fields(pairs)
+	l.space(6)
+}
+
+func stackSection(l *layout, tr translator, stack []models.Supplement, asOf time.Time) {
+	l.heading(tr.t("stack"))
+	l.paragraph(fmt.Sprintf(tr.t("stack_as_of"), tr.date(asOf)), l.fonts.Regular, smallSize, colorMuted)
+
+	groups := map[string][]models.Supplement{}
+	for _, s := range stack {
+		category := tr.t("uncategorized")
+		if s.Category != nil && strings.TrimSpace(*s.Category) != "" {
+			category = strings.TrimSpace(*s.Category)
+		}
+		groups[category] = append(groups[category], s)
+	}
+	categories := make([]string, 0, len(groups))
+	for c := range groups {
+		categories = append(categories, c)
+	}
+	sort.Strings(categories)
+
+	columns := []column{{tr.t("supplement"), 0.45}, {tr.t("dose"), 0.3}, {tr.t("time_of_day"), 0.25}}
+	for _, category := range categories {
+		supplements := groups[category]
+		sort.Slice(supplements, func(i, j int) bool { return supplements[i].Name < supplements[j].Name })
+		rows := make([][]string, len(supplements))
+		for i, s := range supplements {
+			rows[i] = []string{s.Name, deref(s.Dose), deref(s.TimeOfDay)}
+		}
+		l.subheading(category)
+		l.table(columns, rows, nil)
+	}
+	l.space(6)
+}
+
+func labsSection(l *layout, tr translator, markers []MarkerSeries) {
+	l.heading(tr.t("labs"))
+	if len(markers) == 0 {
+		l.paragraph(tr.t("labs_none"), l.fonts.Regular, bodySize, colorMuted)
+		return
+	}
+
+	columns := []column{{tr.t("marker"), 0.27}, {tr.t("latest"), 0.18}, {tr.t("reference"), 0.17}, {tr.t("change"), 0.12}, {tr.t("trend"), 0.26}}
+	rows := make([][]string, len(markers))
+	for i, m := range markers {
+		last := m.Points[len(m.Points)-1]
+		latest := tr.number(last.Value)
+		if m.Unit != "" {
+			latest += " " + m.Unit
+		}
+		latest += "\n" + tr.date(last.Date)
+		change := ""
+		if m.Change != nil {
+			change = fmt.Sprintf("%+.0f%%", *m.Change*100)
+		}
+		rows[i] = []string{m.Name, latest, referenceText(tr, m.RefMin, m.RefMax), change, ""}
+	}
+
+	l.table(columns, rows, func(row int, xs []float64, top, height float64) {
+		m := markers[row]
+		if m.OutOfRange {
+			l.page.Rect(xs[0], top+2, 2.5, height-4, colorAlert)
+		}
+		values := make([]float64, len(m.Points))
+		for i, p := range m.Points {
+			values[i] = p.Value
+		}
+		x, w := xs[4]+cellPad, xs[5]-xs[4]-2*cellPad-30
+		sparkline(l.page, x, top+(height-sparkHeight)/2, w, sparkHeight, values, m.RefMin, m.RefMax)
+		l.page.Text(l.fonts.Regular, smallSize, x+w+6, top+height/2+smallSize/3, fmt.Sprintf(tr.t("points"), len(values)), colorMuted)
+	})
+	l.space(6)
+}
+
+func referenceText(tr translator, refMin, refMax *float64) string {
+	switch {
+	case refMin != nil && refMax != nil:
+		return fmt.Sprintf(tr.t("range_between"), tr.number(*refMin), tr.number(*refMax))
+	case refMin != nil:
+		return fmt.Sprintf(tr.t("range_above"), tr.number(*refMin))
+	case refMax != nil:
+		return fmt.Sprintf(tr.t("range_below"), tr.number(*refMax))
+	}
+	return "—"
+}
+
+func interactionsSection(l *layout, tr translator, interactions []models.InteractionWithNames) {
+	l.heading(tr.t("interactions"))
+	if len(interactions) == 0 {
+		l.paragraph(tr.t("interactions_none"), l.fonts.Regular, bodySize, colorMuted)
+		return
+	}
+	columns := []column{{tr.t("pair"), 0.3}, {tr.t("description"), 0.4}, {tr.t("solution"), 0.3}}
+	rows := make([][]string, len(interactions))
+	for i, in := range interactions {
+		rows[i] = []string{in.Supplement1Name + " + " + in.Supplement2Name, deref(in.Description), deref(in.Solution)}
+	}
+	l.table(columns, rows, nil)
+	l.space(6)
+}
+
+func cycleSection(l *layout, tr translator, c *models.Cycle) {
+	l.heading(tr.t("cycle"))
+	if c == nil {
+		l.paragraph(tr.t("cycle_none"), l.fonts.Regular, bodySize, colorMuted)
+		return
+	}
+
+	pairs := [][2]string{{tr.t("cycle_date"), tr.date(c.CycleDate)}}
+	if c.CycleType != nil {
+		pairs = append(pairs, [2]string{tr.t("cycle_type"), tr.t("cycle_type_" + *c.CycleType)})
+	}
+	if c.NextReviewDate != nil {
+		pairs = append(pairs, [2]string{tr.t("next_review"), tr.date(*c.NextReviewDate)})
+	}
+	l.fields(pairs)
+
+	key := verdictKey(c.Verdict)
+	l.banner(tr.t("verdict")+": "+tr.t(key), verdictColors[key])
+
+	if summary := strings.TrimSpace(deref(c.MasterCuratorOutput)); summary != "" {
+		l.subheading(tr.t("summary"))
+		l.paragraph(summary, l.fonts.Regular, bodySize, colorText)
+	}
+
+	if decisions := cycleDecisions(c.Decisions); len(decisions) > 0 {
+		l.subheading(tr.t("decisions"))
+		keys := make([]string, 0, len(decisions))
+		for k := range decisions {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			l.paragraph(tr.t("decision_"+k), l.fonts.Bold, bodySize, colorText)
+			l.bullets(decisions[k])
+		}
+	}
+
+	var required []string
+	if c.RequiredLabs != nil && json.Unmarshal(*c.RequiredLabs, &required) == nil && len(required) > 0 {
+		l.subheading(tr.t("required_labs"))
+		l.bullets(required)
+	}
+}
+
+// cycleDecisions reads decisions as {"added": [...], "removed": [...]};
+// values that are not string lists are printed as JSON
+func cycleDecisions(raw *json.RawMessage) map[string][]string {
+	var fields map[string]json.RawMessage
+	if raw == nil || json.Unmarshal(*raw, &fields) != nil {
+		return nil
+	}
+	decisions := make(map[string][]string, len(fields))
+	for k, v := range fields {
+		var list []string
+		var s string
+		switch {
+		case json.Unmarshal(v, &list) == nil:
+		case json.Unmarshal(v, &s) == nil:
+			list = []string{s}
+		default:
+			list = []string{string(v)}
+		}
+		if len(list) > 0 {
+			decisions[k] = list
+		}
+	}
+	return decisions
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,330 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"reflect"
+	"sort"
+	"strings"
+
+	"health-ai-portal/internal/cycleinput"
+	"health-ai-portal/internal/models"
+	"health-ai-portal/pkg/markdown"
+)
+
+// Cycle report formats
+const (
+	FormatMarkdown = "md"
+	FormatHTML     = "html"
+)
+
+// inputOrder is the field order of the input_data template per object
+// path ("" for the top level); unknown keys follow alphabetically
+var inputOrder = templateOrder(reflect.TypeOf(models.CycleInputData{}), "", map[string][]string{})
+
+// verdictMarks decorate the verdict in Markdown, where there is no colour
+var verdictMarks = map[string]string{"go": "🟢", "wait": "🟡", "stop": "🔴"}
+
+// verdictKey is the label key of a cycle's verdict
+func verdictKey(verdict *string) string {
+	switch v := strings.ToLower(strings.TrimSpace(deref(verdict))); v {
+	case "go", "wait", "stop":
+		return "verdict_" + v
+	}
+	return "verdict_none"
+}
+
+// CycleMarkdown assembles a cycle's inputs and the four role outputs into
+// one Markdown document, in the style of 08_cycles_history.md
+func CycleMarkdown(c models.Cycle, lang string) string {
+	tr := translator{lang: lang}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", cycleTitle(tr, c))
+	if meta := cycleMeta(tr, c); meta != "" {
+		b.WriteString(meta + "\n\n")
+	}
+	mark := verdictMarks[strings.TrimPrefix(verdictKey(c.Verdict), "verdict_")]
+	if mark != "" {
+		mark += " "
+	}
+	fmt.Fprintf(&b, "> **%s%s: %s**\n\n", mark, tr.t("verdict"), tr.t(verdictKey(c.Verdict)))
+	b.WriteString(cycleBody(tr, c))
+	return b.String()
+}
+
+// CycleHTML is CycleMarkdown as a standalone page with a coloured verdict
+// banner
+func CycleHTML(c models.Cycle, lang string) string {
+	tr := translator{lang: lang}
+	title := cycleTitle(tr, c)
+	key := verdictKey(c.Verdict)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html lang=\"%s\">\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>%s</style>\n</head>\n<body>\n",
+		html.EscapeString(lang), html.EscapeString(title), cycleCSS)
+	fmt.Fprintf(&b, "<h1>%s</h1>\n", html.EscapeString(title))
+	if meta := cycleMeta(tr, c); meta != "" {
+		b.WriteString(markdown.HTML(meta))
+	}
+	fmt.Fprintf(&b, "<div class=\"banner %s\">%s: %s</div>\n",
+		strings.TrimPrefix(key, "verdict_"), html.EscapeString(tr.t("verdict")), html.EscapeString(tr.t(key)))
+	b.WriteString(markdown.HTML(cycleBody(tr, c)))
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+const cycleCSS = `
+body { font-family: -apple-system, "Segoe UI", Roboto, "DejaVu Sans", sans-serif; max-width: 60rem; margin: 2rem auto; padding: 0 1rem; color: #212529; line-height: 1.5; }
+h1, h2, h3 { line-height: 1.25; }
+h2 { border-bottom: 1px solid #dee2e6; padding-bottom: .3rem; margin-top: 2.5rem; }
+table { border-collapse: collapse; margin: 1rem 0; }
+th, td { border: 1px solid #dee2e6; padding: .35rem .6rem; vertical-align: top; text-align: left; }
+th { background: #e9ecef; }
+blockquote { border-left: 4px solid #ced4da; margin: 1rem 0; padding: 0 1rem; color: #495057; }
+code { background: #f1f3f5; padding: 0 .2rem; border-radius: 3px; }
+pre { background: #f1f3f5; padding: .75rem; overflow-x: auto; }
+.banner { padding: .75rem 1rem; border-radius: 4px; color: #fff; font-weight: bold; font-size: 1.15rem; margin: 1rem 0; }
+.banner.go { background: #198754; }
+.banner.wait { background: #cc8a00; }
+.banner.stop { background: #c82333; }
+.banner.none { background: #6c757d; }
+@media print { body { margin: 0; max-width: none; } h2 { break-after: avoid; } }
+`
+
+func cycleTitle(tr translator, c models.Cycle) string {
+	return fmt.Sprintf(tr.t("cycle_title"), c.ID, tr.date(c.CycleDate))
+}
+
+func cycleMeta(tr translator, c models.Cycle) string {
+	var parts []string
+	if c.CycleType != nil {
+		parts = append(parts, fmt.Sprintf("**%s:** %s", tr.t("cycle_type"), tr.t("cycle_type_"+*c.CycleType)))
+	}
+	if c.NextReviewDate != nil {
+		parts = append(parts, fmt.Sprintf("**%s:** %s", tr.t("next_review"), tr.date(*c.NextReviewDate)))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// cycleBody is everything after the verdict: the contents and sections
+func cycleBody(tr translator, c models.Cycle) string {
+	var s strings.Builder
+
+	var required []string
+	if c.RequiredLabs != nil && json.Unmarshal(*c.RequiredLabs, &required) == nil && len(required) > 0 {
+		fmt.Fprintf(&s, "## %s\n\n", tr.t("required_labs"))
+		for _, lab := range required {
+			fmt.Fprintf(&s, "- %s\n", lab)
+		}
+		s.WriteString("\n")
+	}
+
+	if decisions := cycleDecisions(c.Decisions); len(decisions) > 0 {
+		fmt.Fprintf(&s, "## %s\n\n", tr.t("decisions"))
+		keys := make([]string, 0, len(decisions))
+		for k := range decisions {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&s, "**%s:**\n\n", tr.t("decision_"+k))
+			for _, d := range decisions[k] {
+				fmt.Fprintf(&s, "- %s\n", d)
+			}
+			s.WriteString("\n")
+		}
+	}
+
+	if c.InputData != nil {
+		fmt.Fprintf(&s, "## %s\n\n", tr.t("input_data"))
+		s.WriteString(inputTables(tr, *c.InputData))
+	}
+
+	roles := []struct {
+		key    string
+		output *string
+	}{
+		{"role_rsl", c.RSLOutput},
+		{"role_curator", c.MasterCuratorOutput},
+		{"role_red_team", c.RedTeamOutput},
+		{"role_meta", c.MetaSupervisorOutput},
+	}
+	for _, role := range roles {
+		fmt.Fprintf(&s, "## %s\n\n", tr.t(role.key))
+		output := strings.TrimSpace(deref(role.output))
+		if output == "" {
+			fmt.Fprintf(&s, "*%s*\n\n", tr.t("no_output"))
+			continue
+		}
+		// Role outputs have headings of their own; nest them under the role
+		s.WriteString(markdown.ShiftHeadings(output, 3))
+		s.WriteString("\n\n")
+	}
+
+	body := s.String()
+	return contents(tr, body) + body
+}
+
+// contents links the second- and third-level headings of body
+func contents(tr translator, body string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n\n", tr.t("contents"))
+	for _, h := range markdown.Headings(body) {
+		if h.Level > 3 {
+			continue
+		}
+		indent := strings.Repeat("  ", h.Level-2)
+		text := strings.NewReplacer("[", `\[`, "]", `\]`).Replace(h.Text)
+		fmt.Fprintf(&b, "%s- [%s](#%s)\n", indent, text, h.ID)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// inputTables renders input_data as a table per section, top-level values
+// together in a general one
+func inputTables(tr translator, raw json.RawMessage) string {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return "```json\n" + string(raw) + "\n```\n\n"
+	}
+	// Old templates are upgraded so string numbers and unmeasured zeros
+	// print as they would today; newer ones are shown as they are
+	cycleinput.Migrate(doc)
+	delete(doc, "schema_version")
+
+	keys := orderedKeys(doc, inputOrder[""])
+	var b strings.Builder
+	var general [][2]string
+	for _, k := range keys {
+		if _, ok := doc[k].(map[string]interface{}); !ok {
+			if v := inputValue(tr, doc[k]); v != "" {
+				general = append(general, [2]string{inputLabel(tr, k), v})
+			}
+		}
+	}
+	if len(general) > 0 {
+		fmt.Fprintf(&b, "### %s\n\n", tr.t("input_general"))
+		b.WriteString(markdownTable(tr, general))
+	}
+
+	for _, k := range keys {
+		section, ok := doc[k].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var rows [][2]string
+		flattenInput(tr, k, section, &rows)
+		if len(rows) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "### %s\n\n", inputLabel(tr, k))
+		b.WriteString(markdownTable(tr, rows))
+	}
+	return b.String()
+}
+
+func flattenInput(tr translator, path string, section map[string]interface{}, rows *[][2]string) {
+	for _, k := range orderedKeys(section, inputOrder[path]) {
+		key := path + "." + k
+		if nested, ok := section[k].(map[string]interface{}); ok {
+			flattenInput(tr, key, nested, rows)
+			continue
+		}
+		if v := inputValue(tr, section[k]); v != "" {
+			*rows = append(*rows, [2]string{inputLabel(tr, key), v})
+		}
+	}
+}
+
+func templateOrder(t reflect.Type, path string, order map[string][]string) map[string][]string {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		order[path] = append(order[path], name)
+		if f.Type.Kind() == reflect.Struct {
+			child := name
+			if path != "" {
+				child = path + "." + name
+			}
+			templateOrder(f.Type, child, order)
+		}
+	}
+	return order
+}
+
+// orderedKeys lists keys in the given order first, then the rest sorted
+func orderedKeys(m map[string]interface{}, order []string) []string {
+	var keys []string
+	for _, k := range order {
+		if _, ok := m[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	var rest []string
+	for k := range m {
+		if !contains(order, k) {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// inputLabel names a dotted input path, falling back to its last key
+func inputLabel(tr translator, path string) string {
+	if label, ok := labels[tr.lang]["input."+path]; ok {
+		return label
+	}
+	return path[strings.LastIndex(path, ".")+1:]
+}
+
+func inputValue(tr translator, v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case bool:
+		if v {
+			return tr.t("yes")
+		}
+		return tr.t("no")
+	case float64:
+		return tr.number(v)
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			if s := inputValue(tr, item); s != "" {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, ", ")
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func markdownTable(tr translator, rows [][2]string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "| %s | %s |\n|---|---|\n", tr.t("field"), tr.t("value"))
+	cell := strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ")
+	for _, r := range rows {
+		fmt.Fprintf(&b, "| %s | %s |\n", cell.Replace(r[0]), cell.Replace(r[1]))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,254 @@
+package report
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Report languages
+const (
+	LangRU = "ru"
+	LangEN = "en"
+)
+
+var labels = map[string]map[string]string{
+	LangRU: {
+		"title":                             "Сводка для врача",
+		"period":                            "Период",
+		"generated":                         "Сформирован",
+		"page":                              "стр. %d из %d",
+		"patient":                           "Пациент",
+		"name":                              "Имя",
+		"birth_date":                        "Дата рождения",
+		"age":                               "Возраст",
+		"years":                             "лет",
+		"height":                            "Рост",
+		"weight":                            "Вес",
+		"body_fat":                          "Жир",
+		"bmi":                               "ИМТ",
+		"stack":                             "Текущий стек",
+		"stack_as_of":                       "Принимается на %s",
+		"uncategorized":                     "Без категории",
+		"supplement":                        "Препарат",
+		"dose":                              "Доза",
+		"time_of_day":                       "Приём",
+		"labs":                              "Анализы: отклонения и динамика",
+		"labs_none":                         "Отклонений и выраженной динамики за период нет.",
+		"marker":                            "Показатель",
+		"latest":                            "Последнее",
+		"reference":                         "Референс",
+		"change":                            "Изменение",
+		"trend":                             "Динамика",
+		"out_of_range":                      "вне нормы",
+		"interactions":                      "Критические взаимодействия",
+		"interactions_none":                 "Критических взаимодействий в текущем стеке нет.",
+		"pair":                              "Сочетание",
+		"description":                       "Описание",
+		"solution":                          "Что делать",
+		"cycle":                             "Последний цикл",
+		"cycle_none":                        "Циклов за период нет.",
+		"cycle_date":                        "Дата",
+		"cycle_type":                        "Тип",
+		"verdict":                           "Вердикт",
+		"verdict_go":                        "GO — продолжать",
+		"verdict_wait":                      "WAIT — ждать данных",
+		"verdict_stop":                      "STOP — остановиться",
+		"verdict_none":                      "нет",
+		"summary":                           "Резюме",
+		"decisions":                         "Решения",
+		"decision_added":                    "Добавить",
+		"decision_removed":                  "Убрать",
+		"decision_changed":                  "Изменить",
+		"decision_continued":                "Продолжить",
+		"required_labs":                     "Необходимые анализы",
+		"next_review":                       "Следующий пересмотр",
+		"cycle_type_full":                   "полный",
+		"cycle_type_partial":                "частичный",
+		"cycle_type_control":                "контрольный",
+		"range_below":                       "< %s",
+		"range_above":                       "> %s",
+		"range_between":                     "%s – %s",
+		"points":                            "%d изм.",
+		"cycle_title":                       "Цикл #%d от %s",
+		"contents":                          "Содержание",
+		"input_data":                        "Входные данные",
+		"input_general":                     "Общее",
+		"field":                             "Параметр",
+		"value":                             "Значение",
+		"yes":                               "да",
+		"no":                                "нет",
+		"no_output":                         "Нет ответа.",
+		"role_rsl":                          "Research & Strategy Lead",
+		"role_curator":                      "Master Curator",
+		"role_red_team":                     "Red Team",
+		"role_meta":                         "Meta-Supervisor",
+		"input.goals":                       "Цели",
+		"input.wellbeing":                   "Самочувствие",
+		"input.wellbeing.sleep":             "Сон",
+		"input.wellbeing.energy":            "Энергия",
+		"input.wellbeing.cognitive_clarity": "Когнитивная ясность",
+		"input.wellbeing.libido":            "Либидо",
+		"input.wellbeing.skin":              "Кожа",
+		"input.wellbeing.gi":                "ЖКТ",
+		"input.wellbeing.blood_pressure":    "Ортостаз / давление",
+		"input.wellbeing.other":             "Прочее",
+		"input.training":                    "Тренировки",
+		"input.training.frequency":          "Частота",
+		"input.training.split":              "Сплит",
+		"input.training.exercises":          "Ключевые упражнения",
+		"input.training.steps":              "Шаги",
+		"input.training.cardio":             "Кардио",
+		"input.nutrition":                   "Питание",
+		"input.nutrition.calories":          "Калории",
+		"input.nutrition.protein":           "Белок",
+		"input.nutrition.carbs":             "Углеводы",
+		"input.nutrition.fats":              "Жиры",
+		"input.nutrition.if":                "IF",
+		"input.nutrition.caffeine":          "Кофеин",
+		"input.nutrition.alcohol":           "Алкоголь",
+		"input.metrics":                     "Показатели",
+		"input.metrics.weight":              "Вес",
+		"input.metrics.blood_pressure":      "Давление",
+		"input.metrics.pulse":               "Пульс",
+		"input.metrics.hrv":                 "ВСР",
+		"input.metrics.glucose":             "Глюкоза",
+		"input.changes":                     "Изменения",
+		"input.ai_request":                  "Запрос к ИИ",
+	},
+	LangEN: {
+		"title":                             "Clinician summary",
+		"period":                            "Period",
+		"generated":                         "Generated",
+		"page":                              "page %d of %d",
+		"patient":                           "Patient",
+		"name":                              "Name",
+		"birth_date":                        "Date of birth",
+		"age":                               "Age",
+		"years":                             "years",
+		"height":                            "Height",
+		"weight":                            "Weight",
+		"body_fat":                          "Body fat",
+		"bmi":                               "BMI",
+		"stack":                             "Current stack",
+		"stack_as_of":                       "Taken as of %s",
+		"uncategorized":                     "Uncategorized",
+		"supplement":                        "Supplement",
+		"dose":                              "Dose",
+		"time_of_day":                       "Timing",
+		"labs":                              "Labs: out of range and trending",
+		"labs_none":                         "No out-of-range or trending markers in the period.",
+		"marker":                            "Marker",
+		"latest":                            "Latest",
+		"reference":                         "Reference",
+		"change":                            "Change",
+		"trend":                             "Trend",
+		"out_of_range":                      "out of range",
+		"interactions":                      "Critical interactions",
+		"interactions_none":                 "No critical interactions in the current stack.",
+		"pair":                              "Combination",
+		"description":                       "Description",
+		"solution":                          "Management",
+		"cycle":                             "Latest cycle",
+		"cycle_none":                        "No cycles in the period.",
+		"cycle_date":                        "Date",
+		"cycle_type":                        "Type",
+		"verdict":                           "Verdict",
+		"verdict_go":                        "GO — continue",
+		"verdict_wait":                      "WAIT — await data",
+		"verdict_stop":                      "STOP — halt",
+		"verdict_none":                      "none",
+		"summary":                           "Summary",
+		"decisions":                         "Decisions",
+		"decision_added":                    "Add",
+		"decision_removed":                  "Remove",
+		"decision_changed":                  "Change",
+		"decision_continued":                "Continue",
+		"required_labs":                     "Required labs",
+		"next_review":                       "Next review",
+		"cycle_type_full":                   "full",
+		"cycle_type_partial":                "partial",
+		"cycle_type_control":                "control",
+		"range_below":                       "< %s",
+		"range_above":                       "> %s",
+		"range_between":                     "%s – %s",
+		"points":                            "%d pts",
+		"cycle_title":                       "Cycle #%d of %s",
+		"contents":                          "Contents",
+		"input_data":                        "Input data",
+		"input_general":                     "General",
+		"field":                             "Field",
+		"value":                             "Value",
+		"yes":                               "yes",
+		"no":                                "no",
+		"no_output":                         "No output.",
+		"role_rsl":                          "Research & Strategy Lead",
+		"role_curator":                      "Master Curator",
+		"role_red_team":                     "Red Team",
+		"role_meta":                         "Meta-Supervisor",
+		"input.goals":                       "Goals",
+		"input.wellbeing":                   "Wellbeing",
+		"input.wellbeing.sleep":             "Sleep",
+		"input.wellbeing.energy":            "Energy",
+		"input.wellbeing.cognitive_clarity": "Cognitive clarity",
+		"input.wellbeing.libido":            "Libido",
+		"input.wellbeing.skin":              "Skin",
+		"input.wellbeing.gi":                "GI",
+		"input.wellbeing.blood_pressure":    "Orthostasis / blood pressure",
+		"input.wellbeing.other":             "Other",
+		"input.training":                    "Training",
+		"input.training.frequency":          "Frequency",
+		"input.training.split":              "Split",
+		"input.training.exercises":          "Key exercises",
+		"input.training.steps":              "Steps",
+		"input.training.cardio":             "Cardio",
+		"input.nutrition":                   "Nutrition",
+		"input.nutrition.calories":          "Calories",
+		"input.nutrition.protein":           "Protein",
+		"input.nutrition.carbs":             "Carbs",
+		"input.nutrition.fats":              "Fats",
+		"input.nutrition.if":                "Intermittent fasting",
+		"input.nutrition.caffeine":          "Caffeine",
+		"input.nutrition.alcohol":           "Alcohol",
+		"input.metrics":                     "Metrics",
+		"input.metrics.weight":              "Weight",
+		"input.metrics.blood_pressure":      "Blood pressure",
+		"input.metrics.pulse":               "Pulse",
+		"input.metrics.hrv":                 "HRV",
+		"input.metrics.glucose":             "Glucose",
+		"input.changes":                     "Changes",
+		"input.ai_request":                  "Request to AI",
+	},
+}
+
+// translator formats text in one report language
+type translator struct {
+	lang string
+}
+
+// t returns the label for key, or the key itself when there is none
+func (tr translator) t(key string) string {
+	if s, ok := labels[tr.lang][key]; ok {
+		return s
+	}
+	return key
+}
+
+func (tr translator) date(t time.Time) string {
+	if tr.lang == LangEN {
+		return t.Format("Jan 2, 2006")
+	}
+	return t.Format("02.01.2006")
+}
+
+// number writes a value with a decimal comma in Russian
+func (tr translator) number(v float64) string {
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if len(s) > 10 {
+		s = strconv.FormatFloat(v, 'g', 6, 64)
+	}
+	if tr.lang == LangRU {
+		s = strings.Replace(s, ".", ",", 1)
+	}
+	return s
+}
@@ -0,0 +1,338 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"health-ai-portal/pkg/pdfdoc"
+)
+
+// Fonts are the faces a report is set in; Bold falls back to Regular
+type Fonts struct {
+	Regular *pdfdoc.Font
+	Bold    *pdfdoc.Font
+}
+
+const (
+	marginLeft   = 48.0
+	marginRight  = 48.0
+	marginTop    = 48.0
+	marginBottom = 56.0
+	contentWidth = pdfdoc.PageWidth - marginLeft - marginRight
+
+	bodySize   = 9.5
+	smallSize  = 8.0
+	lineHeight = 1.35
+	cellPad    = 4.0
+)
+
+var (
+	colorText   = pdfdoc.RGB(33, 37, 41)
+	colorMuted  = pdfdoc.RGB(108, 117, 125)
+	colorRule   = pdfdoc.RGB(206, 212, 218)
+	colorHeader = pdfdoc.RGB(233, 236, 239)
+	colorBand   = pdfdoc.RGB(220, 237, 224)
+	colorLine   = pdfdoc.RGB(13, 110, 253)
+	colorAlert  = pdfdoc.RGB(200, 35, 51)
+	colorOK     = pdfdoc.RGB(25, 135, 84)
+	colorWarn   = pdfdoc.RGB(204, 138, 0)
+)
+
+// layout flows content down pages, starting a new page when a block does
+// not fit
+type layout struct {
+	doc   *pdfdoc.Document
+	page  *pdfdoc.Page
+	fonts Fonts
+	y     float64
+}
+
+func newLayout(doc *pdfdoc.Document, fonts Fonts) *layout {
+	if fonts.Bold == nil {
+		fonts.Bold = fonts.Regular
+	}
+	l := &layout{doc: doc, fonts: fonts}
+	l.newPage()
+	return l
+}
+
+func (l *layout) newPage() {
+	l.page = l.doc.AddPage()
+	l.y = marginTop
+}
+
+// ensure starts a new page unless h more points fit on this one
+func (l *layout) ensure(h float64) {
+	if l.y+h > pdfdoc.PageHeight-marginBottom {
+		l.newPage()
+	}
+}
+
+func (l *layout) space(h float64) {
+	l.y += h
+}
+
+func (l *layout) heading(text string) {
+	l.ensure(48)
+	l.y += 14
+	l.page.Text(l.fonts.Bold, 13, marginLeft, l.y, text, colorText)
+	l.y += 5
+	l.page.Line(marginLeft, l.y, marginLeft+contentWidth, l.y, 0.6, colorRule)
+	l.y += 10
+}
+
+func (l *layout) subheading(text string) {
+	l.ensure(30)
+	l.y += bodySize + 2
+	l.page.Text(l.fonts.Bold, bodySize+0.5, marginLeft, l.y, text, colorText)
+	l.y += 5
+}
+
+// paragraph writes wrapped text at the left margin
+func (l *layout) paragraph(text string, font *pdfdoc.Font, size float64, c pdfdoc.Color) {
+	l.paragraphAt(marginLeft, contentWidth, text, font, size, c)
+}
+
+func (l *layout) paragraphAt(x, width float64, text string, font *pdfdoc.Font, size float64, c pdfdoc.Color) {
+	for _, line := range wrap(font, size, text, width) {
+		l.ensure(size * lineHeight)
+		l.y += size * lineHeight
+		l.page.Text(font, size, x, l.y, line, c)
+	}
+}
+
+func (l *layout) bullets(items []string) {
+	for _, item := range items {
+		lines := wrap(l.fonts.Regular, bodySize, item, contentWidth-12)
+		for i, line := range lines {
+			l.ensure(bodySize * lineHeight)
+			l.y += bodySize * lineHeight
+			if i == 0 {
+				l.page.Text(l.fonts.Regular, bodySize, marginLeft+2, l.y, "•", colorText)
+			}
+			l.page.Text(l.fonts.Regular, bodySize, marginLeft+12, l.y, line, colorText)
+		}
+	}
+}
+
+// fields writes label: value pairs in two columns
+func (l *layout) fields(pairs [][2]string) {
+	half := contentWidth / 2
+	for i := 0; i < len(pairs); i += 2 {
+		l.ensure(bodySize * lineHeight)
+		l.y += bodySize * lineHeight
+		for j := 0; j < 2 && i+j < len(pairs); j++ {
+			x := marginLeft + float64(j)*half
+			label := pairs[i+j][0] + ": "
+			l.page.Text(l.fonts.Regular, bodySize, x, l.y, label, colorMuted)
+			value := truncate(l.fonts.Bold, bodySize, pairs[i+j][1], half-l.fonts.Regular.Width(label, bodySize)-8)
+			l.page.Text(l.fonts.Bold, bodySize, x+l.fonts.Regular.Width(label, bodySize), l.y, value, colorText)
+		}
+	}
+}
+
+// column of a table; widths are fractions of the content width
+type column struct {
+	title string
+	width float64
+}
+
+// table draws a header and wrapped rows, repeating the header after a page
+// break. draw, when set, paints extra content into a row's cells.
+func (l *layout) table(columns []column, rows [][]string, draw func(row int, x []float64, top, height float64)) {
+	xs := make([]float64, len(columns)+1)
+	xs[0] = marginLeft
+	for i, c := range columns {
+		xs[i+1] = xs[i] + c.width*contentWidth
+	}
+
+	header := func() {
+		h := bodySize*lineHeight + 2*cellPad
+		l.ensure(h + bodySize*lineHeight*2)
+		l.page.Rect(marginLeft, l.y, contentWidth, h, colorHeader)
+		for i, c := range columns {
+			l.page.Text(l.fonts.Bold, smallSize, xs[i]+cellPad, l.y+cellPad+smallSize, c.title, colorText)
+		}
+		l.y += h
+	}
+	header()
+
+	for r, row := range rows {
+		cells := make([][]string, len(columns))
+		lines := 1
+		for i := range columns {
+			if i < len(row) {
+				cells[i] = wrap(l.fonts.Regular, bodySize, row[i], xs[i+1]-xs[i]-2*cellPad)
+			}
+			if len(cells[i]) > lines {
+				lines = len(cells[i])
+			}
+		}
+		h := float64(lines)*bodySize*lineHeight + 2*cellPad
+		if draw != nil && h < sparkHeight+2*cellPad {
+			h = sparkHeight + 2*cellPad
+		}
+		if l.y+h > pdfdoc.PageHeight-marginBottom {
+			l.newPage()
+			header()
+		}
+		for i, cell := range cells {
+			for j, line := range cell {
+				l.page.Text(l.fonts.Regular, bodySize, xs[i]+cellPad, l.y+cellPad+bodySize+float64(j)*bodySize*lineHeight, line, colorText)
+			}
+		}
+		if draw != nil {
+			draw(r, xs, l.y, h)
+		}
+		l.y += h
+		l.page.Line(marginLeft, l.y, marginLeft+contentWidth, l.y, 0.4, colorRule)
+	}
+}
+
+// banner is a full-width coloured bar with white bold text
+func (l *layout) banner(text string, c pdfdoc.Color) {
+	h := 22.0
+	l.ensure(h + 6)
+	l.y += 4
+	l.page.Rect(marginLeft, l.y, contentWidth, h, c)
+	l.page.Text(l.fonts.Bold, 11, marginLeft+8, l.y+15, text, pdfdoc.RGB(255, 255, 255))
+	l.y += h + 4
+}
+
+const sparkHeight = 20.0
+
+// sparkline plots values in the box with the reference range shaded and
+// points outside it in red
+func sparkline(p *pdfdoc.Page, x, y, w, h float64, values []float64, refMin, refMax *float64) {
+	if len(values) == 0 {
+		return
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = minFloat(lo, v), maxFloat(hi, v)
+	}
+	if refMin != nil {
+		lo = minFloat(lo, *refMin)
+	}
+	if refMax != nil {
+		hi = maxFloat(hi, *refMax)
+	}
+	if hi == lo {
+		hi, lo = hi+1, lo-1
+	}
+	pad := (hi - lo) * 0.08
+	lo, hi = lo-pad, hi+pad
+	yOf := func(v float64) float64 { return y + h - (v-lo)/(hi-lo)*h }
+
+	bandTop, bandBottom := y, y+h
+	if refMax != nil {
+		bandTop = yOf(*refMax)
+	}
+	if refMin != nil {
+		bandBottom = yOf(*refMin)
+	}
+	if refMin != nil || refMax != nil {
+		p.Rect(x, bandTop, w, bandBottom-bandTop, colorBand)
+	}
+
+	points := make([]pdfdoc.Point, len(values))
+	for i, v := range values {
+		px := x + w/2
+		if len(values) > 1 {
+			px = x + float64(i)/float64(len(values)-1)*w
+		}
+		points[i] = pdfdoc.Point{X: px, Y: yOf(v)}
+	}
+	p.Polyline(points, 0.9, colorLine)
+	for i, v := range values {
+		c := colorLine
+		if outside(v, refMin, refMax) {
+			c = colorAlert
+		}
+		size := 2.2
+		if i == len(values)-1 {
+			size = 3.2
+		}
+		p.Dot(points[i].X, points[i].Y, size, c)
+	}
+}
+
+// footers numbers the pages once they are all laid out
+func (l *layout) footers(text, pageFormat string) {
+	pages := l.doc.Pages()
+	for i, p := range pages {
+		y := pdfdoc.PageHeight - marginBottom/2
+		p.Line(marginLeft, y-10, marginLeft+contentWidth, y-10, 0.4, colorRule)
+		p.Text(l.fonts.Regular, smallSize, marginLeft, y, text, colorMuted)
+		num := fmt.Sprintf(pageFormat, i+1, len(pages))
+		p.Text(l.fonts.Regular, smallSize, marginLeft+contentWidth-l.fonts.Regular.Width(num, smallSize), y, num, colorMuted)
+	}
+}
+
+// wrap breaks text into lines no wider than width, splitting words that
+// are longer than a line
+func wrap(f *pdfdoc.Font, size float64, text string, width float64) []string {
+	var lines []string
+	for _, para := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if f.Width(candidate, size) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			for f.Width(word, size) > width {
+				cut := fitRunes(f, size, word, width)
+				lines = append(lines, word[:cut])
+				word = word[cut:]
+			}
+			line = word
+		}
+		if line != "" || len(lines) == 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// fitRunes is the byte length of the longest prefix of s that fits, at
+// least one rune
+func fitRunes(f *pdfdoc.Font, size float64, s string, width float64) int {
+	end := 0
+	for i, r := range s {
+		next := i + len(string(r))
+		if end > 0 && f.Width(s[:next], size) > width {
+			break
+		}
+		end = next
+	}
+	return end
+}
+
+func truncate(f *pdfdoc.Font, size float64, s string, width float64) string {
+	if f.Width(s, size) <= width {
+		return s
+	}
+	cut := fitRunes(f, size, s, width-f.Width("…", size))
+	return s[:cut] + "…"
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
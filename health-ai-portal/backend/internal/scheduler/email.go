@@ -0,0 +1,65 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"health-ai-portal/internal/database"
+)
+
+// EmailNotifier sends reminders over SMTP to the user's notification_email
+type EmailNotifier struct {
+	db   *database.DB
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewEmailNotifier creates an SMTP channel. Without a username no AUTH is
+// attempted, which suits local relays and test sinks.
+func NewEmailNotifier(db *database.DB, host, port, username, password, from string) *EmailNotifier {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &EmailNotifier{db: db, addr: net.JoinHostPort(host, port), auth: auth, from: from}
+}
+
+func (e *EmailNotifier) Name() string { return "email" }
+
+func (e *EmailNotifier) Notify(ctx context.Context, n Notification) error {
+	var to *string
+	if err := e.db.GetContext(ctx, &to, `SELECT notification_email FROM users WHERE id = $1`, n.Reminder.UserID); err != nil {
+		return err
+	}
+	if to == nil || *to == "" {
+		return fmt.Errorf("no notification email set for user %d", n.Reminder.UserID)
+	}
+
+	return e.send(*to, n)
+}
+
+func (e *EmailNotifier) send(to string, n Notification) error {
+	return smtp.SendMail(e.addr, e.auth, e.from, []string{to}, e.message(to, n))
+}
+
+// message builds a UTF-8 plain-text email
+func (e *EmailNotifier) message(to string, n Notification) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + e.from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", n.Subject()) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(n.Body(), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"health-ai-portal/internal/models"
+)
+
+// Notification is one due reminder occurrence handed to a notifier
+type Notification struct {
+	DeliveryID   int // reminder_deliveries row, for write-back from the channel
+	Reminder     models.Reminder
+	ScheduledFor time.Time
+	Location     *time.Location
+	CatchUp      bool // fired late because the server was down
+	Snoozed      bool // repeat of an occurrence the user snoozed
+}
+
+// Subject is a one-line summary for channels with a title (email, push)
+func (n Notification) Subject() string {
+	subject := fmt.Sprintf("⏰ %s — %s", n.ScheduledFor.In(n.Location).Format("15:04"), n.Reminder.Title)
+	if n.Snoozed {
+		subject += " (повтор)"
+	}
+	return subject
+}
+
+// Body is the plain-text message body
+func (n Notification) Body() string {
+	var lines []string
+	if n.Reminder.Description != nil && *n.Reminder.Description != "" {
+		lines = append(lines, *n.Reminder.Description)
+	}
+	if n.CatchUp {
+		lines = append(lines, "Напоминание отправлено с опозданием: сервер был недоступен.")
+	}
+	if len(lines) == 0 {
+		lines = append(lines, n.Reminder.Title)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Notifier delivers notifications over one channel. Name is stored with every
+// delivery, so it should be short and stable (e.g. "log", "telegram").
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, n Notification) error
+}
+
+// LogNotifier writes notifications to the server log. Used when no real
+// channel is configured.
+type LogNotifier struct{}
+
+func (LogNotifier) Name() string { return "log" }
+
+func (LogNotifier) Notify(ctx context.Context, n Notification) error {
+	suffix := ""
+	if n.CatchUp {
+		suffix = " (catch-up)"
+	}
+	if n.Snoozed {
+		suffix += " (snoozed)"
+	}
+	log.Printf("Reminder #%d %q due at %s%s", n.Reminder.ID, n.Reminder.Title,
+		n.ScheduledFor.In(n.Location).Format("2006-01-02 15:04 MST"), suffix)
+	return nil
+}
@@ -0,0 +1,213 @@
+package scheduler
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"health-ai-portal/internal/models"
+
+	"github.com/SherClockHolmes/webpush-go"
+)
+
+func testNotification() Notification {
+	description := "Витамин D3, омега-3"
+	return Notification{
+		DeliveryID:   9,
+		Reminder:     models.Reminder{ID: 3, UserID: 1, Title: "Добавки утром", Description: &description},
+		ScheduledFor: time.Date(2025, 3, 1, 5, 30, 0, 0, time.UTC),
+		Location:     time.FixedZone("MSK", 3*3600),
+		CatchUp:      true,
+	}
+}
+
+// smtpSink is a local SMTP server that accepts every message
+type smtpSink struct {
+	net.Listener
+	mu       sync.Mutex
+	from     string
+	to       []string
+	messages []string
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpSink{Listener: l}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpSink) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 sink ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 sink")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			s.mu.Lock()
+			s.from = strings.Trim(strings.TrimSpace(line)[len("MAIL FROM:"):], "<>")
+			s.mu.Unlock()
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			s.mu.Lock()
+			s.to = append(s.to, strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>"))
+			s.mu.Unlock()
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, data.String())
+			s.mu.Unlock()
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestEmailNotifierSends(t *testing.T) {
+	sink := newSMTPSink(t)
+	host, port, _ := net.SplitHostPort(sink.Addr().String())
+	e := NewEmailNotifier(nil, host, port, "", "", "portal@example.com")
+
+	if err := e.send("me@example.com", testNotification()); err != nil {
+		t.Fatal(err)
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if sink.from != "portal@example.com" || len(sink.to) != 1 || sink.to[0] != "me@example.com" {
+		t.Errorf("envelope from %q to %q", sink.from, sink.to)
+	}
+	if len(sink.messages) != 1 {
+		t.Fatalf("messages = %d, want 1", len(sink.messages))
+	}
+	msg := sink.messages[0]
+	for _, want := range []string{
+		"Subject: =?utf-8?q?",
+		"Content-Type: text/plain; charset=utf-8",
+		"Витамин D3, омега-3\r\n",
+		"с опозданием",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message lacks %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestEmailNotifierUnreachable(t *testing.T) {
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+	l.Close()
+
+	e := NewEmailNotifier(nil, host, port, "", "", "portal@example.com")
+	if err := e.send("me@example.com", testNotification()); err == nil {
+		t.Error("sending to a closed port succeeded")
+	}
+}
+
+// pushEndpoint is a stub push service answering with status
+func pushEndpoint(t *testing.T, status int, requests *[]*http.Request) string {
+	t.Helper()
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		*requests = append(*requests, r)
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func testSubscription(t *testing.T, id int, endpoint string) models.PushSubscription {
+	t.Helper()
+	_, p256dh, err := webpush.GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := make([]byte, 16)
+	rand.Read(auth)
+	return models.PushSubscription{ID: id, Endpoint: endpoint, P256dh: p256dh, Auth: base64.RawURLEncoding.EncodeToString(auth)}
+}
+
+func TestWebPushNotifierPush(t *testing.T) {
+	private, public, err := webpush.GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewWebPushNotifier(nil, public, private, "mailto:me@example.com")
+
+	var accepted, gone, broken []*http.Request
+	subs := []models.PushSubscription{
+		testSubscription(t, 1, pushEndpoint(t, http.StatusCreated, &accepted)),
+		testSubscription(t, 2, pushEndpoint(t, http.StatusGone, &gone)),
+		testSubscription(t, 3, pushEndpoint(t, http.StatusInternalServerError, &broken)),
+	}
+
+	sent, expired, failures := p.push(context.Background(), subs, []byte(`{"title":"⏰"}`))
+	if len(sent) != 1 || sent[0] != 1 {
+		t.Errorf("sent = %v, want [1]", sent)
+	}
+	if len(expired) != 1 || expired[0] != 2 {
+		t.Errorf("expired = %v, want [2]", expired)
+	}
+	if len(failures) != 2 || !strings.Contains(failures[1], "HTTP 500") {
+		t.Errorf("failures = %q", failures)
+	}
+
+	if len(accepted) != 1 {
+		t.Fatalf("endpoint got %d requests, want 1", len(accepted))
+	}
+	r := accepted[0]
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "vapid t=") {
+		t.Errorf("Authorization = %q, want a VAPID token", r.Header.Get("Authorization"))
+	}
+	if r.Header.Get("Content-Encoding") != "aes128gcm" || r.Header.Get("TTL") != "3600" || r.Header.Get("Urgency") != "high" {
+		t.Errorf("headers = %v", r.Header)
+	}
+}
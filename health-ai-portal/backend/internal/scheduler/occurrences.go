@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"time"
+
+	"health-ai-portal/internal/models"
+	"health-ai-portal/pkg/rrule"
+	"health-ai-portal/pkg/schedule"
+)
+
+// Rule returns the reminder's recurrence. Rows without an rrule (or with an
+// invalid one) fall back to days_of_week.
+func Rule(r models.Reminder) *rrule.Rule {
+	if r.RRule != nil && *r.RRule != "" {
+		if rule, err := rrule.Parse(*r.RRule); err == nil {
+			return rule
+		}
+	}
+	return rrule.FromDaysOfWeek(DaysOfWeek(r))
+}
+
+// DaysOfWeek decodes the stored ISO weekdays (1=Mon..7=Sun)
+func DaysOfWeek(r models.Reminder) []int {
+	var days []int
+	if len(r.DaysOfWeek) > 0 {
+		json.Unmarshal(r.DaysOfWeek, &days)
+	}
+	return days
+}
+
+// ExDates decodes the skipped dates into a YYYY-MM-DD set
+func ExDates(r models.Reminder) map[string]bool {
+	var dates []string
+	if len(r.ExDates) > 0 {
+		json.Unmarshal(r.ExDates, &dates)
+	}
+	set := make(map[string]bool, len(dates))
+	for _, d := range dates {
+		set[d] = true
+	}
+	return set
+}
+
+// DTStart anchors the rule; older rows use their creation date
+func DTStart(r models.Reminder) time.Time {
+	if r.DTStart != nil {
+		return *r.DTStart
+	}
+	return r.CreatedAt
+}
+
+// OnDay reports whether the reminder recurs on date (a calendar date in the
+// user's timezone) and the date is not excluded
+func OnDay(r models.Reminder, date time.Time) bool {
+	if ExDates(r)[date.Format("2006-01-02")] {
+		return false
+	}
+	return Rule(r).Occurs(DTStart(r), date)
+}
+
+// OccurrenceOn returns the fire time on date in loc. False when the reminder
+// has no time or is not due that day.
+func OccurrenceOn(r models.Reminder, date time.Time, loc *time.Location) (time.Time, bool) {
+	if r.Time == nil || !OnDay(r, date) {
+		return time.Time{}, false
+	}
+	return clockOn(*r.Time, date, loc)
+}
+
+func clockOn(clock string, date time.Time, loc *time.Location) (time.Time, bool) {
+	normalized, ok := schedule.NormalizeClock(clock)
+	if !ok {
+		return time.Time{}, false
+	}
+	return schedule.Slot{ClockTime: normalized}.At(date, loc), true
+}
+
+// Occurrences returns fire times in (from, to], oldest first. Dates are
+// evaluated in loc so a reminder at 08:00 Europe/Moscow fires at 05:00 UTC.
+func Occurrences(r models.Reminder, from, to time.Time, loc *time.Location) []time.Time {
+	if r.Time == nil {
+		return nil
+	}
+
+	excluded := ExDates(r)
+	var result []time.Time
+	for _, date := range Rule(r).Dates(DTStart(r), from.In(loc), to.In(loc)) {
+		if excluded[date.Format("2006-01-02")] {
+			continue
+		}
+		at, ok := clockOn(*r.Time, date, loc)
+		if ok && at.After(from) && !at.After(to) {
+			result = append(result, at)
+		}
+	}
+	return result
+}
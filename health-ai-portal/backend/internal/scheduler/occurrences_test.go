@@ -0,0 +1,110 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"health-ai-portal/internal/models"
+	"health-ai-portal/pkg/rrule"
+)
+
+func TestOccurrences(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skip(err)
+	}
+	dtstart := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := "08:00"
+	rule := "FREQ=DAILY;INTERVAL=2"
+	r := models.Reminder{
+		Time:    &clock,
+		RRule:   &rule,
+		DTStart: &dtstart,
+		ExDates: json.RawMessage(`["2024-01-05", "2024-01-06"]`),
+	}
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, loc)
+	to := time.Date(2024, 1, 9, 23, 59, 0, 0, loc)
+	var got []string
+	for _, at := range Occurrences(r, from, to, loc) {
+		got = append(got, at.UTC().Format("01-02 15:04"))
+	}
+	// 01-05 is excluded; 01-06 is not an occurrence anyway
+	if want := "01-01 05:00,01-03 05:00,01-07 05:00,01-09 05:00"; strings.Join(got, ",") != want {
+		t.Errorf("occurrences = %v, want %s", got, want)
+	}
+
+	for day, want := range map[int]bool{3: true, 4: false, 5: false, 7: true} {
+		if got := OnDay(r, time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC)); got != want {
+			t.Errorf("OnDay(01-%02d) = %v, want %v", day, got, want)
+		}
+	}
+}
+
+// Rows without an rrule fall back to days_of_week, as do invalid rules
+func TestRuleFallback(t *testing.T) {
+	invalid := "FREQ=HOURLY"
+	for _, tc := range []struct {
+		r    models.Reminder
+		want string
+	}{
+		{models.Reminder{DaysOfWeek: json.RawMessage(`[1, 3, 5]`)}, "FREQ=WEEKLY;BYDAY=MO,WE,FR"},
+		{models.Reminder{DaysOfWeek: json.RawMessage(`null`)}, "FREQ=DAILY"},
+		{models.Reminder{RRule: &invalid, DaysOfWeek: json.RawMessage(`[6]`)}, "FREQ=WEEKLY;BYDAY=SA"},
+	} {
+		if got := Rule(tc.r).String(); got != tc.want {
+			t.Errorf("Rule(%s) = %s, want %s", tc.r.DaysOfWeek, got, tc.want)
+		}
+	}
+}
+
+// Migration 011 must turn days_of_week into the rule the API would build
+func TestDaysOfWeekMigration(t *testing.T) {
+	db := testDB(t)
+
+	migration, err := os.ReadFile("../database/migrations/011_reminder_rrule.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, days := range []string{`null`, `[]`, `[1, 3, 5]`, `[7, 6, 6]`, `[1, 2, 3, 4, 5, 6, 7]`, `[0, 2, "x"]`, `{"mon": true}`} {
+		var id int
+		if err := db.Get(&id, `
+			INSERT INTO reminders (user_id, title, days_of_week, is_active) VALUES (1, 'migration test', $1, false) RETURNING id
+		`, days); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Exec(`DELETE FROM reminders WHERE id = $1`, id) })
+		// Only rows without an rrule are converted
+		db.Exec(`UPDATE reminders SET rrule = NULL, dtstart = NULL WHERE id = $1`, id)
+	}
+
+	if _, err := db.Exec(string(migration)); err != nil {
+		t.Fatal(err)
+	}
+
+	var reminders []models.Reminder
+	if err := db.Select(&reminders, `SELECT * FROM reminders WHERE title = 'migration test'`); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range reminders {
+		var days []int
+		var raw []interface{}
+		json.Unmarshal(r.DaysOfWeek, &raw)
+		for _, d := range raw {
+			if n, ok := d.(float64); ok {
+				days = append(days, int(n))
+			}
+		}
+		want := rrule.FromDaysOfWeek(days).String()
+		if r.RRule == nil || *r.RRule != want {
+			t.Errorf("days_of_week %s migrated to %v, want %s", r.DaysOfWeek, r.RRule, want)
+		}
+		if r.DTStart == nil {
+			t.Errorf("days_of_week %s: dtstart not set", r.DaysOfWeek)
+		}
+	}
+}
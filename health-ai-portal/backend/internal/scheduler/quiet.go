@@ -0,0 +1,40 @@
+package scheduler
+
+import (
+	"time"
+
+	"health-ai-portal/pkg/schedule"
+)
+
+// QuietUntil reports whether t falls into the quiet hours [start, end) in loc
+// and when they end. A start after end wraps midnight (22:00–07:00).
+func QuietUntil(t time.Time, loc *time.Location, start, end *string) (time.Time, bool) {
+	if start == nil || end == nil {
+		return time.Time{}, false
+	}
+	from, ok1 := schedule.NormalizeClock(*start)
+	to, ok2 := schedule.NormalizeClock(*end)
+	if !ok1 || !ok2 || from == to {
+		return time.Time{}, false
+	}
+
+	local := t.In(loc)
+	now := local.Format("15:04")
+	endToday := schedule.Slot{ClockTime: to}.At(local, loc)
+
+	if from < to {
+		if now >= from && now < to {
+			return endToday, true
+		}
+		return time.Time{}, false
+	}
+
+	// Wrapping window: quiet in the evening part or the morning part
+	switch {
+	case now >= from:
+		return schedule.Slot{ClockTime: to}.At(local.AddDate(0, 0, 1), loc), true
+	case now < to:
+		return endToday, true
+	}
+	return time.Time{}, false
+}
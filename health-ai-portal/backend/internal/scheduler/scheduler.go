@@ -0,0 +1,289 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/models"
+)
+
+// Retry backoff doubles from retryBase up to retryMax
+const (
+	retryBase = time.Minute
+	retryMax  = time.Hour
+)
+
+// claimLease is how long a delivery picked up for sending stays hidden from
+// other schedulers. A scheduler that dies mid-send leaves it to be retried
+// once the lease runs out.
+const claimLease = 5 * time.Minute
+
+// Scheduler periodically fires due reminders through the configured channels.
+// Every occurrence within the catch-up window is claimed per channel through
+// reminder_deliveries, so reminders missed while the server was down are sent
+// once on the next tick and never twice. Claimed deliveries are sent outside
+// quiet hours and retried with backoff.
+type Scheduler struct {
+	db          *database.DB
+	channels    map[string]Notifier
+	order       []string // registration order, used when a user has no routes
+	interval    time.Duration
+	catchUp     time.Duration
+	maxAttempts int
+}
+
+func New(db *database.DB, interval, catchUp time.Duration, maxAttempts int, notifiers ...Notifier) *Scheduler {
+	if len(notifiers) == 0 {
+		notifiers = []Notifier{LogNotifier{}}
+	}
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	if catchUp < interval {
+		catchUp = interval
+	}
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	s := &Scheduler{
+		db:          db,
+		channels:    make(map[string]Notifier, len(notifiers)),
+		interval:    interval,
+		catchUp:     catchUp,
+		maxAttempts: maxAttempts,
+	}
+	for _, n := range notifiers {
+		s.channels[n.Name()] = n
+		s.order = append(s.order, n.Name())
+	}
+	return s
+}
+
+// Run ticks until ctx is cancelled. The first tick runs immediately to
+// deliver anything missed during downtime.
+func (s *Scheduler) Run(ctx context.Context) {
+	log.Printf("Reminder scheduler started (every %s, catch-up %s, channels %v)", s.interval, s.catchUp, s.order)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Tick(ctx, time.Now()); err != nil {
+			log.Printf("Reminder scheduler: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// activeReminder is a reminder joined with its owner's timezone
+type activeReminder struct {
+	models.Reminder
+	Timezone string `db:"timezone"`
+}
+
+// Tick claims every occurrence due in (now - catchUp, now] and then sends
+// all pending deliveries whose attempt time has come.
+func (s *Scheduler) Tick(ctx context.Context, now time.Time) error {
+	var reminders []activeReminder
+	err := s.db.SelectContext(ctx, &reminders, `
+		SELECT r.*, COALESCE(u.timezone, 'UTC') AS timezone
+		FROM reminders r
+		JOIN users u ON r.user_id = u.id
+		WHERE r.is_active = true
+	`)
+	if err != nil {
+		return err
+	}
+
+	from := now.Add(-s.catchUp)
+	for _, r := range reminders {
+		loc, err := time.LoadLocation(r.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+
+		// Never fire occurrences from before the reminder existed
+		start := from
+		if r.CreatedAt.After(start) {
+			start = r.CreatedAt
+		}
+
+		for _, at := range Occurrences(r.Reminder, start, now, loc) {
+			s.claim(ctx, r.Reminder, at, now, false)
+		}
+
+		if r.SnoozedUntil != nil && r.SnoozedUntil.After(from) && !r.SnoozedUntil.After(now) {
+			s.claim(ctx, r.Reminder, *r.SnoozedUntil, now, true)
+			s.db.ExecContext(ctx, `
+				UPDATE reminders SET snoozed_until = NULL
+				WHERE id = $1 AND snoozed_until = $2
+			`, r.ID, *r.SnoozedUntil)
+		}
+	}
+
+	return s.sendDue(ctx, now)
+}
+
+// claim creates a pending delivery per routed channel. Existing rows are left
+// alone, which is what makes catch-up idempotent.
+func (s *Scheduler) claim(ctx context.Context, r models.Reminder, at, now time.Time, snoozed bool) {
+	catchUp := now.Sub(at) > 2*s.interval
+
+	for _, channel := range s.route(ctx, r.UserID, r.ReminderType) {
+		_, err := s.db.ExecContext(ctx, `
+			INSERT INTO reminder_deliveries (reminder_id, user_id, scheduled_for, channel, catch_up, snoozed, next_attempt_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (reminder_id, scheduled_for, channel) DO NOTHING
+		`, r.ID, r.UserID, at, channel, catchUp, snoozed, now)
+		if err != nil {
+			log.Printf("Reminder #%d: claim via %s failed: %v", r.ID, channel, err)
+		}
+	}
+}
+
+// route returns the channels for a reminder type: the user's routes for that
+// type, else their "default" routes, else every configured channel
+func (s *Scheduler) route(ctx context.Context, userID int, reminderType *string) []string {
+	var routes []models.NotificationRoute
+	if err := s.db.SelectContext(ctx, &routes, `SELECT * FROM notification_routes WHERE user_id = $1 ORDER BY id`, userID); err != nil {
+		log.Printf("Reminder scheduler: loading routes: %v", err)
+	}
+
+	typ := "default"
+	if reminderType != nil && *reminderType != "" {
+		typ = *reminderType
+	}
+
+	for _, want := range []string{typ, "default"} {
+		var channels []string
+		for _, route := range routes {
+			if route.ReminderType == want {
+				if _, ok := s.channels[route.Channel]; ok {
+					channels = append(channels, route.Channel)
+				}
+			}
+		}
+		if len(channels) > 0 {
+			return channels
+		}
+	}
+	return s.order
+}
+
+// pendingDelivery is a delivery joined with the user's timezone and quiet hours
+type pendingDelivery struct {
+	models.ReminderDelivery
+	Timezone        string  `db:"timezone"`
+	QuietHoursStart *string `db:"quiet_hours_start"`
+	QuietHoursEnd   *string `db:"quiet_hours_end"`
+}
+
+// sendDue attempts every pending delivery whose next_attempt_at has passed.
+// Each is claimed first by moving next_attempt_at past a lease, skipping rows
+// another scheduler is claiming, so two replicas or overlapping ticks never
+// send the same delivery. Deliveries falling into quiet hours are pushed to
+// the end of them without using up an attempt.
+func (s *Scheduler) sendDue(ctx context.Context, now time.Time) error {
+	var pending []pendingDelivery
+	err := s.db.SelectContext(ctx, &pending, `
+		UPDATE reminder_deliveries d SET next_attempt_at = $2
+		FROM users u
+		WHERE u.id = d.user_id AND d.id IN (
+			SELECT id FROM reminder_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT 100
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING d.*, COALESCE(u.timezone, 'UTC') AS timezone, u.quiet_hours_start, u.quiet_hours_end
+	`, now, now.Add(claimLease))
+	if err != nil {
+		return err
+	}
+
+	for _, d := range pending {
+		loc, err := time.LoadLocation(d.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+
+		if until, quiet := QuietUntil(now, loc, d.QuietHoursStart, d.QuietHoursEnd); quiet {
+			s.db.ExecContext(ctx, `UPDATE reminder_deliveries SET next_attempt_at = $2 WHERE id = $1`, d.ID, until)
+			continue
+		}
+
+		var reminder models.Reminder
+		if err := s.db.GetContext(ctx, &reminder, `SELECT * FROM reminders WHERE id = $1`, d.ReminderID); err != nil {
+			s.finish(ctx, d.ReminderDelivery, now, err)
+			continue
+		}
+
+		notifier, ok := s.channels[d.Channel]
+		if !ok {
+			s.finish(ctx, d.ReminderDelivery, now, fmt.Errorf("channel %s is not configured", d.Channel))
+			continue
+		}
+
+		notifyErr := notifier.Notify(ctx, Notification{
+			DeliveryID:   d.ID,
+			Reminder:     reminder,
+			ScheduledFor: d.ScheduledFor,
+			Location:     loc,
+			CatchUp:      d.CatchUp,
+			Snoozed:      d.Snoozed,
+		})
+		s.finish(ctx, d.ReminderDelivery, now, notifyErr)
+	}
+
+	return nil
+}
+
+// finish records an attempt: sent, retry later, or failed for good
+func (s *Scheduler) finish(ctx context.Context, d models.ReminderDelivery, now time.Time, sendErr error) {
+	attempts := d.Attempts + 1
+
+	var err error
+	switch {
+	case sendErr == nil:
+		_, err = s.db.ExecContext(ctx, `
+			UPDATE reminder_deliveries SET
+				status = 'sent', attempts = $2, error = NULL, delivered_at = NOW(), next_attempt_at = NULL
+			WHERE id = $1
+		`, d.ID, attempts)
+	case attempts < s.maxAttempts:
+		log.Printf("Reminder #%d: delivery via %s failed (attempt %d/%d): %v", d.ReminderID, d.Channel, attempts, s.maxAttempts, sendErr)
+		_, err = s.db.ExecContext(ctx, `
+			UPDATE reminder_deliveries SET attempts = $2, error = $3, next_attempt_at = $4
+			WHERE id = $1
+		`, d.ID, attempts, sendErr.Error(), now.Add(backoff(attempts)))
+	default:
+		log.Printf("Reminder #%d: delivery via %s failed after %d attempts: %v", d.ReminderID, d.Channel, attempts, sendErr)
+		_, err = s.db.ExecContext(ctx, `
+			UPDATE reminder_deliveries SET status = 'failed', attempts = $2, error = $3, next_attempt_at = NULL
+			WHERE id = $1
+		`, d.ID, attempts, sendErr.Error())
+	}
+	if err != nil {
+		log.Printf("Reminder #%d: recording delivery failed: %v", d.ReminderID, err)
+	}
+}
+
+// backoff is the wait before retry number attempt+1
+func backoff(attempt int) time.Duration {
+	wait := retryBase
+	for i := 1; i < attempt; i++ {
+		wait *= 2
+		if wait >= retryMax {
+			return retryMax
+		}
+	}
+	return wait
+}
@@ -0,0 +1,117 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"health-ai-portal/internal/database"
+)
+
+// testDB connects to TEST_DATABASE_URL and migrates it; tests that need
+// Postgres are skipped without one
+func testDB(t *testing.T) *database.DB {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := database.New(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.RunMigrations("../database/migrations"); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// countingNotifier records how often each delivery was sent
+type countingNotifier struct {
+	mu    sync.Mutex
+	sends map[int]int
+}
+
+func (c *countingNotifier) Name() string { return "counting" }
+
+func (c *countingNotifier) Notify(ctx context.Context, n Notification) error {
+	time.Sleep(5 * time.Millisecond) // hold the claim while others tick
+	c.mu.Lock()
+	c.sends[n.DeliveryID]++
+	c.mu.Unlock()
+	return nil
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt, want := range map[int]time.Duration{1: time.Minute, 2: 2 * time.Minute, 4: 8 * time.Minute, 10: time.Hour} {
+		if got := backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+}
+
+func TestSendDueOnce(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	var reminderID int
+	if err := db.Get(&reminderID, `
+		INSERT INTO reminders (user_id, title, is_active) VALUES (1, 'claim test', false) RETURNING id
+	`); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Exec(`DELETE FROM reminders WHERE id = $1`, reminderID) })
+
+	var quietStart, quietEnd *string
+	db.QueryRow(`SELECT quiet_hours_start, quiet_hours_end FROM users WHERE id = 1`).Scan(&quietStart, &quietEnd)
+	t.Cleanup(func() {
+		db.Exec(`UPDATE users SET quiet_hours_start = $1, quiet_hours_end = $2 WHERE id = 1`, quietStart, quietEnd)
+	})
+	db.Exec(`UPDATE users SET quiet_hours_start = NULL, quiet_hours_end = NULL WHERE id = 1`)
+
+	now := time.Now()
+	const deliveries = 30
+	for i := 0; i < deliveries; i++ {
+		_, err := db.Exec(`
+			INSERT INTO reminder_deliveries (reminder_id, user_id, scheduled_for, channel, next_attempt_at)
+			VALUES ($1, 1, $2, 'counting', $3)
+		`, reminderID, now.Add(-time.Duration(i)*time.Minute), now.Add(-time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	notifier := &countingNotifier{sends: map[int]int{}}
+	s := New(db, time.Minute, time.Minute, 3, notifier)
+
+	// Four schedulers tick at once, as replicas or overlapping ticks would
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.sendDue(ctx, now); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(notifier.sends) != deliveries {
+		t.Errorf("sent %d deliveries, want %d", len(notifier.sends), deliveries)
+	}
+	for id, n := range notifier.sends {
+		if n != 1 {
+			t.Errorf("delivery %d sent %d times", id, n)
+		}
+	}
+
+	var pending int
+	db.Get(&pending, `SELECT COUNT(*) FROM reminder_deliveries WHERE reminder_id = $1 AND status <> 'sent'`, reminderID)
+	if pending != 0 {
+		t.Errorf("%d deliveries not marked sent", pending)
+	}
+}
@@ -0,0 +1,118 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"health-ai-portal/internal/database"
+	"health-ai-portal/internal/models"
+
+	"github.com/SherClockHolmes/webpush-go"
+)
+
+// WebPushNotifier sends reminders to every push subscription of the user,
+// signed with the server's VAPID key pair
+type WebPushNotifier struct {
+	db         *database.DB
+	publicKey  string
+	privateKey string
+	subject    string // mailto: or https: contact required by push services
+	client     *http.Client
+}
+
+func NewWebPushNotifier(db *database.DB, publicKey, privateKey, subject string) *WebPushNotifier {
+	return &WebPushNotifier{
+		db:         db,
+		publicKey:  publicKey,
+		privateKey: privateKey,
+		subject:    subject,
+		client:     &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (p *WebPushNotifier) Name() string { return "webpush" }
+
+// pushPayload is what the service worker receives
+type pushPayload struct {
+	Title        string    `json:"title"`
+	Body         string    `json:"body"`
+	ReminderID   int       `json:"reminder_id"`
+	DeliveryID   int       `json:"delivery_id"`
+	ScheduledFor time.Time `json:"scheduled_for"`
+}
+
+// Notify succeeds when at least one device accepted the message. Expired
+// subscriptions (404/410) are deleted.
+func (p *WebPushNotifier) Notify(ctx context.Context, n Notification) error {
+	var subs []models.PushSubscription
+	if err := p.db.SelectContext(ctx, &subs, `SELECT * FROM push_subscriptions WHERE user_id = $1`, n.Reminder.UserID); err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return fmt.Errorf("no push subscriptions for user %d", n.Reminder.UserID)
+	}
+
+	payload, err := json.Marshal(pushPayload{
+		Title:        n.Subject(),
+		Body:         n.Body(),
+		ReminderID:   n.Reminder.ID,
+		DeliveryID:   n.DeliveryID,
+		ScheduledFor: n.ScheduledFor,
+	})
+	if err != nil {
+		return err
+	}
+
+	sent, expired, failures := p.push(ctx, subs, payload)
+	for _, id := range expired {
+		p.db.ExecContext(ctx, `DELETE FROM push_subscriptions WHERE id = $1`, id)
+	}
+	for _, id := range sent {
+		p.db.ExecContext(ctx, `UPDATE push_subscriptions SET last_used_at = NOW() WHERE id = $1`, id)
+	}
+
+	if len(sent) == 0 {
+		return fmt.Errorf("web push failed: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// push sends payload to every subscription and sorts them into accepted,
+// expired (404/410) and failed
+func (p *WebPushNotifier) push(ctx context.Context, subs []models.PushSubscription, payload []byte) (sent, expired []int, failures []string) {
+	for _, sub := range subs {
+		resp, err := webpush.SendNotificationWithContext(ctx, payload, &webpush.Subscription{
+			Endpoint: sub.Endpoint,
+			Keys:     webpush.Keys{P256dh: sub.P256dh, Auth: sub.Auth},
+		}, &webpush.Options{
+			HTTPClient:      p.client,
+			Subscriber:      p.subject,
+			VAPIDPublicKey:  p.publicKey,
+			VAPIDPrivateKey: p.privateKey,
+			TTL:             3600,
+			Urgency:         webpush.UrgencyHigh,
+		})
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+			expired = append(expired, sub.ID)
+			failures = append(failures, fmt.Sprintf("subscription %d expired", sub.ID))
+		case resp.StatusCode >= 300:
+			failures = append(failures, fmt.Sprintf("subscription %d: HTTP %d %s", sub.ID, resp.StatusCode, strings.TrimSpace(string(body))))
+		default:
+			sent = append(sent, sub.ID)
+		}
+	}
+	return sent, expired, failures
+}
@@ -0,0 +1,131 @@
+// Package archive reads and writes health record exports: a zip with a
+// manifest.json and one JSON array of rows per table.
+package archive
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Format identifies archives produced by this package
+const Format = "health-ai-portal"
+
+// Version is the archive layout version. Bump it when the layout changes in
+// a way older readers cannot handle; new tables and columns do not need it.
+const Version = 1
+
+const manifestFile = "manifest.json"
+
+// Manifest describes an archive
+type Manifest struct {
+	Format        string         `json:"format"`
+	Version       int            `json:"version"`
+	SchemaVersion int            `json:"schema_version"` // database migration the rows come from
+	ExportedAt    time.Time      `json:"exported_at"`
+	Tables        map[string]int `json:"tables"` // row count per table
+}
+
+// Archive is a manifest with the exported rows, keyed by table name. Each row
+// is a JSON object of column values.
+type Archive struct {
+	Manifest Manifest
+	Tables   map[string][]json.RawMessage
+}
+
+// Write stores the archive as a zip. The manifest's format, version and row
+// counts are filled in from the archive.
+func Write(w io.Writer, a *Archive) error {
+	a.Manifest.Format = Format
+	a.Manifest.Version = Version
+	a.Manifest.Tables = make(map[string]int, len(a.Tables))
+
+	names := make([]string, 0, len(a.Tables))
+	for name, rows := range a.Tables {
+		names = append(names, name)
+		a.Manifest.Tables[name] = len(rows)
+	}
+	sort.Strings(names)
+
+	zw := zip.NewWriter(w)
+	if err := writeJSON(zw, manifestFile, a.Manifest); err != nil {
+		return err
+	}
+	for _, name := range names {
+		rows := a.Tables[name]
+		if rows == nil {
+			rows = []json.RawMessage{}
+		}
+		if err := writeJSON(zw, name+".json", rows); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeJSON(zw *zip.Writer, name string, v interface{}) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// Read opens an archive and checks that this version can read it
+func Read(r io.ReaderAt, size int64) (*Archive, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("not a zip archive: %v", err)
+	}
+
+	a := &Archive{Tables: make(map[string][]json.RawMessage)}
+	found := false
+	for _, f := range zr.File {
+		name := path.Base(f.Name)
+		if f.FileInfo().IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		if name == manifestFile {
+			if err := readJSON(f, &a.Manifest); err != nil {
+				return nil, err
+			}
+			found = true
+			continue
+		}
+		var rows []json.RawMessage
+		if err := readJSON(f, &rows); err != nil {
+			return nil, err
+		}
+		a.Tables[strings.TrimSuffix(name, ".json")] = rows
+	}
+
+	if !found {
+		return nil, fmt.Errorf("%s is missing", manifestFile)
+	}
+	if a.Manifest.Format != Format {
+		return nil, fmt.Errorf("unknown archive format %q", a.Manifest.Format)
+	}
+	if a.Manifest.Version > Version {
+		return nil, fmt.Errorf("archive version %d is newer than supported version %d", a.Manifest.Version, Version)
+	}
+	return a, nil
+}
+
+func readJSON(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := json.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("%s: %v", f.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,322 @@
+// Package correlate lines supplement changes up with the lab results that
+// follow them. For every change and marker it takes the last result before
+// the change and the first one inside each lag window after it, and ranks
+// the differences by how far they exceed the marker's reference change
+// value. These are single before/after pairs from one person: leads to
+// check, not effects.
+package correlate
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"health-ai-portal/pkg/trend"
+)
+
+// Confidence levels, highest first
+const (
+	High   = "high"
+	Medium = "medium"
+	Low    = "low"
+)
+
+// Caveats attached to an association
+const (
+	CaveatConfounded       = "confounded"        // other changes happened around this one
+	CaveatBelowRCV         = "below_rcv"         // the change is within normal variation
+	CaveatNoRCV            = "no_rcv"            // no variation data to judge the change by
+	CaveatApproximateDate  = "approximate_date"  // the change's date was backfilled
+	CaveatOldBaseline      = "old_baseline"      // the result before is long before the change
+	CaveatSingleWindow     = "single_window"     // seen in one lag window only
+	CaveatInconsistentSign = "inconsistent_sign" // windows disagree on the direction
+)
+
+const (
+	// unjudgedChangePct is the change that counts as notable for markers
+	// without variation data
+	unjudgedChangePct = 20
+	// carryoverDays is how long an earlier change may still be moving a
+	// marker, so it confounds later ones
+	carryoverDays = 84
+)
+
+// Event is a supplement change
+type Event struct {
+	SupplementID int
+	Supplement   string
+	Type         string // added, removed, dose_changed, paused or resumed
+	Date         time.Time
+	Approximate  bool // the date is when the row was created, not the change
+}
+
+// Result is a lab result
+type Result struct {
+	Marker string
+	Unit   string
+	Date   time.Time
+	Value  float64
+}
+
+// Window is a lag after a change in which a result counts as its outcome
+type Window struct {
+	Name    string
+	MinDays int
+	MaxDays int
+}
+
+// DefaultWindows suit markers that settle within weeks to months
+var DefaultWindows = []Window{
+	{"2-6w", 14, 42},
+	{"6-12w", 43, 84},
+	{"3-6m", 85, 180},
+}
+
+// Options tune the search
+type Options struct {
+	Windows      []Window
+	BaselineDays int // how old the result before a change may be
+	// Variation returns a marker's variation; nil uses trend.VariationFor
+	Variation func(marker string) (trend.Variation, bool)
+}
+
+// DefaultOptions are used for zero fields
+var DefaultOptions = Options{Windows: DefaultWindows, BaselineDays: 180}
+
+// Delta is the outcome in one lag window
+type Delta struct {
+	Window      string
+	AfterDate   time.Time
+	AfterValue  float64
+	LagDays     int
+	Change      float64
+	Percent     float64
+	ExceedsRCV  bool
+	Confounders []string // other supplements changed since shortly before this one
+}
+
+// Association is a supplement change and what one marker did after it
+type Association struct {
+	SupplementID int
+	Supplement   string
+	EventType    string
+	EventDate    time.Time
+	Marker       string
+	Unit         string
+	BeforeDate   time.Time
+	BeforeValue  float64
+	RCVPercent   *float64
+	Deltas       []Delta
+	Best         int // index of the delta the ranking is based on
+	Score        float64
+	Confidence   string
+	Caveats      []string
+}
+
+// Associate pairs every event with every marker measured before and after
+// it, strongest first
+func Associate(events []Event, results []Result, opts Options) []Association {
+	if opts.Windows == nil {
+		opts.Windows = DefaultOptions.Windows
+	}
+	if opts.BaselineDays == 0 {
+		opts.BaselineDays = DefaultOptions.BaselineDays
+	}
+	if opts.Variation == nil {
+		opts.Variation = trend.VariationFor
+	}
+
+	byMarker := map[string][]Result{}
+	for _, r := range results {
+		byMarker[r.Marker] = append(byMarker[r.Marker], r)
+	}
+	for _, rs := range byMarker {
+		sort.SliceStable(rs, func(i, j int) bool { return rs[i].Date.Before(rs[j].Date) })
+	}
+
+	associations := []Association{}
+	for _, e := range events {
+		for marker, rs := range byMarker {
+			if a, ok := associate(e, marker, rs, events, opts); ok {
+				associations = append(associations, a)
+			}
+		}
+	}
+
+	sort.SliceStable(associations, func(i, j int) bool {
+		a, b := associations[i], associations[j]
+		if rank(a.Confidence) != rank(b.Confidence) {
+			return rank(a.Confidence) < rank(b.Confidence)
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if !a.EventDate.Equal(b.EventDate) {
+			return a.EventDate.After(b.EventDate)
+		}
+		return a.Marker < b.Marker
+	})
+	return associations
+}
+
+func associate(e Event, marker string, rs []Result, events []Event, opts Options) (Association, bool) {
+	// The last result on or before the day of the change
+	before := -1
+	for i, r := range rs {
+		if r.Date.After(e.Date) {
+			break
+		}
+		before = i
+	}
+	if before < 0 || rs[before].Value == 0 {
+		return Association{}, false
+	}
+	base := rs[before]
+
+	a := Association{
+		SupplementID: e.SupplementID,
+		Supplement:   e.Supplement,
+		EventType:    e.Type,
+		EventDate:    e.Date,
+		Marker:       marker,
+		Unit:         base.Unit,
+		BeforeDate:   base.Date,
+		BeforeValue:  base.Value,
+		Caveats:      []string{},
+	}
+	var rcv float64
+	if v, ok := opts.Variation(marker); ok {
+		rcv = v.RCV()
+		a.RCVPercent = &rcv
+	}
+
+	since := e.Date.AddDate(0, 0, -carryoverDays)
+	if base.Date.Before(since) {
+		since = base.Date
+	}
+	for _, w := range opts.Windows {
+		from := e.Date.AddDate(0, 0, w.MinDays)
+		to := e.Date.AddDate(0, 0, w.MaxDays)
+		for _, r := range rs[before+1:] {
+			if r.Date.Before(from) || !strings.EqualFold(r.Unit, base.Unit) {
+				continue
+			}
+			if r.Date.After(to) {
+				break
+			}
+			d := Delta{
+				Window:      w.Name,
+				AfterDate:   r.Date,
+				AfterValue:  r.Value,
+				LagDays:     int(math.Round(r.Date.Sub(e.Date).Hours() / 24)),
+				Change:      r.Value - base.Value,
+				Percent:     (r.Value - base.Value) / math.Abs(base.Value) * 100,
+				Confounders: confounders(e, events, since, r.Date),
+			}
+			d.ExceedsRCV = a.RCVPercent != nil && math.Abs(d.Percent) > rcv
+			a.Deltas = append(a.Deltas, d)
+			break
+		}
+	}
+	if len(a.Deltas) == 0 {
+		return Association{}, false
+	}
+
+	for i, d := range a.Deltas {
+		if deltaScore(d, a.RCVPercent) > deltaScore(a.Deltas[a.Best], a.RCVPercent) {
+			a.Best = i
+		}
+	}
+	best := a.Deltas[a.Best]
+	a.Score = deltaScore(best, a.RCVPercent)
+	a.Confidence, a.Caveats = judge(a, best, e, opts)
+	return a, true
+}
+
+// deltaScore is the change in multiples of the RCV, or of the notable
+// change without variation data, discounted for every confounder
+func deltaScore(d Delta, rcv *float64) float64 {
+	scale := float64(unjudgedChangePct)
+	if rcv != nil && *rcv > 0 {
+		scale = *rcv
+	}
+	return math.Abs(d.Percent) / scale / float64(1+len(d.Confounders))
+}
+
+func judge(a Association, best Delta, e Event, opts Options) (string, []string) {
+	caveats := []string{}
+	notable := best.ExceedsRCV
+	if a.RCVPercent == nil {
+		caveats = append(caveats, CaveatNoRCV)
+		notable = math.Abs(best.Percent) >= unjudgedChangePct
+	} else if !best.ExceedsRCV {
+		caveats = append(caveats, CaveatBelowRCV)
+	}
+	if len(best.Confounders) > 0 {
+		caveats = append(caveats, CaveatConfounded)
+	}
+	if e.Approximate {
+		caveats = append(caveats, CaveatApproximateDate)
+	}
+	if e.Date.Sub(a.BeforeDate).Hours()/24 > float64(opts.BaselineDays) {
+		caveats = append(caveats, CaveatOldBaseline)
+	}
+	if len(a.Deltas) == 1 {
+		caveats = append(caveats, CaveatSingleWindow)
+	} else {
+		for _, d := range a.Deltas {
+			if d.Change*best.Change < 0 {
+				caveats = append(caveats, CaveatInconsistentSign)
+				break
+			}
+		}
+	}
+
+	switch {
+	case !notable:
+		return Low, caveats
+	case best.ExceedsRCV && len(best.Confounders) == 0 && !e.Approximate && !contains(caveats, CaveatOldBaseline) && !contains(caveats, CaveatInconsistentSign):
+		return High, caveats
+	case len(best.Confounders) <= 2 && !contains(caveats, CaveatInconsistentSign):
+		return Medium, caveats
+	}
+	return Low, caveats
+}
+
+// confounders names the other supplements changed between two results or
+// recently enough before the change to still be acting
+func confounders(e Event, events []Event, from, to time.Time) []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, other := range events {
+		if other.SupplementID == e.SupplementID || other.Date.Before(from) || other.Date.After(to) {
+			continue
+		}
+		if !seen[other.Supplement] {
+			seen[other.Supplement] = true
+			names = append(names, other.Supplement)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func rank(confidence string) int {
+	switch confidence {
+	case High:
+		return 0
+	case Medium:
+		return 1
+	}
+	return 2
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+package correlate
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"health-ai-portal/pkg/trend"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func day(n int) time.Time { return start.AddDate(0, 0, n) }
+
+// opts give "Ferritin" an RCV of about 31% and leave "Zinc" without one
+var opts = Options{Variation: func(marker string) (trend.Variation, bool) {
+	if marker == "Ferritin" {
+		return trend.Variation{Biological: 10, Analytical: 5}, true
+	}
+	return trend.Variation{}, false
+}}
+
+func results(marker, unit string, days []int, values ...float64) []Result {
+	rs := make([]Result, len(values))
+	for i, v := range values {
+		rs[i] = Result{Marker: marker, Unit: unit, Date: day(days[i]), Value: v}
+	}
+	return rs
+}
+
+var iron = Event{SupplementID: 1, Supplement: "Iron", Type: "added", Date: day(100)}
+
+// One result before the change on day 100 and one in each lag window
+var windowDays = []int{90, 120, 160, 250}
+
+func find(t *testing.T, as []Association, supplement, marker string) Association {
+	t.Helper()
+	for _, a := range as {
+		if a.Supplement == supplement && a.Marker == marker {
+			return a
+		}
+	}
+	t.Fatalf("no association of %s with %s in %+v", supplement, marker, as)
+	return Association{}
+}
+
+func TestAssociate(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		events     []Event
+		results    []Result
+		marker     string
+		confidence string
+		caveats    []string
+		windows    int
+	}{
+		{
+			name:       "clear rise",
+			events:     []Event{iron},
+			results:    results("Ferritin", "ng/ml", windowDays, 100, 150, 145, 140),
+			marker:     "Ferritin",
+			confidence: High,
+			caveats:    []string{},
+			windows:    3,
+		},
+		{
+			name:       "another change in between",
+			events:     []Event{iron, {SupplementID: 2, Supplement: "Vitamin C", Type: "added", Date: day(110)}},
+			results:    results("Ferritin", "ng/ml", windowDays, 100, 150, 145, 140),
+			marker:     "Ferritin",
+			confidence: Medium,
+			caveats:    []string{CaveatConfounded},
+			windows:    3,
+		},
+		{
+			name:       "an earlier change still acting",
+			events:     []Event{{SupplementID: 2, Supplement: "Vitamin C", Type: "removed", Date: day(40)}, iron},
+			results:    results("Ferritin", "ng/ml", windowDays, 100, 150, 145, 140),
+			marker:     "Ferritin",
+			confidence: Medium,
+			caveats:    []string{CaveatConfounded},
+			windows:    3,
+		},
+		{
+			name:       "no RCV for the marker",
+			events:     []Event{iron},
+			results:    results("Zinc", "ug/dl", windowDays, 100, 150, 145, 140),
+			marker:     "Zinc",
+			confidence: Medium,
+			caveats:    []string{CaveatNoRCV},
+			windows:    3,
+		},
+		{
+			name:       "no RCV and a small change",
+			events:     []Event{iron},
+			results:    results("Zinc", "ug/dl", windowDays, 100, 110, 112, 108),
+			marker:     "Zinc",
+			confidence: Low,
+			caveats:    []string{CaveatNoRCV},
+			windows:    3,
+		},
+		{
+			name:       "within normal variation",
+			events:     []Event{iron},
+			results:    results("Ferritin", "ng/ml", windowDays, 100, 110, 112, 108),
+			marker:     "Ferritin",
+			confidence: Low,
+			caveats:    []string{CaveatBelowRCV},
+			windows:    3,
+		},
+		{
+			name:       "windows disagree on the sign",
+			events:     []Event{iron},
+			results:    results("Ferritin", "ng/ml", windowDays, 100, 150, 60, 140),
+			marker:     "Ferritin",
+			confidence: Low,
+			caveats:    []string{CaveatInconsistentSign},
+			windows:    3,
+		},
+		{
+			name:       "old baseline",
+			events:     []Event{iron},
+			results:    results("Ferritin", "ng/ml", []int{-100, 120, 160, 250}, 100, 150, 145, 140),
+			marker:     "Ferritin",
+			confidence: Medium,
+			caveats:    []string{CaveatOldBaseline},
+			windows:    3,
+		},
+		{
+			name:       "backfilled date",
+			events:     []Event{{SupplementID: 1, Supplement: "Iron", Type: "added", Date: day(100), Approximate: true}},
+			results:    results("Ferritin", "ng/ml", windowDays, 100, 150, 145, 140),
+			marker:     "Ferritin",
+			confidence: Medium,
+			caveats:    []string{CaveatApproximateDate},
+			windows:    3,
+		},
+		{
+			// Results in another unit are not compared with the baseline
+			name:       "unit mismatch",
+			events:     []Event{iron},
+			results:    append(results("Ferritin", "ng/ml", []int{90, 160}, 100, 150), results("Ferritin", "pmol/l", []int{120, 250}, 330, 310)...),
+			marker:     "Ferritin",
+			confidence: High,
+			caveats:    []string{CaveatSingleWindow},
+			windows:    1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a := find(t, Associate(tc.events, tc.results, opts), "Iron", tc.marker)
+			if a.Confidence != tc.confidence || !reflect.DeepEqual(a.Caveats, tc.caveats) || len(a.Deltas) != tc.windows {
+				t.Errorf("confidence %s, caveats %v, %d windows; want %s, %v, %d",
+					a.Confidence, a.Caveats, len(a.Deltas), tc.confidence, tc.caveats, tc.windows)
+			}
+		})
+	}
+}
+
+func TestAssociateDeltas(t *testing.T) {
+	as := Associate([]Event{iron}, results("Ferritin", "ng/ml", windowDays, 100, 150, 60, 140), opts)
+	if len(as) != 1 {
+		t.Fatalf("associations = %+v", as)
+	}
+	a := as[0]
+	if !a.BeforeDate.Equal(day(90)) || a.BeforeValue != 100 || a.RCVPercent == nil {
+		t.Errorf("baseline = %s %v, rcv %v", a.BeforeDate, a.BeforeValue, a.RCVPercent)
+	}
+
+	var windows []string
+	var lags []int
+	for _, d := range a.Deltas {
+		windows = append(windows, d.Window)
+		lags = append(lags, d.LagDays)
+	}
+	if !reflect.DeepEqual(windows, []string{"2-6w", "6-12w", "3-6m"}) || !reflect.DeepEqual(lags, []int{20, 60, 150}) {
+		t.Errorf("windows %v at %v days", windows, lags)
+	}
+	// +50% outweighs -40% and +40%; all exceed the RCV
+	if a.Best != 0 || !a.Deltas[0].ExceedsRCV || !a.Deltas[1].ExceedsRCV || !a.Deltas[2].ExceedsRCV {
+		t.Errorf("best %d, deltas %+v", a.Best, a.Deltas)
+	}
+	if want := 50 / *a.RCVPercent; math.Abs(a.Score-want) > 1e-9 {
+		t.Errorf("score = %v, want %v", a.Score, want)
+	}
+}
+
+func TestAssociateSkips(t *testing.T) {
+	for name, rs := range map[string][]Result{
+		"nothing before the change": results("Ferritin", "ng/ml", []int{120, 160}, 150, 145),
+		"nothing after the change":  results("Ferritin", "ng/ml", []int{50, 90}, 100, 105),
+		"after too soon":            results("Ferritin", "ng/ml", []int{90, 105}, 100, 150),
+		"zero baseline":             results("Ferritin", "ng/ml", []int{90, 120}, 0, 150),
+	} {
+		if as := Associate([]Event{iron}, rs, opts); len(as) != 0 {
+			t.Errorf("%s: associations = %+v", name, as)
+		}
+	}
+}
+
+func TestAssociateOrder(t *testing.T) {
+	rs := append(results("Ferritin", "ng/ml", windowDays, 100, 150, 145, 140), results("Zinc", "ug/dl", windowDays, 100, 105, 104, 103)...)
+	as := Associate([]Event{iron}, rs, opts)
+	if len(as) != 2 || as[0].Marker != "Ferritin" || as[0].Confidence != High || as[1].Confidence != Low {
+		t.Errorf("order = %+v", as)
+	}
+}
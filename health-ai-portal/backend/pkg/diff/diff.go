@@ -0,0 +1,292 @@
+// Package diff compares JSON documents field by field and text line by line.
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Kinds of field changes
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Change is one field that differs between two JSON documents. Path joins
+// object keys with dots; arrays are compared as whole values.
+type Change struct {
+	Path  string      `json:"path"`
+	Kind  string      `json:"kind"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+	Delta *float64    `json:"delta,omitempty"` // To - From for numbers
+}
+
+// JSON lists the fields that differ between a and b, ordered by path. Empty
+// input counts as an empty object; null and "" values count as missing.
+func JSON(a, b []byte) ([]Change, error) {
+	from, err := flatten(a)
+	if err != nil {
+		return nil, err
+	}
+	to, err := flatten(b)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(from)+len(to))
+	for p := range from {
+		paths = append(paths, p)
+	}
+	for p := range to {
+		if _, ok := from[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	changes := []Change{}
+	for _, p := range paths {
+		x, inFrom := from[p]
+		y, inTo := to[p]
+		switch {
+		case !inFrom:
+			changes = append(changes, Change{Path: p, Kind: Added, To: y})
+		case !inTo:
+			changes = append(changes, Change{Path: p, Kind: Removed, From: x})
+		case !reflect.DeepEqual(x, y):
+			c := Change{Path: p, Kind: Changed, From: x, To: y}
+			if fx, ok := x.(float64); ok {
+				if fy, ok := y.(float64); ok {
+					delta := fy - fx
+					c.Delta = &delta
+				}
+			}
+			changes = append(changes, c)
+		}
+	}
+	return changes, nil
+}
+
+func flatten(data []byte) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return fields, nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	walk("", v, fields)
+	return fields, nil
+}
+
+func walk(prefix string, v interface{}, fields map[string]interface{}) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, child := range value {
+			path := k
+			if prefix != "" {
+				path = prefix + "." + k
+			}
+			walk(path, child, fields)
+		}
+	case nil:
+	case string:
+		if value != "" && prefix != "" {
+			fields[prefix] = value
+		}
+	default:
+		if prefix != "" {
+			fields[prefix] = value
+		}
+	}
+}
+
+// Line operations
+const (
+	Equal  = ' '
+	Insert = '+'
+	Delete = '-'
+)
+
+// Line is one line of a line diff
+type Line struct {
+	Op   byte
+	Text string
+}
+
+// maxLCSCells bounds the LCS table; larger inputs are diffed as a whole
+// replacement
+const maxLCSCells = 4_000_000
+
+// Lines diffs two texts line by line (longest common subsequence)
+func Lines(a, b string) []Line {
+	x, y := splitLines(a), splitLines(b)
+
+	// Common prefix and suffix need no table
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+
+	var result []Line
+	for _, t := range x[:prefix] {
+		result = append(result, Line{Equal, t})
+	}
+	result = append(result, lcs(x[prefix:len(x)-suffix], y[prefix:len(y)-suffix])...)
+	for _, t := range x[len(x)-suffix:] {
+		result = append(result, Line{Equal, t})
+	}
+	return result
+}
+
+func lcs(x, y []string) []Line {
+	n, m := len(x), len(y)
+	var result []Line
+	if n*m > maxLCSCells {
+		for _, t := range x {
+			result = append(result, Line{Delete, t})
+		}
+		for _, t := range y {
+			result = append(result, Line{Insert, t})
+		}
+		return result
+	}
+
+	// table[i][j] is the LCS length of x[i:] and y[j:]
+	table := make([][]int, n+1)
+	for i := range table {
+		table[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else if table[i+1][j] >= table[i][j+1] {
+				table[i][j] = table[i+1][j]
+			} else {
+				table[i][j] = table[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case x[i] == y[j]:
+			result = append(result, Line{Equal, x[i]})
+			i++
+			j++
+		case table[i+1][j] >= table[i][j+1]:
+			result = append(result, Line{Delete, x[i]})
+			i++
+		default:
+			result = append(result, Line{Insert, y[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		result = append(result, Line{Delete, x[i]})
+	}
+	for ; j < m; j++ {
+		result = append(result, Line{Insert, y[j]})
+	}
+	return result
+}
+
+func splitLines(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// Stats counts inserted and deleted lines
+func Stats(lines []Line) (added, removed int) {
+	for _, l := range lines {
+		switch l.Op {
+		case Insert:
+			added++
+		case Delete:
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// Unified renders a line diff in unified format with context lines around
+// each change. Identical texts give "".
+func Unified(lines []Line, fromName, toName string, context int) string {
+	var changed []int
+	for i, l := range lines {
+		if l.Op != Equal {
+			changed = append(changed, i)
+		}
+	}
+	if len(changed) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+
+	for k := 0; k < len(changed); {
+		start := max(changed[k]-context, 0)
+		end := changed[k]
+		// Merge changes whose context overlaps into one hunk
+		for k < len(changed) && changed[k] <= end+2*context+1 {
+			end = changed[k]
+			k++
+		}
+		end = min(end+context, len(lines)-1)
+
+		// Line numbers before the hunk
+		fromLine, toLine := 1, 1
+		for _, l := range lines[:start] {
+			if l.Op != Insert {
+				fromLine++
+			}
+			if l.Op != Delete {
+				toLine++
+			}
+		}
+		fromCount, toCount := 0, 0
+		for _, l := range lines[start : end+1] {
+			if l.Op != Insert {
+				fromCount++
+			}
+			if l.Op != Delete {
+				toCount++
+			}
+		}
+
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(fromLine, fromCount), hunkRange(toLine, toCount))
+		for _, l := range lines[start : end+1] {
+			b.WriteByte(l.Op)
+			b.WriteString(l.Text)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+func hunkRange(line, count int) string {
+	if count == 0 {
+		line--
+	}
+	if count == 1 {
+		return fmt.Sprint(line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
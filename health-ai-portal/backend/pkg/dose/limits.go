@@ -0,0 +1,158 @@
+package dose
+
+import (
+	"strings"
+)
+
+// UpperLimit is a tolerable upper intake level (adult, per day)
+type UpperLimit struct {
+	Ingredient string  `json:"ingredient"`
+	Amount     float64 `json:"amount"`
+	Unit       string  `json:"unit"`
+	Note       string  `json:"note,omitempty"`
+}
+
+// Tolerable upper intake levels for adults (IOM/EFSA, supplemental intake)
+var upperLimits = map[string]UpperLimit{
+	"Vitamin D":   {Ingredient: "Vitamin D", Amount: 4000, Unit: "IU"},
+	"Vitamin A":   {Ingredient: "Vitamin A", Amount: 3000, Unit: "mcg", Note: "preformed retinol"},
+	"Vitamin E":   {Ingredient: "Vitamin E", Amount: 1000, Unit: "mg"},
+	"Vitamin C":   {Ingredient: "Vitamin C", Amount: 2000, Unit: "mg"},
+	"Vitamin B6":  {Ingredient: "Vitamin B6", Amount: 100, Unit: "mg"},
+	"Niacin":      {Ingredient: "Niacin", Amount: 35, Unit: "mg", Note: "nicotinic acid; NMN/NR not counted"},
+	"Folate":      {Ingredient: "Folate", Amount: 1000, Unit: "mcg"},
+	"Zinc":        {Ingredient: "Zinc", Amount: 40, Unit: "mg"},
+	"Selenium":    {Ingredient: "Selenium", Amount: 400, Unit: "mcg"},
+	"Iodine":      {Ingredient: "Iodine", Amount: 1100, Unit: "mcg"},
+	"Magnesium":   {Ingredient: "Magnesium", Amount: 350, Unit: "mg", Note: "supplemental, elemental"},
+	"Iron":        {Ingredient: "Iron", Amount: 45, Unit: "mg"},
+	"Copper":      {Ingredient: "Copper", Amount: 10, Unit: "mg"},
+	"Calcium":     {Ingredient: "Calcium", Amount: 2500, Unit: "mg"},
+	"Boron":       {Ingredient: "Boron", Amount: 20, Unit: "mg"},
+	"Manganese":   {Ingredient: "Manganese", Amount: 11, Unit: "mg"},
+	"Molybdenum":  {Ingredient: "Molybdenum", Amount: 2000, Unit: "mcg"},
+	"Choline":     {Ingredient: "Choline", Amount: 3500, Unit: "mg"},
+	"Fluoride":    {Ingredient: "Fluoride", Amount: 10, Unit: "mg"},
+	"Phosphorus":  {Ingredient: "Phosphorus", Amount: 4000, Unit: "mg"},
+	"Chromium":    {Ingredient: "Chromium", Amount: 1000, Unit: "mcg", Note: "EFSA guidance"},
+	"Vitamin K":   {Ingredient: "Vitamin K", Amount: 0, Unit: "mcg", Note: "no UL established"},
+	"Vitamin B12": {Ingredient: "Vitamin B12", Amount: 0, Unit: "mcg", Note: "no UL established"},
+}
+
+// Ingredient mappings (Russian and brand spellings to normalized)
+var ingredientMappings = map[string]string{
+	"витамин d":      "Vitamin D",
+	"витамин d3":     "Vitamin D",
+	"vitamin d3":     "Vitamin D",
+	"d3":             "Vitamin D",
+	"витамин a":      "Vitamin A",
+	"витамин e":      "Vitamin E",
+	"витамин c":      "Vitamin C",
+	"витамин b6":     "Vitamin B6",
+	"p5p":            "Vitamin B6",
+	"пиридоксин":     "Vitamin B6",
+	"ниацин":         "Niacin",
+	"vitamin b3":     "Niacin",
+	"vitamin b9":     "Folate",
+	"фолиевая":       "Folate",
+	"метилфолат":     "Folate",
+	"цинк":           "Zinc",
+	"селен":          "Selenium",
+	"йод":            "Iodine",
+	"магний":         "Magnesium",
+	"железо":         "Iron",
+	"медь":           "Copper",
+	"кальций":        "Calcium",
+	"бор":            "Boron",
+	"марганец":       "Manganese",
+	"хром":           "Chromium",
+	"витамин k2":     "Vitamin K",
+	"витамин k":      "Vitamin K",
+	"витамин b12":    "Vitamin B12",
+	"b12":            "Vitamin B12",
+	"метилкобаламин": "Vitamin B12",
+}
+
+// NormalizeIngredient maps a supplement name to a canonical ingredient key.
+// Unknown names are returned unchanged.
+func NormalizeIngredient(name string) string {
+	lower := strings.ToLower(strings.TrimSpace(name))
+	if n, ok := ingredientMappings[lower]; ok {
+		return n
+	}
+	for key, limit := range upperLimits {
+		if strings.ToLower(key) == lower {
+			return limit.Ingredient
+		}
+	}
+	for alias, n := range ingredientMappings {
+		if strings.HasPrefix(lower, alias+" ") {
+			return n
+		}
+	}
+	return strings.TrimSpace(name)
+}
+
+// LookupLimit returns the upper intake level for a canonical ingredient
+func LookupLimit(ingredient string) (UpperLimit, bool) {
+	limit, ok := upperLimits[ingredient]
+	if !ok || limit.Amount == 0 {
+		return limit, false
+	}
+	return limit, true
+}
+
+// Convert converts an amount between mass units and, for vitamin D, between
+// IU and mcg. ok is false when the units are not convertible.
+func Convert(amount float64, from, to, ingredient string) (float64, bool) {
+	if from == to {
+		return amount, true
+	}
+
+	toMcg := map[string]float64{"g": 1e6, "mg": 1e3, "mcg": 1}
+	if f, ok := toMcg[from]; ok {
+		if t, ok := toMcg[to]; ok {
+			return amount * f / t, true
+		}
+	}
+
+	// Vitamin D: 1 mcg = 40 IU
+	if ingredient == "Vitamin D" {
+		switch {
+		case from == "IU" && toMcg[to] > 0:
+			return amount / 40 / toMcg[to], true
+		case toMcg[from] > 0 && to == "IU":
+			return amount * toMcg[from] * 40, true
+		}
+	}
+
+	return 0, false
+}
+
+// DailyAmount returns the amount taken on a dosing day
+func (d Dose) DailyAmount() *float64 {
+	if d.Amount == nil {
+		return nil
+	}
+	times := d.TimesPerDay
+	if times < 1 {
+		times = 1
+	}
+	v := *d.Amount * float64(times)
+	return &v
+}
+
+// DaysPerWeek is how many days a week a frequency doses on. An as-needed
+// dose counts as a dosing day: on the day it is taken it is all there.
+func DaysPerWeek(frequency string, timesPerWeek *int) float64 {
+	switch frequency {
+	case FrequencyEveryOtherDay:
+		return 3.5
+	case FrequencyWeekly:
+		if timesPerWeek != nil && *timesPerWeek > 0 && *timesPerWeek <= 7 {
+			return float64(*timesPerWeek)
+		}
+		return 1
+	}
+	return 7
+}
@@ -0,0 +1,226 @@
+package dose
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Frequencies understood by the structured dosing model
+const (
+	FrequencyDaily         = "daily"
+	FrequencyEveryOtherDay = "every_other_day"
+	FrequencyWeekly        = "weekly"
+	FrequencyAsNeeded      = "as_needed"
+)
+
+// Dose is the structured form of a free-text dose like "500-1000 мг" or
+// "250 мкг п/к 2×/неделю".
+type Dose struct {
+	Amount       *float64 `json:"amount"`
+	AmountMin    *float64 `json:"amount_min"`
+	Unit         string   `json:"unit"`
+	Form         string   `json:"form"`
+	Frequency    string   `json:"frequency"`
+	TimesPerDay  int      `json:"times_per_day"`
+	TimesPerWeek *int     `json:"times_per_week"`
+	WithFood     string   `json:"with_food"`
+}
+
+// Unit mappings (Russian and common spellings to normalized)
+var unitMappings = map[string]string{
+	"мг":    "mg",
+	"mg":    "mg",
+	"мкг":   "mcg",
+	"mcg":   "mcg",
+	"µg":    "mcg",
+	"ug":    "mcg",
+	"г":     "g",
+	"g":     "g",
+	"гр":    "g",
+	"ме":    "IU",
+	"iu":    "IU",
+	"ед":    "IU",
+	"мл":    "ml",
+	"ml":    "ml",
+	"капс":  "caps",
+	"caps":  "caps",
+	"cap":   "caps",
+	"таб":   "tab",
+	"tab":   "tab",
+	"tabs":  "tab",
+	"кап":   "drops",
+	"капли": "drops",
+	"drops": "drops",
+	"spu":   "SPU",
+	"fu":    "FU",
+
+	// Whole words, as in "1 капсула" or "2 tablets"
+	"капсула":  "caps",
+	"капсулы":  "caps",
+	"капсул":   "caps",
+	"capsule":  "caps",
+	"capsules": "caps",
+	"таблетка": "tab",
+	"таблетки": "tab",
+	"таблеток": "tab",
+	"tablet":   "tab",
+	"tablets":  "tab",
+}
+
+// unitForms is the form a count unit implies when the text names no form
+var unitForms = map[string]string{
+	"caps":  "capsule",
+	"tab":   "tablet",
+	"drops": "drops",
+}
+
+// Form mappings (route/form hints found in dose text), checked in order
+var formMappings = []struct{ key, form string }{
+	{"п/к", "injection_sc"},
+	{"sc", "injection_sc"},
+	{"в/м", "injection_im"},
+	{"im", "injection_im"},
+	{"сублинг", "sublingual"},
+	{"sublingual", "sublingual"},
+	{"капс", "capsule"},
+	{"caps", "capsule"},
+	{"таб", "tablet"},
+	{"tab", "tablet"},
+	{"спрей", "spray"},
+	{"spray", "spray"},
+	{"капли", "drops"},
+	{"drops", "drops"},
+	{"порошок", "powder"},
+	{"powder", "powder"},
+}
+
+var (
+	thousandsPattern = regexp.MustCompile(`(\d)[,\x{00a0} ](\d{3})(\D|$)`)
+	amountPattern    = regexp.MustCompile(`(\d+(?:[.,]\d+)?)(?:\s*[-–]\s*(\d+(?:[.,]\d+)?))?\s*([a-zA-Zа-яА-ЯёЁµ]+)?`)
+	perDayPattern    = regexp.MustCompile(`(?i)(\d+)\s*(?:×|x|х|раза?)\s*(?:/|в)?\s*(?:день|сутки|day|d)`)
+	perWeekPattern   = regexp.MustCompile(`(?i)(\d+)\s*(?:×|x|х|раза?)\s*(?:/|в)?\s*(?:неделю|нед|week|wk)`)
+	withFoodPattern  = regexp.MustCompile(`(?i)(с едой|во время еды|with food|with meal)`)
+	emptyFoodPattern = regexp.MustCompile(`(?i)(натощак|без еды|empty stomach|without food)`)
+)
+
+// Parse converts free-text dose (and optional schedule text such as the
+// legacy time_of_day value) into a structured Dose. ok is false when no
+// amount could be recognised.
+func Parse(text string, schedule string) (d Dose, ok bool) {
+	d = Dose{Frequency: FrequencyDaily, TimesPerDay: 1}
+	lower := strings.ToLower(strings.TrimSpace(text))
+	combined := lower + " " + strings.ToLower(schedule)
+
+	if m := amountPattern.FindStringSubmatch(stripThousands(lower)); m != nil {
+		if v, err := parseFloat(m[1]); err == nil {
+			d.Amount = &v
+			ok = true
+		}
+		if m[2] != "" {
+			if v, err := parseFloat(m[2]); err == nil {
+				min := *d.Amount
+				d.AmountMin = &min
+				d.Amount = &v
+			}
+		}
+		if m[3] != "" {
+			d.Unit = NormalizeUnit(m[3])
+		}
+	}
+
+	for _, m := range formMappings {
+		if containsWord(lower, m.key) {
+			d.Form = m.form
+			break
+		}
+	}
+	if d.Form == "" {
+		d.Form = unitForms[d.Unit]
+	}
+
+	if m := perDayPattern.FindStringSubmatch(combined); m != nil {
+		if n, err := strconv.Atoi(m[1]); err == nil && n > 0 {
+			d.TimesPerDay = n
+		}
+	}
+
+	switch {
+	case strings.Contains(combined, "через день") || strings.Contains(combined, "every other day") || containsWord(combined, "eod"):
+		d.Frequency = FrequencyEveryOtherDay
+	case perWeekPattern.MatchString(combined):
+		n, _ := strconv.Atoi(perWeekPattern.FindStringSubmatch(combined)[1])
+		d.Frequency = FrequencyWeekly
+		d.TimesPerWeek = &n
+	case isWeekday(combined) || strings.Contains(combined, "weekly") || strings.Contains(combined, "в неделю"):
+		one := 1
+		d.Frequency = FrequencyWeekly
+		d.TimesPerWeek = &one
+	case strings.Contains(combined, "по требованию") || strings.Contains(combined, "по необходимости") ||
+		strings.Contains(combined, "as needed") || containsWord(combined, "prn"):
+		d.Frequency = FrequencyAsNeeded
+	}
+
+	switch {
+	case withFoodPattern.MatchString(combined):
+		d.WithFood = "with"
+	case emptyFoodPattern.MatchString(combined):
+		d.WithFood = "without"
+	}
+
+	return d, ok
+}
+
+// NormalizeUnit maps a unit spelling to its canonical form ("мг" → "mg").
+func NormalizeUnit(unit string) string {
+	u := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(unit), "."))
+	if n, ok := unitMappings[u]; ok {
+		return n
+	}
+	return unit
+}
+
+var weekdays = []string{
+	"понедельник", "вторник", "среда", "четверг", "пятница", "суббота", "воскресенье",
+	"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday",
+}
+
+func isWeekday(s string) bool {
+	for _, d := range weekdays {
+		if strings.Contains(s, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsWord reports whether key appears in s as a standalone token
+func containsWord(s, key string) bool {
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool {
+		return r == ' ' || r == ',' || r == '(' || r == ')' || r == '.' || r == ';'
+	}) {
+		if f == key {
+			return true
+		}
+	}
+	return false
+}
+
+// stripThousands drops thousands separators ("5,000 IU", "10 000 ме") so
+// the comma is not read as a decimal point. A separator needs exactly three
+// digits after it, which leaves "2,5 мг" alone.
+func stripThousands(s string) string {
+	for {
+		next := thousandsPattern.ReplaceAllString(s, "$1$2$3")
+		if next == s {
+			return s
+		}
+		s = next
+	}
+}
+
+// parseFloat handles both comma and dot as decimal separators
+func parseFloat(s string) (float64, error) {
+	s = strings.Replace(s, ",", ".", -1)
+	return strconv.ParseFloat(strings.TrimSpace(s), 64)
+}
@@ -0,0 +1,54 @@
+package dose
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	type want struct {
+		amount, min  float64 // 0 when unset
+		unit, form   string
+		frequency    string
+		timesPerDay  int
+		timesPerWeek int // 0 when unset
+		withFood     string
+	}
+	for _, tc := range []struct {
+		text     string
+		schedule string
+		ok       bool
+		want     want
+	}{
+		{"5000 IU", "", true, want{amount: 5000, unit: "IU", frequency: FrequencyDaily, timesPerDay: 1}},
+		{"5,000 IU", "", true, want{amount: 5000, unit: "IU", frequency: FrequencyDaily, timesPerDay: 1}},
+		{"10 000 ме", "", true, want{amount: 10000, unit: "IU", frequency: FrequencyDaily, timesPerDay: 1}},
+		{"2,5 мг", "", true, want{amount: 2.5, unit: "mg", frequency: FrequencyDaily, timesPerDay: 1}},
+		{"2 caps", "", true, want{amount: 2, unit: "caps", form: "capsule", frequency: FrequencyDaily, timesPerDay: 1}},
+		{"1-2 tabs", "", true, want{amount: 2, min: 1, unit: "tab", form: "tablet", frequency: FrequencyDaily, timesPerDay: 1}},
+		{"200–400 мг", "", true, want{amount: 400, min: 200, unit: "mg", frequency: FrequencyDaily, timesPerDay: 1}},
+		{"1 капсула 2 раза в день", "", true, want{amount: 1, unit: "caps", form: "capsule", frequency: FrequencyDaily, timesPerDay: 2}},
+		{"10 мг через день", "", true, want{amount: 10, unit: "mg", frequency: FrequencyEveryOtherDay, timesPerDay: 1}},
+		{"2 капс 3 раза в неделю", "", true, want{amount: 2, unit: "caps", form: "capsule", frequency: FrequencyWeekly, timesPerDay: 1, timesPerWeek: 3}},
+		{"1 tab", "понедельник", true, want{amount: 1, unit: "tab", form: "tablet", frequency: FrequencyWeekly, timesPerDay: 1, timesPerWeek: 1}},
+		{"по требованию 1 таб", "", true, want{amount: 1, unit: "tab", form: "tablet", frequency: FrequencyAsNeeded, timesPerDay: 1}},
+		{"100 мкг п/к", "", true, want{amount: 100, unit: "mcg", form: "injection_sc", frequency: FrequencyDaily, timesPerDay: 1}},
+		{"50 мкг с едой", "", true, want{amount: 50, unit: "mcg", frequency: FrequencyDaily, timesPerDay: 1, withFood: "with"}},
+		{"500mg", "утром натощак", true, want{amount: 500, unit: "mg", frequency: FrequencyDaily, timesPerDay: 1, withFood: "without"}},
+		{"перед сном", "", false, want{frequency: FrequencyDaily, timesPerDay: 1}},
+		{"as needed", "", false, want{frequency: FrequencyAsNeeded, timesPerDay: 1}},
+		{"", "", false, want{frequency: FrequencyDaily, timesPerDay: 1}},
+	} {
+		d, ok := Parse(tc.text, tc.schedule)
+		got := want{unit: d.Unit, form: d.Form, frequency: d.Frequency, timesPerDay: d.TimesPerDay, withFood: d.WithFood}
+		if d.Amount != nil {
+			got.amount = *d.Amount
+		}
+		if d.AmountMin != nil {
+			got.min = *d.AmountMin
+		}
+		if d.TimesPerWeek != nil {
+			got.timesPerWeek = *d.TimesPerWeek
+		}
+		if ok != tc.ok || got != tc.want {
+			t.Errorf("Parse(%q, %q) = %+v, %v; want %+v, %v", tc.text, tc.schedule, got, ok, tc.want, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package fhir
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// molarMass in g/mol of markers reported both by mass and by amount of
+// substance. Lipid fractions are reported as cholesterol.
+var molarMass = map[string]float64{
+	"Testosterone Total": 288.42,
+	"Testosterone Free":  288.42,
+	"Estradiol":          272.38,
+	"Cortisol":           362.46,
+	"DHEA-S":             368.49,
+	"fT3":                650.97,
+	"fT4":                776.87,
+	"Glucose":            180.16,
+	"Cholesterol Total":  386.65,
+	"LDL":                386.65,
+	"HDL":                386.65,
+	"Triglycerides":      885.70,
+	"Bilirubin Total":    584.66,
+	"Creatinine":         113.12,
+	"Urea":               60.06,
+	"Uric Acid":          168.11,
+	"Iron":               55.845,
+	"Vitamin D":          400.64,
+	"Vitamin B12":        1355.37,
+	"Folate":             441.40,
+	"Homocysteine":       135.18,
+}
+
+var unitPrefixes = map[string]float64{
+	"": 1, "k": 1e3, "d": 1e-1, "c": 1e-2, "m": 1e-3, "u": 1e-6, "n": 1e-9, "p": 1e-12, "f": 1e-15,
+}
+
+// unitAtoms are the base units conversion understands, longest first
+var unitAtoms = []string{"[IU]", "mol", "g", "L", "U"}
+
+// atom splits a UCUM unit atom into its base unit and scale
+func atom(s string) (base string, scale float64) {
+	if strings.HasPrefix(s, "10*") {
+		if exp, err := strconv.Atoi(s[3:]); err == nil {
+			return "10*", math.Pow(10, float64(exp))
+		}
+	}
+	for _, base := range unitAtoms {
+		if prefix, ok := strings.CutSuffix(s, base); ok {
+			if scale, ok := unitPrefixes[prefix]; ok {
+				return base, scale
+			}
+		}
+	}
+	return s, 1
+}
+
+// Convert converts a value between two UCUM units: within the same kind
+// ("ng/dL" to "ng/mL") or, for markers with a known molar mass, between mass
+// and amount of substance ("ng/dL" to "nmol/L"). False when not convertible.
+func Convert(marker string, value float64, from, to string) (float64, bool) {
+	if from == to {
+		return value, true
+	}
+	fromNum, fromDen, ok1 := strings.Cut(from, "/")
+	toNum, toDen, ok2 := strings.Cut(to, "/")
+	if !ok1 || !ok2 {
+		return 0, false
+	}
+
+	fn, fnScale := atom(fromNum)
+	fd, fdScale := atom(fromDen)
+	tn, tnScale := atom(toNum)
+	td, tdScale := atom(toDen)
+	if fd != td {
+		return 0, false
+	}
+
+	// Value in base units of the source, per base unit of volume
+	base := value * fnScale / fdScale
+	switch {
+	case fn == tn:
+	case fn == "g" && tn == "mol" && molarMass[marker] > 0:
+		base /= molarMass[marker]
+	case fn == "mol" && tn == "g" && molarMass[marker] > 0:
+		base *= molarMass[marker]
+	default:
+		return 0, false
+	}
+
+	// Six significant digits hide floating point noise without losing what
+	// a lab reports
+	converted, _ := strconv.ParseFloat(strconv.FormatFloat(base*tdScale/tnScale, 'g', 6, 64), 64)
+	return converted, true
+}
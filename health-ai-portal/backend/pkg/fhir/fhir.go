@@ -0,0 +1,176 @@
+// Package fhir holds the subset of FHIR R4 needed to exchange lab results:
+// Bundle, Patient and Observation, plus LOINC and UCUM lookups for the
+// marker names and units the app stores.
+package fhir
+
+import "encoding/json"
+
+// Code systems
+const (
+	SystemLOINC          = "http://loinc.org"
+	SystemUCUM           = "http://unitsofmeasure.org"
+	SystemInterpretation = "http://terminology.hl7.org/CodeSystem/v3-ObservationInterpretation"
+	SystemCategory       = "http://terminology.hl7.org/CodeSystem/observation-category"
+)
+
+// ContentType is the FHIR JSON media type
+const ContentType = "application/fhir+json"
+
+type Coding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+type CodeableConcept struct {
+	Coding []Coding `json:"coding,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+type Quantity struct {
+	Value      *float64 `json:"value,omitempty"`
+	Comparator string   `json:"comparator,omitempty"` // <, <=, >=, >
+	Unit       string   `json:"unit,omitempty"`
+	System     string   `json:"system,omitempty"`
+	Code       string   `json:"code,omitempty"`
+}
+
+type Reference struct {
+	Reference string `json:"reference,omitempty"`
+	Display   string `json:"display,omitempty"`
+}
+
+type Identifier struct {
+	System string `json:"system,omitempty"`
+	Value  string `json:"value"`
+}
+
+type HumanName struct {
+	Text string `json:"text"`
+}
+
+type ReferenceRange struct {
+	Low  *Quantity `json:"low,omitempty"`
+	High *Quantity `json:"high,omitempty"`
+	Text string    `json:"text,omitempty"`
+}
+
+type Period struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+type Annotation struct {
+	Text string `json:"text"`
+}
+
+type Patient struct {
+	ResourceType string      `json:"resourceType"` // "Patient"
+	ID           string      `json:"id"`
+	Name         []HumanName `json:"name,omitempty"`
+	BirthDate    string      `json:"birthDate,omitempty"`
+}
+
+type Observation struct {
+	ResourceType      string            `json:"resourceType"` // "Observation"
+	ID                string            `json:"id,omitempty"`
+	Identifier        []Identifier      `json:"identifier,omitempty"`
+	Status            string            `json:"status"`
+	Category          []CodeableConcept `json:"category,omitempty"`
+	Code              CodeableConcept   `json:"code"`
+	Subject           *Reference        `json:"subject,omitempty"`
+	EffectiveDateTime string            `json:"effectiveDateTime,omitempty"`
+	EffectivePeriod   *Period           `json:"effectivePeriod,omitempty"`
+	Issued            string            `json:"issued,omitempty"`
+	Performer         []Reference       `json:"performer,omitempty"`
+	ValueQuantity     *Quantity         `json:"valueQuantity,omitempty"`
+	ValueString       string            `json:"valueString,omitempty"`
+	DataAbsentReason  *CodeableConcept  `json:"dataAbsentReason,omitempty"`
+	Interpretation    []CodeableConcept `json:"interpretation,omitempty"`
+	Note              []Annotation      `json:"note,omitempty"`
+	ReferenceRange    []ReferenceRange  `json:"referenceRange,omitempty"`
+}
+
+// BundleEntry holds any resource; Resource is kept raw so readers can look
+// at resourceType first
+type BundleEntry struct {
+	FullURL  string          `json:"fullUrl,omitempty"`
+	Resource json.RawMessage `json:"resource"`
+	Search   *BundleSearch   `json:"search,omitempty"`
+}
+
+type BundleSearch struct {
+	Mode string `json:"mode"` // match or include
+}
+
+type BundleLink struct {
+	Relation string `json:"relation"`
+	URL      string `json:"url"`
+}
+
+type Bundle struct {
+	ResourceType string        `json:"resourceType"` // "Bundle"
+	Type         string        `json:"type"`
+	Timestamp    string        `json:"timestamp,omitempty"`
+	Total        *int          `json:"total,omitempty"`
+	Link         []BundleLink  `json:"link,omitempty"`
+	Entry        []BundleEntry `json:"entry"`
+}
+
+// Add appends a resource to the bundle
+func (b *Bundle) Add(fullURL string, resource interface{}, mode string) error {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return err
+	}
+	entry := BundleEntry{FullURL: fullURL, Resource: data}
+	if mode != "" {
+		entry.Search = &BundleSearch{Mode: mode}
+	}
+	b.Entry = append(b.Entry, entry)
+	return nil
+}
+
+// Interpretation flags a value against its reference range: L, N or H.
+// Without any bound there is nothing to compare against.
+func Interpretation(value float64, min, max *float64) *CodeableConcept {
+	if min == nil && max == nil {
+		return nil
+	}
+	code, display := "N", "Normal"
+	if min != nil && value < *min {
+		code, display = "L", "Low"
+	} else if max != nil && value > *max {
+		code, display = "H", "High"
+	}
+	return &CodeableConcept{
+		Coding: []Coding{{System: SystemInterpretation, Code: code, Display: display}},
+		Text:   display,
+	}
+}
+
+// LaboratoryCategory is the observation category of lab results
+var LaboratoryCategory = CodeableConcept{
+	Coding: []Coding{{System: SystemCategory, Code: "laboratory", Display: "Laboratory"}},
+}
+
+type OperationOutcomeIssue struct {
+	Severity    string `json:"severity"`
+	Code        string `json:"code"`
+	Diagnostics string `json:"diagnostics,omitempty"`
+}
+
+// OperationOutcome reports errors the FHIR way
+type OperationOutcome struct {
+	ResourceType string                  `json:"resourceType"` // "OperationOutcome"
+	Issue        []OperationOutcomeIssue `json:"issue"`
+}
+
+// NewOperationOutcome is a single error issue; code is a FHIR issue type
+// such as "invalid" or "not-found"
+func NewOperationOutcome(code, diagnostics string) OperationOutcome {
+	return OperationOutcome{
+		ResourceType: "OperationOutcome",
+		Issue:        []OperationOutcomeIssue{{Severity: "error", Code: code, Diagnostics: diagnostics}},
+	}
+}
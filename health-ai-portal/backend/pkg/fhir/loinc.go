@@ -0,0 +1,208 @@
+package fhir
+
+import "strings"
+
+// loincCode is one LOINC term for a marker
+type loincCode struct {
+	code, display string
+}
+
+// loincCodes maps the canonical marker names (see pdf.NormalizeMarkerName)
+// to their LOINC terms. LOINC codes differ by what is measured, so each
+// marker lists a term per unit property; a result whose unit has no term
+// here is exported without a LOINC coding rather than with a wrong one.
+var loincCodes = map[string]map[Property]loincCode{
+	"Testosterone Total": {
+		PropertyMass:      {"2986-8", "Testosterone [Mass/volume] in Serum or Plasma"},
+		PropertySubstance: {"14913-8", "Testosterone [Moles/volume] in Serum or Plasma"},
+	},
+	"Testosterone Free": {
+		PropertyMass:      {"2991-8", "Testosterone Free [Mass/volume] in Serum or Plasma"},
+		PropertySubstance: {"14914-6", "Testosterone Free [Moles/volume] in Serum or Plasma"},
+	},
+	"Estradiol": {
+		PropertyMass:      {"2243-4", "Estradiol (E2) [Mass/volume] in Serum or Plasma"},
+		PropertySubstance: {"14715-7", "Estradiol (E2) [Moles/volume] in Serum or Plasma"},
+	},
+	"Prolactin": {
+		PropertyMass: {"2842-3", "Prolactin [Mass/volume] in Serum or Plasma"},
+	},
+	"TSH": {
+		PropertyArbitrary: {"3016-3", "Thyrotropin [Units/volume] in Serum or Plasma"},
+	},
+	"fT3": {
+		PropertyMass:      {"3051-0", "Triiodothyronine (T3) Free [Mass/volume] in Serum or Plasma"},
+		PropertySubstance: {"14928-6", "Triiodothyronine (T3) Free [Moles/volume] in Serum or Plasma"},
+	},
+	"fT4": {
+		PropertyMass:      {"3024-7", "Thyroxine (T4) free [Mass/volume] in Serum or Plasma"},
+		PropertySubstance: {"14920-3", "Thyroxine (T4) free [Moles/volume] in Serum or Plasma"},
+	},
+	"LH": {
+		PropertyArbitrary: {"10501-5", "Lutropin [Units/volume] in Serum or Plasma"},
+	},
+	"FSH": {
+		PropertyArbitrary: {"15067-2", "Follitropin [Units/volume] in Serum or Plasma"},
+	},
+	"DHEA-S": {
+		PropertyMass:      {"2191-5", "Dehydroepiandrosterone sulfate [Mass/volume] in Serum or Plasma"},
+		PropertySubstance: {"14687-8", "Dehydroepiandrosterone sulfate [Moles/volume] in Serum or Plasma"},
+	},
+	"Cortisol": {
+		PropertyMass:      {"2143-6", "Cortisol [Mass/volume] in Serum or Plasma"},
+		PropertySubstance: {"14675-3", "Cortisol [Moles/volume] in Serum or Plasma"},
+	},
+	"ACTH": {
+		PropertyMass: {"2141-0", "Corticotropin [Mass/volume] in Plasma"},
+	},
+	"Insulin": {
+		PropertyArbitrary: {"20448-7", "Insulin [Units/volume] in Serum or Plasma"},
+	},
+	"Glucose": {
+		PropertyMass:      {"2345-7", "Glucose [Mass/volume] in Serum or Plasma"},
+		PropertySubstance: {"14749-6", "Glucose [Moles/volume] in Serum or Plasma"},
+	},
+	"HbA1c": {
+		PropertyFraction: {"4548-4", "Hemoglobin A1c/Hemoglobin.total in Blood"},
+		PropertyRatio:    {"59261-8", "Hemoglobin A1c/Hemoglobin.total in Blood by IFCC protocol"},
+	},
+	"Cholesterol Total": {
+		PropertyMass:      {"2093-3", "Cholesterol [Mass/volume] in Serum or Plasma"},
+		PropertySubstance: {"14647-2", "Cholesterol [Moles/volume] in Serum or Plasma"},
+	},
+	"LDL": {
+		PropertyMass:      {"2089-1", "Cholesterol in LDL [Mass/volume] in Serum or Plasma"},
+		PropertySubstance: {"22748-8", "Cholesterol in LDL [Moles/volume] in Serum or Plasma"},
+	},
+	"HDL": {
+		PropertyMass:      {"2085-9", "Cholesterol in HDL [Mass/volume] in Serum or Plasma"},
+		PropertySubstance: {"14646-4", "Cholesterol in HDL [Moles/volume] in Serum or Plasma"},
+	},
+	"Triglycerides": {
+		PropertyMass:      {"2571-8", "Triglyceride [Mass/volume] in Serum or Plasma"},
+		PropertySubstance: {"14927-8", "Triglyceride [Moles/volume] in Serum or Plasma"},
+	},
+	"ALT": {
+		PropertyArbitrary: {"1742-6", "Alanine aminotransferase [Enzymatic activity/volume] in Serum or Plasma"},
+	},
+	"AST": {
+		PropertyArbitrary: {"1920-8", "Aspartate aminotransferase [Enzymatic activity/volume] in Serum or Plasma"},
+	},
+	"GGT": {
+		PropertyArbitrary: {"2324-2", "Gamma glutamyl transferase [Enzymatic activity/volume] in Serum or Plasma"},
+	},
+	"Bilirubin Total": {
+		PropertyMass:      {"1975-2", "Bilirubin.total [Mass/volume] in Serum or Plasma"},
+		PropertySubstance: {"14631-6", "Bilirubin.total [Moles/volume] in Serum or Plasma"},
+	},
+	"Creatinine": {
+		PropertyMass:      {"2160-0", "Creatinine [Mass/volume] in Serum or Plasma"},
+		PropertySubstance: {"14682-9", "Creatinine [Moles/volume] in Serum or Plasma"},
+	},
+	"Urea": {
+		PropertyMass:      {"3091-6", "Urea [Mass/volume] in Serum or Plasma"},
+		PropertySubstance: {"22664-7", "Urea [Moles/volume] in Serum or Plasma"},
+	},
+	"Uric Acid": {
+		PropertyMass:      {"3084-1", "Urate [Mass/volume] in Serum or Plasma"},
+		PropertySubstance: {"14933-6", "Urate [Moles/volume] in Serum or Plasma"},
+	},
+	"Ferritin": {
+		PropertyMass: {"2276-4", "Ferritin [Mass/volume] in Serum or Plasma"},
+	},
+	"Iron": {
+		PropertyMass:      {"2498-4", "Iron [Mass/volume] in Serum or Plasma"},
+		PropertySubstance: {"14798-3", "Iron [Moles/volume] in Serum or Plasma"},
+	},
+	"Vitamin D": {
+		PropertyMass:      {"1989-3", "25-Hydroxyvitamin D3+25-Hydroxyvitamin D2 [Mass/volume] in Serum or Plasma"},
+		PropertySubstance: {"62292-8", "25-Hydroxyvitamin D2+25-Hydroxyvitamin D3 [Moles/volume] in Serum or Plasma"},
+	},
+	"Vitamin B12": {
+		PropertyMass:      {"2132-9", "Cobalamin (Vitamin B12) [Mass/volume] in Serum or Plasma"},
+		PropertySubstance: {"14685-2", "Cobalamin (Vitamin B12) [Moles/volume] in Serum or Plasma"},
+	},
+	"Folate": {
+		PropertyMass:      {"2284-8", "Folate [Mass/volume] in Serum or Plasma"},
+		PropertySubstance: {"14732-2", "Folate [Moles/volume] in Serum or Plasma"},
+	},
+	"Hemoglobin": {
+		PropertyMass: {"718-7", "Hemoglobin [Mass/volume] in Blood"},
+	},
+	"Hematocrit": {
+		PropertyFraction: {"4544-3", "Hematocrit [Volume Fraction] of Blood by Automated count"},
+	},
+	"RBC": {
+		PropertyCount: {"789-8", "Erythrocytes [#/volume] in Blood by Automated count"},
+	},
+	"WBC": {
+		PropertyCount: {"6690-2", "Leukocytes [#/volume] in Blood by Automated count"},
+	},
+	"Platelets": {
+		PropertyCount: {"777-3", "Platelets [#/volume] in Blood by Automated count"},
+	},
+	"ESR": {
+		PropertyRate: {"30341-2", "Erythrocyte sedimentation rate"},
+	},
+	"CRP": {
+		PropertyMass: {"1988-5", "C reactive protein [Mass/volume] in Serum or Plasma"},
+	},
+	"IGF-1": {
+		PropertyMass: {"2484-4", "Insulin-like growth factor-I [Mass/volume] in Serum or Plasma"},
+	},
+	"SHBG": {
+		PropertySubstance: {"13967-5", "Sex hormone binding globulin [Moles/volume] in Serum or Plasma"},
+	},
+	"Homocysteine": {
+		PropertySubstance: {"13965-9", "Homocysteine [Moles/volume] in Serum or Plasma"},
+	},
+	"PSA": {
+		PropertyMass: {"2857-1", "Prostate specific Ag [Mass/volume] in Serum or Plasma"},
+	},
+}
+
+// LOINC returns the LOINC coding of a marker measured in the given UCUM
+// unit. Without a unit the marker's only term is used when it has one.
+func LOINC(marker, ucum string) (Coding, bool) {
+	terms, ok := loincCodes[marker]
+	if !ok {
+		for name, t := range loincCodes {
+			if strings.EqualFold(name, marker) {
+				terms, ok = t, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return Coding{}, false
+	}
+
+	term, ok := terms[PropertyOf(ucum)]
+	if !ok && ucum == "" && len(terms) == 1 {
+		for _, t := range terms {
+			term, ok = t, true
+		}
+	}
+	if !ok {
+		return Coding{}, false
+	}
+	return Coding{System: SystemLOINC, Code: term.code, Display: term.display}, true
+}
+
+// Marker is a LOINC term resolved back to the marker and unit property
+type Marker struct {
+	Name     string
+	Property Property
+}
+
+// MarkerForLOINC finds the canonical marker a LOINC code stands for
+func MarkerForLOINC(code string) (Marker, bool) {
+	for name, terms := range loincCodes {
+		for property, term := range terms {
+			if term.code == code {
+				return Marker{Name: name, Property: property}, true
+			}
+		}
+	}
+	return Marker{}, false
+}
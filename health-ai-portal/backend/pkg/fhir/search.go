@@ -0,0 +1,87 @@
+package fhir
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DateParam is a parsed FHIR date search parameter such as "ge2024-01" or
+// "2024-03-15". A value covers its whole precision: "2024-01" is all of
+// January.
+type DateParam struct {
+	Prefix string    // eq, ne, lt, le, gt, ge
+	Start  time.Time // first instant the value covers
+	End    time.Time // first instant after it
+}
+
+var datePrefixes = []string{"eq", "ne", "lt", "le", "gt", "ge"}
+
+// ParseDateParam parses a date search value with an optional prefix
+func ParseDateParam(value string) (DateParam, error) {
+	p := DateParam{Prefix: "eq"}
+	for _, prefix := range datePrefixes {
+		if strings.HasPrefix(value, prefix) {
+			p.Prefix, value = prefix, value[len(prefix):]
+			break
+		}
+	}
+
+	for _, layout := range []struct {
+		format string
+		next   func(time.Time) time.Time
+	}{
+		{"2006-01-02", func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }},
+		{"2006-01", func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }},
+		{"2006", func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }},
+	} {
+		if t, err := time.Parse(layout.format, value); err == nil {
+			p.Start, p.End = t, layout.next(t)
+			return p, nil
+		}
+	}
+	return p, fmt.Errorf("invalid date %q: use [prefix]YYYY, YYYY-MM or YYYY-MM-DD", value)
+}
+
+// Matches reports whether a date satisfies the parameter
+func (p DateParam) Matches(t time.Time) bool {
+	in := !t.Before(p.Start) && t.Before(p.End)
+	switch p.Prefix {
+	case "ne":
+		return !in
+	case "lt":
+		return t.Before(p.Start)
+	case "le":
+		return t.Before(p.End)
+	case "gt":
+		return !t.Before(p.End)
+	case "ge":
+		return !t.Before(p.Start)
+	}
+	return in
+}
+
+// Token is a parsed token search value "system|code", "|code" or "code";
+// an empty System matches any system
+type Token struct {
+	System string
+	Code   string
+}
+
+// ParseTokens splits a comma-separated token search value; the values are
+// alternatives
+func ParseTokens(value string) []Token {
+	var tokens []Token
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if system, code, ok := strings.Cut(part, "|"); ok {
+			tokens = append(tokens, Token{System: system, Code: code})
+		} else {
+			tokens = append(tokens, Token{Code: part})
+		}
+	}
+	return tokens
+}
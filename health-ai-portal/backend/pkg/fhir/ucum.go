@@ -0,0 +1,107 @@
+package fhir
+
+import "strings"
+
+// ucumUnits are the unit atoms seen in lab reports, Russian and Latin, with
+// their UCUM code
+var ucumUnits = map[string]string{
+	// amount of substance
+	"моль": "mol", "ммоль": "mmol", "мкмоль": "umol", "нмоль": "nmol", "пмоль": "pmol",
+	"mol": "mol", "mmol": "mmol", "umol": "umol", "nmol": "nmol", "pmol": "pmol",
+	// mass
+	"г": "g", "мг": "mg", "мкг": "ug", "нг": "ng", "пг": "pg",
+	"g": "g", "mg": "mg", "ug": "ug", "mcg": "ug", "ng": "ng", "pg": "pg",
+	// volume
+	"л": "L", "дл": "dL", "мл": "mL", "мкл": "uL", "фл": "fL",
+	"l": "L", "dl": "dL", "ml": "mL", "ul": "uL", "fl": "fL",
+	// arbitrary units
+	"ме": "[IU]", "мме": "m[IU]", "мкме": "u[IU]",
+	"iu": "[IU]", "miu": "m[IU]", "uiu": "u[IU]",
+	"[iu]": "[IU]", "m[iu]": "m[IU]", "u[iu]": "u[IU]",
+	"ед": "U", "мед": "mU", "мкед": "uU",
+	"u": "U", "mu": "mU", "uu": "uU",
+	// time and length
+	"ч": "h", "h": "h", "hr": "h", "мм": "mm", "mm": "mm",
+}
+
+// ucumCounts are cell count multipliers
+var ucumCounts = map[string]string{
+	"10^9": "10*9", "10*9": "10*9", "10е9": "10*9",
+	"10^12": "10*12", "10*12": "10*12", "10е12": "10*12",
+	"10^6": "10*6", "10*6": "10*6", "10^3": "10*3", "10*3": "10*3",
+	"тыс": "10*3", "млн": "10*6",
+}
+
+// UCUM translates a unit as written on a lab report ("нмоль/л", "×10^9/л",
+// "µIU/mL") to its UCUM code. False when a part is not recognised.
+func UCUM(unit string) (string, bool) {
+	s := strings.ToLower(strings.Join(strings.Fields(unit), ""))
+	if s == "" {
+		return "", false
+	}
+	if s == "%" {
+		return "%", true
+	}
+	s = strings.NewReplacer("µ", "u", "μ", "u", "×", "", "x", "", "·", "").Replace(s)
+
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 {
+		return "", false
+	}
+	num, ok := ucumNumerator(parts[0])
+	if !ok {
+		return "", false
+	}
+	den, ok := ucumUnits[parts[1]]
+	if !ok {
+		return "", false
+	}
+	return num + "/" + den, true
+}
+
+func ucumNumerator(s string) (string, bool) {
+	if code, ok := ucumCounts[s]; ok {
+		return code, true
+	}
+	code, ok := ucumUnits[s]
+	return code, ok
+}
+
+// Property is what a UCUM unit measures, as LOINC distinguishes codes
+type Property string
+
+const (
+	PropertyMass      Property = "mass"      // g/L, ng/mL
+	PropertySubstance Property = "substance" // mmol/L
+	PropertyArbitrary Property = "arbitrary" // [IU]/L, U/L
+	PropertyCount     Property = "count"     // 10*9/L
+	PropertyFraction  Property = "fraction"  // %
+	PropertyRatio     Property = "ratio"     // mmol/mol
+	PropertyRate      Property = "rate"      // mm/h
+)
+
+// PropertyOf classifies a UCUM code; "" when it is not one UCUM produces
+func PropertyOf(code string) Property {
+	if code == "%" {
+		return PropertyFraction
+	}
+	num, den, ok := strings.Cut(code, "/")
+	if !ok {
+		return ""
+	}
+	switch {
+	case strings.HasSuffix(num, "mol") && strings.HasSuffix(den, "mol"):
+		return PropertyRatio
+	case strings.HasSuffix(num, "mol"):
+		return PropertySubstance
+	case strings.HasSuffix(num, "g"):
+		return PropertyMass
+	case strings.Contains(num, "[IU]") || strings.HasSuffix(num, "U"):
+		return PropertyArbitrary
+	case strings.HasPrefix(num, "10*"):
+		return PropertyCount
+	case num == "mm" && den == "h":
+		return PropertyRate
+	}
+	return ""
+}
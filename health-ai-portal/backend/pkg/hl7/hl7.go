@@ -0,0 +1,296 @@
+// Package hl7 parses HL7 v2 messages, enough to read lab results from
+// ORU^R01 observation reports.
+package hl7
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Segment is one line of a message. Fields are numbered as in the standard:
+// for MSH, field 1 is the field separator itself.
+type Segment struct {
+	Name   string
+	fields []string
+	enc    encoding
+}
+
+// Message is a parsed HL7 v2 message
+type Message struct {
+	Segments []Segment
+}
+
+type encoding struct {
+	field, component, repetition, escape, subcomponent byte
+}
+
+// Parse splits a message into segments. Segments may end with CR, LF or
+// CRLF; the encoding characters are read from MSH.
+func Parse(data string) (*Message, error) {
+	data = strings.TrimLeft(data, "\ufeff \t\r\n")
+	if !strings.HasPrefix(data, "MSH") || len(data) < 8 {
+		return nil, fmt.Errorf("message must start with an MSH segment")
+	}
+
+	enc := encoding{field: data[3], component: '^', repetition: '~', escape: '\\', subcomponent: '&'}
+	chars := data[4:]
+	if i := strings.IndexByte(chars, enc.field); i >= 0 {
+		chars = chars[:i]
+	}
+	for i, c := range []*byte{&enc.component, &enc.repetition, &enc.escape, &enc.subcomponent} {
+		if i < len(chars) {
+			*c = chars[i]
+		}
+	}
+
+	m := &Message{}
+	lines := strings.FieldsFunc(data, func(r rune) bool { return r == '\r' || r == '\n' })
+	for _, line := range lines {
+		if len(line) < 3 {
+			continue
+		}
+		fields := strings.Split(line, string(enc.field))
+		seg := Segment{Name: fields[0], enc: enc}
+		if seg.Name == "MSH" {
+			// MSH-1 is the separator and MSH-2 the encoding characters
+			seg.fields = append([]string{"MSH", string(enc.field)}, fields[1:]...)
+		} else {
+			seg.fields = fields
+		}
+		m.Segments = append(m.Segments, seg)
+	}
+	return m, nil
+}
+
+// Field returns the first component of field n (1-based), escapes decoded.
+// Only the first repetition is read.
+func (s Segment) Field(n int) string {
+	return s.Component(n, 1)
+}
+
+// Component returns component c (1-based) of the first repetition of field n
+func (s Segment) Component(n, c int) string {
+	if n <= 0 || n >= len(s.fields) {
+		return ""
+	}
+	field := s.fields[n]
+	if s.Name == "MSH" && n <= 2 {
+		return field
+	}
+	if i := strings.IndexByte(field, s.enc.repetition); i >= 0 {
+		field = field[:i]
+	}
+	components := strings.Split(field, string(s.enc.component))
+	if c <= 0 || c > len(components) {
+		return ""
+	}
+	value := components[c-1]
+	if i := strings.IndexByte(value, s.enc.subcomponent); i >= 0 {
+		value = value[:i]
+	}
+	return s.enc.unescape(value)
+}
+
+func (e encoding) unescape(s string) string {
+	if strings.IndexByte(s, e.escape) < 0 {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != e.escape {
+			b.WriteByte(s[i])
+			continue
+		}
+		end := strings.IndexByte(s[i+1:], e.escape)
+		if end < 0 {
+			b.WriteString(s[i:])
+			break
+		}
+		switch seq := s[i+1 : i+1+end]; seq {
+		case "F":
+			b.WriteByte(e.field)
+		case "S":
+			b.WriteByte(e.component)
+		case "T":
+			b.WriteByte(e.subcomponent)
+		case "R":
+			b.WriteByte(e.repetition)
+		case "E":
+			b.WriteByte(e.escape)
+		case ".br":
+			b.WriteByte('\n')
+		}
+		i += end + 1
+	}
+	return b.String()
+}
+
+// ParseBatch parses one or more messages, each starting with MSH. Batch
+// and file header and trailer segments are ignored.
+func ParseBatch(data string) ([]*Message, error) {
+	var messages []*Message
+	var current []string
+	flush := func() error {
+		if len(current) == 0 {
+			return nil
+		}
+		m, err := Parse(strings.Join(current, "\r"))
+		if err != nil {
+			return err
+		}
+		messages = append(messages, m)
+		current = nil
+		return nil
+	}
+
+	lines := strings.FieldsFunc(data, func(r rune) bool { return r == '\r' || r == '\n' })
+	for _, line := range lines {
+		line = strings.TrimLeft(line, "\ufeff \t")
+		switch {
+		case strings.HasPrefix(line, "FHS"), strings.HasPrefix(line, "BHS"),
+			strings.HasPrefix(line, "BTS"), strings.HasPrefix(line, "FTS"):
+			continue
+		case strings.HasPrefix(line, "MSH"):
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		case len(current) == 0:
+			return nil, fmt.Errorf("message must start with an MSH segment")
+		}
+		current = append(current, line)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("no HL7 messages found")
+	}
+	return messages, nil
+}
+
+// First returns the first segment with the name
+func (m *Message) First(name string) (Segment, bool) {
+	for _, s := range m.Segments {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Segment{}, false
+}
+
+// Type is the message type from MSH-9, such as "ORU^R01"
+func (m *Message) Type() string {
+	msh, _ := m.First("MSH")
+	t := msh.Component(9, 1)
+	if trigger := msh.Component(9, 2); trigger != "" {
+		t += "^" + trigger
+	}
+	return t
+}
+
+// ParseTime reads an HL7 timestamp, YYYY[MM[DD[HHMM[SS[.S]]]]][+/-ZZZZ]
+func ParseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	loc := time.UTC
+	if i := strings.IndexAny(s, "+-"); i >= 0 {
+		offset := s[i:]
+		s = s[:i]
+		if len(offset) == 5 {
+			h, err1 := strconv.Atoi(offset[1:3])
+			m, err2 := strconv.Atoi(offset[3:5])
+			if err1 == nil && err2 == nil {
+				seconds := h*3600 + m*60
+				if offset[0] == '-' {
+					seconds = -seconds
+				}
+				loc = time.FixedZone(offset, seconds)
+			}
+		}
+	}
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		s = s[:i]
+	}
+
+	layouts := map[int]string{4: "2006", 6: "200601", 8: "20060102", 10: "2006010215", 12: "200601021504", 14: "20060102150405"}
+	layout, ok := layouts[len(s)]
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid HL7 timestamp %q", s)
+	}
+	return time.ParseInLocation(layout, s, loc)
+}
+
+// CodedElement is a CE/CWE value: identifier, text and coding system, with
+// the alternate coding
+type CodedElement struct {
+	Code, Text, System          string
+	AltCode, AltText, AltSystem string
+}
+
+func (s Segment) coded(n int) CodedElement {
+	return CodedElement{
+		Code: s.Component(n, 1), Text: s.Component(n, 2), System: s.Component(n, 3),
+		AltCode: s.Component(n, 4), AltText: s.Component(n, 5), AltSystem: s.Component(n, 6),
+	}
+}
+
+// Observation is one OBX result of an observation report
+type Observation struct {
+	ValueType      string       // OBX-2: NM, SN, ST, ...
+	Identifier     CodedElement // OBX-3
+	Value          string       // OBX-5; for SN the comparator and number joined
+	Units          CodedElement // OBX-6
+	ReferenceRange string       // OBX-7
+	Flags          string       // OBX-8: L, H, N, ...
+	Status         string       // OBX-11: F final, C corrected, P preliminary, X not obtained
+	Observed       time.Time    // OBX-14, else OBR-7, else MSH-7
+	Facility       string       // sending facility from MSH-4
+}
+
+// Observations returns the OBX results of an ORU message. Results without
+// their own time take the time of the OBR they belong to, or of the message.
+func (m *Message) Observations() ([]Observation, error) {
+	if t := m.Type(); t != "ORU^R01" && t != "ORU" {
+		return nil, fmt.Errorf("expected an ORU^R01 message, got %q", t)
+	}
+
+	msh, _ := m.First("MSH")
+	facility := msh.Component(4, 1)
+
+	sent, _ := ParseTime(msh.Field(7))
+
+	var result []Observation
+	requested := sent
+	for _, s := range m.Segments {
+		switch s.Name {
+		case "OBR":
+			if t, err := ParseTime(s.Field(7)); err == nil {
+				requested = t
+			} else {
+				requested = sent
+			}
+		case "OBX":
+			obs := Observation{
+				ValueType:      s.Field(2),
+				Identifier:     s.coded(3),
+				Value:          s.Field(5),
+				Units:          s.coded(6),
+				ReferenceRange: s.Field(7),
+				Flags:          s.Field(8),
+				Status:         s.Field(11),
+				Observed:       requested,
+				Facility:       facility,
+			}
+			if obs.ValueType == "SN" {
+				// Structured numeric: comparator^number
+				obs.Value = s.Component(5, 1) + s.Component(5, 2)
+			}
+			if t, err := ParseTime(s.Field(14)); err == nil {
+				obs.Observed = t
+			}
+			result = append(result, obs)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,287 @@
+// Package ical writes RFC 5545 iCalendar feeds: events with recurrence,
+// exception dates and display alarms, plus the VTIMEZONE they refer to.
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	dateLayout     = "20060102"
+	localLayout    = "20060102T150405"
+	utcLayout      = "20060102T150405Z"
+	maxLineOctets  = 75
+	timezoneYears  = 10  // VTIMEZONE observances are listed this far ahead
+	maxObservances = 200 // guards against zones with unusually many transitions
+)
+
+// Calendar is a VCALENDAR. Timed events are written in Location (with a
+// generated VTIMEZONE); nil or UTC writes them as UTC times.
+type Calendar struct {
+	ProdID          string
+	Name            string
+	Location        *time.Location
+	RefreshInterval time.Duration // how often subscribers should refetch
+	Events          []Event
+}
+
+// Event is a VEVENT. All-day events only use the date of Start.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Categories  []string
+	Start       time.Time
+	AllDay      bool
+	Duration    time.Duration
+	RRule       string // RRULE value without the "RRULE:" prefix
+	ExDates     []time.Time
+	Alarms      []Alarm
+	Stamp       time.Time
+}
+
+// Alarm is a display VALARM firing Before the event start (negative: after)
+type Alarm struct {
+	Before      time.Duration
+	Description string
+}
+
+// WriteTo renders the calendar with CRLF line endings and folded lines
+func (c *Calendar) WriteTo(w io.Writer) (int64, error) {
+	var b lineWriter
+	b.line("BEGIN", "VCALENDAR")
+	b.line("VERSION", "2.0")
+	b.line("PRODID", c.ProdID)
+	b.line("CALSCALE", "GREGORIAN")
+	b.line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		b.line("X-WR-CALNAME", escapeText(c.Name))
+	}
+	if c.RefreshInterval > 0 {
+		b.line("REFRESH-INTERVAL;VALUE=DURATION", formatDuration(c.RefreshInterval))
+		b.line("X-PUBLISHED-TTL", formatDuration(c.RefreshInterval))
+	}
+
+	loc := c.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	if loc != time.UTC {
+		b.line("X-WR-TIMEZONE", loc.String())
+		if from, ok := c.earliestTimed(); ok {
+			writeTimezone(&b, loc, from, time.Now().AddDate(timezoneYears, 0, 0))
+		}
+	}
+
+	for _, e := range c.Events {
+		writeEvent(&b, e, loc)
+	}
+	b.line("END", "VCALENDAR")
+
+	return b.buf.WriteTo(w)
+}
+
+func (c *Calendar) earliestTimed() (time.Time, bool) {
+	var from time.Time
+	found := false
+	for _, e := range c.Events {
+		if !e.AllDay && (!found || e.Start.Before(from)) {
+			from, found = e.Start, true
+		}
+	}
+	return from, found
+}
+
+func writeEvent(b *lineWriter, e Event, loc *time.Location) {
+	b.line("BEGIN", "VEVENT")
+	b.line("UID", e.UID)
+	stamp := e.Stamp
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	b.line("DTSTAMP", stamp.UTC().Format(utcLayout))
+
+	if e.AllDay {
+		b.line("DTSTART;VALUE=DATE", e.Start.Format(dateLayout))
+	} else {
+		name, value := timeProperty("DTSTART", e.Start, loc)
+		b.line(name, value)
+	}
+	if e.Duration > 0 {
+		b.line("DURATION", formatDuration(e.Duration))
+	}
+	if e.RRule != "" {
+		b.line("RRULE", e.RRule)
+	}
+	if len(e.ExDates) > 0 {
+		values := make([]string, len(e.ExDates))
+		name := "EXDATE;VALUE=DATE"
+		for i, d := range e.ExDates {
+			if e.AllDay {
+				values[i] = d.Format(dateLayout)
+			} else {
+				name, values[i] = timeProperty("EXDATE", d, loc)
+			}
+		}
+		b.line(name, strings.Join(values, ","))
+	}
+
+	b.line("SUMMARY", escapeText(e.Summary))
+	if e.Description != "" {
+		b.line("DESCRIPTION", escapeText(e.Description))
+	}
+	if len(e.Categories) > 0 {
+		escaped := make([]string, len(e.Categories))
+		for i, c := range e.Categories {
+			escaped[i] = escapeText(c)
+		}
+		b.line("CATEGORIES", strings.Join(escaped, ","))
+	}
+	b.line("TRANSP", "TRANSPARENT")
+
+	for _, a := range e.Alarms {
+		description := a.Description
+		if description == "" {
+			description = e.Summary
+		}
+		b.line("BEGIN", "VALARM")
+		b.line("ACTION", "DISPLAY")
+		b.line("DESCRIPTION", escapeText(description))
+		b.line("TRIGGER", formatDuration(-a.Before))
+		b.line("END", "VALARM")
+	}
+	b.line("END", "VEVENT")
+}
+
+// timeProperty returns the property name with its TZID parameter and the
+// value for a date-time in loc
+func timeProperty(name string, t time.Time, loc *time.Location) (string, string) {
+	if loc == time.UTC {
+		return name, t.UTC().Format(utcLayout)
+	}
+	return name + ";TZID=" + loc.String(), t.In(loc).Format(localLayout)
+}
+
+// writeTimezone lists every offset change of loc between from and to as
+// STANDARD/DAYLIGHT observances, taken from the Go zone database
+func writeTimezone(b *lineWriter, loc *time.Location, from, to time.Time) {
+	b.line("BEGIN", "VTIMEZONE")
+	b.line("TZID", loc.String())
+
+	t := from.In(loc)
+	for i := 0; i < maxObservances; i++ {
+		name, offset := t.Zone()
+		start, end := t.ZoneBounds()
+
+		component := "STANDARD"
+		if t.IsDST() {
+			component = "DAYLIGHT"
+		}
+		b.line("BEGIN", component)
+		if start.IsZero() {
+			b.line("DTSTART", "19700101T000000")
+			b.line("TZOFFSETFROM", formatOffset(offset))
+		} else {
+			// DTSTART is the local time just before the change
+			_, prev := start.Add(-time.Second).In(loc).Zone()
+			b.line("DTSTART", start.UTC().Add(time.Duration(prev)*time.Second).Format(localLayout))
+			b.line("TZOFFSETFROM", formatOffset(prev))
+		}
+		b.line("TZOFFSETTO", formatOffset(offset))
+		b.line("TZNAME", escapeText(name))
+		b.line("END", component)
+
+		if end.IsZero() || end.After(to) {
+			break
+		}
+		t = end.In(loc)
+	}
+
+	b.line("END", "VTIMEZONE")
+}
+
+// formatOffset renders a UTC offset in seconds as +HHMM (or +HHMMSS)
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+	s := fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds/60%60)
+	if seconds%60 != 0 {
+		s += fmt.Sprintf("%02d", seconds%60)
+	}
+	return s
+}
+
+// formatDuration renders d as an RFC 5545 duration, e.g. PT15M, -P1DT2H
+func formatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+	d -= minutes * time.Minute
+	seconds := d / time.Second
+
+	s := sign + "P"
+	if days > 0 {
+		s += fmt.Sprintf("%dD", days)
+	}
+	if hours > 0 || minutes > 0 || seconds > 0 || days == 0 {
+		s += "T"
+		if hours > 0 {
+			s += fmt.Sprintf("%dH", hours)
+		}
+		if minutes > 0 {
+			s += fmt.Sprintf("%dM", minutes)
+		}
+		if seconds > 0 || (hours == 0 && minutes == 0) {
+			s += fmt.Sprintf("%dS", seconds)
+		}
+	}
+	return s
+}
+
+// escapeText escapes a TEXT value
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, ";", "\\;")
+	s = strings.ReplaceAll(s, ",", "\\,")
+	s = strings.ReplaceAll(s, "\r\n", "\\n")
+	s = strings.ReplaceAll(s, "\n", "\\n")
+	return s
+}
+
+// lineWriter emits content lines folded at 75 octets without splitting
+// UTF-8 sequences
+type lineWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *lineWriter) line(name, value string) {
+	line := name + ":" + value
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.buf.WriteString(line[:cut])
+		w.buf.WriteString("\r\n ")
+		line = line[cut:]
+		limit = maxLineOctets - 1 // the leading space counts
+	}
+	w.buf.WriteString(line)
+	w.buf.WriteString("\r\n")
+}
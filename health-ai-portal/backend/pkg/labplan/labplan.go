@@ -0,0 +1,278 @@
+// Package labplan decides which lab markers are due: those a cycle asked for
+// and those whose retest interval has run out since they were last measured.
+// Everything due is grouped into a single blood draw.
+package labplan
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Priorities, most urgent first
+const (
+	PriorityHigh   = "high"
+	PriorityMedium = "medium"
+	PriorityLow    = "low"
+)
+
+// Reasons a marker is due
+const (
+	ReasonCycle    = "cycle"
+	ReasonInterval = "interval"
+)
+
+// longOverdueDays makes an interval-based marker high priority
+const longOverdueDays = 30
+
+// Interval is how often a marker should be retested
+type Interval struct {
+	Marker  string `json:"marker_name"`
+	Days    int    `json:"interval_days"` // 0 disables retesting
+	Fasting bool   `json:"fasting"`
+	Note    string `json:"note,omitempty"`
+}
+
+// DefaultIntervals are used for markers the user has not configured
+var DefaultIntervals = []Interval{
+	{"Hematocrit", 56, false, "каждые 8 недель на ГЗТ"},
+	{"Hemoglobin", 56, false, "каждые 8 недель на ГЗТ"},
+	{"Testosterone Total", 56, false, "каждые 8 недель на ГЗТ"},
+	{"Estradiol", 56, false, "каждые 8 недель на ГЗТ"},
+	{"Cholesterol Total", 90, true, "липиды раз в 3 месяца"},
+	{"LDL", 90, true, "липиды раз в 3 месяца"},
+	{"HDL", 90, true, "липиды раз в 3 месяца"},
+	{"Triglycerides", 90, true, "липиды раз в 3 месяца"},
+	{"Glucose", 90, true, ""},
+	{"Insulin", 90, true, ""},
+	{"HbA1c", 90, false, ""},
+	{"ALT", 90, false, "печень раз в 3 месяца"},
+	{"AST", 90, false, "печень раз в 3 месяца"},
+	{"GGT", 90, false, "печень раз в 3 месяца"},
+	{"Creatinine", 90, false, ""},
+	{"Ferritin", 90, false, ""},
+	{"Vitamin D", 90, false, ""},
+	{"TSH", 90, false, ""},
+	{"fT3", 90, false, ""},
+	{"fT4", 90, false, ""},
+	{"Homocysteine", 180, true, ""},
+	{"PSA", 180, false, ""},
+}
+
+// fastingMarkers need an overnight fast even without an interval
+var fastingMarkers = map[string]bool{
+	"glucose":           true,
+	"insulin":           true,
+	"cholesterol total": true,
+	"ldl":               true,
+	"hdl":               true,
+	"triglycerides":     true,
+	"iron":              true,
+	"homocysteine":      true,
+	"leptin":            true,
+	"cortisol":          true,
+}
+
+// RequiresFasting reports whether a marker is drawn on an empty stomach
+func RequiresFasting(marker string) bool {
+	return fastingMarkers[Key(marker)]
+}
+
+// Key is the case-insensitive identity of a marker name
+func Key(marker string) string {
+	return strings.ToLower(strings.TrimSpace(marker))
+}
+
+// Required is a marker a cycle asked to be measured
+type Required struct {
+	Marker    string
+	Label     string // as written in the cycle
+	CycleID   int
+	CycleDate time.Time  // measurements on or after it satisfy the request
+	DueBy     *time.Time // usually shortly before the cycle's review
+}
+
+// Item is one marker on the due list
+type Item struct {
+	Marker       string     `json:"marker_name"`
+	Label        string     `json:"label,omitempty"`
+	Reasons      []string   `json:"reasons"`
+	CycleID      *int       `json:"cycle_id,omitempty"`
+	LastMeasured *time.Time `json:"last_measured"`
+	IntervalDays int        `json:"interval_days,omitempty"`
+	DueDate      time.Time  `json:"due_date"`
+	DaysOverdue  int        `json:"days_overdue"` // negative: due in that many days
+	Priority     string     `json:"priority"`
+	Fasting      bool       `json:"fasting"`
+	Note         string     `json:"note,omitempty"`
+}
+
+// Draw is the single lab visit covering every due marker
+type Draw struct {
+	Date    time.Time `json:"date"`
+	Markers []string  `json:"markers"`
+	Fasting bool      `json:"fasting"`
+}
+
+// Plan is the prioritised due list and the draw that covers it
+type Plan struct {
+	Items []Item `json:"items"`
+	Draw  *Draw  `json:"draw"`
+}
+
+// Build lists markers due within horizonDays of today. Interval-based markers
+// are only considered once they have been measured; cycle requests count
+// until a result dated on or after the cycle exists. last maps Key(marker) to
+// the latest test date.
+func Build(today time.Time, required []Required, intervals []Interval, last map[string]time.Time, horizonDays int) Plan {
+	today = civil(today)
+	horizon := today.AddDate(0, 0, horizonDays)
+
+	items := make(map[string]*Item)
+	var order []string
+	add := func(key string, item Item) {
+		if existing, ok := items[key]; ok {
+			existing.Reasons = append(existing.Reasons, item.Reasons...)
+			if item.DueDate.Before(existing.DueDate) {
+				existing.DueDate = item.DueDate
+			}
+			if existing.CycleID == nil {
+				existing.CycleID = item.CycleID
+				existing.Label = item.Label
+			}
+			if existing.IntervalDays == 0 {
+				existing.IntervalDays = item.IntervalDays
+				existing.Note = item.Note
+			}
+			existing.Fasting = existing.Fasting || item.Fasting
+			return
+		}
+		items[key] = &item
+		order = append(order, key)
+	}
+
+	for _, iv := range intervals {
+		key := Key(iv.Marker)
+		measured, ok := last[key]
+		if iv.Days <= 0 || !ok {
+			continue
+		}
+		measured = civil(measured)
+		due := measured.AddDate(0, 0, iv.Days)
+		if due.After(horizon) {
+			continue
+		}
+		add(key, Item{
+			Marker:       iv.Marker,
+			Reasons:      []string{ReasonInterval},
+			LastMeasured: &measured,
+			IntervalDays: iv.Days,
+			DueDate:      due,
+			Fasting:      iv.Fasting || RequiresFasting(iv.Marker),
+			Note:         iv.Note,
+		})
+	}
+
+	for _, req := range required {
+		key := Key(req.Marker)
+		var lastMeasured *time.Time
+		if measured, ok := last[key]; ok {
+			measured = civil(measured)
+			if !measured.Before(civil(req.CycleDate)) {
+				continue
+			}
+			lastMeasured = &measured
+		}
+		due := today
+		if req.DueBy != nil && civil(*req.DueBy).After(today) {
+			due = civil(*req.DueBy)
+		}
+		cycleID := req.CycleID
+		add(key, Item{
+			Marker:       req.Marker,
+			Label:        req.Label,
+			Reasons:      []string{ReasonCycle},
+			CycleID:      &cycleID,
+			LastMeasured: lastMeasured,
+			DueDate:      due,
+			Fasting:      RequiresFasting(req.Marker),
+		})
+	}
+
+	plan := Plan{Items: make([]Item, 0, len(order))}
+	for _, key := range order {
+		item := items[key]
+		item.DaysOverdue = int(today.Sub(item.DueDate).Hours() / 24)
+		item.Priority = priority(*item)
+		plan.Items = append(plan.Items, *item)
+	}
+
+	sort.SliceStable(plan.Items, func(i, j int) bool {
+		a, b := plan.Items[i], plan.Items[j]
+		if rank(a.Priority) != rank(b.Priority) {
+			return rank(a.Priority) < rank(b.Priority)
+		}
+		if !a.DueDate.Equal(b.DueDate) {
+			return a.DueDate.Before(b.DueDate)
+		}
+		return a.Marker < b.Marker
+	})
+
+	if len(plan.Items) > 0 {
+		plan.Draw = group(plan.Items, today)
+	}
+	return plan
+}
+
+// priority: cycle requests and long-overdue markers are high, anything
+// already due is medium, markers coming due within the horizon are low
+func priority(item Item) string {
+	fromCycle := false
+	for _, r := range item.Reasons {
+		if r == ReasonCycle {
+			fromCycle = true
+		}
+	}
+	switch {
+	case item.DaysOverdue >= 0 && (fromCycle || item.DaysOverdue >= longOverdueDays):
+		return PriorityHigh
+	case item.DaysOverdue >= 0 || fromCycle:
+		return PriorityMedium
+	default:
+		return PriorityLow
+	}
+}
+
+func rank(priority string) int {
+	switch priority {
+	case PriorityHigh:
+		return 0
+	case PriorityMedium:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// group puts every due marker into one draw on the earliest due date (today
+// if anything is overdue), so nothing is late and the arm is pricked once
+func group(items []Item, today time.Time) *Draw {
+	draw := &Draw{Date: items[0].DueDate}
+	for _, item := range items {
+		if item.DueDate.Before(draw.Date) {
+			draw.Date = item.DueDate
+		}
+		draw.Markers = append(draw.Markers, item.Marker)
+		draw.Fasting = draw.Fasting || item.Fasting
+	}
+	if draw.Date.Before(today) {
+		draw.Date = today
+	}
+	return draw
+}
+
+// civil drops the clock and zone, keeping the calendar date
+func civil(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,256 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// HTML renders src as an HTML fragment; headings carry id anchors
+func HTML(src string) string {
+	var b strings.Builder
+	renderBlocks(&b, document(src))
+	return b.String()
+}
+
+func renderBlocks(b *strings.Builder, blocks []block) {
+	for _, bl := range blocks {
+		renderBlock(b, bl)
+	}
+}
+
+func renderBlock(b *strings.Builder, bl block) {
+	switch bl.kind {
+	case paragraph:
+		b.WriteString("<p>")
+		b.WriteString(inline(bl.text))
+		b.WriteString("</p>\n")
+	case heading:
+		tag := "h" + string(rune('0'+bl.level))
+		b.WriteString("<" + tag + ` id="` + html.EscapeString(bl.id) + `">`)
+		b.WriteString(inline(bl.text))
+		b.WriteString("</" + tag + ">\n")
+	case rule:
+		b.WriteString("<hr>\n")
+	case code:
+		b.WriteString("<pre><code")
+		if bl.start != "" {
+			b.WriteString(` class="language-` + html.EscapeString(bl.start) + `"`)
+		}
+		b.WriteString(">")
+		b.WriteString(html.EscapeString(bl.text))
+		b.WriteString("</code></pre>\n")
+	case quote:
+		b.WriteString("<blockquote>\n")
+		renderBlocks(b, bl.children)
+		b.WriteString("</blockquote>\n")
+	case list:
+		tag := "ul"
+		if bl.ordered {
+			tag = "ol"
+		}
+		b.WriteString("<" + tag)
+		if bl.ordered && bl.start != "" && bl.start != "1" {
+			b.WriteString(` start="` + html.EscapeString(strings.TrimLeft(bl.start, "0")) + `"`)
+		}
+		b.WriteString(">\n")
+		for _, item := range bl.items {
+			b.WriteString("<li>")
+			// A leading paragraph is written tight, as lists usually are
+			rest := item
+			if len(item) > 0 && item[0].kind == paragraph {
+				b.WriteString(inline(item[0].text))
+				rest = item[1:]
+			}
+			if len(rest) > 0 {
+				b.WriteString("\n")
+				renderBlocks(b, rest)
+			}
+			b.WriteString("</li>\n")
+		}
+		b.WriteString("</" + tag + ">\n")
+	case table:
+		b.WriteString("<table>\n<thead>\n")
+		renderRow(b, "th", bl.header, bl.align, len(bl.header))
+		b.WriteString("</thead>\n<tbody>\n")
+		for _, row := range bl.rows {
+			renderRow(b, "td", row, bl.align, len(bl.header))
+		}
+		b.WriteString("</tbody>\n</table>\n")
+	}
+}
+
+func renderRow(b *strings.Builder, tag string, cells, align []string, width int) {
+	b.WriteString("<tr>")
+	for i := 0; i < width; i++ {
+		b.WriteString("<" + tag)
+		if i < len(align) && align[i] != "" {
+			b.WriteString(` style="text-align:` + align[i] + `"`)
+		}
+		b.WriteString(">")
+		if i < len(cells) {
+			b.WriteString(inline(cells[i]))
+		}
+		b.WriteString("</" + tag + ">")
+	}
+	b.WriteString("</tr>\n")
+}
+
+var (
+	linkTitlePattern = regexp.MustCompile(`^(?:\s+"[^"]*")?\)`)
+	tagPattern       = regexp.MustCompile(`<[^>]*>`)
+)
+
+// delimiters are the emphasis markers, longest first
+var delimiters = []struct{ marker, tag string }{
+	{"**", "strong"},
+	{"__", "strong"},
+	{"~~", "del"},
+	{"*", "em"},
+	{"_", "em"},
+}
+
+// inline renders emphasis, code spans, links and line breaks, escaping
+// everything else
+func inline(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte("\\`*_{}[]()#+-.!|~>", s[i+1]) >= 0:
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+			continue
+
+		case c == '`':
+			n := 1
+			for i+n < len(s) && s[i+n] == '`' {
+				n++
+			}
+			fence := s[i : i+n]
+			if end := strings.Index(s[i+n:], fence); end >= 0 {
+				b.WriteString("<code>")
+				b.WriteString(html.EscapeString(strings.TrimSpace(s[i+n : i+n+end])))
+				b.WriteString("</code>")
+				i += 2*n + end
+				continue
+			}
+
+		case c == '[':
+			if text, href, n := link(s[i:]); n > 0 {
+				if safeURL(href) {
+					b.WriteString(`<a href="` + html.EscapeString(href) + `">` + inline(text) + "</a>")
+				} else {
+					b.WriteString(inline(text))
+				}
+				i += n
+				continue
+			}
+
+		case c == '\n':
+			if strings.HasSuffix(b.String(), "  ") || i > 0 && s[i-1] == '\\' {
+				b.WriteString("<br>")
+			}
+			b.WriteByte('\n')
+			i++
+			continue
+		}
+
+		// Underscores inside a word, as in snake_case, are not emphasis
+		if c == '_' && i > 0 && isWordByte(s[i-1]) {
+			b.WriteString(strings.Repeat("_", underscores(s[i:])))
+			i += underscores(s[i:])
+			continue
+		}
+		if tag, inner, n := emphasis(s[i:]); n > 0 {
+			b.WriteString("<" + tag + ">" + inline(inner) + "</" + tag + ">")
+			i += n
+			continue
+		}
+
+		b.WriteString(html.EscapeString(s[i : i+1]))
+		i++
+	}
+	return b.String()
+}
+
+// emphasis matches a delimited run at the start of s: the opening marker
+// must be followed and the closing one preceded by a non-space
+func emphasis(s string) (tag, inner string, n int) {
+	for _, d := range delimiters {
+		m := d.marker
+		if !strings.HasPrefix(s, m) || len(s) <= len(m) || s[len(m)] == ' ' || strings.HasPrefix(s[len(m):], m[:1]) {
+			continue
+		}
+		for from := len(m) + 1; from < len(s); {
+			end := strings.Index(s[from:], m)
+			if end < 0 {
+				break
+			}
+			end += from
+			closed := end + len(m)
+			if s[end-1] != ' ' && (m[0] != '_' || closed == len(s) || !isWordByte(s[closed])) {
+				return d.tag, s[len(m):end], closed
+			}
+			from = end + 1
+		}
+	}
+	return "", "", 0
+}
+
+// link matches [text](href "title") at the start of s. Parentheses in href
+// must balance, so [x](a(b)) links to a(b) and the last ")" is not left over.
+func link(s string) (text, href string, n int) {
+	bracket := strings.IndexByte(s, ']')
+	if bracket < 0 || bracket+1 >= len(s) || s[bracket+1] != '(' {
+		return "", "", 0
+	}
+	start := bracket + 2
+	depth, end := 0, start
+scan:
+	for ; end < len(s); end++ {
+		switch s[end] {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				break scan
+			}
+			depth--
+		case ' ', '\t', '\n':
+			break scan
+		}
+	}
+	if depth != 0 || end == len(s) {
+		return "", "", 0
+	}
+	tail := linkTitlePattern.FindString(s[end:])
+	if tail == "" {
+		return "", "", 0
+	}
+	return s[1:bracket], s[start:end], end + len(tail)
+}
+
+// isWordByte treats letters, digits and any non-ASCII byte as part of a word
+func isWordByte(c byte) bool {
+	return c >= 0x80 || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func underscores(s string) int {
+	n := 0
+	for n < len(s) && s[n] == '_' {
+		n++
+	}
+	return n
+}
+
+func safeURL(href string) bool {
+	lower := strings.ToLower(href)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") ||
+		strings.HasPrefix(lower, "mailto:") || strings.HasPrefix(lower, "#")
+}
+
+// plainText is inline markup with the formatting removed
+func plainText(s string) string {
+	return strings.TrimSpace(html.UnescapeString(tagPattern.ReplaceAllString(inline(s), "")))
+}
@@ -0,0 +1,35 @@
+package markdown
+
+import "testing"
+
+func TestInline(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		// Link targets with balanced parentheses
+		{"[bad](javascript:alert(1))", "bad"},
+		{"[bad](JavaScript:alert(document.cookie)) x", "bad x"},
+		{"[data](data:text/html,<b>x</b>)", "data"},
+		{"[wiki](https://en.wikipedia.org/wiki/Vitamin_D_(nutrient)) x", `<a href="https://en.wikipedia.org/wiki/Vitamin_D_(nutrient)">wiki</a> x`},
+		{`[t](https://example.com/a "title")`, `<a href="https://example.com/a">t</a>`},
+		{"[mail](mailto:a@example.com)", `<a href="mailto:a@example.com">mail</a>`},
+		{"[top](#verdict)", `<a href="#verdict">top</a>`},
+		{"[open](https://example.com/(a) x", "[open](https://example.com/(a) x"},
+		{`[q](https://example.com/?a="b")`, `<a href="https://example.com/?a=&#34;b&#34;">q</a>`},
+
+		// Emphasis, including inside strong
+		{"**a _b_ c**", "<strong>a <em>b</em> c</strong>"},
+		{"**a *b* c**", "<strong>a <em>b</em> c</strong>"},
+		{"__a *b* c__", "<strong>a <em>b</em> c</strong>"},
+		{"_a **b** c_", "<em>a <strong>b</strong> c</em>"},
+		{"~~old~~ *new*", "<del>old</del> <em>new</em>"},
+		{"snake_case_name", "snake_case_name"},
+		{"витамин_D_3", "витамин_D_3"},
+		{"* not emphasis *", "* not emphasis *"},
+
+		{"`a <b>` & c", "<code>a &lt;b&gt;</code> &amp; c"},
+		{`\*x\*`, "*x*"},
+	} {
+		if got := inline(tc.in); got != tc.want {
+			t.Errorf("inline(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
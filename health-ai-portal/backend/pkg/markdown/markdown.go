@@ -0,0 +1,369 @@
+// Package markdown renders the Markdown the AI roles write: headings,
+// paragraphs, nested lists, tables, block quotes, code and rules, with
+// emphasis, code spans and links inline. Raw HTML is escaped, never passed
+// through, so untrusted text is safe to render.
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type blockKind int
+
+const (
+	paragraph blockKind = iota
+	heading
+	rule
+	code
+	quote
+	list
+	table
+)
+
+type block struct {
+	kind     blockKind
+	level    int       // heading level
+	id       string    // heading anchor
+	text     string    // paragraph and heading text, code body
+	children []block   // quote content
+	items    [][]block // list items
+	ordered  bool
+	start    string
+	header   []string
+	align    []string
+	rows     [][]string
+}
+
+var (
+	headingLine = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	ruleLine    = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	fenceLine   = regexp.MustCompile("^ {0,3}(```+|~~~+)[ \t]*([^`\\s]*)")
+	quoteLine   = regexp.MustCompile(`^ {0,3}> ?`)
+	itemLine    = regexp.MustCompile(`^( *)([-*+]|\d{1,9}[.)])(?:( +)(.*))?$`)
+	delimRow    = regexp.MustCompile(`^ {0,3}\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+)
+
+// splitLines normalises line endings and expands leading tabs
+func splitLines(src string) []string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	lines := strings.Split(src, "\n")
+	for i, line := range lines {
+		indent := 0
+		for indent < len(line) && (line[indent] == ' ' || line[indent] == '\t') {
+			indent++
+		}
+		if strings.Contains(line[:indent], "\t") {
+			lines[i] = strings.ReplaceAll(line[:indent], "\t", "    ") + line[indent:]
+		}
+	}
+	return lines
+}
+
+func blank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// startsBlock reports whether line interrupts a paragraph
+func startsBlock(line string) bool {
+	return headingLine.MatchString(line) || ruleLine.MatchString(line) || fenceLine.MatchString(line) ||
+		quoteLine.MatchString(line) || itemLine.MatchString(line) && !blank(itemLine.FindStringSubmatch(line)[4])
+}
+
+func parse(lines []string) []block {
+	var blocks []block
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case blank(line):
+			i++
+
+		case fenceLine.MatchString(line):
+			m := fenceLine.FindStringSubmatch(line)
+			fence := strings.TrimSpace(m[1])
+			var body []string
+			i++
+			for i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+				body = append(body, lines[i])
+				i++
+			}
+			i++ // closing fence
+			blocks = append(blocks, block{kind: code, text: strings.Join(body, "\n"), start: m[2]})
+
+		case headingLine.MatchString(line):
+			m := headingLine.FindStringSubmatch(line)
+			blocks = append(blocks, block{kind: heading, level: len(m[1]), text: strings.TrimSpace(m[2])})
+			i++
+
+		case ruleLine.MatchString(line):
+			blocks = append(blocks, block{kind: rule})
+			i++
+
+		case quoteLine.MatchString(line):
+			var inner []string
+			for i < len(lines) && !blank(lines[i]) {
+				if quoteLine.MatchString(lines[i]) {
+					inner = append(inner, quoteLine.ReplaceAllString(lines[i], ""))
+				} else {
+					inner = append(inner, lines[i])
+				}
+				i++
+			}
+			blocks = append(blocks, block{kind: quote, children: parse(inner)})
+
+		case itemLine.MatchString(line):
+			var b block
+			b, i = parseList(lines, i)
+			blocks = append(blocks, b)
+
+		case i+1 < len(lines) && strings.Contains(line, "|") && delimRow.MatchString(lines[i+1]):
+			var b block
+			b, i = parseTable(lines, i)
+			blocks = append(blocks, b)
+
+		default:
+			text := []string{strings.TrimSpace(line)}
+			if strings.HasSuffix(line, "  ") {
+				text[0] += "  "
+			}
+			i++
+			for i < len(lines) && !blank(lines[i]) && !startsBlock(lines[i]) {
+				t := strings.TrimLeft(lines[i], " ")
+				text = append(text, t)
+				i++
+			}
+			blocks = append(blocks, block{kind: paragraph, text: strings.Join(text, "\n")})
+		}
+	}
+	return blocks
+}
+
+// parseList reads the list starting at lines[i]. Lines indented past the
+// marker belong to the current item, which is parsed as blocks of its own.
+func parseList(lines []string, i int) (block, int) {
+	first := itemLine.FindStringSubmatch(lines[i])
+	base := len(first[1])
+	ordered := first[2][0] >= '0' && first[2][0] <= '9'
+	b := block{kind: list, ordered: ordered}
+	if ordered {
+		b.start = strings.TrimRight(first[2], ".)")
+	}
+
+	var item []string
+	contentIndent := 0
+	flush := func() {
+		if item != nil {
+			b.items = append(b.items, parse(item))
+		}
+	}
+	sawBlank := false
+	for i < len(lines) {
+		line := lines[i]
+		m := itemLine.FindStringSubmatch(line)
+		sibling := m != nil && len(m[1]) >= base && len(m[1]) < base+2 &&
+			(m[2][0] >= '0' && m[2][0] <= '9') == ordered && !ruleLine.MatchString(line)
+		switch {
+		case sibling:
+			flush()
+			contentIndent = len(m[1]) + len(m[2]) + 1
+			item = []string{m[4]}
+			sawBlank = false
+		case blank(line):
+			// A blank line ends the list unless the item or list goes on
+			next := i + 1
+			for next < len(lines) && blank(lines[next]) {
+				next++
+			}
+			if next == len(lines) || indentOf(lines[next]) <= base && !itemLine.MatchString(lines[next]) {
+				flush()
+				return b, next
+			}
+			item = append(item, "")
+			sawBlank = true
+		case indentOf(line) > base:
+			cut := indentOf(line)
+			if cut > contentIndent {
+				cut = contentIndent
+			}
+			item = append(item, line[cut:])
+		case !sawBlank && !startsBlock(line):
+			item = append(item, strings.TrimSpace(line)) // lazy continuation
+		default:
+			flush()
+			return b, i
+		}
+		i++
+	}
+	flush()
+	return b, i
+}
+
+func parseTable(lines []string, i int) (block, int) {
+	b := block{kind: table, header: splitRow(lines[i])}
+	for _, cell := range splitRow(lines[i+1]) {
+		switch {
+		case strings.HasPrefix(cell, ":") && strings.HasSuffix(cell, ":"):
+			b.align = append(b.align, "center")
+		case strings.HasSuffix(cell, ":"):
+			b.align = append(b.align, "right")
+		case strings.HasPrefix(cell, ":"):
+			b.align = append(b.align, "left")
+		default:
+			b.align = append(b.align, "")
+		}
+	}
+	i += 2
+	for i < len(lines) && !blank(lines[i]) && strings.Contains(lines[i], "|") {
+		b.rows = append(b.rows, splitRow(lines[i]))
+		i++
+	}
+	return b, i
+}
+
+// splitRow splits a table row on pipes that are not escaped or in code
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	var cells []string
+	var cell strings.Builder
+	inCode := false
+	for j := 0; j < len(line); j++ {
+		c := line[j]
+		switch {
+		case c == '\\' && j+1 < len(line) && line[j+1] == '|':
+			cell.WriteByte('|')
+			j++
+		case c == '`':
+			inCode = !inCode
+			cell.WriteByte(c)
+		case c == '|' && !inCode:
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(c)
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// document parses src and gives every heading a unique anchor
+func document(src string) []block {
+	blocks := parse(splitLines(src))
+	seen := map[string]int{}
+	var assign func([]block)
+	assign = func(bs []block) {
+		for i := range bs {
+			switch bs[i].kind {
+			case heading:
+				slug := Slug(plainText(bs[i].text))
+				if n := seen[slug]; n > 0 {
+					seen[slug] = n + 1
+					slug += "-" + strconv.Itoa(n)
+				} else {
+					seen[slug] = 1
+				}
+				bs[i].id = slug
+			case quote:
+				assign(bs[i].children)
+			case list:
+				for _, item := range bs[i].items {
+					assign(item)
+				}
+			}
+		}
+	}
+	assign(blocks)
+	return blocks
+}
+
+// Heading is a heading of a document with the anchor HTML gives it
+type Heading struct {
+	Level int
+	Text  string
+	ID    string
+}
+
+// Headings lists the document's top-level headings in order
+func Headings(src string) []Heading {
+	var headings []Heading
+	for _, b := range document(src) {
+		if b.kind == heading {
+			headings = append(headings, Heading{Level: b.level, Text: plainText(b.text), ID: b.id})
+		}
+	}
+	return headings
+}
+
+// Slug turns heading text into an anchor the way GitHub does: lower case,
+// punctuation dropped and spaces replaced by hyphens
+func Slug(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		switch {
+		case r == ' ':
+			b.WriteByte('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// ShiftHeadings moves headings so the highest-level one becomes level top,
+// keeping their relative depth; levels past 6 stay at 6. Code blocks are
+// left alone.
+func ShiftHeadings(src string, top int) string {
+	lines := splitLines(src)
+	highest := 7
+	forEachHeading(lines, func(i int, level int) {
+		if level < highest {
+			highest = level
+		}
+	})
+	if highest == 7 || highest == top {
+		return strings.Join(lines, "\n")
+	}
+	forEachHeading(lines, func(i int, level int) {
+		shifted := level - highest + top
+		if shifted > 6 {
+			shifted = 6
+		}
+		if shifted < 1 {
+			shifted = 1
+		}
+		trimmed := strings.TrimLeft(lines[i], " ")
+		lines[i] = strings.Repeat("#", shifted) + trimmed[level:]
+	})
+	return strings.Join(lines, "\n")
+}
+
+func forEachHeading(lines []string, fn func(i, level int)) {
+	fence := ""
+	for i, line := range lines {
+		if m := fenceLine.FindStringSubmatch(line); m != nil {
+			switch {
+			case fence == "":
+				fence = strings.TrimSpace(m[1])
+			case strings.HasPrefix(strings.TrimSpace(line), fence):
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+		if m := headingLine.FindStringSubmatch(line); m != nil {
+			fn(i, len(m[1]))
+		}
+	}
+}
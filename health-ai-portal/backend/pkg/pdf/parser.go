@@ -124,6 +124,46 @@ var categoryMappings = map[string]string{
 	"PSA":                "prostate",
 }
 
+// NormalizeMarkerName maps a Russian marker name ("ферритин") to the name
+// results are stored under. Unknown names are returned unchanged.
+func NormalizeMarkerName(name string) string {
+	if normalized, ok := markerMappings[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return normalized
+	}
+	return strings.TrimSpace(name)
+}
+
+// MatchMarkerName resolves free text such as "Кортизол утром" to a stored
+// marker name: an exact mapping first, then the longest mapping the text
+// starts with. False when nothing matches.
+func MatchMarkerName(text string) (string, bool) {
+	lower := strings.ToLower(strings.TrimSpace(text))
+	if normalized, ok := markerMappings[lower]; ok {
+		return normalized, true
+	}
+	for _, normalized := range markerMappings {
+		if strings.EqualFold(lower, normalized) {
+			return normalized, true
+		}
+	}
+
+	best := ""
+	for russianName := range markerMappings {
+		if strings.HasPrefix(lower, russianName+" ") && len(russianName) > len(best) {
+			best = russianName
+		}
+	}
+	if best != "" {
+		return markerMappings[best], true
+	}
+	return "", false
+}
+
+// MarkerCategory returns the category of a stored marker name, "" when unknown
+func MarkerCategory(name string) string {
+	return categoryMappings[name]
+}
+
 // ParseLabText parses raw text from a lab PDF and extracts markers
 func ParseLabText(text string, labName string, testDate time.Time) (*ParsedLabResult, error) {
 	result := &ParsedLabResult{
@@ -0,0 +1,314 @@
+// Package pdfdoc writes simple PDF documents: text in embedded TrueType
+// fonts, lines, rectangles and polylines on A4 pages. Coordinates are in
+// points from the top-left corner of the page.
+package pdfdoc
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A4 page size in points
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Color is an RGB color with components from 0 to 1
+type Color struct{ R, G, B float64 }
+
+// RGB builds a color from 0-255 components
+func RGB(r, g, b int) Color {
+	return Color{float64(r) / 255, float64(g) / 255, float64(b) / 255}
+}
+
+// Point is a position on the page
+type Point struct{ X, Y float64 }
+
+// Document collects pages and the fonts they use
+type Document struct {
+	Title   string
+	Author  string
+	Created time.Time
+
+	pages []*Page
+	fonts []*docFont
+}
+
+type docFont struct {
+	font *Font
+	res  string
+	used map[uint16]rune
+}
+
+// Page is one A4 page; drawing appends to its content stream
+type Page struct {
+	doc     *Document
+	content bytes.Buffer
+	fonts   map[string]bool
+}
+
+func New() *Document {
+	return &Document{Created: time.Now()}
+}
+
+// AddPage appends an empty page
+func (d *Document) AddPage() *Page {
+	p := &Page{doc: d, fonts: map[string]bool{}}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Pages returns the pages in order, so footers can be added once the page
+// count is known
+func (d *Document) Pages() []*Page {
+	return d.pages
+}
+
+func (d *Document) font(f *Font) *docFont {
+	for _, df := range d.fonts {
+		if df.font == f {
+			return df
+		}
+	}
+	df := &docFont{font: f, res: "F" + strconv.Itoa(len(d.fonts)+1), used: map[uint16]rune{}}
+	d.fonts = append(d.fonts, df)
+	return df
+}
+
+func num(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func (p *Page) y(top float64) float64 {
+	return PageHeight - top
+}
+
+// Text draws s with its baseline at (x, y)
+func (p *Page) Text(f *Font, size, x, y float64, s string, c Color) {
+	if s == "" {
+		return
+	}
+	df := p.doc.font(f)
+	p.fonts[df.res] = true
+
+	var hex strings.Builder
+	for _, r := range s {
+		g := f.glyph(r)
+		if _, ok := df.used[g]; !ok {
+			df.used[g] = r
+		}
+		fmt.Fprintf(&hex, "%04X", g)
+	}
+	fmt.Fprintf(&p.content, "BT %s %s %s rg /%s %s Tf %s %s Td <%s> Tj ET\n",
+		num(c.R), num(c.G), num(c.B), df.res, num(size), num(x), num(p.y(y)), hex.String())
+}
+
+// Line draws a straight line
+func (p *Page) Line(x1, y1, x2, y2, width float64, c Color) {
+	fmt.Fprintf(&p.content, "%s w %s %s %s RG %s %s m %s %s l S\n",
+		num(width), num(c.R), num(c.G), num(c.B), num(x1), num(p.y(y1)), num(x2), num(p.y(y2)))
+}
+
+// Rect fills a rectangle whose top-left corner is (x, y)
+func (p *Page) Rect(x, y, w, h float64, c Color) {
+	fmt.Fprintf(&p.content, "%s %s %s rg %s %s %s %s re f\n",
+		num(c.R), num(c.G), num(c.B), num(x), num(p.y(y+h)), num(w), num(h))
+}
+
+// Polyline strokes the points in order
+func (p *Page) Polyline(points []Point, width float64, c Color) {
+	if len(points) < 2 {
+		return
+	}
+	fmt.Fprintf(&p.content, "%s w %s %s %s RG 1 J 1 j ", num(width), num(c.R), num(c.G), num(c.B))
+	for i, pt := range points {
+		op := "l"
+		if i == 0 {
+			op = "m"
+		}
+		fmt.Fprintf(&p.content, "%s %s %s ", num(pt.X), num(p.y(pt.Y)), op)
+	}
+	p.content.WriteString("S\n")
+}
+
+// Dot fills a small square centred on (x, y)
+func (p *Page) Dot(x, y, size float64, c Color) {
+	p.Rect(x-size/2, y-size/2, size, size, c)
+}
+
+// writer numbers objects and records their offsets for the xref table
+type writer struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+func (w *writer) reserve() int {
+	w.offsets = append(w.offsets, 0)
+	return len(w.offsets)
+}
+
+func (w *writer) begin(id int) {
+	w.offsets[id-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n", id)
+}
+
+func (w *writer) object(id int, body string) {
+	w.begin(id)
+	w.buf.WriteString(body)
+	w.buf.WriteString("\nendobj\n")
+}
+
+func (w *writer) stream(id int, dict string, data []byte, compress bool) {
+	if compress {
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		zw.Write(data)
+		zw.Close()
+		data = z.Bytes()
+		dict += " /Filter /FlateDecode"
+	}
+	w.begin(id)
+	fmt.Fprintf(&w.buf, "<< %s /Length %d >>\nstream\n", dict, len(data))
+	w.buf.Write(data)
+	w.buf.WriteString("\nendstream\nendobj\n")
+}
+
+// WriteTo serialises the document
+func (d *Document) WriteTo(out io.Writer) (int64, error) {
+	w := &writer{}
+	w.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	catalog, pagesID, info := w.reserve(), w.reserve(), w.reserve()
+
+	fontIDs := make(map[string]int, len(d.fonts))
+	for _, df := range d.fonts {
+		fontIDs[df.res] = d.writeFont(w, df)
+	}
+
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	kids := make([]string, len(d.pages))
+	for i, p := range d.pages {
+		pageID, contentID := w.reserve(), w.reserve()
+		kids[i] = fmt.Sprintf("%d 0 R", pageID)
+
+		res := make([]string, 0, len(p.fonts))
+		for name := range p.fonts {
+			res = append(res, fmt.Sprintf("/%s %d 0 R", name, fontIDs[name]))
+		}
+		sort.Strings(res)
+		w.object(pageID, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			pagesID, num(PageWidth), num(PageHeight), strings.Join(res, " "), contentID))
+		w.stream(contentID, "", p.content.Bytes(), true)
+	}
+
+	w.object(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	w.object(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+	w.object(info, fmt.Sprintf("<< /Title %s /Author %s /Producer (health-ai-portal) /CreationDate (D:%s) >>",
+		textString(d.Title), textString(d.Author), d.Created.UTC().Format("20060102150405Z")))
+
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, off := range w.offsets {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(w.offsets)+1, catalog, info, xref)
+
+	n, err := out.Write(w.buf.Bytes())
+	return int64(n), err
+}
+
+// Bytes returns the serialised document
+func (d *Document) Bytes() []byte {
+	var b bytes.Buffer
+	d.WriteTo(&b)
+	return b.Bytes()
+}
+
+// writeFont embeds a font as a Type 0 font with Identity-H encoding, so text
+// is written as glyph IDs, and a ToUnicode map that keeps it searchable
+func (d *Document) writeFont(w *writer, df *docFont) int {
+	f := df.font
+	fontID, cidID, descID, fileID, cmapID := w.reserve(), w.reserve(), w.reserve(), w.reserve(), w.reserve()
+
+	glyphs := make([]int, 0, len(df.used))
+	for g := range df.used {
+		glyphs = append(glyphs, int(g))
+	}
+	sort.Ints(glyphs)
+
+	var widths strings.Builder
+	for _, g := range glyphs {
+		adv := 0
+		if g < len(f.advances) {
+			adv = f.units(int(f.advances[g]))
+		}
+		fmt.Fprintf(&widths, "%d [%d] ", g, adv)
+	}
+
+	w.object(fontID, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		f.name, cidID, cmapID))
+	w.object(cidID, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /CIDToGIDMap /Identity /DW 1000 /W [%s] >>",
+		f.name, descID, widths.String()))
+	w.object(descID, fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		f.name, f.units(f.bbox[0]), f.units(f.bbox[1]), f.units(f.bbox[2]), f.units(f.bbox[3]),
+		f.units(f.ascent), f.units(f.descent), f.units(f.capHeight), fileID))
+	w.stream(fileID, fmt.Sprintf("/Length1 %d", len(f.data)), f.data, true)
+	w.stream(cmapID, "", toUnicode(glyphs, df.used), true)
+	return fontID
+}
+
+func toUnicode(glyphs []int, used map[uint16]rune) []byte {
+	var b bytes.Buffer
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	b.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	b.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	b.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for start := 0; start < len(glyphs); start += 100 {
+		end := start + 100
+		if end > len(glyphs) {
+			end = len(glyphs)
+		}
+		fmt.Fprintf(&b, "%d beginbfchar\n", end-start)
+		for _, g := range glyphs[start:end] {
+			fmt.Fprintf(&b, "<%04X> <", g)
+			for _, u := range utf16Units(used[uint16(g)]) {
+				fmt.Fprintf(&b, "%04X", u)
+			}
+			b.WriteString(">\n")
+		}
+		b.WriteString("endbfchar\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.Bytes()
+}
+
+func utf16Units(r rune) []uint16 {
+	if r < 0x10000 {
+		return []uint16{uint16(r)}
+	}
+	r -= 0x10000
+	return []uint16{uint16(0xD800 + (r >> 10)), uint16(0xDC00 + (r & 0x3FF))}
+}
+
+// textString encodes document information as UTF-16BE with a byte order mark
+func textString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, r := range s {
+		for _, u := range utf16Units(r) {
+			fmt.Fprintf(&b, "%04X", u)
+		}
+	}
+	b.WriteString(">")
+	return b.String()
+}
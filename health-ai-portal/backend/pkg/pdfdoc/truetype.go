@@ -0,0 +1,271 @@
+package pdfdoc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf16"
+)
+
+// Font is a TrueType font embedded whole into documents that use it. Only
+// what layout needs is parsed: the character map and advance widths.
+type Font struct {
+	name       string
+	data       []byte
+	unitsPerEm int
+	ascent     int
+	descent    int
+	capHeight  int
+	bbox       [4]int
+	glyphs     map[rune]uint16
+	advances   []uint16
+}
+
+// LoadFont reads a TrueType (.ttf) file
+func LoadFont(path string) (*Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := ParseFont(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return f, nil
+}
+
+// ParseFont parses a TrueType font with a Unicode character map
+func ParseFont(data []byte) (*Font, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("not a TrueType font")
+	}
+	switch binary.BigEndian.Uint32(data) {
+	case 0x00010000, 0x74727565: // 1.0 and "true"
+	default:
+		return nil, fmt.Errorf("not a TrueType font (OpenType CFF and collections are not supported)")
+	}
+
+	tables := make(map[string][]byte)
+	n := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < n; i++ {
+		rec := 12 + 16*i
+		if rec+16 > len(data) {
+			return nil, fmt.Errorf("truncated table directory")
+		}
+		tag := string(data[rec : rec+4])
+		off := int(binary.BigEndian.Uint32(data[rec+8:]))
+		length := int(binary.BigEndian.Uint32(data[rec+12:]))
+		if off < 0 || length < 0 || off+length > len(data) {
+			return nil, fmt.Errorf("table %s out of range", tag)
+		}
+		tables[tag] = data[off : off+length]
+	}
+	for _, tag := range []string{"head", "hhea", "hmtx", "maxp", "cmap"} {
+		if _, ok := tables[tag]; !ok {
+			return nil, fmt.Errorf("missing %s table", tag)
+		}
+	}
+
+	f := &Font{data: data}
+	head, hhea := tables["head"], tables["hhea"]
+	if len(head) < 54 || len(hhea) < 36 || len(tables["maxp"]) < 6 {
+		return nil, fmt.Errorf("truncated header tables")
+	}
+	f.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	if f.unitsPerEm == 0 {
+		return nil, fmt.Errorf("invalid unitsPerEm")
+	}
+	for i := range f.bbox {
+		f.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+2*i:])))
+	}
+	f.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+	f.descent = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+	f.capHeight = f.ascent
+	if os2 := tables["OS/2"]; len(os2) >= 90 && binary.BigEndian.Uint16(os2) >= 2 {
+		f.capHeight = int(int16(binary.BigEndian.Uint16(os2[88:])))
+	}
+
+	numGlyphs := int(binary.BigEndian.Uint16(tables["maxp"][4:]))
+	numMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	hmtx := tables["hmtx"]
+	if numMetrics == 0 || len(hmtx) < 4*numMetrics {
+		return nil, fmt.Errorf("truncated hmtx table")
+	}
+	f.advances = make([]uint16, numGlyphs)
+	for g := 0; g < numGlyphs; g++ {
+		m := g
+		if m >= numMetrics {
+			m = numMetrics - 1
+		}
+		f.advances[g] = binary.BigEndian.Uint16(hmtx[4*m:])
+	}
+
+	glyphs, err := parseCmap(tables["cmap"])
+	if err != nil {
+		return nil, err
+	}
+	f.glyphs = glyphs
+	f.name = postScriptName(tables["name"])
+	return f, nil
+}
+
+// parseCmap reads the Unicode subtable: format 12 (full range) when present,
+// otherwise format 4 (BMP)
+func parseCmap(cmap []byte) (map[rune]uint16, error) {
+	if len(cmap) < 4 {
+		return nil, fmt.Errorf("truncated cmap table")
+	}
+	var bmp, full []byte
+	n := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < n; i++ {
+		rec := 4 + 8*i
+		if rec+8 > len(cmap) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(cmap[rec:])
+		encoding := binary.BigEndian.Uint16(cmap[rec+2:])
+		off := int(binary.BigEndian.Uint32(cmap[rec+4:]))
+		if off+4 > len(cmap) {
+			continue
+		}
+		sub := cmap[off:]
+		unicode := platform == 0 || platform == 3 && (encoding == 1 || encoding == 10)
+		if !unicode {
+			continue
+		}
+		switch binary.BigEndian.Uint16(sub) {
+		case 4:
+			bmp = sub
+		case 12:
+			full = sub
+		}
+	}
+
+	glyphs := make(map[rune]uint16)
+	switch {
+	case full != nil:
+		if len(full) < 16 {
+			return nil, fmt.Errorf("truncated cmap subtable")
+		}
+		groups := int(binary.BigEndian.Uint32(full[12:]))
+		for i := 0; i < groups && 16+12*i+12 <= len(full); i++ {
+			g := full[16+12*i:]
+			start, end := binary.BigEndian.Uint32(g), binary.BigEndian.Uint32(g[4:])
+			gid := binary.BigEndian.Uint32(g[8:])
+			for c := start; c <= end && c-start < 0x10000; c++ {
+				glyphs[rune(c)] = uint16(gid + c - start)
+			}
+		}
+	case bmp != nil:
+		if len(bmp) < 14 {
+			return nil, fmt.Errorf("truncated cmap subtable")
+		}
+		segs := int(binary.BigEndian.Uint16(bmp[6:])) / 2
+		ends := 14
+		starts := ends + 2*segs + 2
+		deltas := starts + 2*segs
+		ranges := deltas + 2*segs
+		if ranges+2*segs > len(bmp) {
+			return nil, fmt.Errorf("truncated cmap subtable")
+		}
+		for s := 0; s < segs; s++ {
+			end := binary.BigEndian.Uint16(bmp[ends+2*s:])
+			start := binary.BigEndian.Uint16(bmp[starts+2*s:])
+			delta := binary.BigEndian.Uint16(bmp[deltas+2*s:])
+			rangeOff := int(binary.BigEndian.Uint16(bmp[ranges+2*s:]))
+			for c := int(start); c <= int(end) && c != 0xFFFF; c++ {
+				var gid uint16
+				if rangeOff == 0 {
+					gid = uint16(c) + delta
+				} else {
+					at := ranges + 2*s + rangeOff + 2*(c-int(start))
+					if at+2 > len(bmp) {
+						continue
+					}
+					if gid = binary.BigEndian.Uint16(bmp[at:]); gid != 0 {
+						gid += delta
+					}
+				}
+				if gid != 0 {
+					glyphs[rune(c)] = gid
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("no Unicode character map")
+	}
+	return glyphs, nil
+}
+
+// postScriptName reads name ID 6, keeping only characters a PDF name allows
+func postScriptName(table []byte) string {
+	name := ""
+	if len(table) >= 6 {
+		count := int(binary.BigEndian.Uint16(table[2:]))
+		storage := int(binary.BigEndian.Uint16(table[4:]))
+		for i := 0; i < count && 6+12*i+12 <= len(table); i++ {
+			rec := table[6+12*i:]
+			platform := binary.BigEndian.Uint16(rec)
+			nameID := binary.BigEndian.Uint16(rec[6:])
+			length := int(binary.BigEndian.Uint16(rec[8:]))
+			off := storage + int(binary.BigEndian.Uint16(rec[10:]))
+			if nameID != 6 || off+length > len(table) {
+				continue
+			}
+			raw := table[off : off+length]
+			if platform == 3 || platform == 0 {
+				units := make([]uint16, len(raw)/2)
+				for j := range units {
+					units[j] = binary.BigEndian.Uint16(raw[2*j:])
+				}
+				name = string(utf16.Decode(units))
+			} else {
+				name = string(raw)
+			}
+			break
+		}
+	}
+	name = strings.Map(func(r rune) rune {
+		if r > 32 && r < 127 && !strings.ContainsRune("()<>[]{}/%#", r) {
+			return r
+		}
+		return -1
+	}, name)
+	if name == "" {
+		name = "EmbeddedFont"
+	}
+	return name
+}
+
+// glyph returns the glyph of r, or glyph 0 (.notdef) when the font lacks it
+func (f *Font) glyph(r rune) uint16 {
+	return f.glyphs[r]
+}
+
+// Has reports whether the font has a glyph for r
+func (f *Font) Has(r rune) bool {
+	_, ok := f.glyphs[r]
+	return ok
+}
+
+// Width is the advance width of s at size points
+func (f *Font) Width(s string, size float64) float64 {
+	units := 0
+	for _, r := range s {
+		if g := int(f.glyph(r)); g < len(f.advances) {
+			units += int(f.advances[g])
+		}
+	}
+	return float64(units) * size / float64(f.unitsPerEm)
+}
+
+// Ascent is the height above the baseline at size points
+func (f *Font) Ascent(size float64) float64 {
+	return float64(f.ascent) * size / float64(f.unitsPerEm)
+}
+
+// units converts font units to the 1000-unit glyph space of PDF
+func (f *Font) units(v int) int {
+	return v * 1000 / f.unitsPerEm
+}
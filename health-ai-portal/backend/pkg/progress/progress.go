@@ -0,0 +1,131 @@
+// Package progress measures a numeric goal against a series of observations:
+// current value, percentage of the way from baseline to target, and whether
+// the target has held long enough to count as achieved.
+package progress
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Directions a target can be approached from
+const (
+	Increase = "increase" // reach at least the target
+	Decrease = "decrease" // get down to at most the target
+)
+
+// Goal is the numeric part of a goal
+type Goal struct {
+	Target    float64
+	Direction string
+	Baseline  *float64  // value progress starts from; defaults to the value at Since
+	Since     time.Time // when tracking started
+	HoldDays  int       // how long the target must hold before it is achieved
+}
+
+// Point is one observation
+type Point struct {
+	Date  time.Time `json:"date"`
+	Value float64   `json:"value"`
+}
+
+// SeriesPoint is an observation with the progress it represents
+type SeriesPoint struct {
+	Date      time.Time `json:"date"`
+	Value     float64   `json:"value"`
+	Percent   float64   `json:"progress_pct"`
+	TargetMet bool      `json:"target_met"`
+}
+
+// Result is the state of a goal as of a date
+type Result struct {
+	Current     *float64      `json:"current_value"`
+	CurrentDate *time.Time    `json:"current_date"`
+	Baseline    *float64      `json:"baseline_value"`
+	Percent     *float64      `json:"progress_pct"`
+	TargetMet   bool          `json:"target_met"`
+	MetSince    *time.Time    `json:"met_since"`
+	Achieved    bool          `json:"achieved"`
+	Series      []SeriesPoint `json:"series"`
+}
+
+// Met reports whether value satisfies the target
+func (g Goal) Met(value float64) bool {
+	if g.Direction == Decrease {
+		return value <= g.Target
+	}
+	return value >= g.Target
+}
+
+// Percent is how far value has moved from baseline to the target, 0..100
+func (g Goal) Percent(baseline, value float64) float64 {
+	if g.Met(value) {
+		return 100
+	}
+	if g.Target == baseline {
+		return 0
+	}
+	pct := (value - baseline) / (g.Target - baseline) * 100
+	return math.Round(math.Max(0, math.Min(100, pct))*10) / 10
+}
+
+// Evaluate measures the goal with the observations known on asOf. The
+// series starts at the last observation before Since so the baseline is
+// visible. The target holds from the first of the trailing observations that
+// all meet it; it is achieved once that is at least HoldDays before asOf.
+func Evaluate(g Goal, points []Point, asOf time.Time) Result {
+	sorted := make([]Point, 0, len(points))
+	for _, p := range points {
+		if !p.Date.After(asOf) {
+			sorted = append(sorted, p)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date.Before(sorted[j].Date) })
+
+	result := Result{Series: []SeriesPoint{}}
+	if len(sorted) == 0 {
+		result.Baseline = g.Baseline
+		return result
+	}
+
+	start := 0
+	for i, p := range sorted {
+		if p.Date.After(g.Since) {
+			break
+		}
+		start = i
+	}
+	sorted = sorted[start:]
+
+	baseline := sorted[0].Value
+	if g.Baseline != nil {
+		baseline = *g.Baseline
+	}
+	result.Baseline = &baseline
+
+	for _, p := range sorted {
+		result.Series = append(result.Series, SeriesPoint{
+			Date:      p.Date,
+			Value:     p.Value,
+			Percent:   g.Percent(baseline, p.Value),
+			TargetMet: g.Met(p.Value),
+		})
+	}
+
+	last := result.Series[len(result.Series)-1]
+	current, date, pct := last.Value, last.Date, last.Percent
+	result.Current, result.CurrentDate, result.Percent = &current, &date, &pct
+	result.TargetMet = last.TargetMet
+
+	if result.TargetMet {
+		since := last.Date
+		for i := len(result.Series) - 1; i >= 0 && result.Series[i].TargetMet; i-- {
+			since = result.Series[i].Date
+		}
+		result.MetSince = &since
+		result.Achieved = !asOf.Before(since.AddDate(0, 0, g.HoldDays))
+	}
+
+	return result
+}
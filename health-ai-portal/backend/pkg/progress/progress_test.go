@@ -0,0 +1,159 @@
+package progress
+
+import (
+	"testing"
+	"time"
+)
+
+func day(d int) time.Time {
+	return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+}
+
+func float(v float64) *float64 { return &v }
+
+func TestEvaluate(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		goal     Goal
+		points   []Point
+		asOf     int
+		current  *float64
+		baseline *float64
+		percent  *float64
+		met      bool
+		metSince int // 0 when the target is not met
+		achieved bool
+	}{
+		{
+			name:     "no observations keep the given baseline",
+			goal:     Goal{Target: 100, Direction: Increase, Baseline: float(40), Since: day(5)},
+			asOf:     20,
+			baseline: float(40),
+		},
+		{
+			name:     "baseline from the last value before tracking",
+			goal:     Goal{Target: 100, Direction: Increase, Since: day(5)},
+			points:   []Point{{day(1), 30}, {day(3), 50}, {day(10), 60}, {day(20), 70}},
+			asOf:     25,
+			current:  float(70),
+			baseline: float(50),
+			percent:  float(40),
+		},
+		{
+			name:     "explicit baseline wins",
+			goal:     Goal{Target: 100, Direction: Increase, Baseline: float(40), Since: day(5)},
+			points:   []Point{{day(3), 50}, {day(10), 70}},
+			asOf:     25,
+			current:  float(70),
+			baseline: float(40),
+			percent:  float(50),
+		},
+		{
+			name:     "decrease counts down",
+			goal:     Goal{Target: 100, Direction: Decrease, Since: day(1)},
+			points:   []Point{{day(1), 120}, {day(10), 115}},
+			asOf:     25,
+			current:  float(115),
+			baseline: float(120),
+			percent:  float(25),
+		},
+		{
+			name:     "moving away from the target is zero",
+			goal:     Goal{Target: 100, Direction: Decrease, Since: day(1)},
+			points:   []Point{{day(1), 120}, {day(10), 130}},
+			asOf:     25,
+			current:  float(130),
+			baseline: float(120),
+			percent:  float(0),
+		},
+		{
+			name:     "baseline already at the target",
+			goal:     Goal{Target: 100, Direction: Decrease, Baseline: float(100), Since: day(1)},
+			points:   []Point{{day(10), 104}},
+			asOf:     25,
+			current:  float(104),
+			baseline: float(100),
+			percent:  float(0),
+		},
+		{
+			name:     "percent rounds to one decimal",
+			goal:     Goal{Target: 4, Direction: Increase, Since: day(1)},
+			points:   []Point{{day(1), 1}, {day(10), 2}},
+			asOf:     25,
+			current:  float(2),
+			baseline: float(1),
+			percent:  float(33.3),
+		},
+		{
+			name:     "met and held",
+			goal:     Goal{Target: 100, Direction: Increase, Since: day(1), HoldDays: 7},
+			points:   []Point{{day(1), 80}, {day(10), 100}, {day(15), 105}},
+			asOf:     17,
+			current:  float(105),
+			baseline: float(80),
+			percent:  float(100),
+			met:      true,
+			metSince: 10,
+			achieved: true,
+		},
+		{
+			name:     "met but not held long enough",
+			goal:     Goal{Target: 100, Direction: Increase, Since: day(1), HoldDays: 14},
+			points:   []Point{{day(1), 80}, {day(10), 100}, {day(15), 105}},
+			asOf:     17,
+			current:  float(105),
+			baseline: float(80),
+			percent:  float(100),
+			met:      true,
+			metSince: 10,
+		},
+		{
+			name:     "a lapse restarts the hold",
+			goal:     Goal{Target: 100, Direction: Increase, Since: day(1), HoldDays: 7},
+			points:   []Point{{day(1), 80}, {day(3), 101}, {day(8), 95}, {day(12), 102}},
+			asOf:     17,
+			current:  float(102),
+			baseline: float(80),
+			percent:  float(100),
+			met:      true,
+			metSince: 12,
+		},
+		{
+			name:     "observations after asOf are unknown",
+			goal:     Goal{Target: 100, Direction: Increase, Since: day(1)},
+			points:   []Point{{day(20), 110}, {day(1), 80}, {day(10), 90}},
+			asOf:     15,
+			current:  float(90),
+			baseline: float(80),
+			percent:  float(50),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := Evaluate(tc.goal, tc.points, day(tc.asOf))
+			if !equalFloat(r.Current, tc.current) || !equalFloat(r.Baseline, tc.baseline) || !equalFloat(r.Percent, tc.percent) {
+				t.Errorf("current, baseline, percent = %v, %v, %v; want %v, %v, %v",
+					deref(r.Current), deref(r.Baseline), deref(r.Percent), deref(tc.current), deref(tc.baseline), deref(tc.percent))
+			}
+			if r.TargetMet != tc.met || r.Achieved != tc.achieved {
+				t.Errorf("met, achieved = %v, %v; want %v, %v", r.TargetMet, r.Achieved, tc.met, tc.achieved)
+			}
+			switch {
+			case tc.metSince == 0 && r.MetSince != nil:
+				t.Errorf("met since %s", r.MetSince)
+			case tc.metSince != 0 && (r.MetSince == nil || !r.MetSince.Equal(day(tc.metSince))):
+				t.Errorf("met since %v, want %s", r.MetSince, day(tc.metSince))
+			}
+		})
+	}
+}
+
+func equalFloat(a, b *float64) bool {
+	return (a == nil) == (b == nil) && (a == nil || *a == *b)
+}
+
+func deref(v *float64) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
@@ -0,0 +1,442 @@
+package rrule
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported frequencies
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+	Yearly  = "YEARLY"
+)
+
+// maxSpan bounds expansion so a malformed rule cannot loop forever
+const maxSpan = 50 * 366
+
+// WeekdayNum is a BYDAY entry: a weekday with an optional ordinal inside the
+// month or year (1MO = first Monday, -1FR = last Friday, 0 = every)
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+// Rule is the date part of an RFC 5545 RRULE. Times of day are not part of
+// the rule; callers combine the dates with their own clock time.
+type Rule struct {
+	Freq       string
+	Interval   int
+	Count      int        // 0 = unlimited
+	Until      *time.Time // inclusive, compared by date
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []int
+}
+
+var dayCodes = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+var dayNames = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Parse reads an RRULE value such as "FREQ=WEEKLY;INTERVAL=8" or
+// "RRULE:FREQ=MONTHLY;BYDAY=1MO". Unsupported parts are rejected rather
+// than silently ignored.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "RRULE:"), "rrule:")
+	if s == "" {
+		return nil, fmt.Errorf("empty rule")
+	}
+
+	r := &Rule{Interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+		key = strings.ToUpper(strings.TrimSpace(key))
+		value = strings.ToUpper(strings.TrimSpace(value))
+
+		var err error
+		switch key {
+		case "FREQ":
+			switch value {
+			case Daily, Weekly, Monthly, Yearly:
+				r.Freq = value
+			default:
+				return nil, fmt.Errorf("unsupported FREQ %s", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if err != nil || r.Interval < 1 {
+				return nil, fmt.Errorf("INTERVAL must be a positive integer")
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+			if err != nil || r.Count < 1 {
+				return nil, fmt.Errorf("COUNT must be a positive integer")
+			}
+		case "UNTIL":
+			until, err := parseDate(value)
+			if err != nil {
+				return nil, fmt.Errorf("UNTIL: %w", err)
+			}
+			r.Until = &until
+		case "BYDAY":
+			for _, item := range strings.Split(value, ",") {
+				wd, err := parseWeekdayNum(item)
+				if err != nil {
+					return nil, err
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			if r.ByMonthDay, err = parseInts(value, -31, 31); err != nil {
+				return nil, fmt.Errorf("BYMONTHDAY: %w", err)
+			}
+		case "BYMONTH":
+			if r.ByMonth, err = parseInts(value, 1, 12); err != nil {
+				return nil, fmt.Errorf("BYMONTH: %w", err)
+			}
+		case "WKST":
+			if value != "MO" {
+				return nil, fmt.Errorf("only WKST=MO is supported")
+			}
+		default:
+			return nil, fmt.Errorf("unsupported rule part %s", key)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, fmt.Errorf("FREQ is required")
+	}
+	if r.Count > 0 && r.Until != nil {
+		return nil, fmt.Errorf("COUNT and UNTIL cannot be combined")
+	}
+	for _, wd := range r.ByDay {
+		if wd.N != 0 && r.Freq != Monthly && r.Freq != Yearly {
+			return nil, fmt.Errorf("ordinal BYDAY is only valid with MONTHLY or YEARLY")
+		}
+	}
+	return r, nil
+}
+
+func parseWeekdayNum(s string) (WeekdayNum, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", s)
+	}
+	day, ok := dayCodes[s[len(s)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", s)
+	}
+	n := 0
+	if prefix := s[:len(s)-2]; prefix != "" {
+		var err error
+		n, err = strconv.Atoi(strings.TrimPrefix(prefix, "+"))
+		if err != nil || n == 0 || n < -53 || n > 53 {
+			return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", s)
+		}
+	}
+	return WeekdayNum{N: n, Day: day}, nil
+}
+
+func parseInts(s string, min, max int) ([]int, error) {
+	var result []int
+	for _, item := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || n < min || n > max || n == 0 {
+			return nil, fmt.Errorf("invalid value %q", item)
+		}
+		result = append(result, n)
+	}
+	return result, nil
+}
+
+// parseDate accepts the DATE and DATE-TIME forms of UNTIL
+func parseDate(s string) (time.Time, error) {
+	for _, layout := range []string{"20060102", "20060102T150405Z", "20060102T150405", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return civil(t), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
+
+// String renders the rule in canonical RRULE form (without the prefix)
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+	}
+	if len(r.ByMonth) > 0 {
+		parts = append(parts, "BYMONTH="+joinInts(r.ByMonth))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			days[i] = dayNames[wd.Day]
+			if wd.N != 0 {
+				days[i] = strconv.Itoa(wd.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+func joinInts(list []int) string {
+	items := make([]string, len(list))
+	for i, n := range list {
+		items[i] = strconv.Itoa(n)
+	}
+	return strings.Join(items, ",")
+}
+
+// FromDaysOfWeek converts ISO weekdays (1=Mon..7=Sun) into a weekly rule.
+// No days, or all seven, means daily.
+func FromDaysOfWeek(days []int) *Rule {
+	seen := make(map[int]bool)
+	var byDay []WeekdayNum
+	for _, d := range days {
+		if d < 1 || d > 7 || seen[d] {
+			continue
+		}
+		seen[d] = true
+		byDay = append(byDay, WeekdayNum{Day: time.Weekday(d % 7)})
+	}
+	if len(byDay) == 0 || len(byDay) == 7 {
+		return &Rule{Freq: Daily, Interval: 1}
+	}
+	sort.Slice(byDay, func(i, j int) bool { return isoDay(byDay[i].Day) < isoDay(byDay[j].Day) })
+	return &Rule{Freq: Weekly, Interval: 1, ByDay: byDay}
+}
+
+// DaysOfWeek returns the ISO weekdays for rules that are plain weekly or daily
+// schedules, so the legacy days_of_week column can mirror them
+func (r *Rule) DaysOfWeek() ([]int, bool) {
+	if r.Interval != 1 || r.Count > 0 || r.Until != nil || len(r.ByMonth) > 0 || len(r.ByMonthDay) > 0 {
+		return nil, false
+	}
+	switch r.Freq {
+	case Daily:
+		if len(r.ByDay) > 0 {
+			break
+		}
+		return []int{1, 2, 3, 4, 5, 6, 7}, true
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+	days := make([]int, 0, len(r.ByDay))
+	for _, wd := range r.ByDay {
+		if wd.N != 0 {
+			return nil, false
+		}
+		days = append(days, isoDay(wd.Day))
+	}
+	sort.Ints(days)
+	return days, true
+}
+
+func isoDay(d time.Weekday) int {
+	if d == time.Sunday {
+		return 7
+	}
+	return int(d)
+}
+
+// Dates expands the rule into calendar dates in [from, to]. dtstart is the
+// first possible occurrence and anchors INTERVAL and COUNT. Returned dates
+// are midnight UTC; only their year, month and day are meaningful.
+func (r *Rule) Dates(dtstart, from, to time.Time) []time.Time {
+	start, lo, hi := civil(dtstart), civil(from), civil(to)
+	if r.Until != nil && r.Until.Before(hi) {
+		hi = *r.Until
+	}
+
+	// Without COUNT earlier days need not be counted, so skip ahead
+	first := start
+	if r.Count == 0 && lo.After(first) {
+		first = lo
+	}
+
+	var result []time.Time
+	count := 0
+	for day, i := first, 0; !day.After(hi) && i < maxSpan; day, i = day.AddDate(0, 0, 1), i+1 {
+		if !r.matches(start, day) {
+			continue
+		}
+		count++
+		if r.Count > 0 && count > r.Count {
+			break
+		}
+		if !day.Before(lo) {
+			result = append(result, day)
+		}
+	}
+	return result
+}
+
+// Occurs reports whether date is one of the rule's occurrences
+func (r *Rule) Occurs(dtstart, date time.Time) bool {
+	return len(r.Dates(dtstart, date, date)) == 1
+}
+
+// matches checks one day against FREQ/INTERVAL and the BY* filters
+func (r *Rule) matches(start, day time.Time) bool {
+	if len(r.ByMonth) > 0 && !containsInt(r.ByMonth, int(day.Month())) {
+		return false
+	}
+
+	switch r.Freq {
+	case Daily:
+		if daysBetween(start, day)%r.Interval != 0 {
+			return false
+		}
+		if len(r.ByMonthDay) > 0 && !r.matchesMonthDay(day) {
+			return false
+		}
+		return len(r.ByDay) == 0 || r.matchesPlainWeekday(day)
+
+	case Weekly:
+		if weeksBetween(start, day)%r.Interval != 0 {
+			return false
+		}
+		if len(r.ByDay) == 0 {
+			return day.Weekday() == start.Weekday()
+		}
+		return r.matchesPlainWeekday(day)
+
+	case Monthly:
+		months := (day.Year()-start.Year())*12 + int(day.Month()) - int(start.Month())
+		if months%r.Interval != 0 {
+			return false
+		}
+		return r.matchesInMonth(start, day)
+
+	case Yearly:
+		if (day.Year()-start.Year())%r.Interval != 0 {
+			return false
+		}
+		if len(r.ByMonth) > 0 {
+			return r.matchesInMonth(start, day)
+		}
+		return r.matchesInYear(start, day)
+	}
+	return false
+}
+
+// matchesInMonth applies BYMONTHDAY / BYDAY inside a month, defaulting to
+// the day of month of dtstart
+func (r *Rule) matchesInMonth(start, day time.Time) bool {
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		return day.Day() == start.Day()
+	}
+	if len(r.ByMonthDay) > 0 && !r.matchesMonthDay(day) {
+		return false
+	}
+	if len(r.ByDay) == 0 {
+		return true
+	}
+
+	last := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	return r.matchesNthWeekday(day, (day.Day()-1)/7+1, -((last-day.Day())/7 + 1))
+}
+
+// matchesInYear applies BYMONTHDAY / BYDAY to a yearly rule without BYMONTH:
+// month days repeat in every month and ordinals count across the whole year
+// (20MO = the 20th Monday). Without either it is dtstart's month and day.
+func (r *Rule) matchesInYear(start, day time.Time) bool {
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		return day.Month() == start.Month() && day.Day() == start.Day()
+	}
+	if len(r.ByMonthDay) > 0 && !r.matchesMonthDay(day) {
+		return false
+	}
+	if len(r.ByDay) == 0 {
+		return true
+	}
+
+	last := time.Date(day.Year(), 12, 31, 0, 0, 0, 0, time.UTC).YearDay()
+	return r.matchesNthWeekday(day, (day.YearDay()-1)/7+1, -((last-day.YearDay())/7 + 1))
+}
+
+// matchesNthWeekday checks BYDAY given the day's ordinal among its weekday
+// in the period, counted from the start and from the end
+func (r *Rule) matchesNthWeekday(day time.Time, nth, nthFromEnd int) bool {
+	for _, wd := range r.ByDay {
+		if wd.Day != day.Weekday() {
+			continue
+		}
+		if wd.N == 0 || wd.N == nth || wd.N == nthFromEnd {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesMonthDay(day time.Time) bool {
+	last := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, md := range r.ByMonthDay {
+		if md == day.Day() || (md < 0 && last+md+1 == day.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesPlainWeekday(day time.Time) bool {
+	for _, wd := range r.ByDay {
+		if wd.Day == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+func civil(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(a, b time.Time) int {
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// weeksBetween counts Monday-started weeks from a's week to b's week
+func weeksBetween(a, b time.Time) int {
+	mondayA := a.AddDate(0, 0, -(isoDay(a.Weekday()) - 1))
+	mondayB := b.AddDate(0, 0, -(isoDay(b.Weekday()) - 1))
+	return daysBetween(mondayA, mondayB) / 7
+}
+
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package rrule
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestDates(t *testing.T) {
+	for _, tc := range []struct {
+		rule     string
+		dtstart  string
+		from, to string
+		want     string // comma-separated dates
+	}{
+		{"FREQ=DAILY;INTERVAL=3", "2024-01-01", "2024-01-05", "2024-01-15", "2024-01-07,2024-01-10,2024-01-13"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", "2024-01-01", "2024-01-01", "2024-01-31", "2024-01-01,2024-01-04,2024-01-15,2024-01-18,2024-01-29"},
+		{"FREQ=MONTHLY;BYDAY=1MO", "2024-01-01", "2024-01-01", "2024-04-30", "2024-01-01,2024-02-05,2024-03-04,2024-04-01"},
+		{"FREQ=MONTHLY;BYDAY=-1FR", "2024-01-01", "2024-01-01", "2024-03-31", "2024-01-26,2024-02-23,2024-03-29"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", "2024-01-01", "2024-01-01", "2024-04-30", "2024-01-31,2024-02-29,2024-03-31,2024-04-30"},
+		{"FREQ=MONTHLY;INTERVAL=2;BYMONTHDAY=15", "2024-01-10", "2024-01-01", "2024-06-30", "2024-01-15,2024-03-15,2024-05-15"},
+
+		// Yearly ordinals count across the year, whatever dtstart's month
+		{"FREQ=YEARLY;BYDAY=20MO", "2024-01-01", "2024-01-01", "2025-12-31", "2024-05-13,2025-05-19"},
+		{"FREQ=YEARLY;BYDAY=-1MO", "2024-01-01", "2024-01-01", "2025-12-31", "2024-12-30,2025-12-29"},
+		{"FREQ=YEARLY;BYDAY=1MO", "2024-06-15", "2025-01-01", "2025-12-31", "2025-01-06"},
+		{"FREQ=YEARLY;BYMONTHDAY=1", "2024-01-01", "2024-01-01", "2024-03-31", "2024-01-01,2024-02-01,2024-03-01"},
+		{"FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU", "2024-01-01", "2024-01-01", "2025-12-31", "2024-03-31,2025-03-30"},
+		{"FREQ=YEARLY;INTERVAL=2;BYMONTH=7", "2024-07-04", "2024-01-01", "2028-12-31", "2024-07-04,2026-07-04,2028-07-04"},
+		{"FREQ=YEARLY", "2024-02-29", "2024-01-01", "2028-12-31", "2024-02-29,2028-02-29"},
+
+		// COUNT is counted from dtstart, UNTIL is inclusive
+		{"FREQ=WEEKLY;COUNT=3", "2024-01-03", "2024-01-10", "2024-03-01", "2024-01-10,2024-01-17"},
+		{"FREQ=DAILY;UNTIL=20240105", "2024-01-01", "2024-01-03", "2024-01-31", "2024-01-03,2024-01-04,2024-01-05"},
+		{"FREQ=DAILY;UNTIL=20240105T120000Z", "2024-01-01", "2024-01-05", "2024-01-31", "2024-01-05"},
+		{"FREQ=MONTHLY;BYDAY=2TU;COUNT=2", "2024-01-01", "2024-01-01", "2024-12-31", "2024-01-09,2024-02-13"},
+		{"FREQ=DAILY", "2024-01-10", "2024-01-01", "2024-01-09", ""},
+	} {
+		r, err := Parse(tc.rule)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tc.rule, err)
+			continue
+		}
+		var got []string
+		for _, d := range r.Dates(date(tc.dtstart), date(tc.from), date(tc.to)) {
+			got = append(got, d.Format("2006-01-02"))
+		}
+		if strings.Join(got, ",") != tc.want {
+			t.Errorf("%s from %s, %s..%s = %v, want %s", tc.rule, tc.dtstart, tc.from, tc.to, got, tc.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string // want is the canonical form, or an error fragment
+		ok       bool
+	}{
+		{"RRULE:freq=weekly;byday=mo,we;wkst=MO", "FREQ=WEEKLY;BYDAY=MO,WE", true},
+		{"FREQ=MONTHLY;BYDAY=+1MO,-1FR", "FREQ=MONTHLY;BYDAY=1MO,-1FR", true},
+		{"FREQ=YEARLY;INTERVAL=2;BYMONTH=1,7;BYMONTHDAY=-1", "FREQ=YEARLY;INTERVAL=2;BYMONTH=1,7;BYMONTHDAY=-1", true},
+		{"FREQ=DAILY;UNTIL=20240105T120000Z", "FREQ=DAILY;UNTIL=20240105", true},
+		{"", "empty rule", false},
+		{"INTERVAL=2", "FREQ is required", false},
+		{"FREQ=HOURLY", "unsupported FREQ", false},
+		{"FREQ=DAILY;COUNT=2;UNTIL=20240105", "cannot be combined", false},
+		{"FREQ=WEEKLY;BYDAY=1MO", "only valid with MONTHLY or YEARLY", false},
+		{"FREQ=MONTHLY;BYMONTHDAY=0", "BYMONTHDAY", false},
+		{"FREQ=DAILY;BYHOUR=8", "unsupported rule part", false},
+		{"FREQ=WEEKLY;WKST=SU", "WKST", false},
+	} {
+		r, err := Parse(tc.in)
+		switch {
+		case tc.ok && err != nil:
+			t.Errorf("Parse(%q): %v", tc.in, err)
+		case tc.ok && r.String() != tc.want:
+			t.Errorf("Parse(%q).String() = %s, want %s", tc.in, r.String(), tc.want)
+		case !tc.ok && (err == nil || !strings.Contains(err.Error(), tc.want)):
+			t.Errorf("Parse(%q) error = %v, want %q", tc.in, err, tc.want)
+		}
+	}
+}
+
+// The days_of_week column converts to a rule and back, as migration 011 and
+// the reminder API do
+func TestDaysOfWeek(t *testing.T) {
+	for _, tc := range []struct {
+		days []int
+		rule string
+		back []int
+	}{
+		{nil, "FREQ=DAILY", []int{1, 2, 3, 4, 5, 6, 7}},
+		{[]int{5, 1, 3}, "FREQ=WEEKLY;BYDAY=MO,WE,FR", []int{1, 3, 5}},
+		{[]int{7, 6, 6}, "FREQ=WEEKLY;BYDAY=SA,SU", []int{6, 7}},
+		{[]int{1, 2, 3, 4, 5, 6, 7}, "FREQ=DAILY", []int{1, 2, 3, 4, 5, 6, 7}},
+		{[]int{0, 8}, "FREQ=DAILY", []int{1, 2, 3, 4, 5, 6, 7}},
+	} {
+		r := FromDaysOfWeek(tc.days)
+		if r.String() != tc.rule {
+			t.Errorf("FromDaysOfWeek(%v) = %s, want %s", tc.days, r, tc.rule)
+		}
+		if back, ok := r.DaysOfWeek(); !ok || !reflect.DeepEqual(back, tc.back) {
+			t.Errorf("%s DaysOfWeek() = %v, %v; want %v", tc.rule, back, ok, tc.back)
+		}
+	}
+
+	for _, rule := range []string{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", "FREQ=MONTHLY;BYDAY=1MO", "FREQ=WEEKLY;BYDAY=MO;COUNT=4"} {
+		r, err := Parse(rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if days, ok := r.DaysOfWeek(); ok {
+			t.Errorf("%s DaysOfWeek() = %v, want no weekday list", rule, days)
+		}
+	}
+}
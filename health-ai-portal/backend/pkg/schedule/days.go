@@ -0,0 +1,99 @@
+package schedule
+
+import (
+	"sort"
+	"time"
+)
+
+// Rule describes on which days a supplement is taken
+type Rule struct {
+	Frequency     string
+	DaysOfWeek    []int // ISO weekdays 1=Mon..7=Sun; empty means any day
+	TimesPerWeek  *int  // spread over the week when no days are given
+	CycleOnWeeks  *int
+	CycleOffWeeks *int
+	Anchor        time.Time // cycle start (or start of intake) for alternation
+}
+
+// IsDoseDay reports whether a dose is due on date
+func (r Rule) IsDoseDay(date time.Time) bool {
+	if r.Frequency == "as_needed" {
+		return false
+	}
+	if r.InOffWeeks(date) {
+		return false
+	}
+	if days := r.Days(); len(days) > 0 && !containsInt(days, ISOWeekday(date)) {
+		return false
+	}
+	if r.Frequency == "every_other_day" && !r.Anchor.IsZero() {
+		return daysBetween(r.Anchor, date)%2 == 0
+	}
+	return true
+}
+
+// Days returns the ISO weekdays doses fall on; empty means any day. Without
+// explicit days a weekly or n-times-a-week rule is spread evenly over the
+// week, starting on the anchor's weekday (Monday without one).
+func (r Rule) Days() []int {
+	if len(r.DaysOfWeek) > 0 {
+		return r.DaysOfWeek
+	}
+	n := 7
+	if r.TimesPerWeek != nil && *r.TimesPerWeek > 0 {
+		n = *r.TimesPerWeek
+	} else if r.Frequency == "weekly" {
+		n = 1
+	}
+	if n >= 7 {
+		return nil
+	}
+
+	start := 1
+	if !r.Anchor.IsZero() {
+		start = ISOWeekday(r.Anchor)
+	}
+	days := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		days = append(days, (start-1+i*7/n)%7+1)
+	}
+	sort.Ints(days)
+	return days
+}
+
+// InOffWeeks reports whether date falls into the off phase of an on/off cycle
+func (r Rule) InOffWeeks(date time.Time) bool {
+	if r.CycleOnWeeks == nil || r.CycleOffWeeks == nil || *r.CycleOffWeeks == 0 || r.Anchor.IsZero() {
+		return false
+	}
+	period := (*r.CycleOnWeeks + *r.CycleOffWeeks) * 7
+	days := daysBetween(r.Anchor, date)
+	if days < 0 {
+		return false
+	}
+	return days%period >= *r.CycleOnWeeks*7
+}
+
+// ISOWeekday returns 1 for Monday through 7 for Sunday
+func ISOWeekday(t time.Time) int {
+	wd := int(t.Weekday())
+	if wd == 0 {
+		return 7
+	}
+	return wd
+}
+
+func daysBetween(from, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
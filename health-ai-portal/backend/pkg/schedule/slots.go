@@ -0,0 +1,246 @@
+package schedule
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Named intake slots, listed in their default order
+const (
+	SlotWake       = "wake"
+	SlotBreakfast  = "breakfast"
+	SlotPreWorkout = "pre_workout"
+	SlotLunch      = "lunch"
+	SlotEvening    = "evening"
+	SlotBedtime    = "bedtime"
+)
+
+// Slot is a named point of the day mapped to a clock time ("HH:MM")
+type Slot struct {
+	Name      string `json:"slot"`
+	Label     string `json:"label"`
+	ClockTime string `json:"clock_time"`
+	SortOrder int    `json:"sort_order"`
+}
+
+// DefaultSlots are used when a user has not configured their own times
+var DefaultSlots = []Slot{
+	{Name: SlotWake, Label: "Подъём", ClockTime: "05:00", SortOrder: 1},
+	{Name: SlotBreakfast, Label: "Завтрак", ClockTime: "07:30", SortOrder: 2},
+	{Name: SlotPreWorkout, Label: "Перед тренировкой", ClockTime: "11:00", SortOrder: 3},
+	{Name: SlotLunch, Label: "Обед", ClockTime: "13:00", SortOrder: 4},
+	{Name: SlotEvening, Label: "Вечер", ClockTime: "19:00", SortOrder: 5},
+	{Name: SlotBedtime, Label: "Перед сном", ClockTime: "22:00", SortOrder: 6},
+}
+
+// IsValidSlot reports whether name is one of the known slots
+func IsValidSlot(name string) bool {
+	for _, s := range DefaultSlots {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Merge overlays user-configured slots on the defaults and returns them
+// ordered by clock time (ties broken by default order).
+func Merge(custom []Slot) []Slot {
+	byName := make(map[string]Slot, len(DefaultSlots))
+	for _, s := range DefaultSlots {
+		byName[s.Name] = s
+	}
+	for _, c := range custom {
+		base, ok := byName[c.Name]
+		if !ok {
+			continue
+		}
+		if c.ClockTime != "" {
+			base.ClockTime = c.ClockTime
+		}
+		if c.Label != "" {
+			base.Label = c.Label
+		}
+		byName[c.Name] = base
+	}
+
+	slots := make([]Slot, 0, len(byName))
+	for _, s := range byName {
+		slots = append(slots, s)
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		if slots[i].ClockTime != slots[j].ClockTime {
+			return slots[i].ClockTime < slots[j].ClockTime
+		}
+		return slots[i].SortOrder < slots[j].SortOrder
+	})
+	return slots
+}
+
+// At returns the slot's clock time on date in loc
+func (s Slot) At(date time.Time, loc *time.Location) time.Time {
+	h, m := parseClock(s.ClockTime)
+	y, mo, d := date.Date()
+	return time.Date(y, mo, d, h, m, 0, 0, loc)
+}
+
+var (
+	clockPattern     = regexp.MustCompile(`^(\d{1,2})[:.](\d{2})`)
+	separatorPattern = regexp.MustCompile(`[,;/+]`)
+)
+
+// NormalizeClock turns "7:30", "07.30" or "07:30:00" into "07:30"
+func NormalizeClock(s string) (string, bool) {
+	m := clockPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return "", false
+	}
+	h, _ := strconv.Atoi(m[1])
+	min, _ := strconv.Atoi(m[2])
+	if h > 23 || min > 59 {
+		return "", false
+	}
+	return twoDigits(h) + ":" + twoDigits(min), true
+}
+
+func parseClock(s string) (int, int) {
+	norm, ok := NormalizeClock(s)
+	if !ok {
+		return 0, 0
+	}
+	h, _ := strconv.Atoi(norm[:2])
+	m, _ := strconv.Atoi(norm[3:])
+	return h, m
+}
+
+func twoDigits(n int) string {
+	if n < 10 {
+		return "0" + strconv.Itoa(n)
+	}
+	return strconv.Itoa(n)
+}
+
+// Weekday names (Russian short/long and English) to ISO weekday 1=Mon..7=Sun
+var weekdayMappings = map[string]int{
+	"пн": 1, "понедельник": 1, "mon": 1, "monday": 1,
+	"вт": 2, "вторник": 2, "tue": 2, "tuesday": 2,
+	"ср": 3, "среда": 3, "wed": 3, "wednesday": 3,
+	"чт": 4, "четверг": 4, "thu": 4, "thursday": 4,
+	"пт": 5, "пятница": 5, "fri": 5, "friday": 5,
+	"сб": 6, "суббота": 6, "sat": 6, "saturday": 6,
+	"вс": 7, "воскресенье": 7, "sun": 7, "sunday": 7,
+}
+
+// Slot keyword mappings for legacy free-text time_of_day values
+var slotKeywords = []struct {
+	keyword string
+	slot    string
+}{
+	{"перед тренировкой", SlotPreWorkout},
+	{"pre-workout", SlotPreWorkout},
+	{"pre_workout", SlotPreWorkout},
+	{"подъём", SlotWake},
+	{"подъем", SlotWake},
+	{"wake", SlotWake},
+	{"натощак", SlotWake},
+	{"завтрак", SlotBreakfast},
+	{"утро", SlotBreakfast},
+	{"breakfast", SlotBreakfast},
+	{"morning", SlotBreakfast},
+	{"обед", SlotLunch},
+	{"lunch", SlotLunch},
+	{"вечер", SlotEvening},
+	{"evening", SlotEvening},
+	{"перед сном", SlotBedtime},
+	{"на ночь", SlotBedtime},
+	{"bedtime", SlotBedtime},
+}
+
+// Category fallbacks when time_of_day says nothing useful
+var categorySlots = map[string]string{
+	"morning": SlotBreakfast,
+	"day":     SlotLunch,
+	"evening": SlotBedtime,
+}
+
+// NormalizeTimeOfDay maps a legacy time_of_day string ("07:30", "Суббота",
+// "2×/неделю (Пн+Чт)", "Перед тренировкой") to slots and ISO weekdays using
+// the given slot clock times. Empty results mean the text had no hint.
+func NormalizeTimeOfDay(text string, category string, slots []Slot) ([]string, []int) {
+	lower := strings.ToLower(strings.TrimSpace(text))
+	var names []string
+
+	for _, part := range separatorPattern.Split(lower, -1) {
+		if clock, ok := NormalizeClock(part); ok {
+			names = appendUnique(names, NearestSlot(clock, slots))
+		}
+	}
+	if len(names) == 0 {
+		// "утро и вечер" names two slots; "утром натощак" is one, on waking
+		fasting := strings.Contains(lower, "натощак")
+		for _, k := range slotKeywords {
+			if fasting && k.slot == SlotBreakfast {
+				continue
+			}
+			if strings.Contains(lower, k.keyword) {
+				names = appendUnique(names, k.slot)
+			}
+		}
+	}
+	if len(names) == 0 {
+		if slot, ok := categorySlots[category]; ok {
+			names = append(names, slot)
+		}
+	}
+
+	var days []int
+	for _, token := range strings.FieldsFunc(lower, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'а' && r <= 'я' || r == 'ё')
+	}) {
+		if d, ok := weekdayMappings[token]; ok {
+			days = appendUniqueInt(days, d)
+		}
+	}
+	sort.Ints(days)
+
+	return names, days
+}
+
+// NearestSlot returns the slot whose clock time is closest to clock
+func NearestSlot(clock string, slots []Slot) string {
+	h, m := parseClock(clock)
+	target := h*60 + m
+	best, bestDiff := SlotBreakfast, 24*60
+	for _, s := range slots {
+		sh, sm := parseClock(s.ClockTime)
+		diff := sh*60 + sm - target
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff < bestDiff {
+			best, bestDiff = s.Name, diff
+		}
+	}
+	return best
+}
+
+func appendUnique(list []string, v string) []string {
+	for _, x := range list {
+		if x == v {
+			return list
+		}
+	}
+	return append(list, v)
+}
+
+func appendUniqueInt(list []int, v int) []int {
+	for _, x := range list {
+		if x == v {
+			return list
+		}
+	}
+	return append(list, v)
+}
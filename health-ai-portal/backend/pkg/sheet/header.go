@@ -0,0 +1,151 @@
+package sheet
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Fields maps a field name to the header captions that name it, lower case.
+// A caption also matches a header that continues it after a non-letter:
+// "доза" matches "Доза (мг)" and "Dose / Notes" is matched by "dose".
+type Fields map[string][]string
+
+// Match returns the field a header cell names, or "". When captions of
+// several fields match, the longest caption wins.
+func (f Fields) Match(cell string) string {
+	cell = NormalizeHeader(cell)
+	if cell == "" {
+		return ""
+	}
+	best, bestLen := "", 0
+	for field, captions := range f {
+		for _, c := range captions {
+			if len(c) <= bestLen || !captionMatches(cell, c) {
+				continue
+			}
+			best, bestLen = field, len(c)
+		}
+	}
+	return best
+}
+
+func captionMatches(cell, caption string) bool {
+	if !strings.HasPrefix(cell, caption) {
+		return false
+	}
+	rest := cell[len(caption):]
+	if rest == "" {
+		return true
+	}
+	r := []rune(rest)[0]
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// NormalizeHeader lower-cases a header cell, folds ё, collapses spaces and
+// drops trailing punctuation such as "Дата:" or "Маркер*"
+func NormalizeHeader(s string) string {
+	s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+	s = strings.ReplaceAll(s, "ё", "е")
+	return strings.TrimRight(s, " :*.")
+}
+
+// Header is a detected header row: its 0-based index and the column of
+// every field found in it
+type Header struct {
+	Row     int
+	Columns map[string]int
+}
+
+// DetectHeader finds the header among the first limit rows: the row naming
+// the most fields, at least two, and the first one on a tie. A field named
+// by several columns keeps the first.
+func DetectHeader(rows [][]string, fields Fields, limit int) (Header, bool) {
+	best := Header{Row: -1}
+	for i := 0; i < len(rows) && i < limit; i++ {
+		columns := HeaderColumns(rows[i], fields)
+		if len(columns) >= 2 && len(columns) > len(best.Columns) {
+			best = Header{Row: i, Columns: columns}
+		}
+	}
+	return best, best.Row >= 0
+}
+
+// HeaderColumns maps the fields named by the cells of a header row
+func HeaderColumns(row []string, fields Fields) map[string]int {
+	columns := make(map[string]int)
+	for c, cell := range row {
+		if field := fields.Match(cell); field != "" {
+			if _, seen := columns[field]; !seen {
+				columns[field] = c
+			}
+		}
+	}
+	return columns
+}
+
+// ResolveColumn finds a column named in a mapping: a header caption
+// (case-insensitive) or, failing that, a column letter such as "C"
+func ResolveColumn(header []string, ref string) (int, bool) {
+	want := NormalizeHeader(ref)
+	if want == "" {
+		return 0, false
+	}
+	for c, cell := range header {
+		if NormalizeHeader(cell) == want {
+			return c, true
+		}
+	}
+	return ColumnIndex(ref)
+}
+
+// Section returns the title of a section row such as "═══ LIPID BLOCK ═══",
+// "Гормоны:" or "LIPID BLOCK", with the decoration stripped. The row holds a
+// single value in its first cell that is decorated, ends with a colon or is
+// several words in capitals; a lone name such as "Витамин D3" is data.
+func Section(row []string) (string, bool) {
+	raw := ""
+	for c, cell := range row {
+		if strings.TrimSpace(cell) == "" {
+			continue
+		}
+		if c != 0 {
+			return "", false
+		}
+		raw = strings.TrimSpace(cell)
+	}
+	text := strings.TrimFunc(raw, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune("═─=-*#_|", r)
+	})
+	if text == "" {
+		return "", false
+	}
+	if text != raw {
+		return text, true
+	}
+	if title, ok := strings.CutSuffix(text, ":"); ok && strings.TrimSpace(title) != "" {
+		return strings.TrimSpace(title), true
+	}
+	return text, strings.Contains(text, " ") && capitals(text)
+}
+
+// capitals reports whether text has letters and none of them are lower case
+func capitals(text string) bool {
+	letters := false
+	for _, r := range text {
+		if unicode.IsLower(r) {
+			return false
+		}
+		letters = letters || unicode.IsLetter(r)
+	}
+	return letters
+}
+
+// Empty reports whether every cell of the row is blank
+func Empty(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,121 @@
+// Package sheet reads tabular data from CSV and XLSX files into plain text
+// cells and finds the header row of hand-made spreadsheets.
+package sheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Sheet is one worksheet as text. Rows keep their position in the file, so
+// Rows[i] is spreadsheet row i+1; rows may have different lengths.
+type Sheet struct {
+	Name string
+	Rows [][]string
+}
+
+// Cell returns the trimmed cell of row r at column c (both 0-based), or ""
+func (s Sheet) Cell(r, c int) string {
+	if r < 0 || r >= len(s.Rows) || c < 0 || c >= len(s.Rows[r]) {
+		return ""
+	}
+	return strings.TrimSpace(s.Rows[r][c])
+}
+
+// Read detects the format from the content: XLSX files are zip archives,
+// anything else is read as CSV.
+func Read(data []byte) ([]Sheet, error) {
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return ReadXLSX(data)
+	}
+	s, err := ReadCSV(data)
+	if err != nil {
+		return nil, err
+	}
+	return []Sheet{s}, nil
+}
+
+// ReadCSV reads comma, semicolon or tab separated text. Text that is not
+// UTF-8 is taken to be Windows-1251, as Excel saves Russian CSV files.
+func ReadCSV(data []byte) (Sheet, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(data) {
+		data = decodeWindows1251(data)
+	}
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = detectDelimiter(data)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	// Blank lines are skipped by the reader but are rows in a spreadsheet;
+	// they are kept so row numbers match what the user sees
+	var rows [][]string
+	nextLine := 1
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Sheet{}, fmt.Errorf("invalid CSV: %v", err)
+		}
+		line, _ := r.FieldPos(0)
+		for ; nextLine < line; nextLine++ {
+			rows = append(rows, nil)
+		}
+		last, _ := r.FieldPos(len(record) - 1)
+		nextLine = last + strings.Count(record[len(record)-1], "\n") + 1
+		rows = append(rows, record)
+	}
+	if len(rows) == 0 {
+		return Sheet{}, fmt.Errorf("CSV file is empty")
+	}
+	return Sheet{Name: "CSV", Rows: rows}, nil
+}
+
+// detectDelimiter picks the separator seen most often in the first lines
+func detectDelimiter(data []byte) rune {
+	lines := bytes.SplitN(data, []byte("\n"), 6)
+	if len(lines) > 5 {
+		lines = lines[:5]
+	}
+	best, bestCount := ',', 0
+	for _, d := range []rune{';', '\t', ','} {
+		count := 0
+		for _, line := range lines {
+			count += bytes.Count(line, []byte(string(d)))
+		}
+		if count > bestCount {
+			best, bestCount = d, count
+		}
+	}
+	return best
+}
+
+// windows1251 maps bytes 0x80-0xBF; 0xC0-0xFF are А-я in order
+var windows1251 = [64]rune{
+	'Ђ', 'Ѓ', '‚', 'ѓ', '„', '…', '†', '‡', '€', '‰', 'Љ', '‹', 'Њ', 'Ќ', 'Ћ', 'Џ',
+	'ђ', '‘', '’', '“', '”', '•', '–', '—', '\ufffd', '™', 'љ', '›', 'њ', 'ќ', 'ћ', 'џ',
+	'\u00a0', 'Ў', 'ў', 'Ј', '¤', 'Ґ', '¦', '§', 'Ё', '©', 'Є', '«', '¬', '\u00ad', '®', 'Ї',
+	'°', '±', 'І', 'і', 'ґ', 'µ', '¶', '·', 'ё', '№', 'є', '»', 'ј', 'Ѕ', 'ѕ', 'ї',
+}
+
+func decodeWindows1251(data []byte) []byte {
+	var b bytes.Buffer
+	b.Grow(len(data) * 2)
+	for _, c := range data {
+		switch {
+		case c < 0x80:
+			b.WriteByte(c)
+		case c < 0xC0:
+			b.WriteRune(windows1251[c-0x80])
+		default:
+			b.WriteRune(rune(c-0xC0) + 'А')
+		}
+	}
+	return b.Bytes()
+}
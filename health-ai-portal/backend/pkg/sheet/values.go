@@ -0,0 +1,112 @@
+package sheet
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseNumber reads numbers as Russian and English spreadsheets write them:
+// "5,2", "5.2", "1 234,5", "1,234.5" and "1.234,5". A comma alone is the
+// decimal separator; with both, the last one is.
+func ParseNumber(s string) (float64, bool) {
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', ' ', ' ', '\'':
+			return -1
+		case '−':
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(s))
+	if s == "" {
+		return 0, false
+	}
+
+	comma, dot := strings.LastIndexByte(s, ','), strings.LastIndexByte(s, '.')
+	switch {
+	case comma >= 0 && dot >= 0 && comma > dot:
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.Replace(s, ",", ".", 1)
+	case comma >= 0 && dot >= 0:
+		s = strings.ReplaceAll(s, ",", "")
+	case strings.Count(s, ",") == 1:
+		s = strings.Replace(s, ",", ".", 1)
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil
+}
+
+// months are matched by their first three letters, which covers the
+// nominative ("март"), genitive ("марта") and abbreviated ("мар.") forms
+var months = map[string]time.Month{
+	"янв": time.January, "фев": time.February, "мар": time.March, "апр": time.April,
+	"май": time.May, "мая": time.May, "июн": time.June, "июл": time.July,
+	"авг": time.August, "сен": time.September, "окт": time.October, "ноя": time.November,
+	"дек": time.December,
+	"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+	"may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+	"sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+}
+
+var (
+	isoDatePattern     = regexp.MustCompile(`^(\d{4})-(\d{1,2})-(\d{1,2})(?:[ T].*)?$`)
+	numericDatePattern = regexp.MustCompile(`^(\d{1,2})[./-](\d{1,2})[./-](\d{2}|\d{4})(?:\s.*)?$`)
+	wordDatePattern    = regexp.MustCompile(`^(?:(\d{1,2})\s+)?(\p{L}+)\.?\s+(\d{4})(?:\s*г(?:ода?)?\.?)?$`)
+)
+
+// ParseDate reads a calendar date: "2024-03-15", "15.03.2024", "15.03.24",
+// "15/03/2024", "15 марта 2024 г." or "март 2024" (the first of the month).
+// Day-first order is assumed for numeric dates; a time after the date is
+// ignored.
+func ParseDate(s string) (time.Time, bool) {
+	s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+	if s == "" {
+		return time.Time{}, false
+	}
+
+	var year, day int
+	var month time.Month
+	if m := isoDatePattern.FindStringSubmatch(s); m != nil {
+		year, _ = strconv.Atoi(m[1])
+		mm, _ := strconv.Atoi(m[2])
+		month = time.Month(mm)
+		day, _ = strconv.Atoi(m[3])
+	} else if m := numericDatePattern.FindStringSubmatch(s); m != nil {
+		day, _ = strconv.Atoi(m[1])
+		mm, _ := strconv.Atoi(m[2])
+		month = time.Month(mm)
+		year, _ = strconv.Atoi(m[3])
+		if len(m[3]) == 2 {
+			year += 2000
+		}
+	} else if m := wordDatePattern.FindStringSubmatch(s); m != nil {
+		name := []rune(m[2])
+		if len(name) < 3 {
+			return time.Time{}, false
+		}
+		var ok bool
+		if month, ok = months[string(name[:3])]; !ok {
+			return time.Time{}, false
+		}
+		day = 1
+		if m[1] != "" {
+			day, _ = strconv.Atoi(m[1])
+		}
+		year, _ = strconv.Atoi(m[3])
+	} else {
+		return time.Time{}, false
+	}
+
+	if month < time.January || month > time.December || day < 1 || year < 1900 || year > 2200 {
+		return time.Time{}, false
+	}
+	t := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if t.Day() != day {
+		// 31.02 and the like
+		return time.Time{}, false
+	}
+	return t, true
+}
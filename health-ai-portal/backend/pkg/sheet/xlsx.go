@@ -0,0 +1,344 @@
+package sheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPartSize bounds one decompressed part of a workbook
+const maxPartSize = 64 << 20
+
+type xlsxWorkbook struct {
+	Properties struct {
+		Date1904 bool `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText is rich or plain text: shared strings and inline strings
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	b.WriteString(t.T)
+	for _, r := range t.Runs {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type xlsxStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R      string   `xml:"r,attr"`
+			T      string   `xml:"t,attr"`
+			S      int      `xml:"s,attr"`
+			V      string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// numberKind is how a number format displays a cell
+type numberKind int
+
+const (
+	kindNumber numberKind = iota
+	kindDate
+	kindTime
+	kindDateTime
+)
+
+// ReadXLSX reads every worksheet of an Office Open XML workbook. Dates and
+// times are returned as "2006-01-02", "15:04" or "2006-01-02 15:04"; numbers
+// in their shortest form with a decimal point.
+func ReadXLSX(data []byte) ([]Sheet, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid XLSX file: %v", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[strings.TrimPrefix(f.Name, "/")] = f
+	}
+
+	var wb xlsxWorkbook
+	if err := readXML(files, "xl/workbook.xml", &wb); err != nil {
+		return nil, fmt.Errorf("invalid XLSX file: %v", err)
+	}
+	var rels xlsxRelationships
+	if err := readXML(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, fmt.Errorf("invalid XLSX file: %v", err)
+	}
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, r := range rels.Relationships {
+		if strings.HasPrefix(r.Target, "/") {
+			targets[r.ID] = strings.TrimPrefix(r.Target, "/")
+		} else {
+			targets[r.ID] = path.Join("xl", r.Target)
+		}
+	}
+
+	var shared []string
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		var sst struct {
+			Items []xlsxText `xml:"si"`
+		}
+		if err := readXML(files, "xl/sharedStrings.xml", &sst); err != nil {
+			return nil, fmt.Errorf("invalid XLSX file: %v", err)
+		}
+		shared = make([]string, len(sst.Items))
+		for i, si := range sst.Items {
+			shared[i] = si.String()
+		}
+	}
+
+	var kinds []numberKind
+	if _, ok := files["xl/styles.xml"]; ok {
+		var styles xlsxStyles
+		if err := readXML(files, "xl/styles.xml", &styles); err != nil {
+			return nil, fmt.Errorf("invalid XLSX file: %v", err)
+		}
+		kinds = styleKinds(styles)
+	}
+
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if wb.Properties.Date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	sheets := make([]Sheet, 0, len(wb.Sheets))
+	for _, s := range wb.Sheets {
+		var ws xlsxWorksheet
+		if err := readXML(files, targets[s.RID], &ws); err != nil {
+			return nil, fmt.Errorf("sheet %q: %v", s.Name, err)
+		}
+
+		sheet := Sheet{Name: s.Name}
+		for _, row := range ws.Rows {
+			r := row.R
+			if r <= 0 {
+				r = len(sheet.Rows) + 1
+			}
+			if r > len(sheet.Rows) {
+				sheet.Rows = append(sheet.Rows, make([][]string, r-len(sheet.Rows))...)
+			}
+			var cells []string
+			for j, c := range row.Cells {
+				col := j
+				if c.R != "" {
+					if n, ok := columnIndex(c.R); ok {
+						col = n
+					}
+				}
+				for len(cells) <= col {
+					cells = append(cells, "")
+				}
+
+				switch c.T {
+				case "s":
+					if n, err := strconv.Atoi(c.V); err == nil && n >= 0 && n < len(shared) {
+						cells[col] = shared[n]
+					}
+				case "inlineStr":
+					cells[col] = c.Inline.String()
+				case "b":
+					if c.V == "1" {
+						cells[col] = "TRUE"
+					} else {
+						cells[col] = "FALSE"
+					}
+				case "str", "e":
+					cells[col] = c.V
+				default:
+					kind := kindNumber
+					if c.S >= 0 && c.S < len(kinds) {
+						kind = kinds[c.S]
+					}
+					cells[col] = formatCell(c.V, kind, epoch)
+				}
+			}
+			sheet.Rows[r-1] = cells
+		}
+		sheets = append(sheets, sheet)
+	}
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("XLSX file has no sheets")
+	}
+	return sheets, nil
+}
+
+func readXML(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("missing %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(io.LimitReader(rc, maxPartSize)).Decode(v)
+}
+
+// columnIndex turns a cell reference such as "AB12" into a 0-based column
+func columnIndex(ref string) (int, bool) {
+	n := 0
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		n = n*26 + int(ref[i]-'A'+1)
+	}
+	if i == 0 {
+		return 0, false
+	}
+	return n - 1, true
+}
+
+// ColumnIndex reads a column letter such as "C" or "AB" as a 0-based index
+func ColumnIndex(letters string) (int, bool) {
+	letters = strings.ToUpper(strings.TrimSpace(letters))
+	if letters == "" || len(letters) > 3 {
+		return 0, false
+	}
+	for _, c := range letters {
+		if c < 'A' || c > 'Z' {
+			return 0, false
+		}
+	}
+	return columnIndex(letters)
+}
+
+// styleKinds classifies every cell style by its number format. Built-in
+// formats 14-17 are dates, 18-21 and 45-47 times and 22 date and time.
+func styleKinds(styles xlsxStyles) []numberKind {
+	custom := make(map[int]string, len(styles.NumFmts))
+	for _, f := range styles.NumFmts {
+		custom[f.ID] = f.Code
+	}
+	kinds := make([]numberKind, len(styles.CellXfs))
+	for i, xf := range styles.CellXfs {
+		id := xf.NumFmtID
+		switch {
+		case id >= 14 && id <= 17:
+			kinds[i] = kindDate
+		case id >= 18 && id <= 21, id >= 45 && id <= 47:
+			kinds[i] = kindTime
+		case id == 22:
+			kinds[i] = kindDateTime
+		default:
+			if code, ok := custom[id]; ok {
+				kinds[i] = formatKind(code)
+			}
+		}
+	}
+	return kinds
+}
+
+// formatKind classifies a custom format code, ignoring quoted text,
+// escaped characters and bracketed colours or locales
+func formatKind(code string) numberKind {
+	var plain strings.Builder
+	inQuote, inBracket := false, false
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case inQuote:
+			inQuote = c != '"'
+		case inBracket:
+			inBracket = c != ']'
+		case c == '"':
+			inQuote = true
+		case c == '[':
+			inBracket = true
+		case c == '\\' || c == '_' || c == '*':
+			i++
+		default:
+			plain.WriteByte(c)
+		}
+	}
+	s := strings.ToLower(plain.String())
+	date := strings.ContainsAny(s, "dy") || strings.Contains(s, "mmm")
+	clock := strings.ContainsAny(s, "hs")
+	switch {
+	case date && clock:
+		return kindDateTime
+	case date:
+		return kindDate
+	case clock:
+		return kindTime
+	}
+	// A lone "mm" or "m" without a clock is a month
+	if strings.Contains(s, "m") && !strings.ContainsAny(s, "0#?") {
+		return kindDate
+	}
+	return kindNumber
+}
+
+func formatCell(v string, kind numberKind, epoch time.Time) string {
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return v
+	}
+	if kind != kindNumber && f >= 0 {
+		// Serial days since the epoch, rounded to the second
+		seconds := int64(math.Round(f * 86400))
+		t := epoch.Add(time.Duration(seconds) * time.Second)
+		switch kind {
+		case kindDate:
+			return t.Format("2006-01-02")
+		case kindTime:
+			return t.Format("15:04")
+		default:
+			return t.Format("2006-01-02 15:04")
+		}
+	}
+	// Fifteen significant digits drop the binary noise Excel stores
+	f, _ = strconv.ParseFloat(strconv.FormatFloat(f, 'g', 15, 64), 64)
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// ColumnName is the column letter of a 0-based index: 0 is "A", 27 is "AB"
+func ColumnName(c int) string {
+	name := ""
+	for c++; c > 0; c = (c - 1) / 26 {
+		name = string(rune('A'+(c-1)%26)) + name
+	}
+	return name
+}
@@ -0,0 +1,124 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeBotAPI answers Bot API calls with result, or with an error envelope
+// when result is nil, and records the last method and params
+func fakeBotAPI(t *testing.T, result interface{}) (*httptest.Server, *string, *map[string]interface{}) {
+	t.Helper()
+	var method string
+	params := map[string]interface{}{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/bottoken/") {
+			t.Errorf("path %s does not carry the token", r.URL.Path)
+		}
+		method = strings.TrimPrefix(r.URL.Path, "/bottoken/")
+		params = map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&params)
+
+		w.Header().Set("Content-Type", "application/json")
+		if result == nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "error_code": 400, "description": "Bad Request: chat not found"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &method, &params
+}
+
+func TestSendMessage(t *testing.T) {
+	srv, method, params := fakeBotAPI(t, Message{MessageID: 7, Chat: Chat{ID: 42}, Text: "hi"})
+	c := NewClient("token", srv.URL+"/")
+
+	markup := &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{{{Text: "ok", CallbackData: "taken:1"}}}}
+	msg, err := c.SendMessage(context.Background(), 42, "hi", markup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.MessageID != 7 {
+		t.Errorf("message id = %d, want 7", msg.MessageID)
+	}
+	if *method != "sendMessage" {
+		t.Errorf("method = %s, want sendMessage", *method)
+	}
+	if (*params)["chat_id"] != float64(42) || (*params)["text"] != "hi" {
+		t.Errorf("params = %v", *params)
+	}
+	if _, ok := (*params)["reply_markup"]; !ok {
+		t.Error("reply_markup not sent")
+	}
+}
+
+func TestEditMessageReplyMarkup(t *testing.T) {
+	srv, method, params := fakeBotAPI(t, true)
+	c := NewClient("token", srv.URL)
+
+	markup := &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{{{Text: "done", CallbackData: "done:1"}}}}
+	if err := c.EditMessageReplyMarkup(context.Background(), 42, 7, markup); err != nil {
+		t.Fatal(err)
+	}
+	if *method != "editMessageReplyMarkup" || (*params)["chat_id"] != float64(42) || (*params)["message_id"] != float64(7) {
+		t.Errorf("%s %v", *method, *params)
+	}
+	if _, ok := (*params)["reply_markup"]; !ok {
+		t.Error("reply_markup not sent")
+	}
+
+	if err := c.EditMessageReplyMarkup(context.Background(), 42, 7, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := (*params)["reply_markup"]; ok {
+		t.Error("reply_markup sent for nil markup")
+	}
+}
+
+func TestGetUpdates(t *testing.T) {
+	srv, method, params := fakeBotAPI(t, []Update{
+		{UpdateID: 10, Message: &Message{Chat: Chat{ID: 42}, Text: "/today"}},
+		{UpdateID: 11, CallbackQuery: &CallbackQuery{ID: "q", Data: "skip:3"}},
+	})
+	c := NewClient("token", srv.URL)
+
+	updates, err := c.GetUpdates(context.Background(), 10, 30*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 2 || updates[0].Message.Text != "/today" || updates[1].CallbackQuery.Data != "skip:3" {
+		t.Errorf("updates = %+v", updates)
+	}
+	if *method != "getUpdates" || (*params)["offset"] != float64(10) || (*params)["timeout"] != float64(30) {
+		t.Errorf("%s %v", *method, *params)
+	}
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	srv, _, _ := fakeBotAPI(t, nil)
+	c := NewClient("token", srv.URL)
+
+	err := c.AnswerCallbackQuery(context.Background(), "q", "done")
+	if err == nil || !strings.Contains(err.Error(), "chat not found") {
+		t.Errorf("err = %v, want the API description", err)
+	}
+}
+
+func TestInvalidResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	c := NewClient("token", srv.URL)
+
+	err := c.EditMessageText(context.Background(), 42, 1, "x")
+	if err == nil || !strings.Contains(err.Error(), "HTTP 502") {
+		t.Errorf("err = %v, want an invalid response error", err)
+	}
+}
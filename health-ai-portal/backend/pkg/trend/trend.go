@@ -0,0 +1,270 @@
+// Package trend analyses a marker's results over time: how fast it moves
+// (least-squares and Theil–Sen slopes), when it will cross a reference
+// bound at that pace, whether the latest change exceeds the reference
+// change value, and which results do not fit the rest.
+package trend
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// daysPerMonth turns a slope per day into one per month
+	daysPerMonth = 365.25 / 12
+	// MinSlopePoints is the fewest results a slope is fitted to
+	MinSlopePoints = 3
+	// MinOutlierPoints is the fewest results outliers are looked for in
+	MinOutlierPoints = 5
+	// outlierScore is the modified z-score above which a result is an
+	// outlier (Iglewicz and Hoaglin)
+	outlierScore = 3.5
+	// horizonDays bounds projections; further out the line means little
+	horizonDays = 2 * 365
+	// zSignificant is the two-sided z for 95% confidence
+	zSignificant = 1.96
+)
+
+// Projection directions
+const (
+	Leaving  = "leaving"  // heading out of the reference range
+	Entering = "entering" // heading back into it
+)
+
+// Point is one result
+type Point struct {
+	Date  time.Time
+	Value float64
+}
+
+// Range is the reference range; either bound may be missing
+type Range struct {
+	Min *float64
+	Max *float64
+}
+
+// Slope is the pace of change in units per month
+type Slope struct {
+	Linear    float64 // least squares
+	TheilSen  float64 // median of pairwise slopes, robust to single bad results
+	Intercept float64 // Theil–Sen value at the first result's date
+	R2        float64 // fit of the least-squares line
+}
+
+// Projection is when the Theil–Sen line crosses a reference bound
+type Projection struct {
+	Bound     string // min or max
+	Value     float64
+	Date      time.Time
+	Days      int // from the latest result
+	Direction string
+}
+
+// Change compares the latest result with the one before
+type Change struct {
+	From        float64
+	To          float64
+	Percent     float64
+	RCV         *float64 // reference change value in percent; nil without variation data
+	Significant bool
+}
+
+// Outlier is a result far from the line through the others
+type Outlier struct {
+	Date  time.Time
+	Value float64
+	Score float64 // modified z-score of its residual
+}
+
+// Analysis is everything known about a series
+type Analysis struct {
+	Slope      *Slope
+	Projection *Projection
+	Change     *Change
+	Outliers   []Outlier
+}
+
+// Analyze looks at points, which need not be sorted. cv is the marker's
+// variation for the reference change value; nil skips the significance
+// test.
+func Analyze(points []Point, ref Range, cv *Variation) Analysis {
+	points = append([]Point(nil), points...)
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Date.Before(points[j].Date) })
+
+	var a Analysis
+	if len(points) >= 2 {
+		a.Change = change(points[len(points)-2], points[len(points)-1], cv)
+	}
+	if len(points) < MinSlopePoints {
+		return a
+	}
+
+	x, y := coordinates(points)
+	slope, ok := fit(x, y)
+	if !ok {
+		return a
+	}
+	a.Slope = &slope
+	a.Projection = project(points[0].Date, x[len(x)-1], slope, ref)
+	if len(points) >= MinOutlierPoints {
+		a.Outliers = outliers(points, x, slope)
+	}
+	return a
+}
+
+// coordinates are days since the first result and the values
+func coordinates(points []Point) ([]float64, []float64) {
+	x := make([]float64, len(points))
+	y := make([]float64, len(points))
+	for i, p := range points {
+		x[i] = p.Date.Sub(points[0].Date).Hours() / 24
+		y[i] = p.Value
+	}
+	return x, y
+}
+
+// fit returns both slopes per month; false when all results share a date
+func fit(x, y []float64) (Slope, bool) {
+	n := float64(len(x))
+	var mx, my float64
+	for i := range x {
+		mx += x[i]
+		my += y[i]
+	}
+	mx /= n
+	my /= n
+	var sxx, sxy, syy float64
+	for i := range x {
+		sxx += (x[i] - mx) * (x[i] - mx)
+		sxy += (x[i] - mx) * (y[i] - my)
+		syy += (y[i] - my) * (y[i] - my)
+	}
+	if sxx == 0 {
+		return Slope{}, false
+	}
+	linear := sxy / sxx
+	r2 := 1.0
+	if syy > 0 {
+		r2 = sxy * sxy / (sxx * syy)
+	}
+
+	var pairwise []float64
+	for i := range x {
+		for j := i + 1; j < len(x); j++ {
+			if x[j] != x[i] {
+				pairwise = append(pairwise, (y[j]-y[i])/(x[j]-x[i]))
+			}
+		}
+	}
+	theilSen := median(pairwise)
+	residuals := make([]float64, len(x))
+	for i := range x {
+		residuals[i] = y[i] - theilSen*x[i]
+	}
+
+	return Slope{
+		Linear:    linear * daysPerMonth,
+		TheilSen:  theilSen * daysPerMonth,
+		Intercept: median(residuals),
+		R2:        r2,
+	}, true
+}
+
+// project follows the Theil–Sen line from the latest result to the bound it
+// is heading for
+func project(start time.Time, lastX float64, s Slope, ref Range) *Projection {
+	perDay := s.TheilSen / daysPerMonth
+	if perDay == 0 {
+		return nil
+	}
+	now := s.Intercept + perDay*lastX
+
+	var bound string
+	var value float64
+	var direction string
+	// Outside the range the line heads back through the near bound first
+	switch {
+	case perDay > 0 && ref.Min != nil && now < *ref.Min:
+		bound, value, direction = "min", *ref.Min, Entering
+	case perDay < 0 && ref.Max != nil && now > *ref.Max:
+		bound, value, direction = "max", *ref.Max, Entering
+	case perDay > 0 && ref.Max != nil && now <= *ref.Max:
+		bound, value, direction = "max", *ref.Max, Leaving
+	case perDay < 0 && ref.Min != nil && now >= *ref.Min:
+		bound, value, direction = "min", *ref.Min, Leaving
+	default:
+		return nil
+	}
+
+	days := (value - now) / perDay
+	if days < 0 || days > horizonDays {
+		return nil
+	}
+	at := start.AddDate(0, 0, int(math.Round(lastX+days)))
+	return &Projection{
+		Bound:     bound,
+		Value:     value,
+		Date:      at,
+		Days:      int(math.Round(days)),
+		Direction: direction,
+	}
+}
+
+func change(prev, last Point, cv *Variation) *Change {
+	if prev.Value == 0 {
+		return nil
+	}
+	c := &Change{
+		From:    prev.Value,
+		To:      last.Value,
+		Percent: (last.Value - prev.Value) / math.Abs(prev.Value) * 100,
+	}
+	if cv != nil {
+		rcv := cv.RCV()
+		c.RCV = &rcv
+		c.Significant = math.Abs(c.Percent) > rcv
+	}
+	return c
+}
+
+// outliers flags results whose residual from the Theil–Sen line has a
+// modified z-score above 3.5
+func outliers(points []Point, x []float64, s Slope) []Outlier {
+	perDay := s.TheilSen / daysPerMonth
+	residuals := make([]float64, len(points))
+	for i, p := range points {
+		residuals[i] = p.Value - (s.Intercept + perDay*x[i])
+	}
+	m := median(residuals)
+	deviations := make([]float64, len(residuals))
+	for i, r := range residuals {
+		deviations[i] = math.Abs(r - m)
+	}
+	mad := median(deviations)
+	if mad == 0 {
+		return nil
+	}
+
+	var result []Outlier
+	for i, r := range residuals {
+		score := 0.6745 * (r - m) / mad
+		if math.Abs(score) > outlierScore {
+			result = append(result, Outlier{Date: points[i].Date, Value: points[i].Value, Score: score})
+		}
+	}
+	return result
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
@@ -0,0 +1,145 @@
+package trend
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// series places values at the given day offsets from start
+func series(days []int, values ...float64) []Point {
+	points := make([]Point, len(values))
+	for i, v := range values {
+		points[i] = Point{Date: start.AddDate(0, 0, days[i]), Value: v}
+	}
+	return points
+}
+
+func bound(v float64) *float64 { return &v }
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestAnalyzeProjection(t *testing.T) {
+	ref := Range{Min: bound(20), Max: bound(40)}
+	for _, tc := range []struct {
+		name      string
+		points    []Point
+		ref       Range
+		bound     string // empty for no projection
+		direction string
+		days      int
+	}{
+		// 2 units per 30 days from 14, so 90 days to the bound
+		{"increasing below min", series([]int{60, 0, 30}, 14, 10, 12), ref, "min", Entering, 90},
+		{"decreasing above max", series([]int{0, 30, 60}, 50, 48, 46), ref, "max", Entering, 90},
+		{"increasing inside the range", series([]int{0, 30, 60}, 30, 32, 34), ref, "max", Leaving, 90},
+		{"decreasing inside the range", series([]int{0, 30, 60}, 30, 28, 26), ref, "min", Leaving, 90},
+		{"decreasing below min", series([]int{0, 30, 60}, 18, 16, 14), ref, "", "", 0},
+		{"no upper bound", series([]int{0, 30, 60}, 30, 32, 34), Range{Min: bound(20)}, "", "", 0},
+		{"flat", series([]int{0, 30, 60}, 30, 30, 30), ref, "", "", 0},
+		{"beyond the horizon", series([]int{0, 300, 600}, 21, 22, 23), ref, "", "", 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a := Analyze(tc.points, tc.ref, nil)
+			if a.Slope == nil {
+				t.Fatal("no slope")
+			}
+			p := a.Projection
+			switch {
+			case tc.bound == "" && p != nil:
+				t.Errorf("projection = %+v, want none", *p)
+			case tc.bound != "" && p == nil:
+				t.Errorf("no projection, want %s %s", tc.direction, tc.bound)
+			case tc.bound != "" && (p.Bound != tc.bound || p.Direction != tc.direction || p.Days != tc.days ||
+				!p.Date.Equal(start.AddDate(0, 0, 60+tc.days))):
+				t.Errorf("projection = %+v, want %s %s in %d days", *p, tc.direction, tc.bound, tc.days)
+			}
+		})
+	}
+}
+
+func TestAnalyzeSlope(t *testing.T) {
+	a := Analyze(series([]int{0, 30, 60, 90}, 10, 12, 14, 16), Range{}, nil)
+	if a.Slope == nil {
+		t.Fatal("no slope")
+	}
+	perMonth := 2.0 / 30 * daysPerMonth
+	if !near(a.Slope.Linear, perMonth) || !near(a.Slope.TheilSen, perMonth) || !near(a.Slope.Intercept, 10) || !near(a.Slope.R2, 1) {
+		t.Errorf("slope = %+v, want %v per month from 10 with R² 1", *a.Slope, perMonth)
+	}
+
+	if a := Analyze(series([]int{0, 30}, 10, 12), Range{}, nil); a.Slope != nil {
+		t.Errorf("two results fitted a slope %+v", *a.Slope)
+	}
+}
+
+// Results on one date have no slope; the change is between the last two
+func TestAnalyzeSameDate(t *testing.T) {
+	a := Analyze(series([]int{10, 10, 10}, 5, 6, 9), Range{Min: bound(1)}, nil)
+	if a.Slope != nil || a.Projection != nil || a.Outliers != nil {
+		t.Errorf("analysis = %+v, want only a change", a)
+	}
+	if a.Change == nil || a.Change.From != 6 || a.Change.To != 9 {
+		t.Errorf("change = %+v, want 6 → 9", a.Change)
+	}
+}
+
+func TestAnalyzeOutliers(t *testing.T) {
+	days := []int{0, 30, 60, 90, 120, 150}
+
+	a := Analyze(series(days, 10, 12, 11, 13, 15, 30), Range{}, nil)
+	if len(a.Outliers) != 1 || a.Outliers[0].Value != 30 || !a.Outliers[0].Date.Equal(start.AddDate(0, 0, 150)) {
+		t.Errorf("outliers = %+v, want the 30", a.Outliers)
+	}
+
+	// On a perfect line the deviations have no spread (MAD = 0), so no
+	// score can be computed and nothing is flagged
+	a = Analyze(series(days, 10, 11, 12, 13, 14, 40), Range{}, nil)
+	if a.Slope == nil || a.Outliers != nil {
+		t.Errorf("outliers = %+v with MAD 0, want none", a.Outliers)
+	}
+
+	if a := Analyze(series(days[:4], 10, 10.6, 11.1, 30), Range{}, nil); a.Outliers != nil {
+		t.Errorf("outliers = %+v from four results", a.Outliers)
+	}
+}
+
+func TestAnalyzeChange(t *testing.T) {
+	// RCV = 1.96 × √2 × √(10² + 5²) ≈ 31%
+	cv := &Variation{Biological: 10, Analytical: 5}
+	for _, tc := range []struct {
+		name        string
+		from, to    float64
+		cv          *Variation
+		percent     float64
+		significant bool
+	}{
+		{"beyond the RCV", 100, 140, cv, 40, true},
+		{"within the RCV", 100, 120, cv, 20, false},
+		{"falling beyond the RCV", 100, 60, cv, -40, true},
+		{"negative start", -10, -5, cv, 50, true},
+		{"no variation data", 100, 140, nil, 40, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := Analyze(series([]int{0, 30}, tc.from, tc.to), Range{}, tc.cv).Change
+			if c == nil {
+				t.Fatal("no change")
+			}
+			if !near(c.Percent, tc.percent) || c.Significant != tc.significant || (c.RCV == nil) != (tc.cv == nil) {
+				t.Errorf("change = %+v, want %v%% significant %v", *c, tc.percent, tc.significant)
+			}
+		})
+	}
+
+	if got := cv.RCV(); math.Abs(got-30.99) > 0.01 {
+		t.Errorf("RCV = %v, want 30.99", got)
+	}
+	if c := Analyze(series([]int{0, 30}, 0, 5), Range{}, cv).Change; c != nil {
+		t.Errorf("change from zero = %+v, want none", *c)
+	}
+	if a := Analyze(series([]int{0}, 5), Range{}, cv); a.Change != nil || a.Slope != nil {
+		t.Errorf("one result analysed as %+v", a)
+	}
+}
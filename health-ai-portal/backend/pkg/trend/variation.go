@@ -0,0 +1,72 @@
+package trend
+
+import "math"
+
+// Variation is a marker's coefficients of variation in percent
+type Variation struct {
+	Biological float64 // within-subject, CVi
+	Analytical float64 // laboratory imprecision, CVa
+}
+
+// RCV is the reference change value in percent: the smallest difference
+// between two results that is unlikely (p < 0.05) to be noise alone
+func (v Variation) RCV() float64 {
+	return zSignificant * math.Sqrt2 * math.Sqrt(v.Analytical*v.Analytical+v.Biological*v.Biological)
+}
+
+// withinSubjectCV is the within-subject biological variation of the stored
+// markers, rounded from the published biological variation databases
+// (EFLM, Westgard). Markers without an entry get no significance test.
+var withinSubjectCV = map[string]float64{
+	"Testosterone Total": 9.3,
+	"Testosterone Free":  9.3,
+	"Estradiol":          18.1,
+	"Prolactin":          6.9,
+	"TSH":                17.7,
+	"fT3":                5.7,
+	"fT4":                4.8,
+	"LH":                 14.5,
+	"FSH":                8.7,
+	"DHEA-S":             4.2,
+	"Cortisol":           20.9,
+	"Insulin":            21.1,
+	"Glucose":            5.0,
+	"HbA1c":              1.6,
+	"Cholesterol Total":  5.3,
+	"LDL":                8.3,
+	"HDL":                5.7,
+	"Triglycerides":      19.9,
+	"ALT":                9.3,
+	"AST":                9.5,
+	"GGT":                8.9,
+	"Bilirubin Total":    21.8,
+	"Creatinine":         4.5,
+	"Urea":               13.9,
+	"Uric Acid":          8.2,
+	"Ferritin":           10.1,
+	"Iron":               26.5,
+	"Vitamin D":          7.3,
+	"Vitamin B12":        15.0,
+	"Folate":             24.0,
+	"Hemoglobin":         2.7,
+	"Hematocrit":         2.8,
+	"RBC":                3.2,
+	"WBC":                11.1,
+	"Platelets":          7.4,
+	"CRP":                42.2,
+	"IGF-1":              6.4,
+	"SHBG":               12.1,
+	"Homocysteine":       9.4,
+	"PSA":                18.1,
+}
+
+// VariationFor returns the variation of a stored marker name. The
+// laboratory's imprecision is rarely known, so the desirable one, half the
+// biological variation, stands in for it.
+func VariationFor(marker string) (Variation, bool) {
+	cvi, ok := withinSubjectCV[marker]
+	if !ok {
+		return Variation{}, false
+	}
+	return Variation{Biological: cvi, Analytical: cvi / 2}, true
+}